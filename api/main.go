@@ -1,9 +1,15 @@
 package main
 
 import (
+	"context"
+	"fmt"
+	"log"
 	"os"
+	"os/signal"
+	"syscall"
 
 	_ "github.com/NdoleStudio/discusswithai/docs"
+	"github.com/NdoleStudio/discusswithai/pkg/config"
 	"github.com/NdoleStudio/discusswithai/pkg/di"
 )
 
@@ -23,11 +29,47 @@ var Version string
 // @host     api.discusswithai.com
 // @schemes  https
 // @BasePath /v1
+//
+// @securitydefinitions.apikey ApiKeyAuth
+// @in                         header
+// @name                       X-API-Key
 func main() {
 	if len(os.Args) == 1 {
 		di.LoadEnv()
 	}
 
-	container := di.NewContainer(Version, os.Getenv("GCP_PROJECT_ID"))
-	container.Logger().Info(container.App().Listen(":8000").Error())
+	cfg, err := config.Load(os.Getenv("CONFIG_FILE"))
+	if err != nil {
+		log.Fatalf("Fatal: cannot load configuration: %v", err)
+	}
+
+	container := di.NewContainer(Version, cfg)
+	logger := container.Logger()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	go func() {
+		if err := container.App().Listen(fmt.Sprintf(":%d", cfg.Port)); err != nil {
+			logger.Error(err)
+		}
+		stop()
+	}()
+
+	<-ctx.Done()
+	logger.Info("shutting down the server")
+
+	// Cloud Run kills the container 10 seconds after sending a SIGTERM so the default SHUTDOWN_TIMEOUT is 9 seconds
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+
+	if err = container.App().ShutdownWithTimeout(cfg.ShutdownTimeout); err != nil {
+		logger.Error(err)
+	}
+
+	if err = container.Close(ctx); err != nil {
+		logger.Error(err)
+	}
+
+	logger.Info("server stopped")
 }
@@ -2,11 +2,48 @@ package cache
 
 import (
 	"context"
+	"errors"
 	"time"
+
+	"github.com/palantir/stacktrace"
 )
 
+var (
+	// ErrNotFound is returned by Cache.Get when there is no item with the key
+	ErrNotFound = errors.New("cache: item not found")
+
+	// ErrLockNotAcquired is returned by Locker.TryLock when the lock is held by someone else
+	ErrLockNotAcquired = errors.New("cache: lock not acquired")
+
+	// ErrLockNotHeld is returned by Locker.Unlock and Locker.Extend when the lease of the Lock has expired or it was taken by someone else
+	ErrLockNotHeld = errors.New("cache: lock not held")
+)
+
+// IsNotFound checks if the error is caused by ErrNotFound even when it was wrapped with stacktrace.Propagate
+func IsNotFound(err error) bool {
+	return err != nil && errors.Is(stacktrace.RootCause(err), ErrNotFound)
+}
+
 // Cache stores items temporarily
 type Cache interface {
+	// Set an item with a ttl. The item does not expire when the ttl is 0.
 	Set(ctx context.Context, key string, value string, ttl time.Duration) error
+
+	// Get an item. It returns ErrNotFound when the item does not exist.
 	Get(ctx context.Context, key string) (value string, err error)
+
+	// Delete an item. It does not return an error when the item does not exist.
+	Delete(ctx context.Context, key string) error
+
+	// SetNX sets an item only if it does not exist and returns true when the item was set
+	SetNX(ctx context.Context, key string, value string, ttl time.Duration) (bool, error)
+
+	// Incr increments a counter by 1. The ttl is applied when the counter is created.
+	Incr(ctx context.Context, key string, ttl time.Duration) (int64, error)
+
+	// IncrBy increments a counter by value. The ttl is applied when the counter is created.
+	IncrBy(ctx context.Context, key string, value int64, ttl time.Duration) (int64, error)
+
+	// Expire sets the ttl of an item and returns false when the item does not exist
+	Expire(ctx context.Context, key string, ttl time.Duration) (bool, error)
 }
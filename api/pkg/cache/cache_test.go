@@ -0,0 +1,37 @@
+package cache
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/palantir/stacktrace"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsNotFound(t *testing.T) {
+	t.Run("wrapped ErrNotFound errors are not found errors", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+
+		// Arrange
+		err := stacktrace.Propagate(ErrNotFound, "cannot load item")
+
+		// Act
+		result := IsNotFound(err)
+
+		// Assert
+		assert.True(t, result)
+	})
+
+	t.Run("other errors are not found errors", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+
+		// Act
+		result := IsNotFound(stacktrace.Propagate(errors.New("connection refused"), "cannot load item"))
+
+		// Assert
+		assert.False(t, result)
+		assert.False(t, IsNotFound(nil))
+	})
+}
@@ -0,0 +1,60 @@
+package cache
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+)
+
+const (
+	// lockRetryInterval is the time to wait before trying to acquire a lock again
+	lockRetryInterval = 50 * time.Millisecond
+)
+
+// Lock is a lease on a key which expires after its ttl unless it is extended
+type Lock struct {
+	Key   string
+	Token string
+}
+
+// Locker manages distributed locks
+type Locker interface {
+	// TryLock acquires the lock for the ttl. It returns ErrLockNotAcquired when the lock is held by someone else.
+	TryLock(ctx context.Context, key string, ttl time.Duration) (*Lock, error)
+
+	// Lock waits until the lock is acquired for the ttl or the context is done
+	Lock(ctx context.Context, key string, ttl time.Duration) (*Lock, error)
+
+	// Unlock releases the Lock. It returns ErrLockNotHeld when the lease has expired.
+	Unlock(ctx context.Context, lock *Lock) error
+
+	// Extend renews the lease of the Lock for the ttl. It returns ErrLockNotHeld when the lease has expired.
+	Extend(ctx context.Context, lock *Lock, ttl time.Duration) error
+}
+
+// lockToken is the random value which identifies the holder of a Lock
+func lockToken() string {
+	token := make([]byte, 16)
+	_, _ = rand.Read(token)
+	return hex.EncodeToString(token)
+}
+
+// waitForLock calls tryLock until the lock is acquired or the context is done
+func waitForLock(ctx context.Context, tryLock func() (*Lock, error)) (*Lock, error) {
+	ticker := time.NewTicker(lockRetryInterval)
+	defer ticker.Stop()
+
+	for {
+		lock, err := tryLock()
+		if err != ErrLockNotAcquired {
+			return lock, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
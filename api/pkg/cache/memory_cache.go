@@ -0,0 +1,177 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/palantir/stacktrace"
+)
+
+// memoryItem is an item in the MemoryCache
+type memoryItem struct {
+	key       string
+	value     string
+	expiresAt time.Time
+}
+
+func (item *memoryItem) isExpired(now time.Time) bool {
+	return !item.expiresAt.IsZero() && !now.Before(item.expiresAt)
+}
+
+// MemoryCache is a Cache which stores items in memory.
+// The least recently used item is evicted when the cache is full. It is used locally and in tests.
+type MemoryCache struct {
+	mutex    sync.Mutex
+	capacity int
+	items    map[string]*list.Element
+	order    *list.List
+	now      func() time.Time
+}
+
+// NewMemoryCache creates a new MemoryCache which can hold up to capacity items
+func NewMemoryCache(capacity int) *MemoryCache {
+	if capacity < 1 {
+		capacity = 1
+	}
+
+	return &MemoryCache{
+		capacity: capacity,
+		items:    map[string]*list.Element{},
+		order:    list.New(),
+		now:      time.Now,
+	}
+}
+
+// Get an item from the memory cache
+func (cache *MemoryCache) Get(_ context.Context, key string) (string, error) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	item := cache.load(key)
+	if item == nil {
+		return "", ErrNotFound
+	}
+	return item.value, nil
+}
+
+// Set an item in the memory cache
+func (cache *MemoryCache) Set(_ context.Context, key string, value string, ttl time.Duration) error {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	cache.store(key, value, ttl)
+	return nil
+}
+
+// Delete an item from the memory cache
+func (cache *MemoryCache) Delete(_ context.Context, key string) error {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	cache.remove(key)
+	return nil
+}
+
+// SetNX sets an item in the memory cache if it does not exist
+func (cache *MemoryCache) SetNX(_ context.Context, key string, value string, ttl time.Duration) (bool, error) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	if cache.load(key) != nil {
+		return false, nil
+	}
+
+	cache.store(key, value, ttl)
+	return true, nil
+}
+
+// Incr increments a counter in the memory cache by 1
+func (cache *MemoryCache) Incr(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	return cache.IncrBy(ctx, key, 1, ttl)
+}
+
+// IncrBy increments a counter in the memory cache by value
+func (cache *MemoryCache) IncrBy(_ context.Context, key string, value int64, ttl time.Duration) (int64, error) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	item := cache.load(key)
+	if item == nil {
+		cache.store(key, strconv.FormatInt(value, 10), ttl)
+		return value, nil
+	}
+
+	current, err := strconv.ParseInt(item.value, 10, 64)
+	if err != nil {
+		return 0, stacktrace.Propagate(err, fmt.Sprintf("the item with key [%s] is not an integer", key))
+	}
+
+	item.value = strconv.FormatInt(current+value, 10)
+	return current + value, nil
+}
+
+// Expire sets the ttl of an item in the memory cache. The item is deleted when the ttl is not positive.
+func (cache *MemoryCache) Expire(_ context.Context, key string, ttl time.Duration) (bool, error) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	item := cache.load(key)
+	if item == nil {
+		return false, nil
+	}
+
+	if ttl <= 0 {
+		cache.remove(key)
+		return true, nil
+	}
+
+	item.expiresAt = cache.now().Add(ttl)
+	return true, nil
+}
+
+// load returns the item which has not expired and marks it as the most recently used
+func (cache *MemoryCache) load(key string) *memoryItem {
+	element, ok := cache.items[key]
+	if !ok {
+		return nil
+	}
+
+	item := element.Value.(*memoryItem)
+	if item.isExpired(cache.now()) {
+		cache.remove(key)
+		return nil
+	}
+
+	cache.order.MoveToFront(element)
+	return item
+}
+
+// store an item and evict the least recently used item when the cache is full
+func (cache *MemoryCache) store(key string, value string, ttl time.Duration) {
+	item := &memoryItem{key: key, value: value}
+	if ttl > 0 {
+		item.expiresAt = cache.now().Add(ttl)
+	}
+
+	if element, ok := cache.items[key]; ok {
+		element.Value = item
+		cache.order.MoveToFront(element)
+		return
+	}
+
+	cache.items[key] = cache.order.PushFront(item)
+	for cache.order.Len() > cache.capacity {
+		cache.remove(cache.order.Back().Value.(*memoryItem).key)
+	}
+}
+
+func (cache *MemoryCache) remove(key string) {
+	if element, ok := cache.items[key]; ok {
+		cache.order.Remove(element)
+		delete(cache.items, key)
+	}
+}
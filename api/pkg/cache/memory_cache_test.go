@@ -0,0 +1,133 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryCache_Get(t *testing.T) {
+	t.Run("the least recently used item is evicted when the cache is full", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		cache := NewMemoryCache(2)
+		ctx := context.Background()
+
+		// Arrange
+		_ = cache.Set(ctx, "first", "1", 0)
+		_ = cache.Set(ctx, "second", "2", 0)
+		_, _ = cache.Get(ctx, "first")
+		_ = cache.Set(ctx, "third", "3", 0)
+
+		// Act
+		_, err := cache.Get(ctx, "second")
+		first, _ := cache.Get(ctx, "first")
+
+		// Assert
+		assert.ErrorIs(t, err, ErrNotFound)
+		assert.Equal(t, "1", first)
+	})
+
+	t.Run("expired items are not found", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		cache := NewMemoryCache(10)
+		ctx := context.Background()
+		now := time.Now()
+		cache.now = func() time.Time { return now }
+
+		// Arrange
+		_ = cache.Set(ctx, "key", "value", time.Minute)
+		now = now.Add(time.Minute)
+
+		// Act
+		_, err := cache.Get(ctx, "key")
+
+		// Assert
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+}
+
+func TestMemoryCache_IncrBy(t *testing.T) {
+	t.Run("the ttl is applied when the counter is created", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		cache := NewMemoryCache(10)
+		ctx := context.Background()
+		now := time.Now()
+		cache.now = func() time.Time { return now }
+
+		// Act
+		first, _ := cache.IncrBy(ctx, "counter", 2, time.Minute)
+		now = now.Add(30 * time.Second)
+		second, _ := cache.Incr(ctx, "counter", time.Minute)
+		now = now.Add(30 * time.Second)
+		third, _ := cache.Incr(ctx, "counter", time.Minute)
+
+		// Assert
+		assert.Equal(t, int64(2), first)
+		assert.Equal(t, int64(3), second)
+		assert.Equal(t, int64(1), third)
+	})
+}
+
+func TestMemoryCache_SetNX(t *testing.T) {
+	t.Run("an item is set only once", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		cache := NewMemoryCache(10)
+		ctx := context.Background()
+
+		// Act
+		first, _ := cache.SetNX(ctx, "key", "first", time.Minute)
+		second, _ := cache.SetNX(ctx, "key", "second", time.Minute)
+		value, _ := cache.Get(ctx, "key")
+
+		// Assert
+		assert.True(t, first)
+		assert.False(t, second)
+		assert.Equal(t, "first", value)
+	})
+}
+
+func TestMemoryLocker_TryLock(t *testing.T) {
+	t.Run("a lock is held until it is released", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		locker := NewMemoryLocker()
+		ctx := context.Background()
+
+		// Act
+		lock, err1 := locker.TryLock(ctx, "conversation", time.Minute)
+		_, err2 := locker.TryLock(ctx, "conversation", time.Minute)
+		err3 := locker.Unlock(ctx, lock)
+		_, err4 := locker.TryLock(ctx, "conversation", time.Minute)
+
+		// Assert
+		assert.Nil(t, err1)
+		assert.ErrorIs(t, err2, ErrLockNotAcquired)
+		assert.Nil(t, err3)
+		assert.Nil(t, err4)
+	})
+
+	t.Run("an expired lock cannot be extended", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		locker := NewMemoryLocker()
+		ctx := context.Background()
+		now := time.Now()
+		locker.now = func() time.Time { return now }
+
+		// Arrange
+		lock, _ := locker.TryLock(ctx, "conversation", time.Second)
+		now = now.Add(time.Second)
+
+		// Act
+		err := locker.Extend(ctx, lock, time.Minute)
+
+		// Assert
+		assert.ErrorIs(t, err, ErrLockNotHeld)
+	})
+}
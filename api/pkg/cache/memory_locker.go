@@ -0,0 +1,85 @@
+package cache
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// memoryLease is a Lock which is held in memory
+type memoryLease struct {
+	token     string
+	expiresAt time.Time
+}
+
+// MemoryLocker is a Locker which holds locks in memory. Locks are only shared by the current process.
+type MemoryLocker struct {
+	mutex  sync.Mutex
+	leases map[string]memoryLease
+	now    func() time.Time
+}
+
+// NewMemoryLocker creates a new MemoryLocker
+func NewMemoryLocker() *MemoryLocker {
+	return &MemoryLocker{
+		leases: map[string]memoryLease{},
+		now:    time.Now,
+	}
+}
+
+// TryLock acquires a lock in memory
+func (locker *MemoryLocker) TryLock(_ context.Context, key string, ttl time.Duration) (*Lock, error) {
+	locker.mutex.Lock()
+	defer locker.mutex.Unlock()
+
+	if _, ok := locker.lease(key); ok {
+		return nil, ErrLockNotAcquired
+	}
+
+	lock := &Lock{Key: key, Token: lockToken()}
+	locker.leases[key] = memoryLease{token: lock.Token, expiresAt: locker.now().Add(ttl)}
+	return lock, nil
+}
+
+// Lock waits until a lock in memory is acquired
+func (locker *MemoryLocker) Lock(ctx context.Context, key string, ttl time.Duration) (*Lock, error) {
+	return waitForLock(ctx, func() (*Lock, error) {
+		return locker.TryLock(ctx, key, ttl)
+	})
+}
+
+// Unlock releases a lock in memory
+func (locker *MemoryLocker) Unlock(_ context.Context, lock *Lock) error {
+	locker.mutex.Lock()
+	defer locker.mutex.Unlock()
+
+	if lease, ok := locker.lease(lock.Key); !ok || lease.token != lock.Token {
+		return ErrLockNotHeld
+	}
+
+	delete(locker.leases, lock.Key)
+	return nil
+}
+
+// Extend renews the lease of a lock in memory
+func (locker *MemoryLocker) Extend(_ context.Context, lock *Lock, ttl time.Duration) error {
+	locker.mutex.Lock()
+	defer locker.mutex.Unlock()
+
+	if lease, ok := locker.lease(lock.Key); !ok || lease.token != lock.Token {
+		return ErrLockNotHeld
+	}
+
+	locker.leases[lock.Key] = memoryLease{token: lock.Token, expiresAt: locker.now().Add(ttl)}
+	return nil
+}
+
+// lease returns the lease of a key which has not expired
+func (locker *MemoryLocker) lease(key string) (memoryLease, bool) {
+	lease, ok := locker.leases[key]
+	if ok && !locker.now().Before(lease.expiresAt) {
+		delete(locker.leases, key)
+		return lease, false
+	}
+	return lease, ok
+}
@@ -11,6 +11,15 @@ import (
 	"github.com/palantir/stacktrace"
 )
 
+// incrByScript increments a counter and sets its ttl when it is created so that the counter always expires
+var incrByScript = redis.NewScript(`
+local value = redis.call("INCRBY", KEYS[1], ARGV[1])
+if tonumber(ARGV[2]) > 0 and redis.call("PTTL", KEYS[1]) == -1 then
+	redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return value
+`)
+
 // RedisCache is the Cache implementation in redis
 type RedisCache struct {
 	tracer telemetry.Tracer
@@ -32,10 +41,10 @@ func (cache *RedisCache) Get(ctx context.Context, key string) (value string, err
 
 	response, err := cache.client.Get(ctx, key).Result()
 	if err == redis.Nil {
-		return "", stacktrace.Propagate(err, fmt.Sprintf("no item found in redis with key [%s]", key))
+		return "", ErrNotFound
 	}
 	if err != nil {
-		return "", stacktrace.Propagate(err, fmt.Sprintf("cannot get item in redis with key [%s]", key))
+		return "", cache.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, fmt.Sprintf("cannot get item in redis with key [%s]", key)))
 	}
 	return response, nil
 }
@@ -51,3 +60,55 @@ func (cache *RedisCache) Set(ctx context.Context, key string, value string, ttl
 	}
 	return nil
 }
+
+// Delete an item from the redis cache
+func (cache *RedisCache) Delete(ctx context.Context, key string) error {
+	ctx, span := cache.tracer.Start(ctx)
+	defer span.End()
+
+	if err := cache.client.Del(ctx, key).Err(); err != nil {
+		return cache.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, fmt.Sprintf("cannot delete item in redis with key [%s]", key)))
+	}
+	return nil
+}
+
+// SetNX sets an item in the redis cache if it does not exist
+func (cache *RedisCache) SetNX(ctx context.Context, key string, value string, ttl time.Duration) (bool, error) {
+	ctx, span := cache.tracer.Start(ctx)
+	defer span.End()
+
+	ok, err := cache.client.SetNX(ctx, key, value, ttl).Result()
+	if err != nil {
+		return false, cache.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, fmt.Sprintf("cannot set item in redis if it does not exist with key [%s]", key)))
+	}
+	return ok, nil
+}
+
+// Incr increments a counter in the redis cache by 1
+func (cache *RedisCache) Incr(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	return cache.IncrBy(ctx, key, 1, ttl)
+}
+
+// IncrBy increments a counter in the redis cache by value
+func (cache *RedisCache) IncrBy(ctx context.Context, key string, value int64, ttl time.Duration) (int64, error) {
+	ctx, span := cache.tracer.Start(ctx)
+	defer span.End()
+
+	result, err := incrByScript.Run(ctx, cache.client, []string{key}, value, ttl.Milliseconds()).Int64()
+	if err != nil {
+		return 0, cache.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, fmt.Sprintf("cannot increment item in redis with key [%s] by [%d]", key, value)))
+	}
+	return result, nil
+}
+
+// Expire sets the ttl of an item in the redis cache
+func (cache *RedisCache) Expire(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	ctx, span := cache.tracer.Start(ctx)
+	defer span.End()
+
+	ok, err := cache.client.Expire(ctx, key, ttl).Result()
+	if err != nil {
+		return false, cache.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, fmt.Sprintf("cannot set ttl of item in redis with key [%s]", key)))
+	}
+	return ok, nil
+}
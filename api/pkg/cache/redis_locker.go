@@ -0,0 +1,98 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/NdoleStudio/discusswithai/pkg/telemetry"
+	"github.com/palantir/stacktrace"
+	"github.com/redis/go-redis/v9"
+)
+
+var (
+	// unlockScript deletes the lock only when it is still held by the token
+	unlockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+	// extendScript renews the lease of the lock only when it is still held by the token
+	extendScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0
+`)
+)
+
+// redisLocker is the Locker implementation in redis
+type redisLocker struct {
+	tracer telemetry.Tracer
+	client *redis.Client
+	prefix string
+}
+
+// NewRedisLocker creates a Locker which stores locks in redis with keys prefixed by "lock:"
+func NewRedisLocker(tracer telemetry.Tracer, client *redis.Client) Locker {
+	return &redisLocker{
+		tracer: tracer,
+		client: client,
+		prefix: "lock:",
+	}
+}
+
+// TryLock acquires a lock in redis
+func (locker *redisLocker) TryLock(ctx context.Context, key string, ttl time.Duration) (*Lock, error) {
+	ctx, span := locker.tracer.Start(ctx)
+	defer span.End()
+
+	lock := &Lock{Key: key, Token: lockToken()}
+	ok, err := locker.client.SetNX(ctx, locker.prefix+key, lock.Token, ttl).Result()
+	if err != nil {
+		return nil, locker.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, fmt.Sprintf("cannot acquire lock in redis with key [%s]", key)))
+	}
+	if !ok {
+		return nil, ErrLockNotAcquired
+	}
+	return lock, nil
+}
+
+// Lock waits until a lock in redis is acquired
+func (locker *redisLocker) Lock(ctx context.Context, key string, ttl time.Duration) (*Lock, error) {
+	return waitForLock(ctx, func() (*Lock, error) {
+		return locker.TryLock(ctx, key, ttl)
+	})
+}
+
+// Unlock releases a lock in redis
+func (locker *redisLocker) Unlock(ctx context.Context, lock *Lock) error {
+	ctx, span := locker.tracer.Start(ctx)
+	defer span.End()
+
+	deleted, err := unlockScript.Run(ctx, locker.client, []string{locker.prefix + lock.Key}, lock.Token).Int64()
+	if err != nil {
+		return locker.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, fmt.Sprintf("cannot release lock in redis with key [%s]", lock.Key)))
+	}
+	if deleted == 0 {
+		return ErrLockNotHeld
+	}
+	return nil
+}
+
+// Extend renews the lease of a lock in redis
+func (locker *redisLocker) Extend(ctx context.Context, lock *Lock, ttl time.Duration) error {
+	ctx, span := locker.tracer.Start(ctx)
+	defer span.End()
+
+	extended, err := extendScript.Run(ctx, locker.client, []string{locker.prefix + lock.Key}, lock.Token, ttl.Milliseconds()).Int64()
+	if err != nil {
+		return locker.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, fmt.Sprintf("cannot extend lock in redis with key [%s]", lock.Key)))
+	}
+	if extended == 0 {
+		return ErrLockNotHeld
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+package completion
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/NdoleStudio/discusswithai/pkg/telemetry"
+	"github.com/palantir/stacktrace"
+	"github.com/sashabaranov/go-openai"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Completer creates chat completions. *openai.Client is a Completer.
+type Completer interface {
+	// CreateChatCompletion creates a completion for the messages of the request
+	CreateChatCompletion(ctx context.Context, request openai.ChatCompletionRequest) (openai.ChatCompletionResponse, error)
+}
+
+// Provider is an API e.g. "openai" which serves the models of a Chain
+type Provider struct {
+	Name    string
+	Client  Completer
+	Breaker *CircuitBreaker
+}
+
+// Link is a model of a Provider in a Chain
+type Link struct {
+	Provider *Provider
+	Model    string
+}
+
+// String returns the link as "provider/model"
+func (link Link) String() string {
+	return fmt.Sprintf("%s/%s", link.Provider.Name, link.Model)
+}
+
+// Chain creates completions with the first model whose provider is available and falls back to the next model on errors
+type Chain struct {
+	tracer  telemetry.Tracer
+	metrics *telemetry.Metrics
+	links   []Link
+}
+
+// NewChain creates a Chain which tries the links in order
+func NewChain(tracer telemetry.Tracer, metrics *telemetry.Metrics, links ...Link) *Chain {
+	return &Chain{
+		tracer:  tracer,
+		metrics: metrics,
+		links:   links,
+	}
+}
+
+// Models returns the models of the chain in the order in which they are tried
+func (chain *Chain) Models() []string {
+	models := make([]string, 0, len(chain.links))
+	for _, link := range chain.links {
+		models = append(models, link.Model)
+	}
+	return models
+}
+
+// CreateChatCompletion creates the completion with the links of the chain in order.
+// The model of the request is replaced by the model of each link and links whose circuit breaker is open are skipped.
+func (chain *Chain) CreateChatCompletion(ctx context.Context, request openai.ChatCompletionRequest) (openai.ChatCompletionResponse, error) {
+	ctx, span := chain.tracer.Start(ctx)
+	defer span.End()
+
+	var lastErr error
+	for i, link := range chain.links {
+		if !link.Provider.Breaker.Allow() {
+			span.AddEvent("completion.skipped", trace.WithAttributes(
+				attribute.String("completion.link", link.String()),
+				attribute.String("completion.breaker", BreakerOpen),
+			))
+			continue
+		}
+
+		request.Model = link.Model
+		start := time.Now()
+		response, err := link.Provider.Client.CreateChatCompletion(ctx, request)
+		chain.metrics.CompletionCreated(ctx, link.Model, time.Since(start), response.Usage.PromptTokens, response.Usage.CompletionTokens, err)
+		if err == nil {
+			link.Provider.Breaker.Success()
+			span.SetAttributes(attribute.String("completion.link", link.String()), attribute.Int("completion.fallbacks", i))
+			return response, nil
+		}
+
+		if ctx.Err() != nil {
+			link.Provider.Breaker.Release()
+			return response, chain.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, "cannot create completion with [%s] because the context is done", link))
+		}
+
+		if isProviderFailure(err) {
+			link.Provider.Breaker.Failure()
+		} else {
+			link.Provider.Breaker.Success()
+		}
+
+		span.AddEvent("completion.fallback", trace.WithAttributes(
+			attribute.String("completion.link", link.String()),
+			attribute.String("completion.breaker", link.Provider.Breaker.State()),
+			attribute.String("error", telemetry.Redact(err.Error())),
+		))
+		lastErr = stacktrace.Propagate(err, "cannot create completion with [%s]", link)
+	}
+
+	if lastErr == nil {
+		return openai.ChatCompletionResponse{}, chain.tracer.WrapErrorSpan(span, stacktrace.NewError("the circuit breakers of all the [%d] models are open", len(chain.links)))
+	}
+	return openai.ChatCompletionResponse{}, chain.tracer.WrapErrorSpan(span, stacktrace.Propagate(lastErr, "cannot create completion with any of the [%d] models", len(chain.links)))
+}
+
+// isProviderFailure checks if the error means that the provider is unavailable.
+// Errors caused by the request e.g. an invalid model do not open the circuit breaker.
+func isProviderFailure(err error) bool {
+	statusCode := 0
+
+	var apiError *openai.APIError
+	var requestError *openai.RequestError
+	if errors.As(err, &apiError) {
+		statusCode = apiError.HTTPStatusCode
+	} else if errors.As(err, &requestError) {
+		statusCode = requestError.HTTPStatusCode
+	}
+
+	return statusCode == 0 || statusCode == http.StatusTooManyRequests || statusCode >= http.StatusInternalServerError
+}
@@ -0,0 +1,111 @@
+package completion
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/NdoleStudio/discusswithai/pkg/telemetry"
+	"github.com/hirosassa/zerodriver"
+	"github.com/sashabaranov/go-openai"
+	"github.com/stretchr/testify/assert"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+)
+
+type stubCompleter struct {
+	models []string
+	err    error
+}
+
+func (completer *stubCompleter) CreateChatCompletion(_ context.Context, request openai.ChatCompletionRequest) (openai.ChatCompletionResponse, error) {
+	completer.models = append(completer.models, request.Model)
+	if completer.err != nil {
+		return openai.ChatCompletionResponse{}, completer.err
+	}
+	return openai.ChatCompletionResponse{
+		Model:   request.Model,
+		Choices: []openai.ChatCompletionChoice{{Message: openai.ChatCompletionMessage{Content: "hello"}}},
+	}, nil
+}
+
+func TestChain_CreateChatCompletion(t *testing.T) {
+	t.Run("it falls back to the next model when the provider fails", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+
+		// Arrange
+		primary := &stubCompleter{err: fmt.Errorf("error, status code: 503, message: %w", &openai.APIError{HTTPStatusCode: http.StatusServiceUnavailable})}
+		secondary := &stubCompleter{}
+		chain := newTestChain(t,
+			Link{Provider: newTestProvider("openai", primary), Model: "gpt-4"},
+			Link{Provider: newTestProvider("secondary", secondary), Model: "llama"},
+		)
+
+		// Act
+		response, err := chain.CreateChatCompletion(context.Background(), openai.ChatCompletionRequest{})
+
+		// Assert
+		assert.Nil(t, err)
+		assert.Equal(t, "llama", response.Model)
+		assert.Equal(t, []string{"gpt-4"}, primary.models)
+	})
+
+	t.Run("it skips the providers whose circuit breaker is open", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+
+		// Arrange
+		primary := &stubCompleter{err: errors.New("connection refused")}
+		secondary := &stubCompleter{}
+		chain := newTestChain(t,
+			Link{Provider: newTestProvider("openai", primary), Model: "gpt-4"},
+			Link{Provider: newTestProvider("secondary", secondary), Model: "llama"},
+		)
+
+		// Act
+		for i := 0; i < 3; i++ {
+			_, err := chain.CreateChatCompletion(context.Background(), openai.ChatCompletionRequest{})
+			assert.Nil(t, err)
+		}
+
+		// Assert
+		assert.Equal(t, 2, len(primary.models))
+		assert.Equal(t, 3, len(secondary.models))
+	})
+
+	t.Run("errors caused by the request do not open the circuit breaker", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+
+		// Arrange
+		provider := newTestProvider("openai", &stubCompleter{err: &openai.APIError{HTTPStatusCode: http.StatusBadRequest}})
+		chain := newTestChain(t, Link{Provider: provider, Model: "gpt-4"})
+
+		// Act
+		for i := 0; i < 3; i++ {
+			_, err := chain.CreateChatCompletion(context.Background(), openai.ChatCompletionRequest{})
+			assert.NotNil(t, err)
+		}
+
+		// Assert
+		assert.Equal(t, BreakerClosed, provider.Breaker.State())
+	})
+}
+
+func newTestProvider(name string, client Completer) *Provider {
+	return &Provider{
+		Name:    name,
+		Client:  client,
+		Breaker: NewCircuitBreaker(BreakerConfig{ConsecutiveFailures: 2, ErrorRate: 1, MinRequests: 10, Window: time.Minute, Cooldown: time.Minute}),
+	}
+}
+
+func newTestChain(t *testing.T, links ...Link) *Chain {
+	logger := telemetry.NewZerologLogger("test", map[string]string{}, zerodriver.NewDevelopmentLogger(), nil)
+	metrics, err := telemetry.NewMetrics(sdkmetric.NewMeterProvider().Meter("test"))
+	assert.Nil(t, err)
+	return NewChain(telemetry.NewOtelLogger("test", logger), metrics, links...)
+}
@@ -0,0 +1,172 @@
+package completion
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// BreakerClosed is the state of a CircuitBreaker which lets all the requests through
+	BreakerClosed = "closed"
+
+	// BreakerOpen is the state of a CircuitBreaker which rejects all the requests until the cooldown has passed
+	BreakerOpen = "open"
+
+	// BreakerHalfOpen is the state of a CircuitBreaker which lets a single probe through after the cooldown
+	BreakerHalfOpen = "half-open"
+)
+
+// BreakerConfig configures when a CircuitBreaker opens
+type BreakerConfig struct {
+	// ConsecutiveFailures opens the breaker after this number of failures in a row
+	ConsecutiveFailures int
+
+	// ErrorRate opens the breaker when the ratio of failures in the Window reaches this value e.g. 0.5
+	ErrorRate float64
+
+	// MinRequests is the number of requests in the Window before the ErrorRate is used
+	MinRequests int
+
+	// Window is the duration over which the ErrorRate is computed
+	Window time.Duration
+
+	// Cooldown is the duration the breaker stays open before a probe is allowed
+	Cooldown time.Duration
+}
+
+// CircuitBreaker stops sending requests to a provider which keeps failing
+type CircuitBreaker struct {
+	config BreakerConfig
+
+	mutex               sync.Mutex
+	state               string
+	openedAt            time.Time
+	probing             bool
+	consecutiveFailures int
+	outcomes            []outcome
+	now                 func() time.Time
+}
+
+type outcome struct {
+	at     time.Time
+	failed bool
+}
+
+// NewCircuitBreaker creates a new CircuitBreaker in the closed state
+func NewCircuitBreaker(config BreakerConfig) *CircuitBreaker {
+	return &CircuitBreaker{
+		config: config,
+		state:  BreakerClosed,
+		now:    time.Now,
+	}
+}
+
+// Allow checks if a request can be sent. When the cooldown of an open breaker has passed, a single probe is allowed
+// and the breaker is half-open until the probe is recorded with Success or Failure.
+func (breaker *CircuitBreaker) Allow() bool {
+	breaker.mutex.Lock()
+	defer breaker.mutex.Unlock()
+
+	switch breaker.state {
+	case BreakerOpen:
+		if breaker.now().Sub(breaker.openedAt) < breaker.config.Cooldown {
+			return false
+		}
+		breaker.state = BreakerHalfOpen
+		breaker.probing = true
+		return true
+	case BreakerHalfOpen:
+		if breaker.probing {
+			return false
+		}
+		breaker.probing = true
+		return true
+	default:
+		return true
+	}
+}
+
+// Success records a successful request and closes a half-open breaker
+func (breaker *CircuitBreaker) Success() {
+	breaker.mutex.Lock()
+	defer breaker.mutex.Unlock()
+
+	if breaker.state == BreakerHalfOpen {
+		breaker.reset()
+		return
+	}
+
+	breaker.consecutiveFailures = 0
+	breaker.record(false)
+}
+
+// Failure records a failed request and opens the breaker when the probe failed or a threshold is reached
+func (breaker *CircuitBreaker) Failure() {
+	breaker.mutex.Lock()
+	defer breaker.mutex.Unlock()
+
+	if breaker.state == BreakerHalfOpen {
+		breaker.open()
+		return
+	}
+
+	breaker.consecutiveFailures++
+	breaker.record(true)
+
+	if breaker.config.ConsecutiveFailures > 0 && breaker.consecutiveFailures >= breaker.config.ConsecutiveFailures {
+		breaker.open()
+		return
+	}
+
+	if breaker.config.ErrorRate > 0 && len(breaker.outcomes) >= breaker.config.MinRequests && breaker.errorRate() >= breaker.config.ErrorRate {
+		breaker.open()
+	}
+}
+
+// Release records a request whose outcome says nothing about the provider e.g. when the request was cancelled by the caller.
+// A half-open breaker allows another probe.
+func (breaker *CircuitBreaker) Release() {
+	breaker.mutex.Lock()
+	defer breaker.mutex.Unlock()
+	breaker.probing = false
+}
+
+// State returns the current state of the breaker e.g. BreakerOpen
+func (breaker *CircuitBreaker) State() string {
+	breaker.mutex.Lock()
+	defer breaker.mutex.Unlock()
+	return breaker.state
+}
+
+func (breaker *CircuitBreaker) open() {
+	breaker.state = BreakerOpen
+	breaker.openedAt = breaker.now()
+	breaker.probing = false
+}
+
+func (breaker *CircuitBreaker) reset() {
+	breaker.state = BreakerClosed
+	breaker.probing = false
+	breaker.consecutiveFailures = 0
+	breaker.outcomes = nil
+}
+
+func (breaker *CircuitBreaker) record(failed bool) {
+	now := breaker.now()
+
+	start := 0
+	for start < len(breaker.outcomes) && now.Sub(breaker.outcomes[start].at) > breaker.config.Window {
+		start++
+	}
+	breaker.outcomes = append(breaker.outcomes[start:], outcome{at: now, failed: failed})
+}
+
+func (breaker *CircuitBreaker) errorRate() float64 {
+	failures := 0
+	for _, outcome := range breaker.outcomes {
+		if outcome.failed {
+			failures++
+		}
+	}
+	return float64(failures) / float64(len(breaker.outcomes))
+}
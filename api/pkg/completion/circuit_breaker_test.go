@@ -0,0 +1,92 @@
+package completion
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	t.Run("it opens after consecutive failures", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+
+		// Arrange
+		breaker := NewCircuitBreaker(BreakerConfig{ConsecutiveFailures: 3, MinRequests: 100, Window: time.Minute, Cooldown: time.Minute})
+
+		// Act
+		breaker.Failure()
+		breaker.Failure()
+		breaker.Success()
+		breaker.Failure()
+		breaker.Failure()
+		stateBefore := breaker.State()
+		breaker.Failure()
+
+		// Assert
+		assert.Equal(t, BreakerClosed, stateBefore)
+		assert.Equal(t, BreakerOpen, breaker.State())
+		assert.False(t, breaker.Allow())
+	})
+
+	t.Run("it opens when the error rate is reached", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+
+		// Arrange
+		breaker := NewCircuitBreaker(BreakerConfig{ConsecutiveFailures: 100, ErrorRate: 0.5, MinRequests: 4, Window: time.Minute, Cooldown: time.Minute})
+
+		// Act
+		breaker.Success()
+		breaker.Failure()
+		breaker.Success()
+		breaker.Failure()
+
+		// Assert
+		assert.Equal(t, BreakerOpen, breaker.State())
+	})
+
+	t.Run("it allows a single probe after the cooldown", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+
+		// Arrange
+		now := time.Now()
+		breaker := NewCircuitBreaker(BreakerConfig{ConsecutiveFailures: 1, Window: time.Minute, Cooldown: time.Minute})
+		breaker.now = func() time.Time { return now }
+		breaker.Failure()
+
+		// Act
+		now = now.Add(2 * time.Minute)
+		probe := breaker.Allow()
+		second := breaker.Allow()
+		breaker.Success()
+
+		// Assert
+		assert.True(t, probe)
+		assert.False(t, second)
+		assert.Equal(t, BreakerClosed, breaker.State())
+		assert.True(t, breaker.Allow())
+	})
+
+	t.Run("a failed probe opens the breaker again", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+
+		// Arrange
+		now := time.Now()
+		breaker := NewCircuitBreaker(BreakerConfig{ConsecutiveFailures: 1, Window: time.Minute, Cooldown: time.Minute})
+		breaker.now = func() time.Time { return now }
+		breaker.Failure()
+		now = now.Add(2 * time.Minute)
+
+		// Act
+		assert.True(t, breaker.Allow())
+		breaker.Failure()
+
+		// Assert
+		assert.Equal(t, BreakerOpen, breaker.State())
+		assert.False(t, breaker.Allow())
+	})
+}
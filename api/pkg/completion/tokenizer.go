@@ -0,0 +1,119 @@
+package completion
+
+import (
+	"sync"
+
+	"github.com/pkoukk/tiktoken-go"
+	"github.com/sashabaranov/go-openai"
+)
+
+const (
+	// tokensPerMessage are the tokens used by the role and the separators of each chat message
+	tokensPerMessage = 3
+
+	// tokensPerName are the extra tokens used when a chat message has a name
+	tokensPerName = 1
+
+	// tokensPerReply prime the reply of the assistant
+	tokensPerReply = 3
+
+	// charactersPerToken is the average number of characters in a token of english text
+	charactersPerToken = 4
+)
+
+// Tokenizer counts the tokens of a text for a model
+type Tokenizer interface {
+	// Count returns the number of tokens in the text
+	Count(text string) int
+
+	// Name is the name of the tokenizer e.g. "tiktoken"
+	Name() string
+}
+
+// CountMessages returns the number of tokens used by chat messages in a completion request.
+// See: https://github.com/openai/openai-cookbook/blob/main/examples/How_to_count_tokens_with_tiktoken.ipynb
+func CountMessages(tokenizer Tokenizer, messages ...openai.ChatCompletionMessage) int {
+	tokens := tokensPerReply
+	for _, message := range messages {
+		tokens += CountMessage(tokenizer, message)
+	}
+	return tokens
+}
+
+// CountMessage returns the number of tokens used by a single chat message without the tokens which prime the reply
+func CountMessage(tokenizer Tokenizer, message openai.ChatCompletionMessage) int {
+	tokens := tokensPerMessage + tokenizer.Count(message.Role) + tokenizer.Count(message.Content)
+	if message.Name != "" {
+		tokens += tokensPerName + tokenizer.Count(message.Name)
+	}
+	if message.FunctionCall != nil {
+		tokens += tokenizer.Count(message.FunctionCall.Name) + tokenizer.Count(message.FunctionCall.Arguments)
+	}
+	return tokens
+}
+
+// Tokenizers creates the Tokenizer of each model once.
+// The encodings are downloaded on first use, so the approximate tokenizer is used when an encoding cannot be loaded.
+type Tokenizers struct {
+	mutex      sync.Mutex
+	tokenizers map[string]*lazyTokenizer
+}
+
+// lazyTokenizer loads the Tokenizer of a model once
+type lazyTokenizer struct {
+	once      sync.Once
+	tokenizer Tokenizer
+}
+
+// NewTokenizers creates a new Tokenizers
+func NewTokenizers() *Tokenizers {
+	return &Tokenizers{tokenizers: map[string]*lazyTokenizer{}}
+}
+
+// For returns the Tokenizer of the model.
+// The encoding is loaded without holding the lock so that a download does not block the tokenizers of other models.
+func (tokenizers *Tokenizers) For(model string) Tokenizer {
+	tokenizers.mutex.Lock()
+	lazy, ok := tokenizers.tokenizers[model]
+	if !ok {
+		lazy = new(lazyTokenizer)
+		tokenizers.tokenizers[model] = lazy
+	}
+	tokenizers.mutex.Unlock()
+
+	lazy.once.Do(func() {
+		lazy.tokenizer = ApproximateTokenizer{}
+		if encoding, err := tiktoken.EncodingForModel(model); err == nil {
+			lazy.tokenizer = &tiktokenTokenizer{encoding: encoding}
+		}
+	})
+	return lazy.tokenizer
+}
+
+// tiktokenTokenizer counts tokens with the byte pair encoding of the OpenAI models
+type tiktokenTokenizer struct {
+	encoding *tiktoken.Tiktoken
+}
+
+// Count returns the number of tokens in the text
+func (tokenizer *tiktokenTokenizer) Count(text string) int {
+	return len(tokenizer.encoding.EncodeOrdinary(text))
+}
+
+// Name is the name of the tokenizer
+func (tokenizer *tiktokenTokenizer) Name() string {
+	return "tiktoken"
+}
+
+// ApproximateTokenizer estimates the tokens of a text from its length
+type ApproximateTokenizer struct{}
+
+// Count returns the estimated number of tokens in the text
+func (tokenizer ApproximateTokenizer) Count(text string) int {
+	return (len(text) + charactersPerToken - 1) / charactersPerToken
+}
+
+// Name is the name of the tokenizer
+func (tokenizer ApproximateTokenizer) Name() string {
+	return "approximate"
+}
@@ -0,0 +1,46 @@
+package completion
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenizers_For(t *testing.T) {
+	t.Run("the tokenizer of a model is created once for concurrent callers", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		tokenizers := NewTokenizers()
+
+		// Arrange
+		var wg sync.WaitGroup
+		results := make([]Tokenizer, 10)
+
+		// Act
+		for i := range results {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				results[i] = tokenizers.For("llama-2-70b")
+			}(i)
+		}
+		wg.Wait()
+
+		// Assert
+		for _, tokenizer := range results {
+			assert.Equal(t, results[0], tokenizer)
+		}
+	})
+
+	t.Run("the approximate tokenizer is used for a model without an encoding", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+
+		// Act
+		tokenizer := NewTokenizers().For("llama-2-70b")
+
+		// Assert
+		assert.Equal(t, "approximate", tokenizer.Name())
+	})
+}
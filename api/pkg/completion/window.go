@@ -0,0 +1,128 @@
+package completion
+
+import (
+	"strings"
+
+	"github.com/palantir/stacktrace"
+	"github.com/sashabaranov/go-openai"
+)
+
+const (
+	// ErrCodeContextWindowExceeded is returned when a prompt does not fit in the context window of a model
+	ErrCodeContextWindowExceeded = stacktrace.ErrorCode(3000)
+)
+
+// Window is the number of tokens in the context window of a model and the tokens reserved for its reply
+type Window struct {
+	Size  int
+	Reply int
+}
+
+// Budget splits a context window between the history, the prompt and the reply
+type Budget struct {
+	Model   string
+	Window  int
+	Prompt  int
+	History int
+	Reply   int
+}
+
+// Windows are the context windows of the models
+type Windows struct {
+	windows      map[string]Window
+	fallback     Window
+	minReply     int
+	historyShare float64
+}
+
+// NewWindows creates Windows with the context windows of the models.
+// The history uses at most historyShare of the context window and the reply is never smaller than minReply tokens.
+func NewWindows(fallback Window, minReply int, historyShare float64, windows map[string]Window) *Windows {
+	return &Windows{
+		windows:      windows,
+		fallback:     fallback,
+		minReply:     minReply,
+		historyShare: historyShare,
+	}
+}
+
+// For returns the Window of the model. The window with the longest matching prefix is used
+// e.g. "gpt-4-0613" uses the window of "gpt-4" and the fallback is used when there is no match.
+func (windows *Windows) For(model string) Window {
+	window, prefix := windows.fallback, ""
+	for name, candidate := range windows.windows {
+		if strings.HasPrefix(model, name) && len(name) > len(prefix) {
+			window, prefix = candidate, name
+		}
+	}
+	return window
+}
+
+// Budget splits the smallest context window of the models so that the completion fits in every model of a Chain.
+// promptTokens are the tokens of the messages which must be sent e.g. the system message and the prompt of the user.
+func (windows *Windows) Budget(promptTokens int, models ...string) (Budget, error) {
+	budget := Budget{Window: windows.fallback.Size, Reply: windows.fallback.Reply}
+	for i, model := range models {
+		if window := windows.For(model); i == 0 || window.Size < budget.Window {
+			budget = Budget{Model: model, Window: window.Size, Reply: window.Reply}
+		}
+	}
+
+	free := budget.Window - promptTokens
+	if free < windows.minReply {
+		return budget, stacktrace.NewErrorWithCode(
+			ErrCodeContextWindowExceeded,
+			"the prompt has [%d] tokens and the [%d] tokens context window of [%s] needs at least [%d] tokens for the reply",
+			promptTokens, budget.Window, budget.Model, windows.minReply,
+		)
+	}
+
+	budget.Prompt = promptTokens
+	if budget.Reply > free {
+		budget.Reply = free
+	}
+	budget.History = int(windows.historyShare * float64(budget.Window))
+	if budget.History > free-budget.Reply {
+		budget.History = free - budget.Reply
+	}
+
+	return budget, nil
+}
+
+// Shrink fits the history and the reply in the budget when the prompt grows to promptTokens e.g. with the results of tool calls.
+// The oldest messages of the history are dropped so that the reply has at least the minimum tokens and the reply is
+// shrunk to the tokens which are left. The error has the code ErrCodeContextWindowExceeded when the prompt alone does not fit.
+func (windows *Windows) Shrink(tokenizer Tokenizer, budget Budget, promptTokens int, history []openai.ChatCompletionMessage) ([]openai.ChatCompletionMessage, int, error) {
+	free := budget.Window - promptTokens
+	if free < windows.minReply {
+		return nil, 0, stacktrace.NewErrorWithCode(
+			ErrCodeContextWindowExceeded,
+			"the prompt has [%d] tokens and the [%d] tokens context window of [%s] needs at least [%d] tokens for the reply",
+			promptTokens, budget.Window, budget.Model, windows.minReply,
+		)
+	}
+
+	history, _ = Fit(tokenizer, history, free-windows.minReply)
+	for _, message := range history {
+		free -= CountMessage(tokenizer, message)
+	}
+
+	if budget.Reply < free {
+		return history, budget.Reply, nil
+	}
+	return history, free, nil
+}
+
+// Fit returns the most recent messages of the history which fit in the number of tokens and the older messages which were dropped
+func Fit(tokenizer Tokenizer, history []openai.ChatCompletionMessage, tokens int) (kept []openai.ChatCompletionMessage, dropped []openai.ChatCompletionMessage) {
+	start := len(history)
+	for start > 0 {
+		count := CountMessage(tokenizer, history[start-1])
+		if count > tokens {
+			break
+		}
+		tokens -= count
+		start--
+	}
+	return history[start:], history[:start]
+}
@@ -0,0 +1,119 @@
+package completion
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/palantir/stacktrace"
+	"github.com/sashabaranov/go-openai"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWindows_Budget(t *testing.T) {
+	windows := NewWindows(Window{Size: 2048, Reply: 500}, 100, 0.5, map[string]Window{
+		"gpt-3.5-turbo":     {Size: 4096, Reply: 1000},
+		"gpt-3.5-turbo-16k": {Size: 16384, Reply: 2000},
+		"gpt-4":             {Size: 8192, Reply: 2000},
+	})
+
+	t.Run("it uses the smallest context window of the models", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+
+		// Act
+		budget, err := windows.Budget(96, "gpt-4-0613", "gpt-3.5-turbo-16k", "gpt-3.5-turbo")
+
+		// Assert
+		assert.Nil(t, err)
+		assert.Equal(t, Budget{Model: "gpt-3.5-turbo", Window: 4096, Prompt: 96, History: 2048, Reply: 1000}, budget)
+	})
+
+	t.Run("the history and the reply shrink for long prompts", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+
+		// Act
+		budget, err := windows.Budget(3800, "gpt-3.5-turbo")
+
+		// Assert
+		assert.Nil(t, err)
+		assert.Equal(t, 296, budget.Reply)
+		assert.Equal(t, 0, budget.History)
+	})
+
+	t.Run("it returns an error when the reply does not fit", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+
+		// Act
+		_, err := windows.Budget(2000, "llama")
+
+		// Assert
+		assert.Equal(t, ErrCodeContextWindowExceeded, stacktrace.GetCode(err))
+	})
+}
+
+func TestWindows_Shrink(t *testing.T) {
+	windows := NewWindows(Window{Size: 200, Reply: 100}, 20, 0.5, nil)
+	budget := Budget{Model: "llama", Window: 200, Prompt: 50, History: 50, Reply: 100}
+	history := []openai.ChatCompletionMessage{
+		{Role: openai.ChatMessageRoleUser, Content: strings.Repeat("a", 40)},
+		{Role: openai.ChatMessageRoleAssistant, Content: strings.Repeat("b", 40)},
+	}
+
+	t.Run("the reply shrinks when the prompt grows", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+
+		// Act
+		kept, reply, err := windows.Shrink(ApproximateTokenizer{}, budget, 120, history)
+
+		// Assert
+		assert.Nil(t, err)
+		assert.Equal(t, history, kept)
+		assert.Equal(t, 50, reply)
+	})
+
+	t.Run("the oldest messages of the history are dropped to keep the minimum reply", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+
+		// Act
+		kept, reply, err := windows.Shrink(ApproximateTokenizer{}, budget, 160, history)
+
+		// Assert
+		assert.Nil(t, err)
+		assert.Equal(t, history[1:], kept)
+		assert.Equal(t, 24, reply)
+	})
+
+	t.Run("it returns an error when the prompt alone does not fit", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+
+		// Act
+		_, _, err := windows.Shrink(ApproximateTokenizer{}, budget, 190, history)
+
+		// Assert
+		assert.Equal(t, ErrCodeContextWindowExceeded, stacktrace.GetCode(err))
+	})
+}
+
+func TestFit(t *testing.T) {
+	// Setup
+	t.Parallel()
+
+	// Arrange
+	history := []openai.ChatCompletionMessage{
+		{Role: openai.ChatMessageRoleUser, Content: strings.Repeat("a", 40)},
+		{Role: openai.ChatMessageRoleAssistant, Content: strings.Repeat("b", 40)},
+		{Role: openai.ChatMessageRoleUser, Content: strings.Repeat("c", 40)},
+	}
+
+	// Act
+	kept, dropped := Fit(ApproximateTokenizer{}, history, 30)
+
+	// Assert
+	assert.Equal(t, history[1:], kept)
+	assert.Equal(t, history[:1], dropped)
+}
@@ -0,0 +1,464 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/palantir/stacktrace"
+)
+
+const (
+	// EnvironmentLocal is used when running the API on a developer's machine
+	EnvironmentLocal = "local"
+
+	// DriverMemory keeps the data in the memory of the process
+	DriverMemory = "memory"
+
+	// DriverRedis stores the data in redis
+	DriverRedis = "redis"
+
+	// DriverGoogle pushes queued tasks through Google Cloud Tasks
+	DriverGoogle = "google"
+
+	// ProviderOpenAI generates completions with the OpenAI API
+	ProviderOpenAI = "openai"
+
+	// ProviderSecondary generates completions with an OpenAI compatible API at COMPLETION_SECONDARY_BASE_URL
+	ProviderSecondary = "secondary"
+
+	// VectorStoreAuto uses pgvector when the extension can be created in the database
+	VectorStoreAuto = "auto"
+
+	// VectorStorePGVector searches the embeddings of the knowledge bases with pgvector
+	VectorStorePGVector = "pgvector"
+
+	// VectorStoreBruteForce compares all the embeddings of a knowledge base in memory
+	VectorStoreBruteForce = "bruteforce"
+)
+
+// Config is the configuration of the API
+type Config struct {
+	Environment     string        `yaml:"environment" env:"ENV" default:"production"`
+	Port            int           `yaml:"port" env:"PORT" default:"8000"`
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" env:"SHUTDOWN_TIMEOUT" default:"9s"`
+	LogUnredacted   bool          `yaml:"log_unredacted" env:"LOG_UNREDACTED"`
+	AdminAPIKey     string        `yaml:"admin_api_key" env:"ADMIN_API_KEY" secret:"true"`
+	TaskAPIKey      string        `yaml:"task_api_key" env:"TASK_API_KEY" secret:"true"`
+
+	GCP        GCPConfig        `yaml:"gcp"`
+	Database   DatabaseConfig   `yaml:"database"`
+	Redis      RedisConfig      `yaml:"redis"`
+	Tracing    TracingConfig    `yaml:"tracing"`
+	Queue      QueueConfig      `yaml:"queue"`
+	Cache      CacheConfig      `yaml:"cache"`
+	Nexmo      NexmoConfig      `yaml:"nexmo"`
+	Whatsapp   WhatsappConfig   `yaml:"whatsapp"`
+	OpenAPI    OpenAPIConfig    `yaml:"openapi"`
+	Completion CompletionConfig `yaml:"completion"`
+	Tools      ToolsConfig      `yaml:"tools"`
+	Knowledge  KnowledgeConfig  `yaml:"knowledge"`
+	Reminders  RemindersConfig  `yaml:"reminders"`
+	Digests    DigestsConfig    `yaml:"digests"`
+	Webhooks   WebhooksConfig   `yaml:"webhooks"`
+	Health     HealthConfig     `yaml:"health"`
+	Metrics    MetricsConfig    `yaml:"metrics"`
+}
+
+// GCPConfig is the configuration of the Google Cloud project
+type GCPConfig struct {
+	ProjectID      string `yaml:"project_id" env:"GCP_PROJECT_ID"`
+	QueueName      string `yaml:"queue_name" env:"GCP_QUEUE_NAME"`
+	QueueAuthEmail string `yaml:"queue_auth_email" env:"GCP_QUEUE_AUTH_EMAIL"`
+}
+
+// DatabaseConfig is the configuration of the postgres database
+type DatabaseConfig struct {
+	DSN string `yaml:"dsn" env:"DATABASE_DSN" secret:"true"`
+}
+
+// RedisConfig is the configuration of the redis server
+type RedisConfig struct {
+	URL string `yaml:"url" env:"REDIS_URL" secret:"true"`
+}
+
+// TracingConfig is the configuration of the open telemetry exporter
+type TracingConfig struct {
+	UptraceDSN  string `yaml:"uptrace_dsn" env:"UPTRACE_DSN" secret:"true"`
+	Propagators string `yaml:"propagators" env:"OTEL_PROPAGATORS" default:"cloudtrace,tracecontext,baggage"`
+}
+
+// QueueConfig is the configuration of the queue used for background tasks
+type QueueConfig struct {
+	Driver            string `yaml:"driver" env:"QUEUE_DRIVER" default:"memory"`
+	Concurrency       int    `yaml:"concurrency" env:"QUEUE_CONCURRENCY" default:"5"`
+	MaxAttempts       uint   `yaml:"max_attempts" env:"QUEUE_MAX_ATTEMPTS" default:"5"`
+	EventsConsumerURL string `yaml:"events_consumer_url" env:"EVENTS_CONSUMER_URL"`
+}
+
+// CacheConfig is the configuration of the cache
+type CacheConfig struct {
+	Driver   string `yaml:"driver" env:"CACHE_DRIVER"`
+	Capacity int    `yaml:"capacity" env:"CACHE_CAPACITY" default:"10000"`
+}
+
+// NexmoConfig is the configuration of the Nexmo SMS API
+type NexmoConfig struct {
+	APIKey    string `yaml:"api_key" env:"NEXMO_API_KEY" secret:"true"`
+	APISecret string `yaml:"api_secret" env:"NEXMO_API_SECRET" secret:"true"`
+}
+
+// WhatsappConfig is the configuration of the Whatsapp cloud API
+type WhatsappConfig struct {
+	AccessToken      string `yaml:"access_token" env:"WHATSAPP_ACCESS_TOKEN" secret:"true"`
+	DigestTemplate   string `yaml:"digest_template" env:"WHATSAPP_DIGEST_TEMPLATE" default:"daily_digest"`
+	ReminderTemplate string `yaml:"reminder_template" env:"WHATSAPP_REMINDER_TEMPLATE" default:"reminder"`
+	APIVersion       string `yaml:"api_version" env:"WHATSAPP_API_VERSION" default:"v16.0"`
+}
+
+// OpenAPIConfig is the configuration of the OpenAI API
+type OpenAPIConfig struct {
+	AuthToken string `yaml:"auth_token" env:"OPENAPI_AUTH_TOKEN" secret:"true"`
+}
+
+// CompletionConfig is the configuration of the models which generate completions.
+// Models is an ordered list of "provider/model" which are tried until a completion is generated.
+type CompletionConfig struct {
+	Models             string `yaml:"models" env:"COMPLETION_MODELS" default:"openai/gpt-3.5-turbo"`
+	SecondaryBaseURL   string `yaml:"secondary_base_url" env:"COMPLETION_SECONDARY_BASE_URL"`
+	SecondaryAuthToken string `yaml:"secondary_auth_token" env:"COMPLETION_SECONDARY_AUTH_TOKEN" secret:"true"`
+
+	BreakerConsecutiveFailures int           `yaml:"breaker_consecutive_failures" env:"COMPLETION_BREAKER_CONSECUTIVE_FAILURES" default:"5"`
+	BreakerErrorRate           float64       `yaml:"breaker_error_rate" env:"COMPLETION_BREAKER_ERROR_RATE" default:"0.5"`
+	BreakerMinRequests         int           `yaml:"breaker_min_requests" env:"COMPLETION_BREAKER_MIN_REQUESTS" default:"10"`
+	BreakerWindow              time.Duration `yaml:"breaker_window" env:"COMPLETION_BREAKER_WINDOW" default:"1m"`
+	BreakerCooldown            time.Duration `yaml:"breaker_cooldown" env:"COMPLETION_BREAKER_COOLDOWN" default:"30s"`
+
+	ContextWindows  string  `yaml:"context_windows" env:"COMPLETION_CONTEXT_WINDOWS" default:"gpt-3.5-turbo=4096:1000,gpt-3.5-turbo-16k=16384:2000,gpt-4=8192:2000,gpt-4-32k=32768:4000"`
+	ContextWindow   int     `yaml:"context_window" env:"COMPLETION_CONTEXT_WINDOW" default:"4096"`
+	ReplyTokens     int     `yaml:"reply_tokens" env:"COMPLETION_REPLY_TOKENS" default:"1000"`
+	MinReplyTokens  int     `yaml:"min_reply_tokens" env:"COMPLETION_MIN_REPLY_TOKENS" default:"200"`
+	HistoryShare    float64 `yaml:"history_share" env:"COMPLETION_HISTORY_SHARE" default:"0.5"`
+	HistoryMessages int     `yaml:"history_messages" env:"COMPLETION_HISTORY_MESSAGES" default:"50"`
+	SummaryTokens   int     `yaml:"summary_tokens" env:"COMPLETION_SUMMARY_TOKENS" default:"300"`
+}
+
+// ContextWindow is the context window of a model in COMPLETION_CONTEXT_WINDOWS
+type ContextWindow struct {
+	Size        int
+	ReplyTokens int
+}
+
+// CompletionModel is a model of a provider in the CompletionConfig
+type CompletionModel struct {
+	Provider string
+	Model    string
+}
+
+// ToolsConfig is the configuration of the tools which the language model can call while generating a completion.
+// CurrencyRates is a list of "currency=rate" where the rate is in units of the currency per US dollar.
+type ToolsConfig struct {
+	Enabled       bool   `yaml:"enabled" env:"TOOLS_ENABLED" default:"true"`
+	MaxSteps      int    `yaml:"max_steps" env:"TOOLS_MAX_STEPS" default:"3"`
+	CurrencyRates string `yaml:"currency_rates" env:"TOOLS_CURRENCY_RATES" default:"EUR=0.92,GBP=0.79,XAF=603.5,XOF=603.5,NGN=770,KES=145,GHS=11.5,ZAR=18.5,CAD=1.35,JPY=145"`
+	FAQFile       string `yaml:"faq_file" env:"TOOLS_FAQ_FILE"`
+}
+
+// KnowledgeConfig is the configuration of the knowledge bases which are searched to answer prompts.
+// VectorStore is [pgvector] to search the embeddings in Postgres, [bruteforce] to compare them in memory or [auto]
+// to use pgvector when the extension can be created.
+type KnowledgeConfig struct {
+	VectorStore    string  `yaml:"vector_store" env:"KNOWLEDGE_VECTOR_STORE" default:"auto"`
+	EmbeddingModel string  `yaml:"embedding_model" env:"KNOWLEDGE_EMBEDDING_MODEL" default:"text-embedding-ada-002"`
+	ChunkTokens    int     `yaml:"chunk_tokens" env:"KNOWLEDGE_CHUNK_TOKENS" default:"300"`
+	ChunkOverlap   int     `yaml:"chunk_overlap" env:"KNOWLEDGE_CHUNK_OVERLAP" default:"50"`
+	TopK           int     `yaml:"top_k" env:"KNOWLEDGE_TOP_K" default:"4"`
+	MinScore       float64 `yaml:"min_score" env:"KNOWLEDGE_MIN_SCORE" default:"0.75"`
+}
+
+// RemindersConfig is the configuration of reminders
+type RemindersConfig struct {
+	DeliveryURL string `yaml:"delivery_url" env:"REMINDERS_DELIVERY_URL" default:"http://localhost:8000/v1/reminders/deliver"`
+}
+
+// DigestsConfig is the configuration of daily digests.
+// The prompts of the topics which are empty use the default prompts.
+type DigestsConfig struct {
+	DeliveryURL    string `yaml:"delivery_url" env:"DIGESTS_DELIVERY_URL" default:"http://localhost:8000/v1/digests/deliver"`
+	PromptWord     string `yaml:"prompt_word" env:"DIGEST_PROMPT_WORD"`
+	PromptBriefing string `yaml:"prompt_briefing" env:"DIGEST_PROMPT_BRIEFING"`
+	PromptQuestion string `yaml:"prompt_question" env:"DIGEST_PROMPT_QUESTION"`
+}
+
+// WebhooksConfig is the configuration of the delivery of events to webhooks
+type WebhooksConfig struct {
+	DeliveryURL string `yaml:"delivery_url" env:"WEBHOOKS_DELIVERY_URL" default:"http://localhost:8000/v1/webhook-deliveries/deliver"`
+}
+
+// HealthConfig is the configuration of the health checks of the dependencies
+type HealthConfig struct {
+	CheckTimeout time.Duration `yaml:"check_timeout" env:"HEALTH_CHECK_TIMEOUT" default:"5s"`
+	CacheTTL     time.Duration `yaml:"cache_ttl" env:"HEALTH_CACHE_TTL" default:"30s"`
+}
+
+// MetricsConfig is the configuration of the prometheus /metrics endpoint
+type MetricsConfig struct {
+	Enabled bool `yaml:"enabled" env:"METRICS_ENABLED"`
+}
+
+// PropagatorNames are the names of the propagators of the trace context e.g. "tracecontext"
+func (config *Config) PropagatorNames() []string {
+	var names []string
+	for _, name := range strings.Split(config.Tracing.Propagators, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// CompletionModels are the models in COMPLETION_MODELS in the order in which they are tried
+func (config *Config) CompletionModels() []CompletionModel {
+	var models []CompletionModel
+	for _, value := range strings.Split(config.Completion.Models, ",") {
+		if value = strings.TrimSpace(value); value == "" {
+			continue
+		}
+		provider, model, _ := strings.Cut(value, "/")
+		models = append(models, CompletionModel{Provider: strings.TrimSpace(provider), Model: strings.TrimSpace(model)})
+	}
+	return models
+}
+
+// ContextWindows parses COMPLETION_CONTEXT_WINDOWS which has the format "model=size:reply" e.g. "gpt-4=8192:2000".
+// The reply tokens are optional and default to COMPLETION_REPLY_TOKENS.
+func (config *Config) ContextWindows() (map[string]ContextWindow, error) {
+	windows := map[string]ContextWindow{}
+	for _, value := range strings.Split(config.Completion.ContextWindows, ",") {
+		if value = strings.TrimSpace(value); value == "" {
+			continue
+		}
+
+		model, window, found := strings.Cut(value, "=")
+		if !found || strings.TrimSpace(model) == "" {
+			return nil, stacktrace.NewError("context window [%s] must have the format model=size:reply", value)
+		}
+
+		size, reply, hasReply := strings.Cut(window, ":")
+		result := ContextWindow{ReplyTokens: config.Completion.ReplyTokens}
+		var err error
+		if result.Size, err = strconv.Atoi(strings.TrimSpace(size)); err != nil || result.Size <= 0 {
+			return nil, stacktrace.NewError("the size of context window [%s] must be a positive integer", value)
+		}
+		if hasReply {
+			if result.ReplyTokens, err = strconv.Atoi(strings.TrimSpace(reply)); err != nil || result.ReplyTokens <= 0 {
+				return nil, stacktrace.NewError("the reply tokens of context window [%s] must be a positive integer", value)
+			}
+		}
+
+		windows[strings.TrimSpace(model)] = result
+	}
+	return windows, nil
+}
+
+// CurrencyRates parses TOOLS_CURRENCY_RATES which has the format "currency=rate" e.g. "EUR=0.92"
+func (config *Config) CurrencyRates() (map[string]float64, error) {
+	rates := map[string]float64{}
+	for _, value := range strings.Split(config.Tools.CurrencyRates, ",") {
+		if value = strings.TrimSpace(value); value == "" {
+			continue
+		}
+
+		currency, rate, found := strings.Cut(value, "=")
+		if !found || strings.TrimSpace(currency) == "" {
+			return nil, stacktrace.NewError("currency rate [%s] must have the format currency=rate", value)
+		}
+
+		parsed, err := strconv.ParseFloat(strings.TrimSpace(rate), 64)
+		if err != nil || parsed <= 0 {
+			return nil, stacktrace.NewError("the rate of currency [%s] must be a positive number", value)
+		}
+		rates[strings.ToUpper(strings.TrimSpace(currency))] = parsed
+	}
+	return rates, nil
+}
+
+// IsLocal checks if the API is running on a developer's machine
+func (config *Config) IsLocal() bool {
+	return config.Environment == EnvironmentLocal
+}
+
+// MetricsEnabled checks if the metrics can be scraped from the /metrics endpoint. It is always enabled when running locally.
+func (config *Config) MetricsEnabled() bool {
+	return config.Metrics.Enabled || config.IsLocal()
+}
+
+// CacheDriver is the driver of the cache. The redis driver is used when the cache driver is not set and there is a redis URL.
+func (config *Config) CacheDriver() string {
+	if config.Cache.Driver != "" {
+		return config.Cache.Driver
+	}
+	if config.Redis.URL != "" {
+		return DriverRedis
+	}
+	return DriverMemory
+}
+
+// Validate checks that the configuration can be used to run the API and returns all the problems at once
+func (config *Config) Validate() error {
+	var problems []string
+	require := func(value string, key string, reason string) {
+		if strings.TrimSpace(value) == "" {
+			problems = append(problems, fmt.Sprintf("%s is required %s", key, reason))
+		}
+	}
+
+	require(config.Database.DSN, "DATABASE_DSN", "to connect to the database")
+	require(config.OpenAPI.AuthToken, "OPENAPI_AUTH_TOKEN", "to send prompts to OpenAI")
+	require(config.TaskAPIKey, "TASK_API_KEY", "to authenticate queued tasks")
+	if config.TaskAPIKey != "" && config.TaskAPIKey == config.AdminAPIKey {
+		problems = append(problems, "TASK_API_KEY must be different from ADMIN_API_KEY")
+	}
+
+	if !config.IsLocal() {
+		require(config.Nexmo.APIKey, "NEXMO_API_KEY", "to send SMS messages")
+		require(config.Nexmo.APISecret, "NEXMO_API_SECRET", "to send SMS messages")
+		require(config.Whatsapp.AccessToken, "WHATSAPP_ACCESS_TOKEN", "to send whatsapp messages")
+	}
+
+	if config.Port <= 0 || config.Port > 65535 {
+		problems = append(problems, fmt.Sprintf("PORT [%d] must be between 1 and 65535", config.Port))
+	}
+	if config.ShutdownTimeout <= 0 {
+		problems = append(problems, fmt.Sprintf("SHUTDOWN_TIMEOUT [%s] must be positive", config.ShutdownTimeout))
+	}
+
+	if config.Health.CheckTimeout <= 0 {
+		problems = append(problems, fmt.Sprintf("HEALTH_CHECK_TIMEOUT [%s] must be positive", config.Health.CheckTimeout))
+	}
+	if config.Health.CacheTTL < 0 {
+		problems = append(problems, fmt.Sprintf("HEALTH_CACHE_TTL [%s] cannot be negative", config.Health.CacheTTL))
+	}
+
+	if len(config.PropagatorNames()) == 0 {
+		problems = append(problems, "OTEL_PROPAGATORS is required to propagate the trace context")
+	}
+	for _, name := range config.PropagatorNames() {
+		switch strings.ToLower(name) {
+		case "tracecontext", "baggage", "b3", "b3multi", "cloudtrace":
+		default:
+			problems = append(problems, fmt.Sprintf("OTEL_PROPAGATORS [%s] must be a list of [tracecontext, baggage, b3, b3multi, cloudtrace]", name))
+		}
+	}
+
+	if len(config.CompletionModels()) == 0 {
+		problems = append(problems, "COMPLETION_MODELS is required to generate completions")
+	}
+	for _, model := range config.CompletionModels() {
+		switch {
+		case model.Model == "":
+			problems = append(problems, fmt.Sprintf("COMPLETION_MODELS [%s] must have the format provider/model", model.Provider))
+		case model.Provider == ProviderOpenAI:
+		case model.Provider == ProviderSecondary:
+			require(config.Completion.SecondaryBaseURL, "COMPLETION_SECONDARY_BASE_URL", "when COMPLETION_MODELS has a [secondary] model")
+		default:
+			problems = append(problems, fmt.Sprintf("COMPLETION_MODELS provider [%s] must be one of [openai, secondary]", model.Provider))
+		}
+	}
+	if config.Completion.BreakerErrorRate <= 0 || config.Completion.BreakerErrorRate > 1 {
+		problems = append(problems, fmt.Sprintf("COMPLETION_BREAKER_ERROR_RATE [%g] must be between 0 and 1", config.Completion.BreakerErrorRate))
+	}
+	if config.Completion.BreakerConsecutiveFailures <= 0 || config.Completion.BreakerMinRequests <= 0 {
+		problems = append(problems, "COMPLETION_BREAKER_CONSECUTIVE_FAILURES and COMPLETION_BREAKER_MIN_REQUESTS must be positive")
+	}
+	if config.Completion.BreakerWindow <= 0 || config.Completion.BreakerCooldown <= 0 {
+		problems = append(problems, "COMPLETION_BREAKER_WINDOW and COMPLETION_BREAKER_COOLDOWN must be positive")
+	}
+
+	if _, err := config.ContextWindows(); err != nil {
+		problems = append(problems, fmt.Sprintf("COMPLETION_CONTEXT_WINDOWS is invalid because %s", stacktrace.RootCause(err)))
+	}
+	if config.Completion.ContextWindow <= 0 || config.Completion.ReplyTokens <= 0 || config.Completion.MinReplyTokens <= 0 {
+		problems = append(problems, "COMPLETION_CONTEXT_WINDOW, COMPLETION_REPLY_TOKENS and COMPLETION_MIN_REPLY_TOKENS must be positive")
+	}
+	if config.Completion.HistoryShare < 0 || config.Completion.HistoryShare >= 1 {
+		problems = append(problems, fmt.Sprintf("COMPLETION_HISTORY_SHARE [%g] must be between 0 and 1", config.Completion.HistoryShare))
+	}
+	if config.Completion.HistoryMessages < 0 || config.Completion.SummaryTokens <= 0 {
+		problems = append(problems, "COMPLETION_HISTORY_MESSAGES cannot be negative and COMPLETION_SUMMARY_TOKENS must be positive")
+	}
+
+	if config.Tools.MaxSteps <= 0 {
+		problems = append(problems, fmt.Sprintf("TOOLS_MAX_STEPS [%d] must be positive", config.Tools.MaxSteps))
+	}
+	if _, err := config.CurrencyRates(); err != nil {
+		problems = append(problems, fmt.Sprintf("TOOLS_CURRENCY_RATES is invalid because %s", stacktrace.RootCause(err)))
+	}
+
+	switch config.Knowledge.VectorStore {
+	case VectorStoreAuto, VectorStorePGVector, VectorStoreBruteForce:
+	default:
+		problems = append(problems, fmt.Sprintf("KNOWLEDGE_VECTOR_STORE [%s] must be one of [auto, pgvector, bruteforce]", config.Knowledge.VectorStore))
+	}
+	if config.Knowledge.ChunkTokens <= 0 || config.Knowledge.ChunkOverlap < 0 || config.Knowledge.ChunkOverlap >= config.Knowledge.ChunkTokens {
+		problems = append(problems, "KNOWLEDGE_CHUNK_TOKENS must be positive and KNOWLEDGE_CHUNK_OVERLAP must be between 0 and KNOWLEDGE_CHUNK_TOKENS")
+	}
+	if config.Knowledge.TopK <= 0 {
+		problems = append(problems, fmt.Sprintf("KNOWLEDGE_TOP_K [%d] must be positive", config.Knowledge.TopK))
+	}
+	if config.Knowledge.MinScore < -1 || config.Knowledge.MinScore > 1 {
+		problems = append(problems, fmt.Sprintf("KNOWLEDGE_MIN_SCORE [%g] must be between -1 and 1", config.Knowledge.MinScore))
+	}
+
+	switch config.Queue.Driver {
+	case DriverMemory:
+	case DriverRedis:
+		require(config.Redis.URL, "REDIS_URL", "when QUEUE_DRIVER is [redis]")
+	case DriverGoogle:
+		require(config.GCP.ProjectID, "GCP_PROJECT_ID", "when QUEUE_DRIVER is [google]")
+		require(config.GCP.QueueName, "GCP_QUEUE_NAME", "when QUEUE_DRIVER is [google]")
+		require(config.GCP.QueueAuthEmail, "GCP_QUEUE_AUTH_EMAIL", "when QUEUE_DRIVER is [google]")
+	default:
+		problems = append(problems, fmt.Sprintf("QUEUE_DRIVER [%s] must be one of [memory, redis, google]", config.Queue.Driver))
+	}
+	if config.Queue.Concurrency <= 0 {
+		problems = append(problems, fmt.Sprintf("QUEUE_CONCURRENCY [%d] must be positive", config.Queue.Concurrency))
+	}
+	if config.Queue.MaxAttempts == 0 {
+		problems = append(problems, "QUEUE_MAX_ATTEMPTS must be positive")
+	}
+
+	switch config.CacheDriver() {
+	case DriverMemory:
+	case DriverRedis:
+		require(config.Redis.URL, "REDIS_URL", "when CACHE_DRIVER is [redis]")
+	default:
+		problems = append(problems, fmt.Sprintf("CACHE_DRIVER [%s] must be one of [memory, redis]", config.Cache.Driver))
+	}
+	if config.Cache.Capacity <= 0 {
+		problems = append(problems, fmt.Sprintf("CACHE_CAPACITY [%d] must be positive", config.Cache.Capacity))
+	}
+
+	urls := map[string]string{
+		"EVENTS_CONSUMER_URL":           config.Queue.EventsConsumerURL,
+		"REMINDERS_DELIVERY_URL":        config.Reminders.DeliveryURL,
+		"DIGESTS_DELIVERY_URL":          config.Digests.DeliveryURL,
+		"WEBHOOKS_DELIVERY_URL":         config.Webhooks.DeliveryURL,
+		"COMPLETION_SECONDARY_BASE_URL": config.Completion.SecondaryBaseURL,
+	}
+	for _, key := range []string{"EVENTS_CONSUMER_URL", "REMINDERS_DELIVERY_URL", "DIGESTS_DELIVERY_URL", "WEBHOOKS_DELIVERY_URL", "COMPLETION_SECONDARY_BASE_URL"} {
+		if urls[key] == "" {
+			continue
+		}
+		if u, err := url.ParseRequestURI(urls[key]); err != nil || u.Host == "" {
+			problems = append(problems, fmt.Sprintf("%s [%s] must be an absolute URL", key, urls[key]))
+		}
+	}
+
+	if len(problems) > 0 {
+		return stacktrace.NewError("invalid configuration: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
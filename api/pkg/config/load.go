@@ -0,0 +1,169 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/palantir/stacktrace"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	fileSuffix    = "_FILE"
+	redactedValue = "[REDACTED]"
+)
+
+// Load creates the Config from the default values, the optional YAML file at path and the environment variables.
+// Environment variables take precedence over the YAML file and the value of a secret can be read from the file
+// at the path in the environment variable with the "_FILE" suffix e.g. DATABASE_DSN_FILE=/run/secrets/database_dsn
+func Load(path string) (*Config, error) {
+	config := new(Config)
+	if err := visit(reflect.ValueOf(config).Elem(), applyDefault); err != nil {
+		return nil, stacktrace.Propagate(err, "cannot apply the default configuration")
+	}
+
+	if path != "" {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, stacktrace.Propagate(err, fmt.Sprintf("cannot read configuration file [%s]", path))
+		}
+		if err = yaml.Unmarshal(content, config); err != nil {
+			return nil, stacktrace.Propagate(err, fmt.Sprintf("cannot decode configuration file [%s] into %T", path, config))
+		}
+	}
+
+	if err := visit(reflect.ValueOf(config).Elem(), applyEnv); err != nil {
+		return nil, stacktrace.Propagate(err, "cannot load the configuration from the environment")
+	}
+
+	if err := config.Validate(); err != nil {
+		return nil, stacktrace.Propagate(err, "cannot validate the configuration")
+	}
+
+	return config, nil
+}
+
+// Redacted returns a copy of the Config where the secrets which are set are replaced so that it can be logged
+func (config *Config) Redacted() *Config {
+	redacted := *config
+	_ = visit(reflect.ValueOf(&redacted).Elem(), func(field reflect.StructField, value reflect.Value) error {
+		if field.Tag.Get("secret") == "true" && value.String() != "" {
+			value.SetString(redactedValue)
+		}
+		return nil
+	})
+	return &redacted
+}
+
+// String dumps the Config as YAML with the secrets redacted
+func (config *Config) String() string {
+	content, err := yaml.Marshal(config.Redacted())
+	if err != nil {
+		return fmt.Sprintf("cannot encode %T into YAML: %s", config, err)
+	}
+	return string(content)
+}
+
+// visit calls the callback on every field of the struct and its nested structs
+func visit(value reflect.Value, callback func(field reflect.StructField, value reflect.Value) error) error {
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		if field.Type.Kind() == reflect.Struct {
+			if err := visit(value.Field(i), callback); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := callback(field, value.Field(i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func applyDefault(field reflect.StructField, value reflect.Value) error {
+	defaultValue, ok := field.Tag.Lookup("default")
+	if !ok {
+		return nil
+	}
+	if err := setValue(value, defaultValue); err != nil {
+		return stacktrace.Propagate(err, fmt.Sprintf("cannot set default value [%s] of [%s]", defaultValue, field.Name))
+	}
+	return nil
+}
+
+func applyEnv(field reflect.StructField, value reflect.Value) error {
+	key := field.Tag.Get("env")
+	if key == "" {
+		return nil
+	}
+
+	if path := os.Getenv(key + fileSuffix); path != "" {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return stacktrace.Propagate(err, fmt.Sprintf("cannot read [%s] from file [%s]", key, path))
+		}
+		return setEnvValue(key, value, strings.TrimSpace(string(content)))
+	}
+
+	if envValue := os.Getenv(key); envValue != "" {
+		return setEnvValue(key, value, envValue)
+	}
+
+	return nil
+}
+
+func setEnvValue(key string, value reflect.Value, envValue string) error {
+	if err := setValue(value, envValue); err != nil {
+		return stacktrace.Propagate(err, fmt.Sprintf("invalid value for [%s]", key))
+	}
+	return nil
+}
+
+// setValue parses the string into the type of the value
+func setValue(value reflect.Value, input string) error {
+	if value.Type() == reflect.TypeOf(time.Duration(0)) {
+		duration, err := time.ParseDuration(input)
+		if err != nil {
+			return stacktrace.Propagate(err, fmt.Sprintf("cannot parse [%s] as a duration", input))
+		}
+		value.SetInt(int64(duration))
+		return nil
+	}
+
+	switch value.Kind() {
+	case reflect.String:
+		value.SetString(input)
+	case reflect.Bool:
+		parsed, err := strconv.ParseBool(input)
+		if err != nil {
+			return stacktrace.Propagate(err, fmt.Sprintf("cannot parse [%s] as a boolean", input))
+		}
+		value.SetBool(parsed)
+	case reflect.Int, reflect.Int64:
+		parsed, err := strconv.ParseInt(input, 10, 64)
+		if err != nil {
+			return stacktrace.Propagate(err, fmt.Sprintf("cannot parse [%s] as an integer", input))
+		}
+		value.SetInt(parsed)
+	case reflect.Uint, reflect.Uint64:
+		parsed, err := strconv.ParseUint(input, 10, 64)
+		if err != nil {
+			return stacktrace.Propagate(err, fmt.Sprintf("cannot parse [%s] as a positive integer", input))
+		}
+		value.SetUint(parsed)
+	case reflect.Float64:
+		parsed, err := strconv.ParseFloat(input, 64)
+		if err != nil {
+			return stacktrace.Propagate(err, fmt.Sprintf("cannot parse [%s] as a number", input))
+		}
+		value.SetFloat(parsed)
+	default:
+		return stacktrace.NewError("cannot set a value of kind [%s]", value.Kind())
+	}
+	return nil
+}
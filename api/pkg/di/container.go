@@ -3,10 +3,14 @@ package di
 import (
 	"context"
 	"crypto/tls"
+	"encoding/json"
 	"fmt"
+	"net"
 	"net/http"
 	"os"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/NdoleStudio/go-otelroundtripper"
@@ -14,28 +18,52 @@ import (
 
 	"github.com/NdoleStudio/discusswithai/pkg/whatsapp"
 
+	cloudtasks "cloud.google.com/go/cloudtasks/apiv2"
+	"cloud.google.com/go/cloudtasks/apiv2/cloudtaskspb"
 	cloudtrace "github.com/GoogleCloudPlatform/opentelemetry-operations-go/exporter/trace"
 	"github.com/NdoleStudio/discusswithai/pkg/cache"
+	"github.com/NdoleStudio/discusswithai/pkg/completion"
+	"github.com/NdoleStudio/discusswithai/pkg/config"
 	"github.com/NdoleStudio/discusswithai/pkg/entities"
+	"github.com/NdoleStudio/discusswithai/pkg/events"
 	"github.com/NdoleStudio/discusswithai/pkg/handlers"
+	"github.com/NdoleStudio/discusswithai/pkg/i18n"
+	"github.com/NdoleStudio/discusswithai/pkg/knowledge"
+	"github.com/NdoleStudio/discusswithai/pkg/listeners"
 	"github.com/NdoleStudio/discusswithai/pkg/middlewares"
+	"github.com/NdoleStudio/discusswithai/pkg/moderation"
+	"github.com/NdoleStudio/discusswithai/pkg/netguard"
 	"github.com/NdoleStudio/discusswithai/pkg/nexmo"
+	"github.com/NdoleStudio/discusswithai/pkg/queue"
+	"github.com/NdoleStudio/discusswithai/pkg/repositories"
 	"github.com/NdoleStudio/discusswithai/pkg/services"
 	"github.com/NdoleStudio/discusswithai/pkg/telemetry"
+	"github.com/NdoleStudio/discusswithai/pkg/tenancy"
+	"github.com/NdoleStudio/discusswithai/pkg/tools"
 	"github.com/NdoleStudio/discusswithai/pkg/validators"
+	cloudevents "github.com/cloudevents/sdk-go/v2"
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
 	fiberLogger "github.com/gofiber/fiber/v2/middleware/logger"
 	"github.com/gofiber/swagger"
+	"github.com/google/uuid"
 	"github.com/hashicorp/go-retryablehttp"
 	"github.com/hirosassa/zerodriver"
 	"github.com/palantir/stacktrace"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/redis/go-redis/v9"
 	"github.com/rs/zerolog"
 	openapi "github.com/sashabaranov/go-openai"
 	"github.com/uptrace/uptrace-go/uptrace"
+	"github.com/valyala/fasthttp/fasthttpadaptor"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
+	otelprometheus "go.opentelemetry.io/otel/exporters/prometheus"
+	"go.opentelemetry.io/otel/propagation"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
 	"go.opentelemetry.io/otel/sdk/resource"
 	"go.opentelemetry.io/otel/sdk/trace"
 	semconv "go.opentelemetry.io/otel/semconv/v1.18.0"
@@ -50,52 +78,1034 @@ import (
 type Container struct {
 	projectID string
 	version   string
+	config    *config.Config
 	db        *gorm.DB
 	app       *fiber.App
 	logger    telemetry.Logger
+
+	eventDispatcher events.Dispatcher
+	queueBroker     queue.Broker
+	redisClient     *redis.Client
+	cache           cache.Cache
+	locker          cache.Locker
+	healthService   *services.HealthService
+	metrics         *telemetry.Metrics
+	meterProvider   *sdkmetric.MeterProvider
+	metricsRegistry *prometheus.Registry
+	cloudTasks      *cloudtasks.Client
+	propagator      propagation.TextMapPropagator
+	completionChain *completion.Chain
+	tokenizers      *completion.Tokenizers
+	toolRegistry    *tools.Registry
+	tenantService   *services.TenantService
+	pgvector        bool
+
+	flushTraces   func(ctx context.Context) error
+	stopWorkers   context.CancelFunc
+	workerContext context.Context
+	workers       sync.WaitGroup
+}
+
+// NewContainer creates a new dependency injection container
+func NewContainer(version string, cfg *config.Config) (container *Container) {
+	// Set location to UTC
+	now.DefaultConfig = &now.Config{
+		TimeLocation: time.UTC,
+	}
+
+	// Personally identifiable information can only be logged when debugging locally
+	telemetry.ConfigureRedaction(!(cfg.IsLocal() && cfg.LogUnredacted))
+
+	container = &Container{
+		projectID: cfg.GCP.ProjectID,
+		version:   version,
+		config:    cfg,
+		logger:    logger(cfg, 3).WithService(fmt.Sprintf("%T", container)),
+	}
+
+	container.logger.Debug(fmt.Sprintf("loaded configuration\n%s", cfg))
+	container.flushTraces = container.InitializeTraceProvider(version, cfg.GCP.ProjectID)
+	container.InitializeMeterProvider(version, cfg.GCP.ProjectID)
+
+	container.RegisterEventListeners()
+	container.StartQueueWorker()
+	container.StartDigestScheduler()
+
+	container.RegisterHealthRoutes()
+	container.RegisterMetricsRoutes()
+	container.RegisterNexmoRoutes()
+	container.RegisterWhatsappRoutes()
+	container.RegisterAdminRoutes()
+	container.RegisterAPIKeyRoutes()
+	container.RegisterMessageRoutes()
+	container.RegisterWebhookRoutes()
+	container.RegisterEventRoutes()
+	container.RegisterReminderRoutes()
+	container.RegisterDigestRoutes()
+	container.RegisterKnowledgeRoutes()
+	container.RegisterTenantRoutes()
+
+	// this has to be last since it registers the /* route
+	container.RegisterSwaggerRoutes()
+
+	return container
+}
+
+// Close stops the background workers, flushes the spans and closes the
+// database and redis connections. The fiber.App must be shut down before calling Close so that no new requests are accepted.
+func (container *Container) Close(ctx context.Context) error {
+	container.logger.Info("closing container")
+
+	var errs []error
+	if container.stopWorkers != nil {
+		container.stopWorkers()
+	}
+	if err := container.waitForWorkers(ctx); err != nil {
+		errs = append(errs, err)
+	}
+
+	if container.flushTraces != nil {
+		if err := container.flushTraces(ctx); err != nil {
+			errs = append(errs, stacktrace.Propagate(err, "cannot flush spans"))
+		}
+	}
+
+	if container.meterProvider != nil {
+		if err := container.meterProvider.Shutdown(ctx); err != nil {
+			errs = append(errs, stacktrace.Propagate(err, "cannot shut down the meter provider"))
+		}
+	}
+
+	if container.cloudTasks != nil {
+		if err := container.cloudTasks.Close(); err != nil {
+			errs = append(errs, stacktrace.Propagate(err, "cannot close cloud tasks client"))
+		}
+	}
+
+	if container.db != nil {
+		if err := container.closeDB(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if container.redisClient != nil {
+		if err := container.redisClient.Close(); err != nil {
+			errs = append(errs, stacktrace.Propagate(err, "cannot close redis client"))
+		}
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+
+	for _, err := range errs[1:] {
+		container.logger.Error(err)
+	}
+	return stacktrace.Propagate(errs[0], fmt.Sprintf("cannot close container cleanly with [%d] errors", len(errs)))
+}
+
+func (container *Container) waitForWorkers(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		container.workers.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return stacktrace.Propagate(ctx.Err(), "cannot wait for the background workers to stop")
+	}
+}
+
+func (container *Container) closeDB() error {
+	db, err := container.db.DB()
+	if err != nil {
+		return stacktrace.Propagate(err, fmt.Sprintf("cannot get the sql.DB from %T", container.db))
+	}
+	if err = db.Close(); err != nil {
+		return stacktrace.Propagate(err, "cannot close database connection pool")
+	}
+	return nil
+}
+
+// backgroundContext is cancelled when the container is closed
+func (container *Container) backgroundContext() context.Context {
+	if container.stopWorkers == nil {
+		ctx, cancel := context.WithCancel(context.Background())
+		container.stopWorkers = cancel
+		container.workerContext = ctx
+	}
+	return container.workerContext
+}
+
+// RegisterMetricsRoutes registers the prometheus /metrics route when the metrics are enabled
+func (container *Container) RegisterMetricsRoutes() {
+	if container.metricsRegistry == nil {
+		return
+	}
+
+	container.logger.Debug("registering metrics routes")
+	handler := fasthttpadaptor.NewFastHTTPHandler(promhttp.HandlerFor(container.metricsRegistry, promhttp.HandlerOpts{}))
+	container.App().Get("/metrics", func(c *fiber.Ctx) error {
+		handler(c.Context())
+		return nil
+	})
+}
+
+// Metrics creates a new instance of telemetry.Metrics if it has not been created already
+func (container *Container) Metrics() *telemetry.Metrics {
+	if container.metrics != nil {
+		return container.metrics
+	}
+
+	container.logger.Debug(fmt.Sprintf("creating %T", container.metrics))
+	metrics, err := telemetry.NewMetrics(global.Meter(container.projectID))
+	if err != nil {
+		container.logger.Fatal(stacktrace.Propagate(err, fmt.Sprintf("cannot create %T", metrics)))
+	}
+
+	container.metrics = metrics
+	return container.metrics
+}
+
+// RegisterSwaggerRoutes registers routes for swagger
+
+func (container *Container) RegisterSwaggerRoutes() {
+	container.logger.Debug("registering swagger routes")
+	container.App().Get("/*", swagger.HandlerDefault)
+}
+
+// RegisterHealthRoutes registers the /healthz and /readyz probes and the /v1/admin/health diagnostics
+func (container *Container) RegisterHealthRoutes() {
+	container.logger.Debug(fmt.Sprintf("registering %T routes", &handlers.HealthHandler{}))
+	container.HealthHandler().RegisterRoutes(container.App())
+	container.HealthHandler().RegisterDiagnosticsRoutes(
+		container.App(),
+		middlewares.APIKeyAuth(container.Logger(), container.Tracer(), container.APIKeyService(), container.TenantService()),
+		middlewares.RequireRoles(container.Logger(), container.Tracer(), entities.RoleAdmin),
+	)
+}
+
+// HealthHandler creates a new instance of handlers.HealthHandler
+func (container *Container) HealthHandler() (handler *handlers.HealthHandler) {
+	container.logger.Debug(fmt.Sprintf("creating %T", handler))
+	return handlers.NewHealthHandler(
+		container.Logger(),
+		container.Tracer(),
+		container.HealthService(),
+	)
+}
+
+// HealthService creates a new instance of services.HealthService if it has not been created already so that the results are cached
+func (container *Container) HealthService() (service *services.HealthService) {
+	if container.healthService != nil {
+		return container.healthService
+	}
+
+	container.logger.Debug(fmt.Sprintf("creating %T", service))
+	container.healthService = services.NewHealthService(
+		container.Logger(),
+		container.Tracer(),
+		container.config.Health.CheckTimeout,
+		container.config.Health.CacheTTL,
+		container.HealthChecks()...,
+	)
+	return container.healthService
+}
+
+// HealthChecks are the checks of the dependencies which are used by the API.
+// External APIs are not critical since the API can still receive messages when they are down.
+func (container *Container) HealthChecks() []services.HealthCheck {
+	checks := []services.HealthCheck{
+		{
+			Name:     "postgres",
+			Critical: true,
+			Check: func(ctx context.Context) error {
+				db, err := container.DB().DB()
+				if err != nil {
+					return stacktrace.Propagate(err, fmt.Sprintf("cannot get the sql.DB from %T", container.db))
+				}
+				return db.PingContext(ctx)
+			},
+		},
+	}
+
+	if container.config.Queue.Driver == config.DriverRedis || container.config.CacheDriver() == config.DriverRedis {
+		checks = append(checks, services.HealthCheck{
+			Name:     "redis",
+			Critical: true,
+			Check: func(ctx context.Context) error {
+				return container.RedisClient().Ping(ctx).Err()
+			},
+		})
+	}
+
+	if container.config.Queue.Driver == config.DriverGoogle {
+		checks = append(checks, services.HealthCheck{
+			Name:     "cloud_tasks",
+			Critical: true,
+			Check: func(ctx context.Context) error {
+				_, err := container.CloudTasksClient().GetQueue(ctx, &cloudtaskspb.GetQueueRequest{Name: container.config.GCP.QueueName})
+				return err
+			},
+		})
+	}
+
+	checks = append(checks, services.HealthCheck{
+		Name: "openai",
+		Check: func(ctx context.Context) error {
+			_, err := container.OpenAPIClient().ListModels(ctx)
+			return err
+		},
+	})
+
+	if container.config.Whatsapp.AccessToken != "" {
+		checks = append(checks, services.HealthCheck{
+			Name: "whatsapp",
+			Check: func(ctx context.Context) error {
+				_, _, err := container.WhatsappClient().Account.Me(ctx)
+				return err
+			},
+		})
+	}
+
+	if container.config.Nexmo.APIKey != "" {
+		checks = append(checks, services.HealthCheck{
+			Name: "nexmo",
+			Check: func(ctx context.Context) error {
+				_, _, err := container.NexmoClient().Account.Balance(ctx)
+				return err
+			},
+		})
+	}
+
+	return checks
+}
+
+// RegisterNexmoRoutes registers routes for the /v1/nexmo prefix
+func (container *Container) RegisterNexmoRoutes() {
+	container.logger.Debug(fmt.Sprintf("registering %T routes", &handlers.NexmoHandler{}))
+	container.NexmoHandler().RegisterRoutes(container.App())
+}
+
+// RegisterWhatsappRoutes registers routes for the /v1/whatsapp prefix
+func (container *Container) RegisterWhatsappRoutes() {
+	container.logger.Debug(fmt.Sprintf("registering %T routes", &handlers.NexmoHandler{}))
+	container.WhatsappHandler().RegisterRoutes(container.App())
+}
+
+// RegisterAdminRoutes registers routes for the /v1/admin prefix
+func (container *Container) RegisterAdminRoutes() {
+	container.logger.Debug(fmt.Sprintf("registering %T routes", &handlers.AdminHandler{}))
+	container.AdminHandler().RegisterRoutes(
+		container.App(),
+		middlewares.APIKeyAuth(container.Logger(), container.Tracer(), container.APIKeyService(), container.TenantService()),
+		middlewares.RequireRoles(container.Logger(), container.Tracer(), entities.RoleAdmin, entities.RoleSupport),
+	)
+}
+
+// RegisterAPIKeyRoutes registers routes for the /v1/admin/api-keys prefix
+func (container *Container) RegisterAPIKeyRoutes() {
+	container.logger.Debug(fmt.Sprintf("registering %T routes", &handlers.APIKeyHandler{}))
+	container.APIKeyHandler().RegisterRoutes(
+		container.App(),
+		middlewares.APIKeyAuth(container.Logger(), container.Tracer(), container.APIKeyService(), container.TenantService()),
+		middlewares.RequireRoles(container.Logger(), container.Tracer(), entities.RoleAdmin),
+	)
+}
+
+// RegisterMessageRoutes registers routes for the /v1/messages prefix
+func (container *Container) RegisterMessageRoutes() {
+	container.logger.Debug(fmt.Sprintf("registering %T routes", &handlers.MessageHandler{}))
+	container.MessageHandler().RegisterRoutes(
+		container.App(),
+		middlewares.APIKeyAuth(container.Logger(), container.Tracer(), container.APIKeyService(), container.TenantService()),
+		middlewares.RequireRoles(container.Logger(), container.Tracer(), entities.RoleAdmin, entities.RoleIntegrator),
+	)
+}
+
+// RegisterEventRoutes registers routes for the /v1/events prefix
+func (container *Container) RegisterEventRoutes() {
+	container.logger.Debug(fmt.Sprintf("registering %T routes", &handlers.EventHandler{}))
+	container.EventHandler().RegisterRoutes(
+		container.App(),
+		middlewares.APIKeyAuth(container.Logger(), container.Tracer(), container.APIKeyService(), container.TenantService()),
+		middlewares.RequireRoles(container.Logger(), container.Tracer(), entities.RoleTask),
+	)
+}
+
+// EventHandler creates a new instance of handlers.EventHandler
+func (container *Container) EventHandler() (handler *handlers.EventHandler) {
+	container.logger.Debug(fmt.Sprintf("creating %T", handler))
+	return handlers.NewEventHandler(
+		container.Logger(),
+		container.Tracer(),
+		container.EventDispatcher(),
+	)
+}
+
+// RegisterEventListeners registers the listeners of events on the events.Dispatcher
+func (container *Container) RegisterEventListeners() {
+	container.logger.Debug("registering event listeners")
+	_, routes := listeners.NewWebhookListener(container.Logger(), container.Tracer(), container.WebhookService())
+	for eventType, listener := range routes {
+		container.EventDispatcher().SubscribeQueued(eventType, listener)
+	}
+}
+
+// EventDispatcher creates a new instance of events.Dispatcher.
+// Queued listeners run in the same process when the EVENTS_CONSUMER_URL is not set.
+func (container *Container) EventDispatcher() events.Dispatcher {
+	if container.eventDispatcher != nil {
+		return container.eventDispatcher
+	}
+
+	if container.config.Queue.EventsConsumerURL == "" {
+		container.logger.Debug("creating in memory events.Dispatcher")
+		container.eventDispatcher = events.NewMemoryDispatcher(container.Logger(), container.Tracer())
+		return container.eventDispatcher
+	}
+
+	container.logger.Debug("creating queue events.Dispatcher")
+	container.eventDispatcher = events.NewQueueDispatcher(
+		container.Logger(),
+		container.Tracer(),
+		container.QueueClient(),
+		container.config.Queue.EventsConsumerURL,
+		container.taskHeaders(),
+	)
+	return container.eventDispatcher
+}
+
+// QueueClient creates a new instance of queue.Client based on the QUEUE_DRIVER which can be "google", "redis" or "memory"
+func (container *Container) QueueClient() queue.Client {
+	if container.config.Queue.Driver != config.DriverGoogle {
+		return container.QueueBroker()
+	}
+
+	container.logger.Debug("creating google cloud tasks queue.Client")
+	return queue.NewGooglePushQueue(
+		container.Logger(),
+		container.Tracer(),
+		container.CloudTasksClient(),
+		container.config.GCP.QueueName,
+		container.config.GCP.QueueAuthEmail,
+	)
+}
+
+// CloudTasksClient creates a new instance of cloudtasks.Client if it has not been created already
+func (container *Container) CloudTasksClient() *cloudtasks.Client {
+	if container.cloudTasks != nil {
+		return container.cloudTasks
+	}
+
+	container.logger.Debug(fmt.Sprintf("creating %T", container.cloudTasks))
+	client, err := cloudtasks.NewClient(context.Background())
+	if err != nil {
+		container.logger.Fatal(stacktrace.Propagate(err, "cannot initialize cloud tasks client"))
+	}
+
+	container.cloudTasks = client
+	return container.cloudTasks
+}
+
+// QueueBroker creates a new instance of queue.Broker for tasks which are processed by the queue.Worker
+func (container *Container) QueueBroker() queue.Broker {
+	if container.queueBroker != nil {
+		return container.queueBroker
+	}
+
+	if container.config.Queue.Driver == config.DriverRedis {
+		container.logger.Debug("creating redis queue.Broker")
+		container.queueBroker = queue.NewRedisBroker(container.Tracer(), container.RedisClient(), "queue:"+container.projectID)
+		return container.queueBroker
+	}
+
+	container.logger.Debug("creating in memory queue.Broker")
+	container.queueBroker = queue.NewMemoryBroker(1000)
+	return container.queueBroker
+}
+
+// StartQueueWorker processes tasks in the background when the QUEUE_DRIVER is "redis" or "memory"
+func (container *Container) StartQueueWorker() {
+	if container.config.Queue.Driver == config.DriverGoogle {
+		return
+	}
+
+	container.logger.Debug(fmt.Sprintf("starting %T", &queue.Worker{}))
+	worker := queue.NewWorker(
+		container.Logger(),
+		container.Tracer(),
+		container.QueueBroker(),
+		container.HTTPClient("queue"),
+		queue.WorkerConfig{
+			Concurrency: container.config.Queue.Concurrency,
+			MaxAttempts: container.config.Queue.MaxAttempts,
+			Backoff:     5 * time.Second,
+		},
+	)
+
+	if url := container.config.Queue.EventsConsumerURL; url != "" {
+		worker.Handle(url, func(ctx context.Context, task *queue.Task) error {
+			event := cloudevents.NewEvent()
+			if err := event.UnmarshalJSON(task.Body); err != nil {
+				return stacktrace.Propagate(err, fmt.Sprintf("cannot unmarshal task [%s] into %T", telemetry.RedactBody(string(task.Body)), event))
+			}
+			return container.EventDispatcher().Consume(tenancy.WithAllTenants(ctx), event)
+		})
+	}
+
+	worker.Handle(container.reminderDeliveryURL(), func(ctx context.Context, task *queue.Task) error {
+		params := new(services.ReminderDeliverParams)
+		if err := json.Unmarshal(task.Body, params); err != nil {
+			return stacktrace.Propagate(err, fmt.Sprintf("cannot unmarshal task [%s] into %T", telemetry.RedactBody(string(task.Body)), params))
+		}
+		return retryRateLimited(container.ReminderService().Deliver(tenancy.WithAllTenants(ctx), params.ReminderID))
+	})
+
+	worker.Handle(container.digestDeliveryURL(), func(ctx context.Context, task *queue.Task) error {
+		params := new(services.DigestDeliverParams)
+		if err := json.Unmarshal(task.Body, params); err != nil {
+			return stacktrace.Propagate(err, fmt.Sprintf("cannot unmarshal task [%s] into %T", telemetry.RedactBody(string(task.Body)), params))
+		}
+		return retryRateLimited(container.DigestService().Deliver(tenancy.WithAllTenants(ctx), params))
+	})
+
+	worker.Handle(container.webhookDeliveryURL(), func(ctx context.Context, task *queue.Task) error {
+		params := new(services.WebhookDeliverParams)
+		if err := json.Unmarshal(task.Body, params); err != nil {
+			return stacktrace.Propagate(err, fmt.Sprintf("cannot unmarshal task [%s] into %T", telemetry.RedactBody(string(task.Body)), params))
+		}
+		return container.WebhookService().Deliver(tenancy.WithAllTenants(ctx), params.DeliveryID)
+	})
+
+	ctx := container.backgroundContext()
+	container.workers.Add(1)
+	go func() {
+		defer container.workers.Done()
+		worker.Run(ctx)
+	}()
+}
+
+// retryRateLimited makes the queue.Worker retry a task after the delay which was returned by the provider when the
+// task failed because a message was rate limited
+func retryRateLimited(err error) error {
+	if delay, ok := services.RateLimitDelay(err); ok {
+		return queue.RetryAfter(err, delay)
+	}
+	return err
+}
+
+// RegisterReminderRoutes registers routes for the /v1/reminders prefix
+func (container *Container) RegisterReminderRoutes() {
+	container.logger.Debug(fmt.Sprintf("registering %T routes", &handlers.ReminderHandler{}))
+	container.ReminderHandler().RegisterRoutes(
+		container.App(),
+		middlewares.APIKeyAuth(container.Logger(), container.Tracer(), container.APIKeyService(), container.TenantService()),
+		middlewares.RequireRoles(container.Logger(), container.Tracer(), entities.RoleTask),
+	)
+}
+
+// ReminderHandlerValidator creates a new instance of validators.ReminderHandlerValidator
+func (container *Container) ReminderHandlerValidator() (validator *validators.ReminderHandlerValidator) {
+	container.logger.Debug(fmt.Sprintf("creating %T", validator))
+	return validators.NewReminderHandlerValidator(
+		container.Logger(),
+		container.Tracer(),
+	)
+}
+
+// ReminderHandler creates a new instance of handlers.ReminderHandler
+func (container *Container) ReminderHandler() (handler *handlers.ReminderHandler) {
+	container.logger.Debug(fmt.Sprintf("creating %T", handler))
+	return handlers.NewReminderHandler(
+		container.Logger(),
+		container.Tracer(),
+		container.ReminderHandlerValidator(),
+		container.ReminderService(),
+	)
+}
+
+// ReminderService creates a new instance of services.ReminderService
+func (container *Container) ReminderService() (service *services.ReminderService) {
+	container.logger.Debug(fmt.Sprintf("creating %T", service))
+	return services.NewReminderService(
+		container.Logger(),
+		container.Tracer(),
+		container.Catalog(),
+		container.ReminderRepository(),
+		container.OpenAPIService(),
+		container.UserService(),
+		container.MessageService(),
+		container.PromptService(),
+		container.TenantService(),
+		container.QueueClient(),
+		container.config.Whatsapp.ReminderTemplate,
+		container.reminderDeliveryURL(),
+		container.taskHeaders(),
+	)
+}
+
+// ReminderRepository creates a new instance of repositories.ReminderRepository
+func (container *Container) ReminderRepository() repositories.ReminderRepository {
+	container.logger.Debug("creating GORM repositories.ReminderRepository")
+	return repositories.NewGormReminderRepository(
+		container.Logger(),
+		container.Tracer(),
+		container.DB(),
+	)
+}
+
+// reminderDeliveryURL is the URL of the queue task which delivers a reminder.
+// It must be a public URL of the API when the QUEUE_DRIVER is "google".
+func (container *Container) reminderDeliveryURL() string {
+	return container.config.Reminders.DeliveryURL
+}
+
+// RegisterDigestRoutes registers routes for the /v1/digests prefix
+func (container *Container) RegisterDigestRoutes() {
+	container.logger.Debug(fmt.Sprintf("registering %T routes", &handlers.DigestHandler{}))
+	container.DigestHandler().RegisterRoutes(
+		container.App(),
+		middlewares.APIKeyAuth(container.Logger(), container.Tracer(), container.APIKeyService(), container.TenantService()),
+		middlewares.RequireRoles(container.Logger(), container.Tracer(), entities.RoleTask),
+	)
+}
+
+// StartDigestScheduler dispatches the daily digests every minute when the QUEUE_DRIVER is "redis" or "memory".
+// The /v1/digests/dispatch route is called by a scheduler e.g. Google Cloud Scheduler when the QUEUE_DRIVER is "google".
+func (container *Container) StartDigestScheduler() {
+	if container.config.Queue.Driver == config.DriverGoogle {
+		return
+	}
+
+	container.logger.Debug("starting daily digest scheduler")
+	service := container.DigestService()
+	// the scheduler dispatches the subscriptions of all the tenants
+	ctx := tenancy.WithAllTenants(container.backgroundContext())
+	container.workers.Add(1)
+	go func() {
+		defer container.workers.Done()
+		ticker := time.NewTicker(time.Minute)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				container.logger.Info("stopped daily digest scheduler")
+				return
+			case <-ticker.C:
+				if _, err := service.Dispatch(ctx); err != nil {
+					container.logger.Error(stacktrace.Propagate(err, "cannot dispatch daily digests"))
+				}
+			}
+		}
+	}()
+}
+
+// DigestHandlerValidator creates a new instance of validators.DigestHandlerValidator
+func (container *Container) DigestHandlerValidator() (validator *validators.DigestHandlerValidator) {
+	container.logger.Debug(fmt.Sprintf("creating %T", validator))
+	return validators.NewDigestHandlerValidator(
+		container.Logger(),
+		container.Tracer(),
+	)
+}
+
+// DigestHandler creates a new instance of handlers.DigestHandler
+func (container *Container) DigestHandler() (handler *handlers.DigestHandler) {
+	container.logger.Debug(fmt.Sprintf("creating %T", handler))
+	return handlers.NewDigestHandler(
+		container.Logger(),
+		container.Tracer(),
+		container.DigestHandlerValidator(),
+		container.DigestService(),
+	)
+}
+
+// DigestService creates a new instance of services.DigestService
+func (container *Container) DigestService() (service *services.DigestService) {
+	container.logger.Debug(fmt.Sprintf("creating %T", service))
+	return services.NewDigestService(
+		container.Logger(),
+		container.Tracer(),
+		container.EventDispatcher(),
+		container.Catalog(),
+		container.DigestSubscriptionRepository(),
+		container.OpenAPIService(),
+		container.UserService(),
+		container.MessageService(),
+		container.PromptService(),
+		container.TenantService(),
+		container.QueueClient(),
+		container.digestPrompts(),
+		container.config.Whatsapp.DigestTemplate,
+		container.digestDeliveryURL(),
+		container.taskHeaders(),
+	)
+}
+
+// DigestSubscriptionRepository creates a new instance of repositories.DigestSubscriptionRepository
+func (container *Container) DigestSubscriptionRepository() repositories.DigestSubscriptionRepository {
+	container.logger.Debug("creating GORM repositories.DigestSubscriptionRepository")
+	return repositories.NewGormDigestSubscriptionRepository(
+		container.Logger(),
+		container.Tracer(),
+		container.DB(),
+	)
+}
+
+// digestPrompts are the default prompts of the daily digests which can be changed with the DIGEST_PROMPT_<TOPIC> variables e.g. DIGEST_PROMPT_BRIEFING
+func (container *Container) digestPrompts() map[entities.DigestTopic]string {
+	prompts := services.DefaultDigestPrompts()
+	overrides := map[entities.DigestTopic]string{
+		entities.DigestTopicWord:     container.config.Digests.PromptWord,
+		entities.DigestTopicBriefing: container.config.Digests.PromptBriefing,
+		entities.DigestTopicQuestion: container.config.Digests.PromptQuestion,
+	}
+	for topic, prompt := range overrides {
+		if prompt != "" {
+			prompts[topic] = prompt
+		}
+	}
+	return prompts
+}
+
+// digestDeliveryURL is the URL of the queue task which delivers a daily digest.
+// It must be a public URL of the API when the QUEUE_DRIVER is "google".
+func (container *Container) digestDeliveryURL() string {
+	return container.config.Digests.DeliveryURL
+}
+
+// RegisterWebhookRoutes registers routes for the /v1/webhooks prefix
+func (container *Container) RegisterWebhookRoutes() {
+	container.logger.Debug(fmt.Sprintf("registering %T routes", &handlers.WebhookHandler{}))
+	container.WebhookHandler().RegisterRoutes(
+		container.App(),
+		middlewares.APIKeyAuth(container.Logger(), container.Tracer(), container.APIKeyService(), container.TenantService()),
+		middlewares.RequireRoles(container.Logger(), container.Tracer(), entities.RoleAdmin, entities.RoleIntegrator),
+	)
+	container.WebhookHandler().RegisterDeliveryRoutes(
+		container.App(),
+		middlewares.APIKeyAuth(container.Logger(), container.Tracer(), container.APIKeyService(), container.TenantService()),
+		middlewares.RequireRoles(container.Logger(), container.Tracer(), entities.RoleTask),
+	)
+}
+
+// RegisterKnowledgeRoutes registers routes for the /v1/admin/knowledge-bases prefix
+func (container *Container) RegisterKnowledgeRoutes() {
+	container.logger.Debug(fmt.Sprintf("registering %T routes", &handlers.KnowledgeHandler{}))
+	container.KnowledgeHandler().RegisterRoutes(
+		container.App(),
+		middlewares.APIKeyAuth(container.Logger(), container.Tracer(), container.APIKeyService(), container.TenantService()),
+		middlewares.RequireRoles(container.Logger(), container.Tracer(), entities.RoleAdmin),
+	)
+}
+
+// KnowledgeHandlerValidator creates a new instance of validators.KnowledgeHandlerValidator
+func (container *Container) KnowledgeHandlerValidator() (validator *validators.KnowledgeHandlerValidator) {
+	container.logger.Debug(fmt.Sprintf("creating %T", validator))
+	return validators.NewKnowledgeHandlerValidator(
+		container.Logger(),
+		container.Tracer(),
+	)
+}
+
+// KnowledgeHandler creates a new instance of handlers.KnowledgeHandler
+func (container *Container) KnowledgeHandler() (handler *handlers.KnowledgeHandler) {
+	container.logger.Debug(fmt.Sprintf("creating %T", handler))
+	return handlers.NewKnowledgeHandler(
+		container.Logger(),
+		container.Tracer(),
+		container.KnowledgeHandlerValidator(),
+		container.KnowledgeService(),
+	)
+}
+
+// KnowledgeService creates a new instance of services.KnowledgeService
+func (container *Container) KnowledgeService() (service *services.KnowledgeService) {
+	container.logger.Debug(fmt.Sprintf("creating %T", service))
+	return services.NewKnowledgeService(
+		container.Logger(),
+		container.Tracer(),
+		container.KnowledgeEmbedder(),
+		container.Tokenizers().For(container.config.Knowledge.EmbeddingModel),
+		container.KnowledgeRepository(),
+		container.config.Knowledge.ChunkTokens,
+		container.config.Knowledge.ChunkOverlap,
+		container.config.Knowledge.TopK,
+		container.config.Knowledge.MinScore,
+	)
+}
+
+// KnowledgeEmbedder creates the knowledge.Embedder which embeds the documents with the OpenAI API
+func (container *Container) KnowledgeEmbedder() (embedder knowledge.Embedder) {
+	container.logger.Debug(fmt.Sprintf("creating %T", &knowledge.OpenAIEmbedder{}))
+
+	var model openapi.EmbeddingModel
+	if err := model.UnmarshalText([]byte(container.config.Knowledge.EmbeddingModel)); err != nil || model == openapi.Unknown {
+		container.logger.Fatal(stacktrace.NewError("KNOWLEDGE_EMBEDDING_MODEL [%s] is not an OpenAI embedding model", container.config.Knowledge.EmbeddingModel))
+	}
+
+	return knowledge.NewOpenAIEmbedder(container.OpenAPIClient(), model)
+}
+
+// KnowledgeRepository creates a new instance of repositories.KnowledgeRepository
+func (container *Container) KnowledgeRepository() repositories.KnowledgeRepository {
+	container.logger.Debug("creating GORM repositories.KnowledgeRepository")
+	db := container.DB()
+	return repositories.NewGormKnowledgeRepository(
+		container.Logger(),
+		container.Tracer(),
+		db,
+		container.pgvector,
+	)
+}
+
+// RegisterTenantRoutes registers routes for the /v1/admin/tenants prefix
+func (container *Container) RegisterTenantRoutes() {
+	container.logger.Debug(fmt.Sprintf("registering %T routes", &handlers.TenantHandler{}))
+	container.TenantHandler().RegisterRoutes(
+		container.App(),
+		middlewares.APIKeyAuth(container.Logger(), container.Tracer(), container.APIKeyService(), container.TenantService()),
+		middlewares.RequireRoles(container.Logger(), container.Tracer(), entities.RoleAdmin),
+	)
+}
+
+// TenantHandlerValidator creates a new instance of validators.TenantHandlerValidator
+func (container *Container) TenantHandlerValidator() (validator *validators.TenantHandlerValidator) {
+	container.logger.Debug(fmt.Sprintf("creating %T", validator))
+	return validators.NewTenantHandlerValidator(
+		container.Logger(),
+		container.Tracer(),
+	)
+}
+
+// TenantHandler creates a new instance of handlers.TenantHandler
+func (container *Container) TenantHandler() (handler *handlers.TenantHandler) {
+	container.logger.Debug(fmt.Sprintf("creating %T", handler))
+	return handlers.NewTenantHandler(
+		container.Logger(),
+		container.Tracer(),
+		container.TenantHandlerValidator(),
+		container.TenantService(),
+	)
+}
+
+// TenantService creates an instance of services.TenantService if it has not been created already.
+// It is shared so that the clients of the tenants are created once per credential.
+func (container *Container) TenantService() (service *services.TenantService) {
+	if container.tenantService != nil {
+		return container.tenantService
+	}
+
+	container.logger.Debug(fmt.Sprintf("creating %T", service))
+	container.tenantService = services.NewTenantService(
+		container.Logger(),
+		container.Tracer(),
+		container.Metrics(),
+		container.EventDispatcher(),
+		container.Cache(),
+		container.TenantRepository(),
+		container.MessageRepository(),
+		container.WhatsappClient(),
+		container.NexmoClient(),
+		func(accessToken string) *whatsapp.Client {
+			return whatsapp.New(
+				whatsapp.WithHTTPClient(container.HTTPClient("whatsapp")),
+				whatsapp.WithAccessToken(accessToken),
+				whatsapp.WithAPIVersion(container.config.Whatsapp.APIVersion),
+			)
+		},
+		func(apiKey string, apiSecret string) *nexmo.Client {
+			return nexmo.New(
+				nexmo.WithHTTPClient(container.HTTPClient("nexmo")),
+				nexmo.WithAPIKey(apiKey),
+				nexmo.WithAPISecret(apiSecret),
+			)
+		},
+	)
+	return container.tenantService
+}
+
+// TenantRepository creates a new instance of repositories.TenantRepository
+func (container *Container) TenantRepository() repositories.TenantRepository {
+	container.logger.Debug("creating GORM repositories.TenantRepository")
+	return repositories.NewGormTenantRepository(
+		container.Logger(),
+		container.Tracer(),
+		container.DB(),
+	)
+}
+
+// WebhookHandlerValidator creates a new instance of validators.WebhookHandlerValidator
+func (container *Container) WebhookHandlerValidator() (validator *validators.WebhookHandlerValidator) {
+	container.logger.Debug(fmt.Sprintf("creating %T", validator))
+	return validators.NewWebhookHandlerValidator(
+		container.Logger(),
+		container.Tracer(),
+	)
+}
+
+// WebhookHandler creates a new instance of handlers.WebhookHandler
+func (container *Container) WebhookHandler() (handler *handlers.WebhookHandler) {
+	container.logger.Debug(fmt.Sprintf("creating %T", handler))
+	return handlers.NewWebhookHandler(
+		container.Logger(),
+		container.Tracer(),
+		container.WebhookHandlerValidator(),
+		container.WebhookService(),
+	)
+}
+
+// WebhookService creates a new instance of services.WebhookService
+func (container *Container) WebhookService() (service *services.WebhookService) {
+	container.logger.Debug(fmt.Sprintf("creating %T", service))
+	return services.NewWebhookService(
+		container.Logger(),
+		container.Tracer(),
+		container.PublicHTTPClient("webhook"),
+		container.WebhookRepository(),
+		container.WebhookDeliveryRepository(),
+		container.APIKeyRepository(),
+		container.QueueClient(),
+		container.webhookDeliveryURL(),
+		container.taskHeaders(),
+	)
+}
+
+// webhookDeliveryURL is the URL of the queue task which attempts to deliver an event to a webhook
+func (container *Container) webhookDeliveryURL() string {
+	return container.config.Webhooks.DeliveryURL
 }
 
-// NewContainer creates a new dependency injection container
-func NewContainer(version string, projectID string) (container *Container) {
-	// Set location to UTC
-	now.DefaultConfig = &now.Config{
-		TimeLocation: time.UTC,
-	}
+// WebhookRepository creates a new instance of repositories.WebhookRepository
+func (container *Container) WebhookRepository() repositories.WebhookRepository {
+	container.logger.Debug("creating GORM repositories.WebhookRepository")
+	return repositories.NewGormWebhookRepository(
+		container.Logger(),
+		container.Tracer(),
+		container.DB(),
+	)
+}
 
-	container = &Container{
-		projectID: projectID,
-		version:   version,
-		logger:    logger(3).WithService(fmt.Sprintf("%T", container)),
-	}
+// WebhookDeliveryRepository creates a new instance of repositories.WebhookDeliveryRepository
+func (container *Container) WebhookDeliveryRepository() repositories.WebhookDeliveryRepository {
+	container.logger.Debug("creating GORM repositories.WebhookDeliveryRepository")
+	return repositories.NewGormWebhookDeliveryRepository(
+		container.Logger(),
+		container.Tracer(),
+		container.DB(),
+	)
+}
 
-	container.InitializeTraceProvider(version, os.Getenv("GCP_PROJECT_ID"))
+// MessageHandlerValidator creates a new instance of validators.MessageHandlerValidator
+func (container *Container) MessageHandlerValidator() (validator *validators.MessageHandlerValidator) {
+	container.logger.Debug(fmt.Sprintf("creating %T", validator))
+	return validators.NewMessageHandlerValidator(
+		container.Logger(),
+		container.Tracer(),
+	)
+}
 
-	container.RegisterNexmoRoutes()
-	container.RegisterWhatsappRoutes()
+// MessageHandler creates a new instance of handlers.MessageHandler
+func (container *Container) MessageHandler() (handler *handlers.MessageHandler) {
+	container.logger.Debug(fmt.Sprintf("creating %T", handler))
+	return handlers.NewMessageHandler(
+		container.Logger(),
+		container.Tracer(),
+		container.MessageHandlerValidator(),
+		container.PromptService(),
+		container.MessageService(),
+		container.TenantService(),
+	)
+}
 
-	// this has to be last since it registers the /* route
-	container.RegisterSwaggerRoutes()
+// PromptService creates a new instance of services.PromptService
+func (container *Container) PromptService() (service *services.PromptService) {
+	container.logger.Debug(fmt.Sprintf("creating %T", service))
+	return services.NewPromptService(
+		container.Logger(),
+		container.Tracer(),
+		container.Metrics(),
+		container.WebhookService(),
+		container.TenantService(),
+		container.OpenAPIService(),
+		container.MessageService(),
+	)
+}
 
-	return container
+// APIKeyHandlerValidator creates a new instance of validators.APIKeyHandlerValidator
+func (container *Container) APIKeyHandlerValidator() (validator *validators.APIKeyHandlerValidator) {
+	container.logger.Debug(fmt.Sprintf("creating %T", validator))
+	return validators.NewAPIKeyHandlerValidator(
+		container.Logger(),
+		container.Tracer(),
+	)
 }
 
-// RegisterSwaggerRoutes registers routes for swagger
+// APIKeyHandler creates a new instance of handlers.APIKeyHandler
+func (container *Container) APIKeyHandler() (handler *handlers.APIKeyHandler) {
+	container.logger.Debug(fmt.Sprintf("creating %T", handler))
+	return handlers.NewAPIKeyHandler(
+		container.Logger(),
+		container.Tracer(),
+		container.APIKeyHandlerValidator(),
+		container.APIKeyService(),
+	)
+}
 
-func (container *Container) RegisterSwaggerRoutes() {
-	container.logger.Debug("registering swagger routes")
-	container.App().Get("/*", swagger.HandlerDefault)
+// APIKeyService creates a new instance of services.APIKeyService
+func (container *Container) APIKeyService() (service *services.APIKeyService) {
+	container.logger.Debug(fmt.Sprintf("creating %T", service))
+	return services.NewAPIKeyService(
+		container.Logger(),
+		container.Tracer(),
+		container.APIKeyRepository(),
+		container.config.AdminAPIKey,
+		container.config.TaskAPIKey,
+	)
 }
 
-// RegisterNexmoRoutes registers routes for the /v1/nexmo prefix
-func (container *Container) RegisterNexmoRoutes() {
-	container.logger.Debug(fmt.Sprintf("registering %T routes", &handlers.NexmoHandler{}))
-	container.NexmoHandler().RegisterRoutes(container.App())
+// taskHeaders are the headers of the queued tasks. The TASK_API_KEY can only run the queued tasks so that the
+// ADMIN_API_KEY is never stored in the queue.
+func (container *Container) taskHeaders() map[string]string {
+	return map[string]string{
+		"X-API-Key": container.config.TaskAPIKey,
+	}
 }
 
-// RegisterWhatsappRoutes registers routes for the /v1/whatsapp prefix
-func (container *Container) RegisterWhatsappRoutes() {
-	container.logger.Debug(fmt.Sprintf("registering %T routes", &handlers.NexmoHandler{}))
-	container.WhatsappHandler().RegisterRoutes(container.App())
+// APIKeyRepository creates a new instance of repositories.APIKeyRepository
+func (container *Container) APIKeyRepository() repositories.APIKeyRepository {
+	container.logger.Debug("creating GORM repositories.APIKeyRepository")
+	return repositories.NewGormAPIKeyRepository(
+		container.Logger(),
+		container.Tracer(),
+		container.DB(),
+	)
+}
+
+// AdminHandlerValidator creates a new instance of validators.AdminHandlerValidator
+func (container *Container) AdminHandlerValidator() (validator *validators.AdminHandlerValidator) {
+	container.logger.Debug(fmt.Sprintf("creating %T", validator))
+	return validators.NewAdminHandlerValidator(
+		container.Logger(),
+		container.Tracer(),
+	)
+}
+
+// AdminHandler creates a new instance of handlers.AdminHandler
+func (container *Container) AdminHandler() (handler *handlers.AdminHandler) {
+	container.logger.Debug(fmt.Sprintf("creating %T", handler))
+	return handlers.NewAdminHandler(
+		container.Logger(),
+		container.Tracer(),
+		container.AdminHandlerValidator(),
+		container.UserService(),
+		container.ConversationService(),
+		container.MessageService(),
+	)
 }
 
 // NexmoHandlerValidator creates a new instance of validators.NexmoHandlerValidator
@@ -114,6 +1124,7 @@ func (container *Container) WhatsappHandler() (handler *handlers.WhatsappHandler
 		container.Logger(),
 		container.Tracer(),
 		container.WhatsappService(),
+		container.TenantService(),
 	)
 }
 
@@ -125,6 +1136,7 @@ func (container *Container) NexmoHandler() (handler *handlers.NexmoHandler) {
 		container.Logger(),
 		container.Tracer(),
 		container.NexmoService(),
+		container.TenantService(),
 		container.NexmoHandlerValidator(),
 	)
 }
@@ -134,7 +1146,8 @@ func (container *Container) WhatsappClient() (service *whatsapp.Client) {
 	container.logger.Debug(fmt.Sprintf("creating %T", service))
 	return whatsapp.New(
 		whatsapp.WithHTTPClient(container.HTTPClient("whatsapp")),
-		whatsapp.WithAccessToken(os.Getenv("WHATSAPP_ACCESS_TOKEN")),
+		whatsapp.WithAccessToken(container.config.Whatsapp.AccessToken),
+		whatsapp.WithAPIVersion(container.config.Whatsapp.APIVersion),
 	)
 }
 
@@ -143,15 +1156,19 @@ func (container *Container) NexmoClient() (service *nexmo.Client) {
 	container.logger.Debug(fmt.Sprintf("creating %T", service))
 	return nexmo.New(
 		nexmo.WithHTTPClient(container.HTTPClient("nexmo")),
-		nexmo.WithAPIKey(os.Getenv("NEXMO_API_KEY")),
-		nexmo.WithAPISecret(os.Getenv("NEXMO_API_SECRET")),
+		nexmo.WithAPIKey(container.config.Nexmo.APIKey),
+		nexmo.WithAPISecret(container.config.Nexmo.APISecret),
 	)
 }
 
 // OpenAPIClient creates a new instance of openapi.Client
 func (container *Container) OpenAPIClient() (service *openapi.Client) {
 	container.logger.Debug(fmt.Sprintf("creating %T", service))
-	return openapi.NewClient(os.Getenv("OPENAPI_AUTH_TOKEN"))
+	clientConfig := openapi.DefaultConfig(container.config.OpenAPI.AuthToken)
+	clientConfig.HTTPClient = &http.Client{
+		Transport: otelhttp.NewTransport(http.DefaultTransport, container.otelHTTPOptions("openai")...),
+	}
+	return openapi.NewClientWithConfig(clientConfig)
 }
 
 // OpenAPIService creates a new instance of services.OpenAPIService
@@ -159,6 +1176,171 @@ func (container *Container) OpenAPIService() (service *services.OpenAPIService)
 	container.logger.Debug(fmt.Sprintf("creating %T", service))
 	return services.NewOpenAPIService(
 		container.Logger(),
+		container.Tracer(),
+		container.CompletionChain(),
+		container.Tokenizers(),
+		container.ContextWindows(),
+		container.HistoryService(),
+		container.KnowledgeService(),
+		container.TenantService(),
+		container.ModerationService(),
+		container.ToolRegistry(),
+		container.config.Tools.MaxSteps,
+		container.EventDispatcher(),
+	)
+}
+
+// ToolRegistry creates the tools.Registry with the tools which the language model can call.
+// The registry is empty when the tools are disabled.
+func (container *Container) ToolRegistry() (registry *tools.Registry) {
+	if container.toolRegistry != nil {
+		return container.toolRegistry
+	}
+
+	container.logger.Debug(fmt.Sprintf("creating %T", registry))
+	if !container.config.Tools.Enabled {
+		container.toolRegistry = tools.NewRegistry()
+		return container.toolRegistry
+	}
+
+	rates, err := container.config.CurrencyRates()
+	if err != nil {
+		container.logger.Fatal(stacktrace.Propagate(err, "cannot parse the currency rates of the converter"))
+	}
+
+	entries, err := tools.LoadFAQ(container.config.Tools.FAQFile)
+	if err != nil {
+		container.logger.Fatal(stacktrace.Propagate(err, "cannot load the FAQ"))
+	}
+
+	container.toolRegistry = tools.NewRegistry(
+		tools.NewCalculator(),
+		tools.NewConverter(rates),
+		tools.NewDateTime(time.Now),
+		tools.NewFAQ(entries),
+	)
+	return container.toolRegistry
+}
+
+// HistoryService creates a new instance of services.HistoryService
+func (container *Container) HistoryService() (service *services.HistoryService) {
+	container.logger.Debug(fmt.Sprintf("creating %T", service))
+	return services.NewHistoryService(
+		container.Logger(),
+		container.Tracer(),
+		container.CompletionChain(),
+		container.Tokenizers(),
+		container.ConversationService(),
+		container.MessageRepository(),
+		container.config.Completion.HistoryMessages,
+		container.config.Completion.SummaryTokens,
+	)
+}
+
+// Tokenizers creates the completion.Tokenizers which are shared by all the services so that each encoding is loaded once
+func (container *Container) Tokenizers() (tokenizers *completion.Tokenizers) {
+	if container.tokenizers != nil {
+		return container.tokenizers
+	}
+
+	container.logger.Debug(fmt.Sprintf("creating %T", tokenizers))
+	container.tokenizers = completion.NewTokenizers()
+	return container.tokenizers
+}
+
+// ContextWindows creates the completion.Windows of the models in the configuration
+func (container *Container) ContextWindows() (windows *completion.Windows) {
+	container.logger.Debug(fmt.Sprintf("creating %T", windows))
+
+	contextWindows, err := container.config.ContextWindows()
+	if err != nil {
+		container.logger.Fatal(stacktrace.Propagate(err, "cannot parse the context windows of the models"))
+	}
+
+	models := map[string]completion.Window{}
+	for model, window := range contextWindows {
+		models[model] = completion.Window{Size: window.Size, Reply: window.ReplyTokens}
+	}
+
+	return completion.NewWindows(
+		completion.Window{Size: container.config.Completion.ContextWindow, Reply: container.config.Completion.ReplyTokens},
+		container.config.Completion.MinReplyTokens,
+		container.config.Completion.HistoryShare,
+		models,
+	)
+}
+
+// CompletionChain creates the completion.Chain of the models in the configuration.
+// It is a singleton so that the circuit breakers of the providers are shared by all the requests.
+func (container *Container) CompletionChain() (chain *completion.Chain) {
+	if container.completionChain != nil {
+		return container.completionChain
+	}
+
+	container.logger.Debug(fmt.Sprintf("creating %T with models [%s]", chain, container.config.Completion.Models))
+
+	providers := map[string]*completion.Provider{}
+	var links []completion.Link
+	for _, model := range container.config.CompletionModels() {
+		if _, ok := providers[model.Provider]; !ok {
+			providers[model.Provider] = &completion.Provider{
+				Name:    model.Provider,
+				Client:  container.completionClient(model.Provider),
+				Breaker: container.CircuitBreaker(),
+			}
+		}
+		links = append(links, completion.Link{Provider: providers[model.Provider], Model: model.Model})
+	}
+
+	container.completionChain = completion.NewChain(container.Tracer(), container.Metrics(), links...)
+	return container.completionChain
+}
+
+func (container *Container) completionClient(provider string) *openapi.Client {
+	if provider == config.ProviderSecondary {
+		return container.SecondaryOpenAPIClient()
+	}
+	return container.OpenAPIClient()
+}
+
+// SecondaryOpenAPIClient creates an openapi.Client for the OpenAI compatible API of the secondary completion provider
+func (container *Container) SecondaryOpenAPIClient() (service *openapi.Client) {
+	container.logger.Debug(fmt.Sprintf("creating secondary %T", service))
+	clientConfig := openapi.DefaultConfig(container.config.Completion.SecondaryAuthToken)
+	clientConfig.BaseURL = strings.TrimRight(container.config.Completion.SecondaryBaseURL, "/")
+	clientConfig.HTTPClient = &http.Client{
+		Transport: otelhttp.NewTransport(http.DefaultTransport, container.otelHTTPOptions(config.ProviderSecondary)...),
+	}
+	return openapi.NewClientWithConfig(clientConfig)
+}
+
+// CircuitBreaker creates a new completion.CircuitBreaker with the thresholds in the configuration
+func (container *Container) CircuitBreaker() (breaker *completion.CircuitBreaker) {
+	container.logger.Debug(fmt.Sprintf("creating %T", breaker))
+	return completion.NewCircuitBreaker(completion.BreakerConfig{
+		ConsecutiveFailures: container.config.Completion.BreakerConsecutiveFailures,
+		ErrorRate:           container.config.Completion.BreakerErrorRate,
+		MinRequests:         container.config.Completion.BreakerMinRequests,
+		Window:              container.config.Completion.BreakerWindow,
+		Cooldown:            container.config.Completion.BreakerCooldown,
+	})
+}
+
+// ModerationService creates a new instance of services.ModerationService
+func (container *Container) ModerationService() (service *services.ModerationService) {
+	container.logger.Debug(fmt.Sprintf("creating %T", service))
+	return services.NewModerationService(
+		container.Logger(),
+		container.Tracer(),
+		container.Moderator(),
+		container.UserRepository(),
+	)
+}
+
+// Moderator creates a new instance of moderation.Moderator
+func (container *Container) Moderator() moderation.Moderator {
+	container.logger.Debug("creating OpenAI moderation.Moderator")
+	return moderation.NewOpenAIModerator(
 		container.Tracer(),
 		container.OpenAPIClient(),
 	)
@@ -170,8 +1352,15 @@ func (container *Container) WhatsappService() (service *services.WhatsappService
 	return services.NewWhatsappService(
 		container.Logger(),
 		container.Tracer(),
-		container.WhatsappClient(),
+		container.Metrics(),
+		container.TenantService(),
+		container.Catalog(),
 		container.OpenAPIService(),
+		container.UserService(),
+		container.ModerationService(),
+		container.MessageService(),
+		container.ReminderService(),
+		container.DigestService(),
 	)
 }
 
@@ -181,12 +1370,89 @@ func (container *Container) NexmoService() (service *services.NexmoService) {
 	return services.NewNexmoService(
 		container.Logger(),
 		container.Tracer(),
-		container.NexmoClient(),
+		container.Metrics(),
+		container.TenantService(),
 		container.Cache(),
+		container.Catalog(),
 		container.OpenAPIService(),
+		container.UserService(),
+		container.ModerationService(),
+		container.MessageService(),
+		container.ReminderService(),
+		container.DigestService(),
+	)
+}
+
+// UserService creates a new instance of services.UserService
+func (container *Container) UserService() (service *services.UserService) {
+	container.logger.Debug(fmt.Sprintf("creating %T", service))
+	return services.NewUserService(
+		container.Logger(),
+		container.Tracer(),
+		container.Catalog(),
+		container.UserRepository(),
+	)
+}
+
+// ConversationService creates a new instance of services.ConversationService
+func (container *Container) ConversationService() (service *services.ConversationService) {
+	container.logger.Debug(fmt.Sprintf("creating %T", service))
+	return services.NewConversationService(
+		container.Logger(),
+		container.Tracer(),
+		container.UserService(),
+		container.ConversationRepository(),
+	)
+}
+
+// MessageService creates a new instance of services.MessageService
+func (container *Container) MessageService() (service *services.MessageService) {
+	container.logger.Debug(fmt.Sprintf("creating %T", service))
+	return services.NewMessageService(
+		container.Logger(),
+		container.Tracer(),
+		container.ConversationService(),
+		container.EventDispatcher(),
+		container.MessageRepository(),
+	)
+}
+
+// ConversationRepository creates a new instance of repositories.ConversationRepository
+func (container *Container) ConversationRepository() repositories.ConversationRepository {
+	container.logger.Debug("creating GORM repositories.ConversationRepository")
+	return repositories.NewGormConversationRepository(
+		container.Logger(),
+		container.Tracer(),
+		container.DB(),
+	)
+}
+
+// MessageRepository creates a new instance of repositories.MessageRepository
+func (container *Container) MessageRepository() repositories.MessageRepository {
+	container.logger.Debug("creating GORM repositories.MessageRepository")
+	return repositories.NewGormMessageRepository(
+		container.Logger(),
+		container.Tracer(),
+		container.DB(),
+	)
+}
+
+// UserRepository creates a new instance of repositories.UserRepository
+func (container *Container) UserRepository() repositories.UserRepository {
+	container.logger.Debug("creating GORM repositories.UserRepository")
+	return repositories.NewGormUserRepository(
+		container.Logger(),
+		container.Tracer(),
+		container.DB(),
 	)
 }
 
+// Catalog creates a new instance of i18n.Catalog
+func (container *Container) Catalog() (catalog *i18n.Catalog) {
+	container.logger.Debug(fmt.Sprintf("creating %T", catalog))
+	return i18n.NewCatalog()
+}
+
 // HTTPClient creates a new http.Client
 func (container *Container) HTTPClient(name string) *http.Client {
 	container.logger.Debug(fmt.Sprintf("creating %s %T", name, http.DefaultClient))
@@ -196,17 +1462,64 @@ func (container *Container) HTTPClient(name string) *http.Client {
 	}
 }
 
-// HTTPRoundTripper creates an open telemetry http.RoundTripper
+// PublicHTTPClient creates an http.Client for the URLs which are chosen by integrators e.g. webhooks.
+// The connections to localhost and to private networks are refused to prevent server side request forgery.
+func (container *Container) PublicHTTPClient(name string) *http.Client {
+	container.logger.Debug(fmt.Sprintf("creating public %s %T", name, http.DefaultClient))
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = (&net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control:   netguard.DialControl,
+	}).DialContext
+
+	return &http.Client{
+		Transport: otelhttp.NewTransport(transport, container.otelHTTPOptions(name)...),
+		Timeout:   30 * time.Second,
+	}
+}
+
+// HTTPRoundTripper creates an open telemetry http.RoundTripper which injects the trace context into the outbound requests
 func (container *Container) HTTPRoundTripper(name string) http.RoundTripper {
 	container.logger.Debug(fmt.Sprintf("Debug: initializing %s %T", name, http.DefaultTransport))
-	return otelroundtripper.New(
-		otelroundtripper.WithName(name),
-		otelroundtripper.WithParent(container.RetryHTTPRoundTripper()),
-		otelroundtripper.WithMeter(global.Meter(container.projectID)),
-		otelroundtripper.WithAttributes(container.InitializeOtelResources(container.version, container.projectID).Attributes()...),
+	return otelhttp.NewTransport(
+		otelroundtripper.New(
+			otelroundtripper.WithName(name),
+			otelroundtripper.WithParent(container.RetryHTTPRoundTripper()),
+			otelroundtripper.WithMeter(global.Meter(container.projectID)),
+			otelroundtripper.WithAttributes(container.InitializeOtelResources(container.version, container.projectID).Attributes()...),
+		),
+		container.otelHTTPOptions(name)...,
 	)
 }
 
+// otelHTTPOptions creates the options of the client spans of the outbound requests to name e.g. "nexmo"
+func (container *Container) otelHTTPOptions(name string) []otelhttp.Option {
+	return []otelhttp.Option{
+		otelhttp.WithPropagators(container.Propagator()),
+		otelhttp.WithSpanNameFormatter(func(_ string, request *http.Request) string {
+			return fmt.Sprintf("%s %s", name, request.Method)
+		}),
+	}
+}
+
+// Propagator creates the propagation.TextMapPropagator which extracts and injects the trace context
+func (container *Container) Propagator() propagation.TextMapPropagator {
+	if container.propagator != nil {
+		return container.propagator
+	}
+
+	container.logger.Debug(fmt.Sprintf("creating propagation.TextMapPropagator %v", container.config.PropagatorNames()))
+	propagator, err := telemetry.NewPropagator(container.config.PropagatorNames()...)
+	if err != nil {
+		container.logger.Fatal(stacktrace.Propagate(err, "cannot create the trace context propagator"))
+	}
+
+	container.propagator = propagator
+	return container.propagator
+}
+
 // RetryHTTPRoundTripper creates a retryable http.RoundTripper
 func (container *Container) RetryHTTPRoundTripper() http.RoundTripper {
 	container.logger.Debug(fmt.Sprintf("initializing retry %T", http.DefaultTransport))
@@ -225,15 +1538,15 @@ func (container *Container) App() (app *fiber.App) {
 
 	app = fiber.New()
 
-	if isLocal() {
+	if container.config.IsLocal() {
 		app.Use(fiberLogger.New())
 	}
 	app.Use(
 		middlewares.OtelTraceContext(
 			container.Tracer(),
 			container.Logger(),
-			"X-Cloud-Trace-Context",
-			os.Getenv("GCP_PROJECT_ID"),
+			container.Propagator(),
+			container.config.GCP.ProjectID,
 		),
 	)
 	app.Use(cors.New())
@@ -251,31 +1564,72 @@ func (container *Container) InitializeOtelResources(version string, namespace st
 		semconv.ServiceNamespaceKey.String(namespace),
 		semconv.ServiceVersionKey.String(version),
 		semconv.ServiceInstanceIDKey.String(hostName()),
-		attribute.String("service.environment", os.Getenv("ENV")),
+		attribute.String("service.environment", container.config.Environment),
 	)
 }
 
-// Cache creates a new instance of cache.Cache
+// Cache creates a new instance of cache.Cache based on the CACHE_DRIVER which can be "redis" or "memory".
+// The memory cache is used when the CACHE_DRIVER is not set and there is no REDIS_URL.
 func (container *Container) Cache() cache.Cache {
-	container.logger.Debug("creating cache.Cache")
-	opt, err := redis.ParseURL(os.Getenv("REDIS_URL"))
+	if container.cache != nil {
+		return container.cache
+	}
+
+	if container.config.CacheDriver() == config.DriverRedis {
+		container.logger.Debug("creating redis cache.Cache")
+		container.cache = cache.NewRedisCache(container.Tracer(), container.RedisClient())
+		return container.cache
+	}
+
+	container.logger.Debug("creating in memory cache.Cache")
+	container.cache = cache.NewMemoryCache(container.config.Cache.Capacity)
+	return container.cache
+}
+
+// Locker creates a new instance of cache.Locker using the same CACHE_DRIVER as the cache.Cache
+func (container *Container) Locker() cache.Locker {
+	if container.locker != nil {
+		return container.locker
+	}
+
+	if container.config.CacheDriver() == config.DriverRedis {
+		container.logger.Debug("creating redis cache.Locker")
+		container.locker = cache.NewRedisLocker(container.Tracer(), container.RedisClient())
+		return container.locker
+	}
+
+	container.logger.Debug("creating in memory cache.Locker")
+	container.locker = cache.NewMemoryLocker()
+	return container.locker
+}
+
+// RedisClient creates a new instance of redis.Client.
+// TLS is used when the REDIS_URL has the "rediss://" scheme.
+func (container *Container) RedisClient() *redis.Client {
+	if container.redisClient != nil {
+		return container.redisClient
+	}
+
+	container.logger.Debug(fmt.Sprintf("creating %T", container.redisClient))
+	opt, err := redis.ParseURL(container.config.Redis.URL)
 	if err != nil {
-		container.logger.Fatal(stacktrace.Propagate(err, fmt.Sprintf("cannot parse redis url [%s]", os.Getenv("REDIS_URL"))))
+		container.logger.Fatal(stacktrace.Propagate(err, "cannot parse redis url"))
 	}
-	opt.TLSConfig = &tls.Config{
-		MinVersion: tls.VersionTLS12,
+	if opt.TLSConfig != nil {
+		opt.TLSConfig.MinVersion = tls.VersionTLS12
 	}
 
-	return cache.NewRedisCache(container.Tracer(), redis.NewClient(opt))
+	container.redisClient = redis.NewClient(opt)
+	return container.redisClient
 }
 
 // Logger creates a new instance of telemetry.Logger
 func (container *Container) Logger(skipFrameCount ...int) telemetry.Logger {
 	container.logger.Debug("creating telemetry.Logger")
 	if len(skipFrameCount) > 0 {
-		return logger(skipFrameCount[0])
+		return logger(container.config, skipFrameCount[0])
 	}
-	return logger(3)
+	return logger(container.config, 3)
 }
 
 // GormLogger creates a new instance of gormLogger.Interface
@@ -296,11 +1650,11 @@ func (container *Container) DB() (db *gorm.DB) {
 	container.logger.Debug(fmt.Sprintf("creating %T", db))
 
 	config := &gorm.Config{}
-	if isLocal() {
+	if container.config.IsLocal() {
 		config = &gorm.Config{Logger: container.GormLogger()}
 	}
 
-	db, err := gorm.Open(postgres.Open(os.Getenv("DATABASE_DSN")), config)
+	db, err := gorm.Open(postgres.Open(container.config.Database.DSN), config)
 	if err != nil {
 		container.logger.Fatal(err)
 	}
@@ -312,9 +1666,96 @@ func (container *Container) DB() (db *gorm.DB) {
 		container.logger.Fatal(stacktrace.Propagate(err, fmt.Sprintf("cannot migrate %T", &entities.Message{})))
 	}
 
+	if err = db.AutoMigrate(&entities.User{}); err != nil {
+		container.logger.Fatal(stacktrace.Propagate(err, fmt.Sprintf("cannot migrate %T", &entities.User{})))
+	}
+
+	if err = db.AutoMigrate(&entities.Conversation{}); err != nil {
+		container.logger.Fatal(stacktrace.Propagate(err, fmt.Sprintf("cannot migrate %T", &entities.Conversation{})))
+	}
+
+	if err = db.AutoMigrate(&entities.APIKey{}); err != nil {
+		container.logger.Fatal(stacktrace.Propagate(err, fmt.Sprintf("cannot migrate %T", &entities.APIKey{})))
+	}
+
+	if err = db.AutoMigrate(&entities.Webhook{}); err != nil {
+		container.logger.Fatal(stacktrace.Propagate(err, fmt.Sprintf("cannot migrate %T", &entities.Webhook{})))
+	}
+
+	if err = db.AutoMigrate(&entities.WebhookDelivery{}); err != nil {
+		container.logger.Fatal(stacktrace.Propagate(err, fmt.Sprintf("cannot migrate %T", &entities.WebhookDelivery{})))
+	}
+
+	if err = db.AutoMigrate(&entities.Reminder{}); err != nil {
+		container.logger.Fatal(stacktrace.Propagate(err, fmt.Sprintf("cannot migrate %T", &entities.Reminder{})))
+	}
+
+	if err = db.AutoMigrate(&entities.DigestSubscription{}); err != nil {
+		container.logger.Fatal(stacktrace.Propagate(err, fmt.Sprintf("cannot migrate %T", &entities.DigestSubscription{})))
+	}
+
+	if err = db.AutoMigrate(&entities.KnowledgeBase{}, &entities.KnowledgeDocument{}, &entities.KnowledgeChunk{}); err != nil {
+		container.logger.Fatal(stacktrace.Propagate(err, fmt.Sprintf("cannot migrate %T", &entities.KnowledgeChunk{})))
+	}
+
+	if err = db.AutoMigrate(&entities.Tenant{}, &entities.TenantNumber{}); err != nil {
+		container.logger.Fatal(stacktrace.Propagate(err, fmt.Sprintf("cannot migrate %T", &entities.Tenant{})))
+	}
+
+	container.migrateDefaultTenant(db)
+	container.pgvector = container.migrateVectorStore(db)
+
 	return container.db
 }
 
+// migrateDefaultTenant moves the rows which were created before tenants existed to the default tenant and drops the
+// unique index of the users which did not include the tenant.
+func (container *Container) migrateDefaultTenant(db *gorm.DB) {
+	if db.Migrator().HasIndex(&entities.User{}, "idx_users_channel_channel_id") {
+		if err := db.Migrator().DropIndex(&entities.User{}, "idx_users_channel_channel_id"); err != nil {
+			container.logger.Fatal(stacktrace.Propagate(err, "cannot drop the index [idx_users_channel_channel_id]"))
+		}
+	}
+
+	tables := []string{"users", "conversations", "messages", "reminders", "digest_subscriptions", "knowledge_bases", "api_keys", "webhooks", "webhook_deliveries"}
+	for _, table := range tables {
+		query := fmt.Sprintf("UPDATE %s SET tenant_id = ? WHERE tenant_id IS NULL", table)
+		if err := db.Exec(query, uuid.Nil).Error; err != nil {
+			container.logger.Fatal(stacktrace.Propagate(err, fmt.Sprintf("cannot move the rows of [%s] to the default tenant", table)))
+		}
+	}
+
+	// the documents and chunks of knowledge bases belong to the tenant of their knowledge base
+	for _, table := range []string{"knowledge_documents", "knowledge_chunks"} {
+		query := fmt.Sprintf("UPDATE %[1]s SET tenant_id = knowledge_bases.tenant_id FROM knowledge_bases WHERE %[1]s.knowledge_base_id = knowledge_bases.id AND %[1]s.tenant_id IS NULL", table)
+		if err := db.Exec(query).Error; err != nil {
+			container.logger.Fatal(stacktrace.Propagate(err, fmt.Sprintf("cannot move the rows of [%s] to the tenant of their knowledge base", table)))
+		}
+	}
+}
+
+// migrateVectorStore adds the pgvector column of the embeddings when KNOWLEDGE_VECTOR_STORE allows it.
+// It returns false when the embeddings are compared in memory because pgvector is not available.
+func (container *Container) migrateVectorStore(db *gorm.DB) bool {
+	if container.config.Knowledge.VectorStore == config.VectorStoreBruteForce {
+		return false
+	}
+
+	err := db.Exec("CREATE EXTENSION IF NOT EXISTS vector").Error
+	if err == nil {
+		err = db.Exec("ALTER TABLE knowledge_chunks ADD COLUMN IF NOT EXISTS embedding_vector vector").Error
+	}
+
+	if err != nil && container.config.Knowledge.VectorStore == config.VectorStorePGVector {
+		container.logger.Fatal(stacktrace.Propagate(err, "cannot enable pgvector for the knowledge chunks"))
+	}
+	if err != nil {
+		container.logger.Warn(stacktrace.Propagate(err, "pgvector is not available, the knowledge chunks are searched in memory"))
+		return false
+	}
+	return true
+}
+
 // Tracer creates a new instance of telemetry.Tracer
 func (container *Container) Tracer() (t telemetry.Tracer) {
 	container.logger.Debug("creating telemetry.Tracer")
@@ -324,18 +1765,41 @@ func (container *Container) Tracer() (t telemetry.Tracer) {
 	)
 }
 
-// InitializeTraceProvider initializes the open telemetry trace provider
-func (container *Container) InitializeTraceProvider(version string, namespace string) func() {
-	if isLocal() {
+// InitializeMeterProvider exports the metrics in the prometheus format when the metrics are enabled
+func (container *Container) InitializeMeterProvider(version string, namespace string) {
+	if !container.config.MetricsEnabled() {
+		return
+	}
+
+	container.logger.Debug("initializing prometheus meter provider")
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(collectors.NewGoCollector(), collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
+
+	exporter, err := otelprometheus.New(otelprometheus.WithRegisterer(registry))
+	if err != nil {
+		container.logger.Fatal(stacktrace.Propagate(err, "cannot create prometheus exporter"))
+	}
+
+	container.meterProvider = sdkmetric.NewMeterProvider(
+		sdkmetric.WithReader(exporter),
+		sdkmetric.WithResource(container.InitializeOtelResources(version, namespace)),
+	)
+	container.metricsRegistry = registry
+	global.SetMeterProvider(container.meterProvider)
+}
+
+// InitializeTraceProvider initializes the open telemetry trace provider and returns a function which flushes the buffered spans
+func (container *Container) InitializeTraceProvider(version string, namespace string) func(ctx context.Context) error {
+	if container.config.IsLocal() {
 		return container.initializeUptraceProvider(version, namespace)
 	}
 	return container.initializeGoogleTraceProvider(version, namespace)
 }
 
-func (container *Container) initializeGoogleTraceProvider(version string, namespace string) func() {
+func (container *Container) initializeGoogleTraceProvider(version string, namespace string) func(ctx context.Context) error {
 	container.logger.Debug("initializing google trace provider")
 
-	exporter, err := cloudtrace.New(cloudtrace.WithProjectID(os.Getenv("GCP_PROJECT_ID")))
+	exporter, err := cloudtrace.New(cloudtrace.WithProjectID(container.config.GCP.ProjectID))
 	if err != nil {
 		container.logger.Fatal(stacktrace.Propagate(err, "cannot create cloud trace exporter"))
 	}
@@ -347,46 +1811,43 @@ func (container *Container) initializeGoogleTraceProvider(version string, namesp
 	)
 
 	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(container.Propagator())
 
-	return func() {
-		_ = exporter.Shutdown(context.Background())
-	}
+	return tp.Shutdown
 }
 
-func (container *Container) initializeUptraceProvider(version string, namespace string) (flush func()) {
+func (container *Container) initializeUptraceProvider(version string, namespace string) (flush func(ctx context.Context) error) {
 	container.logger.Debug("initializing uptrace provider")
 	// Configure OpenTelemetry with sensible defaults.
 	uptrace.ConfigureOpentelemetry(
-		uptrace.WithDSN(os.Getenv("UPTRACE_DSN")),
+		uptrace.WithDSN(container.config.Tracing.UptraceDSN),
 		uptrace.WithServiceName(namespace),
 		uptrace.WithServiceVersion(version),
+		uptrace.WithTextMapPropagator(container.Propagator()),
+		// the metrics are exported by the prometheus meter provider when they are enabled
+		uptrace.WithMetricsEnabled(!container.config.MetricsEnabled()),
 	)
 
 	// Send buffered spans and free resources.
-	return func() {
-		err := uptrace.Shutdown(context.Background())
-		if err != nil {
-			container.logger.Error(err)
-		}
-	}
+	return uptrace.Shutdown
 }
 
-func logger(skipFrameCount int) telemetry.Logger {
+func logger(cfg *config.Config, skipFrameCount int) telemetry.Logger {
 	fields := map[string]string{
 		"pid":      strconv.Itoa(os.Getpid()),
 		"hostname": hostName(),
 	}
 
 	return telemetry.NewZerologLogger(
-		os.Getenv("GCP_PROJECT_ID"),
+		cfg.GCP.ProjectID,
 		fields,
-		logDriver(skipFrameCount),
+		logDriver(cfg, skipFrameCount),
 		nil,
 	)
 }
 
-func logDriver(skipFrameCount int) *zerodriver.Logger {
-	if isLocal() {
+func logDriver(cfg *config.Config, skipFrameCount int) *zerodriver.Logger {
+	if cfg.IsLocal() {
 		return consoleLogger(skipFrameCount)
 	}
 	return jsonLogger(skipFrameCount)
@@ -435,7 +1896,3 @@ func consoleLogger(skipFrameCount int) *zerodriver.Logger {
 		Logger: &l,
 	}
 }
-
-func isLocal() bool {
-	return os.Getenv("ENV") == "local"
-}
@@ -0,0 +1,65 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Role determines the routes which can be accessed with an APIKey
+type Role string
+
+const (
+	// RoleAdmin can access all routes including the management of API keys
+	RoleAdmin = Role("admin")
+
+	// RoleSupport can look up users, conversations and messages
+	RoleSupport = Role("support")
+
+	// RoleIntegrator can send messages programmatically
+	RoleIntegrator = Role("integrator")
+
+	// RoleTask can only run the queued tasks e.g. delivering events, reminders and daily digests
+	RoleTask = Role("task")
+)
+
+// APIKey is used to authenticate requests to the API.
+// The requests of an integrator can only access the data of the tenant of its APIKey.
+// The SigningKey signs the callbacks of the messages which are sent with the APIKey.
+type APIKey struct {
+	ID         uuid.UUID `json:"id" gorm:"primaryKey;type:uuid;" example:"0b0c8b3e-4f2a-4d5c-9a7e-3f1b2c3d4e5f"`
+	TenantID   uuid.UUID `json:"tenant_id" gorm:"type:uuid;index" example:"6f1d2c3b-4a5e-4f60-8a7b-9c0d1e2f3a4b"`
+	Name       string    `json:"name" example:"Support Dashboard"`
+	Prefix     string    `json:"prefix" example:"dwai_5f1b2c3d"`
+	Hash       string    `json:"-" gorm:"uniqueIndex"`
+	SigningKey string    `json:"-"`
+	Roles      []Role    `json:"roles" gorm:"serializer:json" example:"support"`
+	CreatedAt  time.Time `json:"created_at" example:"2022-06-05T14:26:02.302718+03:00"`
+	UpdatedAt  time.Time `json:"updated_at" example:"2022-06-05T14:26:10.303278+03:00"`
+}
+
+// Principal is the authenticated client which is making a request
+type Principal struct {
+	APIKeyID uuid.UUID
+	TenantID uuid.UUID
+	Name     string
+	Roles    []Role
+}
+
+// CanAccessAllTenants checks if the Principal operates the platform and can access the data of all the tenants.
+// The requests of other principals are scoped to the tenant of their API key.
+func (principal Principal) CanAccessAllTenants() bool {
+	return principal.HasAnyRole(RoleAdmin, RoleSupport, RoleTask)
+}
+
+// HasAnyRole checks if the Principal has at least one of the roles
+func (principal Principal) HasAnyRole(roles ...Role) bool {
+	for _, role := range roles {
+		for _, principalRole := range principal.Roles {
+			if role == principalRole {
+				return true
+			}
+		}
+	}
+	return false
+}
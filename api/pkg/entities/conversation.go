@@ -0,0 +1,20 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Conversation groups the messages exchanged between a user and one of our phone numbers
+type Conversation struct {
+	ID            uuid.UUID `json:"id" gorm:"primaryKey;type:uuid;" example:"4c5d0ed2-9b6b-4a5c-8a27-0b4c0c7d4e0a"`
+	TenantID      uuid.UUID `json:"tenant_id" gorm:"type:uuid;index" example:"6f1d2c3b-4a5e-4f60-8a7b-9c0d1e2f3a4b"`
+	UserID        uuid.UUID `json:"user_id" gorm:"type:uuid;index" example:"32343a19-da5e-4b1b-a767-3298a73703cb"`
+	Channel       Channel   `json:"channel" gorm:"uniqueIndex:idx_conversations_channel_channel_id_owner" example:"sms"`
+	ChannelID     string    `json:"channel_id" gorm:"uniqueIndex:idx_conversations_channel_channel_id_owner" example:"+18005550199"`
+	Owner         string    `json:"owner" gorm:"uniqueIndex:idx_conversations_channel_channel_id_owner" example:"+18005550100"`
+	LastMessageAt time.Time `json:"last_message_at" example:"2022-06-05T14:26:09.527976+03:00"`
+	CreatedAt     time.Time `json:"created_at" example:"2022-06-05T14:26:02.302718+03:00"`
+	UpdatedAt     time.Time `json:"updated_at" example:"2022-06-05T14:26:10.303278+03:00"`
+}
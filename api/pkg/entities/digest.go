@@ -0,0 +1,55 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DigestTopic is the kind of AI generated message which is sent in a daily digest
+type DigestTopic string
+
+// String converts DigestTopic to string
+func (topic DigestTopic) String() string {
+	return string(topic)
+}
+
+const (
+	// DigestTopicWord is a word of the day with its meaning and an example
+	DigestTopicWord = DigestTopic("word")
+
+	// DigestTopicBriefing is a news-style briefing generated from a configured prompt
+	DigestTopicBriefing = DigestTopic("briefing")
+
+	// DigestTopicQuestion is a study question with its answer
+	DigestTopicQuestion = DigestTopic("question")
+)
+
+// DigestTopics returns all the topics which a user can subscribe to
+func DigestTopics() []DigestTopic {
+	return []DigestTopic{DigestTopicWord, DigestTopicBriefing, DigestTopicQuestion}
+}
+
+// DigestSubscription is the subscription of a user to a daily AI generated message
+type DigestSubscription struct {
+	ID             uuid.UUID   `json:"id" gorm:"primaryKey;type:uuid;" example:"0f0e8a3c-7b1d-4c2e-9a5f-6b7c8d9e0f1a"`
+	TenantID       uuid.UUID   `json:"tenant_id" gorm:"type:uuid;index" example:"6f1d2c3b-4a5e-4f60-8a7b-9c0d1e2f3a4b"`
+	UserID         uuid.UUID   `json:"user_id" gorm:"type:uuid;uniqueIndex:idx_digest_subscriptions_user_id_topic" example:"32343a19-da5e-4b1b-a767-3298a73703cb"`
+	Topic          DigestTopic `json:"topic" gorm:"uniqueIndex:idx_digest_subscriptions_user_id_topic" example:"word"`
+	Channel        Channel     `json:"channel" example:"whatsapp"`
+	ChannelID      string      `json:"channel_id" example:"+18005550199"`
+	Owner          string      `json:"owner" example:"+18005550100"`
+	LocalTime      string      `json:"local_time" example:"08:00"`
+	Timezone       string      `json:"timezone" example:"Africa/Douala"`
+	NextDeliveryAt time.Time   `json:"next_delivery_at" gorm:"index" example:"2022-06-06T08:00:00+01:00"`
+	DeliveredAt    *time.Time  `json:"delivered_at" example:"2022-06-05T08:00:00+01:00"`
+	ClaimedAt      *time.Time  `json:"claimed_at" example:"2022-06-05T08:00:02.302718+01:00"`
+	MessageID      *uuid.UUID  `json:"message_id" gorm:"type:uuid" example:"8f9c71b8-b84e-4417-8408-a62274f65a08"`
+	CreatedAt      time.Time   `json:"created_at" example:"2022-06-05T14:26:02.302718+03:00"`
+	UpdatedAt      time.Time   `json:"updated_at" example:"2022-06-05T14:26:10.303278+03:00"`
+}
+
+// IsDelivered checks if the digest which was due at deliveryAt was already delivered
+func (subscription *DigestSubscription) IsDelivered(deliveryAt time.Time) bool {
+	return subscription.DeliveredAt != nil && !subscription.DeliveredAt.Before(deliveryAt)
+}
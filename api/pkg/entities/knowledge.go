@@ -0,0 +1,44 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// KnowledgeBase is a collection of documents which the AI assistant uses to answer the users of a business.
+// It is used for the conversations with the Owner or for the prompts which are sent through the API with the Persona.
+type KnowledgeBase struct {
+	ID        uuid.UUID `json:"id" gorm:"primaryKey;type:uuid;" example:"8f9c71b8-b84e-4417-8408-a62274f65a08"`
+	TenantID  uuid.UUID `json:"tenant_id" gorm:"type:uuid;index" example:"6f1d2c3b-4a5e-4f60-8a7b-9c0d1e2f3a4b"`
+	Name      string    `json:"name" example:"Online shop FAQ"`
+	Owner     *string   `json:"owner" gorm:"index" example:"+18005550100"`
+	Persona   *string   `json:"persona" example:"You are a friendly support agent for an online shop"`
+	CreatedAt time.Time `json:"created_at" example:"2022-06-05T14:26:02.302718+03:00"`
+	UpdatedAt time.Time `json:"updated_at" example:"2022-06-05T14:26:10.303278+03:00"`
+}
+
+// KnowledgeDocument is a document which was uploaded to a KnowledgeBase
+type KnowledgeDocument struct {
+	ID              uuid.UUID `json:"id" gorm:"primaryKey;type:uuid;" example:"32343a19-da5e-4b1b-a767-3298a73703ca"`
+	TenantID        uuid.UUID `json:"tenant_id" gorm:"type:uuid;index" example:"6f1d2c3b-4a5e-4f60-8a7b-9c0d1e2f3a4b"`
+	KnowledgeBaseID uuid.UUID `json:"knowledge_base_id" gorm:"type:uuid;index" example:"8f9c71b8-b84e-4417-8408-a62274f65a08"`
+	Name            string    `json:"name" example:"shipping.pdf"`
+	Format          string    `json:"format" example:"pdf"`
+	Size            int       `json:"size" example:"20480"`
+	Chunks          int       `json:"chunks" example:"12"`
+	CreatedAt       time.Time `json:"created_at" example:"2022-06-05T14:26:02.302718+03:00"`
+	UpdatedAt       time.Time `json:"updated_at" example:"2022-06-05T14:26:10.303278+03:00"`
+}
+
+// KnowledgeChunk is a part of a KnowledgeDocument with the embedding which is used to find it
+type KnowledgeChunk struct {
+	ID              uuid.UUID `json:"id" gorm:"primaryKey;type:uuid;" example:"4c5d0ed2-9b6b-4a5c-8a27-0b4c0c7d4e0a"`
+	TenantID        uuid.UUID `json:"tenant_id" gorm:"type:uuid;index" example:"6f1d2c3b-4a5e-4f60-8a7b-9c0d1e2f3a4b"`
+	KnowledgeBaseID uuid.UUID `json:"knowledge_base_id" gorm:"type:uuid;index" example:"8f9c71b8-b84e-4417-8408-a62274f65a08"`
+	DocumentID      uuid.UUID `json:"document_id" gorm:"type:uuid;index" example:"32343a19-da5e-4b1b-a767-3298a73703ca"`
+	Position        int       `json:"position" example:"3"`
+	Content         string    `json:"content" example:"Orders are shipped within 2 business days."`
+	Embedding       []byte    `json:"-"`
+	CreatedAt       time.Time `json:"created_at" example:"2022-06-05T14:26:02.302718+03:00"`
+}
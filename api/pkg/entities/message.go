@@ -24,12 +24,59 @@ const (
 	ChannelEmail = Channel("email")
 )
 
+// MessageRole is the author of a message
+type MessageRole string
+
+const (
+	// MessageRoleUser is a message sent by a user
+	MessageRoleUser = MessageRole("user")
+
+	// MessageRoleAssistant is a message sent by the AI assistant
+	MessageRoleAssistant = MessageRole("assistant")
+
+	// MessageRoleSummary is a summary of the older messages in a conversation which is sent to the AI assistant
+	MessageRoleSummary = MessageRole("summary")
+
+	// MessageRoleFunction is a call of a tool by the AI assistant and its result
+	MessageRoleFunction = MessageRole("function")
+)
+
+// MessageStatus is the delivery status of a message
+type MessageStatus string
+
+const (
+	// MessageStatusReceived is a message which was received from a user
+	MessageStatusReceived = MessageStatus("received")
+
+	// MessageStatusPending is a message which is waiting to be sent to a user
+	MessageStatusPending = MessageStatus("pending")
+
+	// MessageStatusSent is a message which was sent to a user
+	MessageStatusSent = MessageStatus("sent")
+
+	// MessageStatusFailed is a message which could not be sent to a user
+	MessageStatusFailed = MessageStatus("failed")
+
+	// MessageStatusInternal is a message which is never sent to a user e.g. a summary
+	MessageStatusInternal = MessageStatus("internal")
+)
+
 // Message stores an incoming prompt for a user
 type Message struct {
-	ID        uuid.UUID `json:"id" gorm:"primaryKey;type:string;" example:"8f9c71b8-b84e-4417-8408-a62274f65a08"`
-	ChannelID string    `json:"channel_id"`
-	Channel   Channel   `json:"channel"`
-	Name      string    `json:"name" example:"John Doe"`
-	CreatedAt time.Time `json:"created_at" example:"2022-06-05T14:26:02.302718+03:00"`
-	UpdatedAt time.Time `json:"updated_at" example:"2022-06-05T14:26:10.303278+03:00"`
+	ID                uuid.UUID     `json:"id" gorm:"primaryKey;type:uuid;" example:"8f9c71b8-b84e-4417-8408-a62274f65a08"`
+	TenantID          uuid.UUID     `json:"tenant_id" gorm:"type:uuid;index" example:"6f1d2c3b-4a5e-4f60-8a7b-9c0d1e2f3a4b"`
+	ConversationID    uuid.UUID     `json:"conversation_id" gorm:"type:uuid;index" example:"4c5d0ed2-9b6b-4a5c-8a27-0b4c0c7d4e0a"`
+	ChannelID         string        `json:"channel_id" gorm:"index" example:"+18005550199"`
+	Channel           Channel       `json:"channel" example:"sms"`
+	Owner             string        `json:"owner" example:"+18005550100"`
+	Role              MessageRole   `json:"role" example:"user"`
+	Name              string        `json:"name" example:"John Doe"`
+	Content           string        `json:"content" example:"What is the capital of Cameroon?"`
+	ProviderMessageID string        `json:"provider_message_id" example:"0A0000000123ABCD1"`
+	Status            MessageStatus `json:"status" example:"sent"`
+	FailureReason     *string       `json:"failure_reason" example:"cannot send SMS to [+18005550199]"`
+	CallbackURL       *string       `json:"callback_url" example:"https://example.com/webhooks/discusswithai"`
+	APIKeyID          *uuid.UUID    `json:"api_key_id" gorm:"type:uuid" example:"0b0c8b3e-4f2a-4d5c-9a7e-3f1b2c3d4e5f"`
+	CreatedAt         time.Time     `json:"created_at" gorm:"index" example:"2022-06-05T14:26:02.302718+03:00"`
+	UpdatedAt         time.Time     `json:"updated_at" example:"2022-06-05T14:26:10.303278+03:00"`
 }
@@ -0,0 +1,54 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ReminderStatus is the delivery status of a reminder
+type ReminderStatus string
+
+const (
+	// ReminderStatusScheduled is a reminder which is waiting to be sent to a user
+	ReminderStatusScheduled = ReminderStatus("scheduled")
+
+	// ReminderStatusSending is a reminder which was claimed by a worker which is sending it to a user
+	ReminderStatusSending = ReminderStatus("sending")
+
+	// ReminderStatusSent is a reminder which was sent to a user
+	ReminderStatusSent = ReminderStatus("sent")
+
+	// ReminderStatusFailed is a reminder which could not be sent to a user
+	ReminderStatusFailed = ReminderStatus("failed")
+
+	// ReminderStatusCancelled is a reminder which was cancelled by the user before it was sent
+	ReminderStatusCancelled = ReminderStatus("cancelled")
+)
+
+// Reminder is a message which a user asked us to send to them at a later time
+type Reminder struct {
+	ID        uuid.UUID      `json:"id" gorm:"primaryKey;type:uuid;" example:"5b9a1c2e-3f4d-4e5a-9b6c-7d8e9f0a1b2c"`
+	TenantID  uuid.UUID      `json:"tenant_id" gorm:"type:uuid;index" example:"6f1d2c3b-4a5e-4f60-8a7b-9c0d1e2f3a4b"`
+	UserID    uuid.UUID      `json:"user_id" gorm:"type:uuid;index" example:"32343a19-da5e-4b1b-a767-3298a73703cb"`
+	Channel   Channel        `json:"channel" example:"whatsapp"`
+	ChannelID string         `json:"channel_id" example:"+18005550199"`
+	Owner     string         `json:"owner" example:"+18005550100"`
+	Content   string         `json:"content" example:"Call mum"`
+	Timezone  string         `json:"timezone" example:"Africa/Douala"`
+	RemindAt  time.Time      `json:"remind_at" gorm:"index" example:"2022-06-06T08:00:00+01:00"`
+	Status    ReminderStatus `json:"status" example:"scheduled"`
+	MessageID *uuid.UUID     `json:"message_id" gorm:"type:uuid" example:"8f9c71b8-b84e-4417-8408-a62274f65a08"`
+	CreatedAt time.Time      `json:"created_at" example:"2022-06-05T14:26:02.302718+03:00"`
+	UpdatedAt time.Time      `json:"updated_at" example:"2022-06-05T14:26:10.303278+03:00"`
+}
+
+// IsScheduled checks if the reminder is still waiting to be sent
+func (reminder *Reminder) IsScheduled() bool {
+	return reminder.Status == ReminderStatusScheduled
+}
+
+// IsSending checks if the reminder was claimed by a worker which is sending it
+func (reminder *Reminder) IsSending() bool {
+	return reminder.Status == ReminderStatusSending
+}
@@ -0,0 +1,42 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Tenant is a business which answers its users through its own phone numbers.
+// The messages to the numbers of a tenant are sent with its credentials, persona and knowledge base.
+type Tenant struct {
+	ID                  uuid.UUID  `json:"id" gorm:"primaryKey;type:uuid;" example:"6f1d2c3b-4a5e-4f60-8a7b-9c0d1e2f3a4b"`
+	Name                string     `json:"name" example:"Online Shop"`
+	Persona             *string    `json:"persona" example:"You are a friendly support agent for an online shop"`
+	KnowledgeBaseID     *uuid.UUID `json:"knowledge_base_id" gorm:"type:uuid" example:"8f9c71b8-b84e-4417-8408-a62274f65a08"`
+	WhatsappAccessToken *string    `json:"-"`
+	NexmoAPIKey         *string    `json:"-"`
+	NexmoAPISecret      *string    `json:"-"`
+	DailyMessageLimit   uint       `json:"daily_message_limit" example:"1000"`
+	CreatedAt           time.Time  `json:"created_at" example:"2022-06-05T14:26:02.302718+03:00"`
+	UpdatedAt           time.Time  `json:"updated_at" example:"2022-06-05T14:26:10.303278+03:00"`
+}
+
+// HasWhatsappCredentials checks if the messages of the tenant are sent with its own whatsapp access token
+func (tenant *Tenant) HasWhatsappCredentials() bool {
+	return tenant.WhatsappAccessToken != nil && *tenant.WhatsappAccessToken != ""
+}
+
+// HasNexmoCredentials checks if the SMS of the tenant are sent with its own nexmo API key
+func (tenant *Tenant) HasNexmoCredentials() bool {
+	return tenant.NexmoAPIKey != nil && *tenant.NexmoAPIKey != "" && tenant.NexmoAPISecret != nil && *tenant.NexmoAPISecret != ""
+}
+
+// TenantNumber is a phone number of a Tenant on a channel.
+// For whatsapp, the Number is the phone_number_id of the whatsapp business account.
+type TenantNumber struct {
+	ID        uuid.UUID `json:"id" gorm:"primaryKey;type:uuid;" example:"1a2b3c4d-5e6f-4a7b-8c9d-0e1f2a3b4c5d"`
+	TenantID  uuid.UUID `json:"tenant_id" gorm:"type:uuid;index" example:"6f1d2c3b-4a5e-4f60-8a7b-9c0d1e2f3a4b"`
+	Channel   Channel   `json:"channel" gorm:"uniqueIndex:idx_tenant_numbers_channel_number" example:"sms"`
+	Number    string    `json:"number" gorm:"uniqueIndex:idx_tenant_numbers_channel_number" example:"+18005550100"`
+	CreatedAt time.Time `json:"created_at" example:"2022-06-05T14:26:02.302718+03:00"`
+}
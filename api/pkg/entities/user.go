@@ -0,0 +1,26 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// User is a person who sends prompts through a channel
+type User struct {
+	ID                   uuid.UUID  `json:"id" gorm:"primaryKey;type:uuid;" example:"32343a19-da5e-4b1b-a767-3298a73703cb"`
+	TenantID             uuid.UUID  `json:"tenant_id" gorm:"type:uuid;uniqueIndex:idx_users_tenant_id_channel_channel_id" example:"6f1d2c3b-4a5e-4f60-8a7b-9c0d1e2f3a4b"`
+	Channel              Channel    `json:"channel" gorm:"uniqueIndex:idx_users_tenant_id_channel_channel_id" example:"whatsapp"`
+	ChannelID            string     `json:"channel_id" gorm:"uniqueIndex:idx_users_tenant_id_channel_channel_id" example:"+18005550199"`
+	Name                 string     `json:"name" example:"John Doe"`
+	Locale               *string    `json:"locale" example:"fr"`
+	ModerationViolations uint       `json:"moderation_violations" example:"1"`
+	FlaggedAt            *time.Time `json:"flagged_at" example:"2022-06-05T14:26:09.527976+03:00"`
+	CreatedAt            time.Time  `json:"created_at" example:"2022-06-05T14:26:02.302718+03:00"`
+	UpdatedAt            time.Time  `json:"updated_at" example:"2022-06-05T14:26:10.303278+03:00"`
+}
+
+// IsFlagged checks if the user has been flagged as a repeat offender of our usage policy
+func (user *User) IsFlagged() bool {
+	return user.FlaggedAt != nil
+}
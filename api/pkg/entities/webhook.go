@@ -0,0 +1,69 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Webhook is a URL where events are sent for an integrator
+type Webhook struct {
+	ID         uuid.UUID `json:"id" gorm:"primaryKey;type:uuid;" example:"8f9c71b8-b84e-4417-8408-a62274f65a08"`
+	TenantID   uuid.UUID `json:"tenant_id" gorm:"type:uuid;index" example:"6f1d2c3b-4a5e-4f60-8a7b-9c0d1e2f3a4b"`
+	APIKeyID   uuid.UUID `json:"api_key_id" gorm:"type:uuid;index" example:"0b0c8b3e-4f2a-4d5c-9a7e-3f1b2c3d4e5f"`
+	URL        string    `json:"url" example:"https://example.com/webhooks/discusswithai"`
+	SigningKey string    `json:"-"`
+	Events     []string  `json:"events" gorm:"serializer:json" example:"message.sent"`
+	CreatedAt  time.Time `json:"created_at" example:"2022-06-05T14:26:02.302718+03:00"`
+	UpdatedAt  time.Time `json:"updated_at" example:"2022-06-05T14:26:10.303278+03:00"`
+}
+
+// HasEvent checks if the Webhook is subscribed to an event type
+func (webhook Webhook) HasEvent(eventType string) bool {
+	for _, event := range webhook.Events {
+		if event == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookDeliveryStatus is the status of a WebhookDelivery
+type WebhookDeliveryStatus string
+
+const (
+	// WebhookDeliveryStatusPending is a delivery which is still being retried
+	WebhookDeliveryStatusPending = WebhookDeliveryStatus("pending")
+
+	// WebhookDeliveryStatusSucceeded is a delivery which was accepted by the webhook URL
+	WebhookDeliveryStatusSucceeded = WebhookDeliveryStatus("succeeded")
+
+	// WebhookDeliveryStatusFailed is a delivery which failed after all the retries
+	WebhookDeliveryStatusFailed = WebhookDeliveryStatus("failed")
+)
+
+// WebhookDelivery is the attempt to send an event to a Webhook.
+// The callback of a message is a WebhookDelivery without a Webhook which is sent to the CallbackURL and signed
+// with the signing key of the APIKey which sent the message.
+type WebhookDelivery struct {
+	ID                 uuid.UUID             `json:"id" gorm:"primaryKey;type:uuid;" example:"32343a19-da5e-4b1b-a767-3298a73703ca"`
+	TenantID           uuid.UUID             `json:"tenant_id" gorm:"type:uuid;index" example:"6f1d2c3b-4a5e-4f60-8a7b-9c0d1e2f3a4b"`
+	WebhookID          uuid.UUID             `json:"webhook_id" gorm:"type:uuid;index" example:"8f9c71b8-b84e-4417-8408-a62274f65a08"`
+	APIKeyID           *uuid.UUID            `json:"api_key_id" gorm:"type:uuid" example:"0b0c8b3e-4f2a-4d5c-9a7e-3f1b2c3d4e5f"`
+	CallbackURL        *string               `json:"callback_url" example:"https://example.com/callbacks/discusswithai"`
+	EventID            string                `json:"event_id" example:"4c5d0ed2-9b6b-4a5c-8a27-0b4c0c7d4e0a"`
+	EventType          string                `json:"event_type" example:"message.sent"`
+	Payload            string                `json:"payload" example:"{\"specversion\":\"1.0\"}"`
+	Status             WebhookDeliveryStatus `json:"status" example:"succeeded"`
+	Attempts           uint                  `json:"attempts" example:"1"`
+	ResponseStatusCode *int                  `json:"response_status_code" example:"200"`
+	Error              *string               `json:"error" example:"context deadline exceeded"`
+	DeliveredAt        *time.Time            `json:"delivered_at" example:"2022-06-05T14:26:10.303278+03:00"`
+	CreatedAt          time.Time             `json:"created_at" gorm:"index" example:"2022-06-05T14:26:02.302718+03:00"`
+	UpdatedAt          time.Time             `json:"updated_at" example:"2022-06-05T14:26:10.303278+03:00"`
+}
+
+// IsCallback checks if the WebhookDelivery is the callback of a message
+func (delivery WebhookDelivery) IsCallback() bool {
+	return delivery.CallbackURL != nil
+}
@@ -0,0 +1,22 @@
+package events
+
+import (
+	"context"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+)
+
+// Dispatcher publishes domain events to the listeners which are subscribed to them
+type Dispatcher interface {
+	// Subscribe registers a Listener which runs synchronously when an event type is published
+	Subscribe(eventType string, listener Listener)
+
+	// SubscribeQueued registers a Listener which runs in the background when an event type is published
+	SubscribeQueued(eventType string, listener Listener)
+
+	// Publish sends the cloudevents.Event to all the listeners of its type
+	Publish(ctx context.Context, event cloudevents.Event) error
+
+	// Consume runs the queued listeners of a cloudevents.Event which was published in the background
+	Consume(ctx context.Context, event cloudevents.Event) error
+}
@@ -0,0 +1,117 @@
+package events
+
+import (
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/cloudevents/sdk-go/v2/types"
+	"github.com/google/uuid"
+)
+
+const (
+	// MessageReceived is emitted when a message is received from a user
+	MessageReceived = "message.received"
+
+	// CompletionGenerated is emitted when a completion is generated for a prompt
+	CompletionGenerated = "completion.generated"
+
+	// MessageSent is emitted when a message is delivered to a user by the provider
+	MessageSent = "message.sent"
+
+	// MessageFailed is emitted when a message cannot be delivered to a user by the provider
+	MessageFailed = "message.failed"
+
+	// TenantQuotaExceeded is emitted when a message is rejected because the tenant has sent its daily limit of messages
+	TenantQuotaExceeded = "tenant.quota_exceeded"
+
+	// SubscriptionChanged is emitted when a user subscribes to a daily digest, changes its time or unsubscribes from it
+	SubscriptionChanged = "subscription.changed"
+)
+
+const (
+	// SubscriptionStatusActive is the status in the SubscriptionPayload of a digest subscription which is delivered
+	SubscriptionStatusActive = "active"
+
+	// SubscriptionStatusCancelled is the status in the SubscriptionPayload of a digest subscription which was deleted
+	SubscriptionStatusCancelled = "cancelled"
+)
+
+// Types returns all the event types which can be sent to webhooks
+func Types() []string {
+	return []string{
+		MessageReceived,
+		CompletionGenerated,
+		MessageSent,
+		MessageFailed,
+		TenantQuotaExceeded,
+		SubscriptionChanged,
+	}
+}
+
+// MessagePayload is the data of the MessageReceived, MessageSent and MessageFailed events
+type MessagePayload struct {
+	MessageID         string  `json:"message_id"`
+	ConversationID    string  `json:"conversation_id"`
+	Channel           string  `json:"channel"`
+	ChannelID         string  `json:"channel_id"`
+	Owner             string  `json:"owner"`
+	Content           string  `json:"content"`
+	Status            string  `json:"status"`
+	ProviderMessageID string  `json:"provider_message_id"`
+	FailureReason     *string `json:"failure_reason"`
+}
+
+// CompletionPayload is the data of the CompletionGenerated event
+type CompletionPayload struct {
+	Channel    string `json:"channel"`
+	ChannelID  string `json:"channel_id"`
+	Prompt     string `json:"prompt"`
+	Completion string `json:"completion"`
+}
+
+// TenantQuotaPayload is the data of the TenantQuotaExceeded event
+type TenantQuotaPayload struct {
+	TenantID          string `json:"tenant_id"`
+	Channel           string `json:"channel"`
+	DailyMessageLimit uint   `json:"daily_message_limit"`
+	MessagesSent      int64  `json:"messages_sent"`
+}
+
+// SubscriptionPayload is the data of the SubscriptionChanged event
+type SubscriptionPayload struct {
+	SubscriptionID string     `json:"subscription_id"`
+	UserID         string     `json:"user_id"`
+	Channel        string     `json:"channel"`
+	ChannelID      string     `json:"channel_id"`
+	Owner          string     `json:"owner"`
+	Topic          string     `json:"topic"`
+	LocalTime      string     `json:"local_time"`
+	Timezone       string     `json:"timezone"`
+	Status         string     `json:"status"`
+	NextDeliveryAt *time.Time `json:"next_delivery_at"`
+}
+
+// TenantIDExtension is the cloudevents extension with the ID of the tenant which owns an event.
+// The webhooks of a tenant only receive the events of the same tenant.
+const TenantIDExtension = "tenantid"
+
+// SetTenantID sets the ID of the tenant which owns the cloudevents.Event. The ID of the default tenant is uuid.Nil.
+func SetTenantID(event *cloudevents.Event, tenantID uuid.UUID) {
+	event.SetExtension(TenantIDExtension, tenantID.String())
+}
+
+// TenantID returns the ID of the tenant which owns the cloudevents.Event.
+// It returns false when the event does not have a valid TenantIDExtension.
+func TenantID(event cloudevents.Event) (uuid.UUID, bool) {
+	value, err := types.ToString(event.Extensions()[TenantIDExtension])
+	if err != nil {
+		return uuid.Nil, false
+	}
+
+	tenantID, err := uuid.Parse(value)
+	if err != nil {
+		return uuid.Nil, false
+	}
+
+	return tenantID, true
+}
@@ -0,0 +1,48 @@
+package events
+
+import (
+	"testing"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTenantID(t *testing.T) {
+	t.Run("the tenant of an event is kept when it is sent through a queue", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+
+		// Arrange
+		tenantID := uuid.New()
+		event := cloudevents.NewEvent()
+		event.SetID(uuid.New().String())
+		event.SetSource("test")
+		event.SetType(MessageReceived)
+		SetTenantID(&event, tenantID)
+
+		body, err := event.MarshalJSON()
+		assert.Nil(t, err)
+
+		// Act
+		consumed := cloudevents.NewEvent()
+		err = consumed.UnmarshalJSON(body)
+		actual, ok := TenantID(consumed)
+
+		// Assert
+		assert.Nil(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, tenantID, actual)
+	})
+
+	t.Run("an event without a tenant has no tenant ID", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+
+		// Act
+		_, ok := TenantID(cloudevents.NewEvent())
+
+		// Assert
+		assert.False(t, ok)
+	})
+}
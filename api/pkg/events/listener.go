@@ -0,0 +1,24 @@
+package events
+
+import (
+	"context"
+	"fmt"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/palantir/stacktrace"
+)
+
+// Listener handles a cloudevents.Event which was published on a Dispatcher
+type Listener func(ctx context.Context, event cloudevents.Event) error
+
+// Typed creates a Listener which decodes the data of the cloudevents.Event into the payload type T
+func Typed[T any](listener func(ctx context.Context, event cloudevents.Event, payload *T) error) Listener {
+	return func(ctx context.Context, event cloudevents.Event) error {
+		payload := new(T)
+		if err := event.DataAs(payload); err != nil {
+			msg := fmt.Sprintf("cannot decode data of event [%s] with type [%s] into [%T]", event.ID(), event.Type(), payload)
+			return stacktrace.Propagate(err, msg)
+		}
+		return listener(ctx, event, payload)
+	}
+}
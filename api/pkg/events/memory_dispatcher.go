@@ -0,0 +1,56 @@
+package events
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/NdoleStudio/discusswithai/pkg/telemetry"
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/palantir/stacktrace"
+)
+
+// memoryDispatcher runs all the listeners in the same process when an event is published.
+// It is used locally and in tests where there is no queue.
+type memoryDispatcher struct {
+	*registry
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+}
+
+// NewMemoryDispatcher creates a Dispatcher which runs queued listeners synchronously
+func NewMemoryDispatcher(logger telemetry.Logger, tracer telemetry.Tracer) Dispatcher {
+	return &memoryDispatcher{
+		registry: newRegistry(),
+		logger:   logger.WithService(fmt.Sprintf("%T", &memoryDispatcher{})),
+		tracer:   tracer,
+	}
+}
+
+func (dispatcher *memoryDispatcher) Publish(ctx context.Context, event cloudevents.Event) error {
+	ctx, span := dispatcher.tracer.Start(ctx)
+	defer span.End()
+
+	err := dispatcher.run(ctx, event, false)
+	if queuedErr := dispatcher.run(ctx, event, true); queuedErr != nil {
+		err = queuedErr
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot publish event [%s] with type [%s]", event.ID(), event.Type())
+		return dispatcher.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+func (dispatcher *memoryDispatcher) Consume(ctx context.Context, event cloudevents.Event) error {
+	ctx, span := dispatcher.tracer.Start(ctx)
+	defer span.End()
+
+	if err := dispatcher.run(ctx, event, true); err != nil {
+		msg := fmt.Sprintf("cannot consume event [%s] with type [%s]", event.ID(), event.Type())
+		return dispatcher.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
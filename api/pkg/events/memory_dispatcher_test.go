@@ -0,0 +1,80 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/NdoleStudio/discusswithai/pkg/telemetry"
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/hirosassa/zerodriver"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryDispatcher_Publish(t *testing.T) {
+	t.Run("typed listeners receive the payload of the event", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		dispatcher := newTestDispatcher()
+
+		// Arrange
+		var received []string
+		dispatcher.Subscribe(MessageSent, Typed(func(ctx context.Context, event cloudevents.Event, payload *MessagePayload) error {
+			received = append(received, "sync:"+payload.MessageID)
+			return nil
+		}))
+		dispatcher.SubscribeQueued(MessageSent, Typed(func(ctx context.Context, event cloudevents.Event, payload *MessagePayload) error {
+			received = append(received, "queued:"+payload.MessageID)
+			return nil
+		}))
+		dispatcher.Subscribe(MessageFailed, func(ctx context.Context, event cloudevents.Event) error {
+			received = append(received, "failed")
+			return nil
+		})
+
+		// Act
+		err := dispatcher.Publish(context.Background(), newTestEvent(t, MessageSent, &MessagePayload{MessageID: "message-1"}))
+
+		// Assert
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"sync:message-1", "queued:message-1"}, received)
+	})
+
+	t.Run("all listeners run when one of them fails", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		dispatcher := newTestDispatcher()
+
+		// Arrange
+		count := 0
+		dispatcher.Subscribe(MessageReceived, func(ctx context.Context, event cloudevents.Event) error {
+			count++
+			return errors.New("listener failed")
+		})
+		dispatcher.Subscribe(MessageReceived, func(ctx context.Context, event cloudevents.Event) error {
+			count++
+			return nil
+		})
+
+		// Act
+		err := dispatcher.Publish(context.Background(), newTestEvent(t, MessageReceived, &MessagePayload{}))
+
+		// Assert
+		assert.NotNil(t, err)
+		assert.Equal(t, 2, count)
+	})
+}
+
+func newTestDispatcher() Dispatcher {
+	logger := telemetry.NewZerologLogger("test", map[string]string{}, zerodriver.NewDevelopmentLogger(), nil)
+	return NewMemoryDispatcher(logger, telemetry.NewOtelLogger("test", logger))
+}
+
+func newTestEvent(t *testing.T, eventType string, payload any) cloudevents.Event {
+	event := cloudevents.NewEvent()
+	event.SetID("event-1")
+	event.SetSource("test")
+	event.SetType(eventType)
+	assert.Nil(t, event.SetData(cloudevents.ApplicationJSON, payload))
+	return event
+}
@@ -0,0 +1,88 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/NdoleStudio/discusswithai/pkg/queue"
+	"github.com/NdoleStudio/discusswithai/pkg/telemetry"
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/palantir/stacktrace"
+)
+
+// queueDispatcher runs synchronous listeners in the same process and pushes events
+// with queued listeners through a queue.Client to the consumer URL.
+type queueDispatcher struct {
+	*registry
+	logger      telemetry.Logger
+	tracer      telemetry.Tracer
+	queue       queue.Client
+	consumerURL string
+	headers     map[string]string
+}
+
+// NewQueueDispatcher creates a Dispatcher which runs queued listeners when the event is sent back to the consumer URL
+func NewQueueDispatcher(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	queue queue.Client,
+	consumerURL string,
+	headers map[string]string,
+) Dispatcher {
+	return &queueDispatcher{
+		registry:    newRegistry(),
+		logger:      logger.WithService(fmt.Sprintf("%T", &queueDispatcher{})),
+		tracer:      tracer,
+		queue:       queue,
+		consumerURL: consumerURL,
+		headers:     headers,
+	}
+}
+
+func (dispatcher *queueDispatcher) Publish(ctx context.Context, event cloudevents.Event) error {
+	ctx, span, ctxLogger := dispatcher.tracer.StartWithLogger(ctx, dispatcher.logger)
+	defer span.End()
+
+	listenerErr := dispatcher.run(ctx, event, false)
+	if listenerErr != nil {
+		msg := fmt.Sprintf("cannot publish event [%s] with type [%s]", event.ID(), event.Type())
+		listenerErr = dispatcher.tracer.WrapErrorSpan(span, stacktrace.Propagate(listenerErr, msg))
+	}
+
+	if !dispatcher.hasQueuedListeners(event.Type()) {
+		return listenerErr
+	}
+
+	body, err := event.MarshalJSON()
+	if err != nil {
+		msg := fmt.Sprintf("cannot marshal event [%s] with type [%s] into JSON", event.ID(), event.Type())
+		return dispatcher.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	taskID, err := dispatcher.queue.Enqueue(ctx, &queue.Task{
+		Method:  http.MethodPost,
+		URL:     dispatcher.consumerURL,
+		Body:    body,
+		Headers: dispatcher.headers,
+	})
+	if err != nil {
+		msg := fmt.Sprintf("cannot enqueue event [%s] with type [%s]", event.ID(), event.Type())
+		return dispatcher.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("enqueued event [%s] with type [%s] as task [%s]", event.ID(), event.Type(), taskID))
+	return listenerErr
+}
+
+func (dispatcher *queueDispatcher) Consume(ctx context.Context, event cloudevents.Event) error {
+	ctx, span := dispatcher.tracer.Start(ctx)
+	defer span.End()
+
+	if err := dispatcher.run(ctx, event, true); err != nil {
+		msg := fmt.Sprintf("cannot consume event [%s] with type [%s]", event.ID(), event.Type())
+		return dispatcher.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
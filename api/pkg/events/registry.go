@@ -0,0 +1,61 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/palantir/stacktrace"
+)
+
+// registry stores the listeners which are subscribed to each event type
+type registry struct {
+	mutex           sync.RWMutex
+	listeners       map[string][]Listener
+	queuedListeners map[string][]Listener
+}
+
+func newRegistry() *registry {
+	return &registry{
+		listeners:       map[string][]Listener{},
+		queuedListeners: map[string][]Listener{},
+	}
+}
+
+func (registry *registry) Subscribe(eventType string, listener Listener) {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+	registry.listeners[eventType] = append(registry.listeners[eventType], listener)
+}
+
+func (registry *registry) SubscribeQueued(eventType string, listener Listener) {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+	registry.queuedListeners[eventType] = append(registry.queuedListeners[eventType], listener)
+}
+
+func (registry *registry) hasQueuedListeners(eventType string) bool {
+	registry.mutex.RLock()
+	defer registry.mutex.RUnlock()
+	return len(registry.queuedListeners[eventType]) > 0
+}
+
+// run calls all the listeners even when one of them fails and returns the last error
+func (registry *registry) run(ctx context.Context, event cloudevents.Event, queued bool) error {
+	registry.mutex.RLock()
+	listeners := registry.listeners[event.Type()]
+	if queued {
+		listeners = registry.queuedListeners[event.Type()]
+	}
+	registry.mutex.RUnlock()
+
+	var result error
+	for index, listener := range listeners {
+		if err := listener(ctx, event); err != nil {
+			msg := fmt.Sprintf("listener [%d] cannot handle event [%s] with type [%s]", index, event.ID(), event.Type())
+			result = stacktrace.Propagate(err, msg)
+		}
+	}
+	return result
+}
@@ -0,0 +1,186 @@
+package handlers
+
+import (
+	"fmt"
+
+	"github.com/NdoleStudio/discusswithai/pkg/requests"
+	"github.com/NdoleStudio/discusswithai/pkg/services"
+	"github.com/NdoleStudio/discusswithai/pkg/telemetry"
+	"github.com/NdoleStudio/discusswithai/pkg/validators"
+	"github.com/davecgh/go-spew/spew"
+	"github.com/gofiber/fiber/v2"
+	"github.com/palantir/stacktrace"
+)
+
+// AdminHandler handles requests from the support team
+type AdminHandler struct {
+	handler
+	logger              telemetry.Logger
+	tracer              telemetry.Tracer
+	validator           *validators.AdminHandlerValidator
+	userService         *services.UserService
+	conversationService *services.ConversationService
+	messageService      *services.MessageService
+}
+
+// NewAdminHandler creates a new AdminHandler
+func NewAdminHandler(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	validator *validators.AdminHandlerValidator,
+	userService *services.UserService,
+	conversationService *services.ConversationService,
+	messageService *services.MessageService,
+) (h *AdminHandler) {
+	return &AdminHandler{
+		logger:              logger.WithService(fmt.Sprintf("%T", h)),
+		tracer:              tracer,
+		validator:           validator,
+		userService:         userService,
+		conversationService: conversationService,
+		messageService:      messageService,
+	}
+}
+
+// RegisterRoutes registers the routes for the AdminHandler
+func (h *AdminHandler) RegisterRoutes(app *fiber.App, middlewares ...fiber.Handler) {
+	router := app.Group("/v1/admin")
+	router.Get("/users", h.computeRoute(middlewares, h.IndexUsers)...)
+	router.Get("/conversations", h.computeRoute(middlewares, h.IndexConversations)...)
+	router.Get("/messages", h.computeRoute(middlewares, h.IndexMessages)...)
+}
+
+// IndexUsers returns the users who have sent prompts
+// @Summary      Get users
+// @Description  Get the users who have sent prompts filtered by channel, date range and text
+// @Security	 ApiKeyAuth
+// @Tags         Admin
+// @Produce      json
+// @Param        skip		query  int  	false	"number of users to skip"		minimum(0)
+// @Param        query		query  string  	false 	"filter users by name or channel ID"
+// @Param        limit		query  int  	false	"number of users to return"		minimum(1)	maximum(100)
+// @Param        channel	query  string  	false	"filter users by channel"		Enums(sms, whatsapp, email)
+// @Param        channel_id	query  string  	false	"filter users by channel ID"
+// @Param        from		query  string  	false	"RFC3339 timestamp of the earliest user"
+// @Param        to			query  string  	false	"RFC3339 timestamp of the latest user"
+// @Success      200 		{object}	responses.Ok[[]entities.User]
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401    	{object}	responses.Unauthorized
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /admin/users [get]
+func (h *AdminHandler) IndexUsers(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.AdminIndexRequest
+	if err := c.QueryParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall params [%s] into %T", c.OriginalURL(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	if errors := h.validator.ValidateIndex(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while fetching users [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while fetching users")
+	}
+
+	users, err := h.userService.Index(ctx, request.ToIndexParams(), request.ToIndexFilters())
+	if err != nil {
+		ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot index users with request [%+#v]", request)))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, fmt.Sprintf("fetched %d %s", len(*users), h.pluralize("user", len(*users))), users)
+}
+
+// IndexConversations returns the conversations between users and our phone numbers
+// @Summary      Get conversations
+// @Description  Get the conversations between users and our phone numbers filtered by channel, date range and text
+// @Security	 ApiKeyAuth
+// @Tags         Admin
+// @Produce      json
+// @Param        skip		query  int  	false	"number of conversations to skip"		minimum(0)
+// @Param        query		query  string  	false 	"filter conversations by channel ID or owner"
+// @Param        limit		query  int  	false	"number of conversations to return"		minimum(1)	maximum(100)
+// @Param        channel	query  string  	false	"filter conversations by channel"		Enums(sms, whatsapp, email)
+// @Param        channel_id	query  string  	false	"filter conversations by channel ID"
+// @Param        from		query  string  	false	"RFC3339 timestamp of the earliest conversation"
+// @Param        to			query  string  	false	"RFC3339 timestamp of the latest conversation"
+// @Success      200 		{object}	responses.Ok[[]entities.Conversation]
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401    	{object}	responses.Unauthorized
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /admin/conversations [get]
+func (h *AdminHandler) IndexConversations(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.AdminIndexRequest
+	if err := c.QueryParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall params [%s] into %T", c.OriginalURL(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	if errors := h.validator.ValidateIndex(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while fetching conversations [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while fetching conversations")
+	}
+
+	conversations, err := h.conversationService.Index(ctx, request.ToIndexParams(), request.ToIndexFilters())
+	if err != nil {
+		ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot index conversations with request [%+#v]", request)))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, fmt.Sprintf("fetched %d %s", len(*conversations), h.pluralize("conversation", len(*conversations))), conversations)
+}
+
+// IndexMessages returns the messages exchanged with users
+// @Summary      Get messages
+// @Description  Get the prompts and replies exchanged with users filtered by channel, date range and text
+// @Security	 ApiKeyAuth
+// @Tags         Admin
+// @Produce      json
+// @Param        skip		query  int  	false	"number of messages to skip"		minimum(0)
+// @Param        query		query  string  	false 	"filter messages containing this text"
+// @Param        limit		query  int  	false	"number of messages to return"		minimum(1)	maximum(100)
+// @Param        channel	query  string  	false	"filter messages by channel"		Enums(sms, whatsapp, email)
+// @Param        channel_id	query  string  	false	"filter messages by channel ID"
+// @Param        from		query  string  	false	"RFC3339 timestamp of the earliest message"
+// @Param        to			query  string  	false	"RFC3339 timestamp of the latest message"
+// @Success      200 		{object}	responses.Ok[[]entities.Message]
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401    	{object}	responses.Unauthorized
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /admin/messages [get]
+func (h *AdminHandler) IndexMessages(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.AdminIndexRequest
+	if err := c.QueryParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall params [%s] into %T", c.OriginalURL(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	if errors := h.validator.ValidateIndex(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while fetching messages [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while fetching messages")
+	}
+
+	messages, err := h.messageService.Index(ctx, request.ToIndexParams(), request.ToIndexFilters())
+	if err != nil {
+		ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot index messages with request [%+#v]", request)))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, fmt.Sprintf("fetched %d %s", len(*messages), h.pluralize("message", len(*messages))), messages)
+}
@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"fmt"
+
+	"github.com/NdoleStudio/discusswithai/pkg/repositories"
+	"github.com/NdoleStudio/discusswithai/pkg/requests"
+	"github.com/NdoleStudio/discusswithai/pkg/services"
+	"github.com/NdoleStudio/discusswithai/pkg/telemetry"
+	"github.com/NdoleStudio/discusswithai/pkg/validators"
+	"github.com/davecgh/go-spew/spew"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+)
+
+// APIKeyHandler handles requests for managing API keys
+type APIKeyHandler struct {
+	handler
+	logger    telemetry.Logger
+	tracer    telemetry.Tracer
+	validator *validators.APIKeyHandlerValidator
+	service   *services.APIKeyService
+}
+
+// NewAPIKeyHandler creates a new APIKeyHandler
+func NewAPIKeyHandler(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	validator *validators.APIKeyHandlerValidator,
+	service *services.APIKeyService,
+) (h *APIKeyHandler) {
+	return &APIKeyHandler{
+		logger:    logger.WithService(fmt.Sprintf("%T", h)),
+		tracer:    tracer,
+		validator: validator,
+		service:   service,
+	}
+}
+
+// RegisterRoutes registers the routes for the APIKeyHandler
+func (h *APIKeyHandler) RegisterRoutes(app *fiber.App, middlewares ...fiber.Handler) {
+	router := app.Group("/v1/admin/api-keys")
+	router.Get("/", h.computeRoute(middlewares, h.Index)...)
+	router.Post("/", h.computeRoute(middlewares, h.Store)...)
+	router.Delete("/:apiKeyID", h.computeRoute(middlewares, h.Delete)...)
+}
+
+// Index returns the API keys
+// @Summary      Get API keys
+// @Description  Get the API keys which can be used to access the API
+// @Security	 ApiKeyAuth
+// @Tags         Admin
+// @Produce      json
+// @Param        skip		query  int  	false	"number of API keys to skip"		minimum(0)
+// @Param        limit		query  int  	false	"number of API keys to return"		minimum(1)	maximum(100)
+// @Success      200 		{object}	responses.Ok[[]entities.APIKey]
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401    	{object}	responses.Unauthorized
+// @Failure 	 403    	{object}	responses.Forbidden
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /admin/api-keys [get]
+func (h *APIKeyHandler) Index(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.AdminIndexRequest
+	if err := c.QueryParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall params [%s] into %T", c.OriginalURL(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	if errors := h.validator.ValidateIndex(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while fetching API keys [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while fetching API keys")
+	}
+
+	apiKeys, err := h.service.Index(ctx, request.ToIndexParams())
+	if err != nil {
+		ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot index API keys with request [%+#v]", request)))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, fmt.Sprintf("fetched %d %s", len(*apiKeys), h.pluralize("API key", len(*apiKeys))), apiKeys)
+}
+
+// Store creates a new API key
+// @Summary      Create an API key
+// @Description  Create a new API key with roles. The key and the signing key of the message callbacks are returned only once in the response.
+// @Security	 ApiKeyAuth
+// @Tags         Admin
+// @Accept       json
+// @Produce      json
+// @Param        payload	body 		requests.APIKeyStoreRequest  	true 	"API key request payload"
+// @Success      201 		{object}	responses.Created[map[string]interface{}]
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401    	{object}	responses.Unauthorized
+// @Failure 	 403    	{object}	responses.Forbidden
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /admin/api-keys [post]
+func (h *APIKeyHandler) Store(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.APIKeyStoreRequest
+	if err := c.BodyParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall [%s] into %T", telemetry.RedactBody(string(c.Body())), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	if errors := h.validator.ValidateStore(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while creating API key [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while creating API key")
+	}
+
+	apiKey, key, err := h.service.Store(ctx, request.ToStoreParams())
+	if err != nil {
+		ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot create API key with request [%+#v]", request)))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseCreated(c, "API key created successfully", fiber.Map{
+		"api_key":     apiKey,
+		"key":         key,
+		"signing_key": apiKey.SigningKey,
+	})
+}
+
+// Delete revokes an API key
+// @Summary      Delete an API key
+// @Description  Revoke an API key so that it can no longer be used to access the API
+// @Security	 ApiKeyAuth
+// @Tags         Admin
+// @Produce      json
+// @Param 		 apiKeyID 	path		string 							true 	"ID of the API key" 	default(32343a19-da5e-4b1b-a767-3298a73703ca)
+// @Success      204 		{object}	responses.NoContent
+// @Failure 	 401    	{object}	responses.Unauthorized
+// @Failure 	 403    	{object}	responses.Forbidden
+// @Failure      404		{object}	responses.NotFound
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /admin/api-keys/{apiKeyID} [delete]
+func (h *APIKeyHandler) Delete(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	if errors := h.validateUUID(c, "apiKeyID"); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while deleting API key [%s]", spew.Sdump(errors), c.Params("apiKeyID"))
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while deleting API key")
+	}
+
+	apiKeyID := uuid.MustParse(c.Params("apiKeyID"))
+	err := h.service.Delete(ctx, apiKeyID)
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseNotFound(c, fmt.Sprintf("cannot find API key with ID [%s]", apiKeyID))
+	}
+	if err != nil {
+		ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot delete API key with ID [%s]", apiKeyID)))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseNoContent(c, "API key deleted successfully")
+}
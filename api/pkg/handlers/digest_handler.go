@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"fmt"
+
+	"github.com/NdoleStudio/discusswithai/pkg/requests"
+	"github.com/NdoleStudio/discusswithai/pkg/services"
+	"github.com/NdoleStudio/discusswithai/pkg/telemetry"
+	"github.com/NdoleStudio/discusswithai/pkg/validators"
+	"github.com/davecgh/go-spew/spew"
+	"github.com/gofiber/fiber/v2"
+	"github.com/palantir/stacktrace"
+)
+
+// DigestHandler dispatches and delivers the daily digests which users subscribed to
+type DigestHandler struct {
+	handler
+	logger    telemetry.Logger
+	tracer    telemetry.Tracer
+	validator *validators.DigestHandlerValidator
+	service   *services.DigestService
+}
+
+// NewDigestHandler creates a new DigestHandler
+func NewDigestHandler(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	validator *validators.DigestHandlerValidator,
+	service *services.DigestService,
+) (h *DigestHandler) {
+	return &DigestHandler{
+		logger:    logger.WithService(fmt.Sprintf("%T", h)),
+		tracer:    tracer,
+		validator: validator,
+		service:   service,
+	}
+}
+
+// RegisterRoutes registers the routes for the DigestHandler
+func (h *DigestHandler) RegisterRoutes(app *fiber.App, middlewares ...fiber.Handler) {
+	router := app.Group("/v1/digests")
+	router.Post("/dispatch", h.computeRoute(middlewares, h.Dispatch)...)
+	router.Post("/deliver", h.computeRoute(middlewares, h.Deliver)...)
+}
+
+// Dispatch enqueues the daily digests which are due
+// @Summary      Dispatch daily digests
+// @Description  Enqueue the delivery of the daily digests which are due. This is called every minute by a scheduler.
+// @Security	 ApiKeyAuth
+// @Tags         Digests
+// @Produce      json
+// @Success      200 		{object}	responses.Ok[int]
+// @Failure 	 401    	{object}	responses.Unauthorized
+// @Failure 	 403    	{object}	responses.Forbidden
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /digests/dispatch [post]
+func (h *DigestHandler) Dispatch(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	count, err := h.service.Dispatch(ctx)
+	if err != nil {
+		ctxLogger.Error(stacktrace.Propagate(err, "cannot dispatch daily digests"))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, fmt.Sprintf("dispatched %d digest %s", count, h.pluralize("subscription", count)), count)
+}
+
+// Deliver sends a daily digest to a user
+// @Summary      Deliver a daily digest
+// @Description  Generate the message of a daily digest and send it to the user
+// @Security	 ApiKeyAuth
+// @Tags         Digests
+// @Accept       json
+// @Produce      json
+// @Param        payload	body 		requests.DigestDeliverRequest  	true 	"Deliver digest request payload"
+// @Success      204 		{object}	responses.NoContent
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401    	{object}	responses.Unauthorized
+// @Failure 	 403    	{object}	responses.Forbidden
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /digests/deliver [post]
+func (h *DigestHandler) Deliver(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.DigestDeliverRequest
+	if err := c.BodyParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall [%s] into %T", telemetry.RedactBody(string(c.Body())), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	if errors := h.validator.ValidateDeliver(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while delivering digest subscription [%s]", spew.Sdump(errors), request.SubscriptionID)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while delivering digest")
+	}
+
+	err := h.service.Deliver(ctx, request.ToDeliverParams())
+	if delay, ok := services.RateLimitDelay(err); ok {
+		ctxLogger.Warn(stacktrace.Propagate(err, fmt.Sprintf("digest subscription with ID [%s] was rate limited", request.SubscriptionID)))
+		return h.responseRateLimited(c, fmt.Sprintf("the digest subscription with ID [%s] was rate limited", request.SubscriptionID), delay)
+	}
+	if err != nil {
+		ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot deliver digest subscription with ID [%s]", request.SubscriptionID)))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseNoContent(c, "digest delivered successfully")
+}
@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"fmt"
+
+	"github.com/NdoleStudio/discusswithai/pkg/events"
+	"github.com/NdoleStudio/discusswithai/pkg/telemetry"
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/gofiber/fiber/v2"
+	"github.com/palantir/stacktrace"
+)
+
+// EventHandler consumes events which were pushed through the queue
+type EventHandler struct {
+	handler
+	logger     telemetry.Logger
+	tracer     telemetry.Tracer
+	dispatcher events.Dispatcher
+}
+
+// NewEventHandler creates a new EventHandler
+func NewEventHandler(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	dispatcher events.Dispatcher,
+) (h *EventHandler) {
+	return &EventHandler{
+		logger:     logger.WithService(fmt.Sprintf("%T", h)),
+		tracer:     tracer,
+		dispatcher: dispatcher,
+	}
+}
+
+// RegisterRoutes registers the routes for the EventHandler
+func (h *EventHandler) RegisterRoutes(app *fiber.App, middlewares ...fiber.Handler) {
+	router := app.Group("/v1/events")
+	router.Post("/consume", h.computeRoute(middlewares, h.Consume)...)
+}
+
+// Consume runs the queued listeners of an event
+// @Summary      Consume an event
+// @Description  Run the queued listeners of a CloudEvent which was pushed through the queue
+// @Security	 ApiKeyAuth
+// @Tags         Events
+// @Accept       json
+// @Produce      json
+// @Success      204 		{object}	responses.NoContent
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401    	{object}	responses.Unauthorized
+// @Failure 	 403    	{object}	responses.Forbidden
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /events/consume [post]
+func (h *EventHandler) Consume(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	event := cloudevents.NewEvent()
+	if err := event.UnmarshalJSON(c.Body()); err != nil {
+		msg := fmt.Sprintf("cannot marshall [%s] into %T", telemetry.RedactBody(string(c.Body())), event)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	if err := h.dispatcher.Consume(ctx, event); err != nil {
+		ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot consume event [%s] with type [%s]", event.ID(), event.Type())))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseNoContent(c, "event consumed successfully")
+}
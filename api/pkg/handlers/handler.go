@@ -1,9 +1,16 @@
 package handlers
 
 import (
+	"fmt"
+	"math"
 	"net/url"
+	"strconv"
+	"time"
 
+	"github.com/NdoleStudio/discusswithai/pkg/entities"
+	"github.com/NdoleStudio/discusswithai/pkg/middlewares"
 	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
 )
 
 // handler is the base struct for handling requests
@@ -21,12 +28,12 @@ func (h *handler) computeRoute(middlewares []fiber.Handler, route fiber.Handler)
 	return append(append([]fiber.Handler{}, middlewares...), route)
 }
 
-//func (h *handler) responseInternalServerError(c *fiber.Ctx) error {
-//	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-//		"status":  "error",
-//		"message": "We ran into an internal error while handling the request.",
-//	})
-//}
+func (h *handler) responseInternalServerError(c *fiber.Ctx) error {
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"status":  "error",
+		"message": "We ran into an internal error while handling the request.",
+	})
+}
 
 //func (h *handler) responseUnauthorized(c *fiber.Ctx) error {
 //	return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
@@ -36,12 +43,12 @@ func (h *handler) computeRoute(middlewares []fiber.Handler, route fiber.Handler)
 //	})
 //}
 
-//func (h *handler) responseForbidden(c *fiber.Ctx) error {
-//	return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
-//		"status":  "error",
-//		"message": fiber.ErrForbidden.Text,
-//	})
-//}
+func (h *handler) responseForbidden(c *fiber.Ctx) error {
+	return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+		"status":  "error",
+		"message": fiber.ErrForbidden.Message,
+	})
+}
 
 func (h *handler) responseUnprocessableEntity(c *fiber.Ctx, errors url.Values, message string) error {
 	return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
@@ -51,19 +58,19 @@ func (h *handler) responseUnprocessableEntity(c *fiber.Ctx, errors url.Values, m
 	})
 }
 
-//func (h *handler) responseNotFound(c *fiber.Ctx, message string) error {
-//	return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-//		"status":  "error",
-//		"message": message,
-//	})
-//}
-//
-//func (h *handler) responseNoContent(c *fiber.Ctx, message string) error {
-//	return c.Status(fiber.StatusNoContent).JSON(fiber.Map{
-//		"status":  "success",
-//		"message": message,
-//	})
-//}
+func (h *handler) responseNotFound(c *fiber.Ctx, message string) error {
+	return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+		"status":  "error",
+		"message": message,
+	})
+}
+
+func (h *handler) responseNoContent(c *fiber.Ctx, message string) error {
+	return c.Status(fiber.StatusNoContent).JSON(fiber.Map{
+		"status":  "success",
+		"message": message,
+	})
+}
 
 func (h *handler) responseAccepted(c *fiber.Ctx, message string) error {
 	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
@@ -72,49 +79,81 @@ func (h *handler) responseAccepted(c *fiber.Ctx, message string) error {
 	})
 }
 
-//func (h *handler) responseOK(c *fiber.Ctx, message string, data interface{}) error {
-//	return c.Status(fiber.StatusOK).JSON(fiber.Map{
-//		"status":  "success",
-//		"message": message,
-//		"data":    data,
-//	})
-//}
+func (h *handler) responseOK(c *fiber.Ctx, message string, data interface{}) error {
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": message,
+		"data":    data,
+	})
+}
 
-//func (h *handler) mergeErrors(errors ...url.Values) url.Values {
-//	result := url.Values{}
-//	for _, item := range errors {
-//		for key, values := range item {
-//			for _, value := range values {
-//				result.Add(key, value)
-//			}
-//		}
-//	}
-//	return result
-//}
+func (h *handler) responseTooManyRequests(c *fiber.Ctx, message string) error {
+	return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
+		"status":  "error",
+		"message": message,
+	})
+}
 
-//func (h *handler) validateUUID(c *fiber.Ctx, param string) url.Values {
-//	_, err := uuid.Parse(c.Params(param))
-//	if err != nil {
-//		return url.Values{
-//			param: []string{
-//				fmt.Sprintf("%s is not a valid UUID string e.g b05b8cc4-6e13-11ed-a1eb-0242ac120002", param),
-//			},
-//		}
-//	}
-//	return nil
-//}
+// responseRateLimited responds with the delay after which the request can be sent again in the Retry-After header
+func (h *handler) responseRateLimited(c *fiber.Ctx, message string, delay time.Duration) error {
+	if delay > 0 {
+		c.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(math.Ceil(delay.Seconds()))))
+	}
+	return h.responseTooManyRequests(c, message)
+}
 
-//func (h *handler) responseCreated(c *fiber.Ctx, message string, data interface{}) error {
-//	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
-//		"status":  "success",
-//		"message": message,
-//		"data":    data,
-//	})
-//}
+func (h *handler) responseServiceUnavailable(c *fiber.Ctx, message string, data interface{}) error {
+	return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+		"status":  "error",
+		"message": message,
+		"data":    data,
+	})
+}
 
-//func (h *handler) pluralize(value string, count int) string {
-//	if count == 1 {
-//		return value
-//	}
-//	return value + "s"
-//}
+func (h *handler) mergeErrors(errors ...url.Values) url.Values {
+	result := url.Values{}
+	for _, item := range errors {
+		for key, values := range item {
+			for _, value := range values {
+				result.Add(key, value)
+			}
+		}
+	}
+	return result
+}
+
+func (h *handler) validateUUID(c *fiber.Ctx, param string) url.Values {
+	_, err := uuid.Parse(c.Params(param))
+	if err != nil {
+		return url.Values{
+			param: []string{
+				fmt.Sprintf("%s is not a valid UUID string e.g b05b8cc4-6e13-11ed-a1eb-0242ac120002", param),
+			},
+		}
+	}
+	return nil
+}
+
+func (h *handler) responseCreated(c *fiber.Ctx, message string, data interface{}) error {
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"status":  "success",
+		"message": message,
+		"data":    data,
+	})
+}
+
+// principal returns the entities.Principal which was authenticated by middlewares.APIKeyAuth
+func (h *handler) principal(c *fiber.Ctx) *entities.Principal {
+	principal, ok := c.Locals(middlewares.PrincipalContextKey).(*entities.Principal)
+	if !ok {
+		return &entities.Principal{}
+	}
+	return principal
+}
+
+func (h *handler) pluralize(value string, count int) string {
+	if count == 1 {
+		return value
+	}
+	return value + "s"
+}
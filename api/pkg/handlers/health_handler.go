@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"fmt"
+
+	"github.com/NdoleStudio/discusswithai/pkg/services"
+	"github.com/NdoleStudio/discusswithai/pkg/telemetry"
+	"github.com/gofiber/fiber/v2"
+)
+
+// HealthHandler reports if the API is alive and if its dependencies are reachable
+type HealthHandler struct {
+	handler
+	logger  telemetry.Logger
+	tracer  telemetry.Tracer
+	service *services.HealthService
+}
+
+// NewHealthHandler creates a new HealthHandler
+func NewHealthHandler(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	service *services.HealthService,
+) (h *HealthHandler) {
+	return &HealthHandler{
+		logger:  logger.WithService(fmt.Sprintf("%T", h)),
+		tracer:  tracer,
+		service: service,
+	}
+}
+
+// RegisterRoutes registers the liveness and readiness routes which are used by probes
+func (h *HealthHandler) RegisterRoutes(app *fiber.App, middlewares ...fiber.Handler) {
+	app.Get("/healthz", h.computeRoute(middlewares, h.Liveness)...)
+	app.Get("/readyz", h.computeRoute(middlewares, h.Readiness)...)
+}
+
+// RegisterDiagnosticsRoutes registers the route with the detailed health report
+func (h *HealthHandler) RegisterDiagnosticsRoutes(app *fiber.App, middlewares ...fiber.Handler) {
+	router := app.Group("/v1/admin")
+	router.Get("/health", h.computeRoute(middlewares, h.Diagnostics)...)
+}
+
+// Liveness checks that the API is running without checking its dependencies
+// @Summary      Liveness probe
+// @Description  Check that the API process is running. The dependencies are not checked.
+// @Tags         Health
+// @Produce      json
+// @Success      200 		{object}	responses.Ok[string]
+// @Router       /healthz [get]
+func (h *HealthHandler) Liveness(c *fiber.Ctx) error {
+	return h.responseOK(c, "the API is alive", services.HealthStatusOK)
+}
+
+// Readiness checks that the critical dependencies of the API are reachable
+// @Summary      Readiness probe
+// @Description  Check that the database, redis and the queue are reachable. External APIs which fail make the status degraded without failing the probe.
+// @Tags         Health
+// @Produce      json
+// @Success      200 		{object}	responses.Ok[map[string]string]
+// @Failure      503		{object}	responses.ServiceUnavailable[map[string]string]
+// @Router       /readyz [get]
+func (h *HealthHandler) Readiness(c *fiber.Ctx) error {
+	ctx, span := h.tracer.StartFromFiberCtx(c)
+	defer span.End()
+
+	report := h.service.Report(ctx)
+
+	// the errors are only visible to admins since they can leak details of the infrastructure
+	statuses := map[string]string{"status": report.Status}
+	for _, check := range report.Checks {
+		statuses[check.Name] = check.Status
+	}
+
+	if report.Status == services.HealthStatusUnavailable {
+		return h.responseServiceUnavailable(c, "the API is not ready to serve requests", statuses)
+	}
+	return h.responseOK(c, fmt.Sprintf("the API is ready with status [%s]", report.Status), statuses)
+}
+
+// Diagnostics returns the detailed health report of the dependencies
+// @Summary      Health diagnostics
+// @Description  Get the result, latency and error of each health check of the dependencies of the API
+// @Security	 ApiKeyAuth
+// @Tags         Admin
+// @Produce      json
+// @Success      200 		{object}	responses.Ok[services.HealthReport]
+// @Failure 	 401    	{object}	responses.Unauthorized
+// @Failure 	 403    	{object}	responses.Forbidden
+// @Failure      503		{object}	responses.ServiceUnavailable[services.HealthReport]
+// @Router       /admin/health [get]
+func (h *HealthHandler) Diagnostics(c *fiber.Ctx) error {
+	ctx, span := h.tracer.StartFromFiberCtx(c)
+	defer span.End()
+
+	report := h.service.Report(ctx)
+	if report.Status == services.HealthStatusUnavailable {
+		return h.responseServiceUnavailable(c, "the API is not ready to serve requests", report)
+	}
+	return h.responseOK(c, fmt.Sprintf("the API is healthy with status [%s]", report.Status), report)
+}
@@ -0,0 +1,321 @@
+package handlers
+
+import (
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/url"
+
+	"github.com/NdoleStudio/discusswithai/pkg/knowledge"
+	"github.com/NdoleStudio/discusswithai/pkg/repositories"
+	"github.com/NdoleStudio/discusswithai/pkg/requests"
+	"github.com/NdoleStudio/discusswithai/pkg/services"
+	"github.com/NdoleStudio/discusswithai/pkg/telemetry"
+	"github.com/NdoleStudio/discusswithai/pkg/validators"
+	"github.com/davecgh/go-spew/spew"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+)
+
+// KnowledgeHandler handles requests from admins for managing the knowledge bases of businesses
+type KnowledgeHandler struct {
+	handler
+	logger    telemetry.Logger
+	tracer    telemetry.Tracer
+	validator *validators.KnowledgeHandlerValidator
+	service   *services.KnowledgeService
+}
+
+// NewKnowledgeHandler creates a new KnowledgeHandler
+func NewKnowledgeHandler(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	validator *validators.KnowledgeHandlerValidator,
+	service *services.KnowledgeService,
+) (h *KnowledgeHandler) {
+	return &KnowledgeHandler{
+		logger:    logger.WithService(fmt.Sprintf("%T", h)),
+		tracer:    tracer,
+		validator: validator,
+		service:   service,
+	}
+}
+
+// RegisterRoutes registers the routes for the KnowledgeHandler
+func (h *KnowledgeHandler) RegisterRoutes(app *fiber.App, middlewares ...fiber.Handler) {
+	router := app.Group("/v1/admin/knowledge-bases")
+	router.Get("/", h.computeRoute(middlewares, h.Index)...)
+	router.Post("/", h.computeRoute(middlewares, h.Store)...)
+	router.Get("/:knowledgeBaseID/documents", h.computeRoute(middlewares, h.IndexDocuments)...)
+	router.Post("/:knowledgeBaseID/documents", h.computeRoute(middlewares, h.Upload)...)
+	router.Delete("/:knowledgeBaseID/documents/:documentID", h.computeRoute(middlewares, h.DeleteDocument)...)
+}
+
+// Index returns the knowledge bases
+// @Summary      Get knowledge bases
+// @Description  Get the knowledge bases which are used to answer the prompts sent to a number or with a persona
+// @Security	 ApiKeyAuth
+// @Tags         Knowledge
+// @Produce      json
+// @Param        skip		query  int  	false	"number of knowledge bases to skip"		minimum(0)
+// @Param        query		query  string  	false 	"filter knowledge bases by name or owner"
+// @Param        limit		query  int  	false	"number of knowledge bases to return"	minimum(1)	maximum(100)
+// @Success      200 		{object}	responses.Ok[[]entities.KnowledgeBase]
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401    	{object}	responses.Unauthorized
+// @Failure 	 403    	{object}	responses.Forbidden
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /admin/knowledge-bases [get]
+func (h *KnowledgeHandler) Index(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.AdminIndexRequest
+	if err := c.QueryParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall params [%s] into %T", c.OriginalURL(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	if errors := h.validator.ValidateIndex(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while fetching knowledge bases [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while fetching knowledge bases")
+	}
+
+	bases, err := h.service.IndexBases(ctx, request.ToIndexParams())
+	if err != nil {
+		ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot index knowledge bases with request [%+#v]", request)))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, fmt.Sprintf("fetched %d knowledge %s", len(*bases), h.pluralize("base", len(*bases))), bases)
+}
+
+// Store creates a new knowledge base
+// @Summary      Create a knowledge base
+// @Description  Create a knowledge base which is used to answer the prompts sent to the owner number or with the persona
+// @Security	 ApiKeyAuth
+// @Tags         Knowledge
+// @Accept       json
+// @Produce      json
+// @Param        payload	body 		requests.KnowledgeBaseStoreRequest  	true 	"Knowledge base request payload"
+// @Success      201 		{object}	responses.Created[entities.KnowledgeBase]
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401    	{object}	responses.Unauthorized
+// @Failure 	 403    	{object}	responses.Forbidden
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /admin/knowledge-bases [post]
+func (h *KnowledgeHandler) Store(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.KnowledgeBaseStoreRequest
+	if err := c.BodyParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall [%s] into %T", telemetry.RedactBody(string(c.Body())), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	if errors := h.validator.ValidateStore(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while creating knowledge base [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while creating knowledge base")
+	}
+
+	base, err := h.service.StoreBase(ctx, request.ToStoreParams())
+	if err != nil {
+		ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot create knowledge base with request [%+#v]", request)))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseCreated(c, "knowledge base created successfully", base)
+}
+
+// IndexDocuments returns the documents of a knowledge base
+// @Summary      Get knowledge base documents
+// @Description  Get the documents which were uploaded to a knowledge base
+// @Security	 ApiKeyAuth
+// @Tags         Knowledge
+// @Produce      json
+// @Param 		 knowledgeBaseID 	path		string 	true 	"ID of the knowledge base" 	default(8f9c71b8-b84e-4417-8408-a62274f65a08)
+// @Param        skip		query  int  	false	"number of documents to skip"		minimum(0)
+// @Param        query		query  string  	false 	"filter documents by name"
+// @Param        limit		query  int  	false	"number of documents to return"		minimum(1)	maximum(100)
+// @Success      200 		{object}	responses.Ok[[]entities.KnowledgeDocument]
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401    	{object}	responses.Unauthorized
+// @Failure 	 403    	{object}	responses.Forbidden
+// @Failure      404		{object}	responses.NotFound
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /admin/knowledge-bases/{knowledgeBaseID}/documents [get]
+func (h *KnowledgeHandler) IndexDocuments(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.AdminIndexRequest
+	if err := c.QueryParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall params [%s] into %T", c.OriginalURL(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	errors := h.mergeErrors(h.validateUUID(c, "knowledgeBaseID"), h.validator.ValidateIndex(ctx, request.Sanitize()))
+	if len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while fetching knowledge base documents [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while fetching knowledge base documents")
+	}
+
+	baseID := uuid.MustParse(c.Params("knowledgeBaseID"))
+	if _, err := h.service.LoadBase(ctx, baseID); stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseNotFound(c, fmt.Sprintf("cannot find knowledge base with ID [%s]", baseID))
+	} else if err != nil {
+		ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot load knowledge base with ID [%s]", baseID)))
+		return h.responseInternalServerError(c)
+	}
+
+	documents, err := h.service.IndexDocuments(ctx, baseID, request.ToIndexParams())
+	if err != nil {
+		ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot index documents of knowledge base [%s] with request [%+#v]", baseID, request)))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, fmt.Sprintf("fetched %d %s", len(*documents), h.pluralize("document", len(*documents))), documents)
+}
+
+// Upload adds a document to a knowledge base
+// @Summary      Upload a document
+// @Description  Upload a text, Markdown or PDF document to a knowledge base. The document is split into chunks which are embedded and searched to answer prompts.
+// @Security	 ApiKeyAuth
+// @Tags         Knowledge
+// @Accept       mpfd
+// @Produce      json
+// @Param 		 knowledgeBaseID 	path		string 	true 	"ID of the knowledge base" 	default(8f9c71b8-b84e-4417-8408-a62274f65a08)
+// @Param        document	formData	file	true	"text, Markdown or PDF document"
+// @Success      201 		{object}	responses.Created[entities.KnowledgeDocument]
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401    	{object}	responses.Unauthorized
+// @Failure 	 403    	{object}	responses.Forbidden
+// @Failure      404		{object}	responses.NotFound
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /admin/knowledge-bases/{knowledgeBaseID}/documents [post]
+func (h *KnowledgeHandler) Upload(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	file, err := c.FormFile("document")
+	if err != nil {
+		ctxLogger.Warn(stacktrace.Propagate(err, "cannot read the document from the multipart form"))
+	}
+
+	errors := h.mergeErrors(h.validateUUID(c, "knowledgeBaseID"), h.validator.ValidateUpload(ctx, file))
+	if len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while uploading document to knowledge base [%s]", spew.Sdump(errors), c.Params("knowledgeBaseID"))
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while uploading document")
+	}
+
+	baseID := uuid.MustParse(c.Params("knowledgeBaseID"))
+	base, err := h.service.LoadBase(ctx, baseID)
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseNotFound(c, fmt.Sprintf("cannot find knowledge base with ID [%s]", baseID))
+	}
+	if err != nil {
+		ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot load knowledge base with ID [%s]", baseID)))
+		return h.responseInternalServerError(c)
+	}
+
+	content, err := h.readFile(file)
+	if err != nil {
+		ctxLogger.Warn(stacktrace.Propagate(err, fmt.Sprintf("cannot read document [%s]", file.Filename)))
+		return h.responseBadRequest(c, err)
+	}
+
+	document, err := h.service.Upload(ctx, &services.KnowledgeUploadParams{
+		Base:        base,
+		Name:        file.Filename,
+		ContentType: file.Header.Get("Content-Type"),
+		Content:     content,
+	})
+	if stacktrace.GetCode(err) == knowledge.ErrCodeUnsupportedFormat {
+		ctxLogger.Warn(stacktrace.Propagate(err, fmt.Sprintf("cannot extract the text of document [%s]", file.Filename)))
+		return h.responseUnprocessableEntity(c, url.Values{"document": []string{stacktrace.RootCause(err).Error()}}, "validation errors while uploading document")
+	}
+	if err != nil {
+		ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot upload document [%s] to knowledge base [%s]", file.Filename, baseID)))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseCreated(c, "document uploaded successfully", document)
+}
+
+// DeleteDocument removes a document from a knowledge base
+// @Summary      Delete a document
+// @Description  Delete a document and its chunks so that it is no longer used to answer prompts
+// @Security	 ApiKeyAuth
+// @Tags         Knowledge
+// @Produce      json
+// @Param 		 knowledgeBaseID 	path		string 	true 	"ID of the knowledge base" 	default(8f9c71b8-b84e-4417-8408-a62274f65a08)
+// @Param 		 documentID 		path		string 	true 	"ID of the document" 		default(32343a19-da5e-4b1b-a767-3298a73703ca)
+// @Success      204 		{object}	responses.NoContent
+// @Failure 	 401    	{object}	responses.Unauthorized
+// @Failure 	 403    	{object}	responses.Forbidden
+// @Failure      404		{object}	responses.NotFound
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /admin/knowledge-bases/{knowledgeBaseID}/documents/{documentID} [delete]
+func (h *KnowledgeHandler) DeleteDocument(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	if errors := h.mergeErrors(h.validateUUID(c, "knowledgeBaseID"), h.validateUUID(c, "documentID")); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while deleting document [%s]", spew.Sdump(errors), c.Params("documentID"))
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while deleting document")
+	}
+
+	baseID := uuid.MustParse(c.Params("knowledgeBaseID"))
+	if _, err := h.service.LoadBase(ctx, baseID); stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseNotFound(c, fmt.Sprintf("cannot find knowledge base with ID [%s]", baseID))
+	} else if err != nil {
+		ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot load knowledge base with ID [%s]", baseID)))
+		return h.responseInternalServerError(c)
+	}
+
+	documentID := uuid.MustParse(c.Params("documentID"))
+	document, err := h.service.LoadDocument(ctx, documentID)
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound || (err == nil && document.KnowledgeBaseID != baseID) {
+		return h.responseNotFound(c, fmt.Sprintf("cannot find document with ID [%s] in knowledge base [%s]", documentID, baseID))
+	}
+	if err != nil {
+		ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot load document with ID [%s]", documentID)))
+		return h.responseInternalServerError(c)
+	}
+
+	if err = h.service.DeleteDocument(ctx, document); err != nil {
+		ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot delete document with ID [%s]", documentID)))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseNoContent(c, "document deleted successfully")
+}
+
+func (h *KnowledgeHandler) readFile(file *multipart.FileHeader) ([]byte, error) {
+	reader, err := file.Open()
+	if err != nil {
+		return nil, stacktrace.Propagate(err, fmt.Sprintf("cannot open file [%s]", file.Filename))
+	}
+	defer func() { _ = reader.Close() }()
+
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, fmt.Sprintf("cannot read file [%s]", file.Filename))
+	}
+	return content, nil
+}
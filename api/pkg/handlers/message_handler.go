@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/NdoleStudio/discusswithai/pkg/entities"
+	"github.com/NdoleStudio/discusswithai/pkg/repositories"
+	"github.com/NdoleStudio/discusswithai/pkg/requests"
+	"github.com/NdoleStudio/discusswithai/pkg/services"
+	"github.com/NdoleStudio/discusswithai/pkg/telemetry"
+	"github.com/NdoleStudio/discusswithai/pkg/validators"
+	"github.com/davecgh/go-spew/spew"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+)
+
+// MessageHandler handles requests from integrators for sending AI generated messages
+type MessageHandler struct {
+	handler
+	logger         telemetry.Logger
+	tracer         telemetry.Tracer
+	validator      *validators.MessageHandlerValidator
+	promptService  *services.PromptService
+	messageService *services.MessageService
+	tenantService  *services.TenantService
+}
+
+// NewMessageHandler creates a new MessageHandler
+func NewMessageHandler(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	validator *validators.MessageHandlerValidator,
+	promptService *services.PromptService,
+	messageService *services.MessageService,
+	tenantService *services.TenantService,
+) (h *MessageHandler) {
+	return &MessageHandler{
+		logger:         logger.WithService(fmt.Sprintf("%T", h)),
+		tracer:         tracer,
+		validator:      validator,
+		promptService:  promptService,
+		messageService: messageService,
+		tenantService:  tenantService,
+	}
+}
+
+// RegisterRoutes registers the routes for the MessageHandler
+func (h *MessageHandler) RegisterRoutes(app *fiber.App, middlewares ...fiber.Handler) {
+	router := app.Group("/v1/messages")
+	router.Post("/", h.computeRoute(middlewares, h.Send)...)
+	router.Get("/:messageID", h.computeRoute(middlewares, h.Show)...)
+}
+
+// Send generates a completion for a prompt and sends it to a user
+// @Summary      Send an AI generated message
+// @Description  Generate a completion for the prompt and send it to a phone number over SMS or whatsapp
+// @Security	 ApiKeyAuth
+// @Tags         Messages
+// @Accept       json
+// @Produce      json
+// @Param        payload	body 		requests.MessageSendRequest  	true 	"Send message request payload"
+// @Success      201 		{object}	responses.Created[entities.Message]
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401    	{object}	responses.Unauthorized
+// @Failure 	 403    	{object}	responses.Forbidden
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      429		{object}	responses.TooManyRequests
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /messages [post]
+func (h *MessageHandler) Send(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.MessageSendRequest
+	if err := c.BodyParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall [%s] into %T", telemetry.RedactBody(string(c.Body())), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	if errors := h.validator.ValidateSend(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while sending message [%s]", spew.Sdump(errors), telemetry.RedactBody(string(c.Body())))
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while sending message")
+	}
+
+	ctx, err := h.tenantService.ResolveSender(ctx, entities.Channel(request.Channel), request.From)
+	if stacktrace.GetCode(err) == services.ErrCodeTenantForbidden {
+		ctxLogger.Warn(stacktrace.Propagate(err, fmt.Sprintf("API key [%s] cannot send from [%s] number [%s]", h.principal(c).APIKeyID, request.Channel, request.From)))
+		return h.responseForbidden(c)
+	}
+	if err != nil {
+		ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot resolve the tenant of [%s] number [%s]", request.Channel, request.From)))
+		return h.responseInternalServerError(c)
+	}
+
+	message, err := h.promptService.Send(ctx, request.ToSendParams(h.principal(c)))
+	if stacktrace.GetCode(err) == services.ErrCodeTenantQuotaExceeded {
+		ctxLogger.Warn(stacktrace.Propagate(err, fmt.Sprintf("the daily limit of messages was reached while sending [%s] message from [%s]", request.Channel, request.From)))
+		return h.responseTooManyRequests(c, fmt.Sprintf("the daily limit of messages was reached for the number [%s]", request.From))
+	}
+	if delay, ok := services.RateLimitDelay(err); ok {
+		ctxLogger.Warn(stacktrace.Propagate(err, fmt.Sprintf("the provider rate limited the [%s] message from [%s]", request.Channel, request.From)))
+		return h.responseRateLimited(c, fmt.Sprintf("the [%s] provider rate limited the messages from the number [%s]", request.Channel, request.From), delay)
+	}
+	if stacktrace.GetCode(err) == services.ErrCodePromptFlagged || stacktrace.GetCode(err) == services.ErrCodeCompletionFlagged {
+		ctxLogger.Warn(stacktrace.Propagate(err, fmt.Sprintf("content was flagged while sending [%s] message to [%s]", request.Channel, request.To)))
+		return h.responseUnprocessableEntity(c, url.Values{"prompt": []string{"the prompt or its completion violates the content policy"}}, "validation errors while sending message")
+	}
+	if err != nil {
+		ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot send [%s] message to [%s]", request.Channel, request.To)))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseCreated(c, fmt.Sprintf("message with status [%s]", message.Status), message)
+}
+
+// Show returns a message
+// @Summary      Get a message
+// @Description  Get a message which was sent through the API
+// @Security	 ApiKeyAuth
+// @Tags         Messages
+// @Produce      json
+// @Param 		 messageID 	path		string 							true 	"ID of the message" 	default(32343a19-da5e-4b1b-a767-3298a73703ca)
+// @Success      200 		{object}	responses.Ok[entities.Message]
+// @Failure 	 401    	{object}	responses.Unauthorized
+// @Failure 	 403    	{object}	responses.Forbidden
+// @Failure      404		{object}	responses.NotFound
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /messages/{messageID} [get]
+func (h *MessageHandler) Show(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	if errors := h.validateUUID(c, "messageID"); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while fetching message [%s]", spew.Sdump(errors), c.Params("messageID"))
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while fetching message")
+	}
+
+	messageID := uuid.MustParse(c.Params("messageID"))
+	message, err := h.messageService.Load(ctx, messageID)
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseNotFound(c, fmt.Sprintf("cannot find message with ID [%s]", messageID))
+	}
+	if err != nil {
+		ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot load message with ID [%s]", messageID)))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, "message fetched successfully", message)
+}
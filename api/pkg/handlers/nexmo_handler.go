@@ -3,6 +3,7 @@ package handlers
 import (
 	"fmt"
 
+	"github.com/NdoleStudio/discusswithai/pkg/entities"
 	"github.com/NdoleStudio/discusswithai/pkg/requests"
 	"github.com/NdoleStudio/discusswithai/pkg/services"
 	"github.com/NdoleStudio/discusswithai/pkg/telemetry"
@@ -15,10 +16,11 @@ import (
 // NexmoHandler handles nexmo events
 type NexmoHandler struct {
 	handler
-	logger    telemetry.Logger
-	tracer    telemetry.Tracer
-	service   *services.NexmoService
-	validator *validators.NexmoHandlerValidator
+	logger        telemetry.Logger
+	tracer        telemetry.Tracer
+	service       *services.NexmoService
+	tenantService *services.TenantService
+	validator     *validators.NexmoHandlerValidator
 }
 
 // NewNexmoHandler creates a new NexmoHandler
@@ -26,13 +28,15 @@ func NewNexmoHandler(
 	logger telemetry.Logger,
 	tracer telemetry.Tracer,
 	service *services.NexmoService,
+	tenantService *services.TenantService,
 	validator *validators.NexmoHandlerValidator,
 ) (h *NexmoHandler) {
 	return &NexmoHandler{
-		logger:    logger.WithService(fmt.Sprintf("%T", h)),
-		tracer:    tracer,
-		service:   service,
-		validator: validator,
+		logger:        logger.WithService(fmt.Sprintf("%T", h)),
+		tracer:        tracer,
+		service:       service,
+		tenantService: tenantService,
+		validator:     validator,
 	}
 }
 
@@ -60,17 +64,23 @@ func (h *NexmoHandler) Receive(c *fiber.Ctx) error {
 
 	var request requests.NexmoReceiveRequest
 	if err := c.BodyParser(&request); err != nil {
-		msg := fmt.Sprintf("cannot marshall [%s] into %T", c.Body(), request)
+		msg := fmt.Sprintf("cannot marshall [%s] into %T", telemetry.RedactBody(string(c.Body())), request)
 		ctxLogger.Warn(stacktrace.Propagate(err, msg))
 		return h.responseBadRequest(c, err)
 	}
 
 	if errors := h.validator.ValidateReceive(ctx, request.Sanitize()); len(errors) != 0 {
-		msg := fmt.Sprintf("validation errors [%s], while receiving message from nexmo [%s]", spew.Sdump(errors), c.Body())
+		msg := fmt.Sprintf("validation errors [%s], while receiving message from nexmo [%s]", spew.Sdump(errors), telemetry.RedactBody(string(c.Body())))
 		ctxLogger.Warn(stacktrace.NewError(msg))
 		return h.responseUnprocessableEntity(c, errors, "validation errors while receiving message")
 	}
 
+	ctx, err := h.tenantService.Resolve(ctx, entities.ChannelSMS, request.To)
+	if err != nil {
+		ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot resolve the tenant of number [%s]", request.To)))
+		return h.responseInternalServerError(c)
+	}
+
 	h.service.Receive(ctx, request.ToReceiveParams())
 
 	return h.responseAccepted(c, "message received successfully")
@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"fmt"
+
+	"github.com/NdoleStudio/discusswithai/pkg/repositories"
+	"github.com/NdoleStudio/discusswithai/pkg/requests"
+	"github.com/NdoleStudio/discusswithai/pkg/services"
+	"github.com/NdoleStudio/discusswithai/pkg/telemetry"
+	"github.com/NdoleStudio/discusswithai/pkg/validators"
+	"github.com/davecgh/go-spew/spew"
+	"github.com/gofiber/fiber/v2"
+	"github.com/palantir/stacktrace"
+)
+
+// ReminderHandler delivers reminders which were scheduled through the queue
+type ReminderHandler struct {
+	handler
+	logger    telemetry.Logger
+	tracer    telemetry.Tracer
+	validator *validators.ReminderHandlerValidator
+	service   *services.ReminderService
+}
+
+// NewReminderHandler creates a new ReminderHandler
+func NewReminderHandler(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	validator *validators.ReminderHandlerValidator,
+	service *services.ReminderService,
+) (h *ReminderHandler) {
+	return &ReminderHandler{
+		logger:    logger.WithService(fmt.Sprintf("%T", h)),
+		tracer:    tracer,
+		validator: validator,
+		service:   service,
+	}
+}
+
+// RegisterRoutes registers the routes for the ReminderHandler
+func (h *ReminderHandler) RegisterRoutes(app *fiber.App, middlewares ...fiber.Handler) {
+	router := app.Group("/v1/reminders")
+	router.Post("/deliver", h.computeRoute(middlewares, h.Deliver)...)
+}
+
+// Deliver sends a reminder which is due
+// @Summary      Deliver a reminder
+// @Description  Send a reminder which is due to the user on the channel where it was requested
+// @Security	 ApiKeyAuth
+// @Tags         Reminders
+// @Accept       json
+// @Produce      json
+// @Param        payload	body 		requests.ReminderDeliverRequest  	true 	"Deliver reminder request payload"
+// @Success      204 		{object}	responses.NoContent
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401    	{object}	responses.Unauthorized
+// @Failure 	 403    	{object}	responses.Forbidden
+// @Failure      404		{object}	responses.NotFound
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /reminders/deliver [post]
+func (h *ReminderHandler) Deliver(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.ReminderDeliverRequest
+	if err := c.BodyParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall [%s] into %T", telemetry.RedactBody(string(c.Body())), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	if errors := h.validator.ValidateDeliver(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while delivering reminder [%s]", spew.Sdump(errors), request.ReminderID)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while delivering reminder")
+	}
+
+	err := h.service.Deliver(ctx, request.ReminderUUID())
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseNotFound(c, fmt.Sprintf("cannot find reminder with ID [%s]", request.ReminderID))
+	}
+	if delay, ok := services.RateLimitDelay(err); ok {
+		ctxLogger.Warn(stacktrace.Propagate(err, fmt.Sprintf("reminder with ID [%s] was rate limited", request.ReminderID)))
+		return h.responseRateLimited(c, fmt.Sprintf("the reminder with ID [%s] was rate limited", request.ReminderID), delay)
+	}
+	if err != nil {
+		ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot deliver reminder with ID [%s]", request.ReminderID)))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseNoContent(c, "reminder delivered successfully")
+}
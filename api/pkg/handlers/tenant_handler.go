@@ -0,0 +1,273 @@
+package handlers
+
+import (
+	"fmt"
+
+	"github.com/NdoleStudio/discusswithai/pkg/entities"
+	"github.com/NdoleStudio/discusswithai/pkg/repositories"
+	"github.com/NdoleStudio/discusswithai/pkg/requests"
+	"github.com/NdoleStudio/discusswithai/pkg/services"
+	"github.com/NdoleStudio/discusswithai/pkg/telemetry"
+	"github.com/NdoleStudio/discusswithai/pkg/validators"
+	"github.com/davecgh/go-spew/spew"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+)
+
+// TenantHandler handles requests from admins for managing the businesses which answer users through their own numbers
+type TenantHandler struct {
+	handler
+	logger    telemetry.Logger
+	tracer    telemetry.Tracer
+	validator *validators.TenantHandlerValidator
+	service   *services.TenantService
+}
+
+// NewTenantHandler creates a new TenantHandler
+func NewTenantHandler(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	validator *validators.TenantHandlerValidator,
+	service *services.TenantService,
+) (h *TenantHandler) {
+	return &TenantHandler{
+		logger:    logger.WithService(fmt.Sprintf("%T", h)),
+		tracer:    tracer,
+		validator: validator,
+		service:   service,
+	}
+}
+
+// RegisterRoutes registers the routes for the TenantHandler
+func (h *TenantHandler) RegisterRoutes(app *fiber.App, middlewares ...fiber.Handler) {
+	router := app.Group("/v1/admin/tenants")
+	router.Get("/", h.computeRoute(middlewares, h.Index)...)
+	router.Post("/", h.computeRoute(middlewares, h.Store)...)
+	router.Put("/:tenantID", h.computeRoute(middlewares, h.Update)...)
+	router.Get("/:tenantID/numbers", h.computeRoute(middlewares, h.IndexNumbers)...)
+	router.Post("/:tenantID/numbers", h.computeRoute(middlewares, h.StoreNumber)...)
+}
+
+// Index returns the tenants
+// @Summary      Get tenants
+// @Description  Get the businesses which answer users through their own numbers
+// @Security	 ApiKeyAuth
+// @Tags         Tenants
+// @Produce      json
+// @Param        skip		query  int  	false	"number of tenants to skip"		minimum(0)
+// @Param        query		query  string  	false 	"filter tenants by name"
+// @Param        limit		query  int  	false	"number of tenants to return"	minimum(1)	maximum(100)
+// @Success      200 		{object}	responses.Ok[[]entities.Tenant]
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401    	{object}	responses.Unauthorized
+// @Failure 	 403    	{object}	responses.Forbidden
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /admin/tenants [get]
+func (h *TenantHandler) Index(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.AdminIndexRequest
+	if err := c.QueryParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall params [%s] into %T", c.OriginalURL(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	if errors := h.validator.ValidateIndex(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while fetching tenants [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while fetching tenants")
+	}
+
+	tenants, err := h.service.Index(ctx, request.ToIndexParams())
+	if err != nil {
+		ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot index tenants with request [%+#v]", request)))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, fmt.Sprintf("fetched %d %s", len(*tenants), h.pluralize("tenant", len(*tenants))), tenants)
+}
+
+// Store creates a new tenant
+// @Summary      Create a tenant
+// @Description  Create a business with its persona, knowledge base, daily message limit and provider credentials
+// @Security	 ApiKeyAuth
+// @Tags         Tenants
+// @Accept       json
+// @Produce      json
+// @Param        payload	body 		requests.TenantStoreRequest  	true 	"Tenant request payload"
+// @Success      201 		{object}	responses.Created[entities.Tenant]
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401    	{object}	responses.Unauthorized
+// @Failure 	 403    	{object}	responses.Forbidden
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /admin/tenants [post]
+func (h *TenantHandler) Store(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.TenantStoreRequest
+	if err := c.BodyParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall request body into %T", request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	if errors := h.validator.ValidateStore(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while creating tenant [%s]", spew.Sdump(errors), request.Name)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while creating tenant")
+	}
+
+	tenant, err := h.service.Store(ctx, request.ToStoreParams())
+	if err != nil {
+		ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot create tenant [%s]", request.Name)))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseCreated(c, "tenant created successfully", tenant)
+}
+
+// Update changes a tenant
+// @Summary      Update a tenant
+// @Description  Update the settings of a business. The credentials are not changed when they are empty.
+// @Security	 ApiKeyAuth
+// @Tags         Tenants
+// @Accept       json
+// @Produce      json
+// @Param 		 tenantID 	path		string 							true 	"ID of the tenant" 	default(6f1d2c3b-4a5e-4f60-8a7b-9c0d1e2f3a4b)
+// @Param        payload	body 		requests.TenantStoreRequest  	true 	"Tenant request payload"
+// @Success      200 		{object}	responses.Ok[entities.Tenant]
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401    	{object}	responses.Unauthorized
+// @Failure 	 403    	{object}	responses.Forbidden
+// @Failure      404		{object}	responses.NotFound
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /admin/tenants/{tenantID} [put]
+func (h *TenantHandler) Update(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.TenantStoreRequest
+	if err := c.BodyParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall request body into %T", request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	errors := h.mergeErrors(h.validateUUID(c, "tenantID"), h.validator.ValidateStore(ctx, request.Sanitize()))
+	if len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while updating tenant [%s]", spew.Sdump(errors), c.Params("tenantID"))
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while updating tenant")
+	}
+
+	tenantID := uuid.MustParse(c.Params("tenantID"))
+	tenant, err := h.service.Load(ctx, tenantID)
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseNotFound(c, fmt.Sprintf("cannot find tenant with ID [%s]", tenantID))
+	}
+	if err != nil {
+		ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot load tenant with ID [%s]", tenantID)))
+		return h.responseInternalServerError(c)
+	}
+
+	if tenant, err = h.service.Update(ctx, tenant, request.ToStoreParams()); err != nil {
+		ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot update tenant with ID [%s]", tenantID)))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, "tenant updated successfully", tenant)
+}
+
+// IndexNumbers returns the numbers of a tenant
+// @Summary      Get tenant numbers
+// @Description  Get the numbers whose messages are answered for a business
+// @Security	 ApiKeyAuth
+// @Tags         Tenants
+// @Produce      json
+// @Param 		 tenantID 	path		string 	true 	"ID of the tenant" 	default(6f1d2c3b-4a5e-4f60-8a7b-9c0d1e2f3a4b)
+// @Success      200 		{object}	responses.Ok[[]entities.TenantNumber]
+// @Failure 	 401    	{object}	responses.Unauthorized
+// @Failure 	 403    	{object}	responses.Forbidden
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /admin/tenants/{tenantID}/numbers [get]
+func (h *TenantHandler) IndexNumbers(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	if errors := h.validateUUID(c, "tenantID"); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while fetching numbers of tenant [%s]", spew.Sdump(errors), c.Params("tenantID"))
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while fetching tenant numbers")
+	}
+
+	tenantID := uuid.MustParse(c.Params("tenantID"))
+	numbers, err := h.service.IndexNumbers(ctx, tenantID)
+	if err != nil {
+		ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot index the numbers of tenant [%s]", tenantID)))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, fmt.Sprintf("fetched %d %s", len(*numbers), h.pluralize("number", len(*numbers))), numbers)
+}
+
+// StoreNumber adds a number to a tenant
+// @Summary      Add a tenant number
+// @Description  Answer the messages which are received by a number with the settings of a business. For whatsapp, the number is the phone number ID.
+// @Security	 ApiKeyAuth
+// @Tags         Tenants
+// @Accept       json
+// @Produce      json
+// @Param 		 tenantID 	path		string 								true 	"ID of the tenant" 	default(6f1d2c3b-4a5e-4f60-8a7b-9c0d1e2f3a4b)
+// @Param        payload	body 		requests.TenantNumberStoreRequest  	true 	"Tenant number request payload"
+// @Success      201 		{object}	responses.Created[entities.TenantNumber]
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401    	{object}	responses.Unauthorized
+// @Failure 	 403    	{object}	responses.Forbidden
+// @Failure      404		{object}	responses.NotFound
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /admin/tenants/{tenantID}/numbers [post]
+func (h *TenantHandler) StoreNumber(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.TenantNumberStoreRequest
+	if err := c.BodyParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall [%s] into %T", telemetry.RedactBody(string(c.Body())), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	errors := h.mergeErrors(h.validateUUID(c, "tenantID"), h.validator.ValidateNumberStore(ctx, request.Sanitize()))
+	if len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while adding number to tenant [%s]", spew.Sdump(errors), c.Params("tenantID"))
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while adding tenant number")
+	}
+
+	tenantID := uuid.MustParse(c.Params("tenantID"))
+	tenant, err := h.service.Load(ctx, tenantID)
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseNotFound(c, fmt.Sprintf("cannot find tenant with ID [%s]", tenantID))
+	}
+	if err != nil {
+		ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot load tenant with ID [%s]", tenantID)))
+		return h.responseInternalServerError(c)
+	}
+
+	number, err := h.service.StoreNumber(ctx, tenant, entities.Channel(request.Channel), request.Number)
+	if err != nil {
+		ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot add number [%s] to tenant [%s]", request.Number, tenantID)))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseCreated(c, "tenant number added successfully", number)
+}
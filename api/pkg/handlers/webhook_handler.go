@@ -0,0 +1,294 @@
+package handlers
+
+import (
+	"fmt"
+
+	"github.com/NdoleStudio/discusswithai/pkg/entities"
+	"github.com/NdoleStudio/discusswithai/pkg/repositories"
+	"github.com/NdoleStudio/discusswithai/pkg/requests"
+	"github.com/NdoleStudio/discusswithai/pkg/services"
+	"github.com/NdoleStudio/discusswithai/pkg/telemetry"
+	"github.com/NdoleStudio/discusswithai/pkg/validators"
+	"github.com/davecgh/go-spew/spew"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+)
+
+// WebhookHandler handles requests from integrators for managing webhooks
+type WebhookHandler struct {
+	handler
+	logger    telemetry.Logger
+	tracer    telemetry.Tracer
+	validator *validators.WebhookHandlerValidator
+	service   *services.WebhookService
+}
+
+// NewWebhookHandler creates a new WebhookHandler
+func NewWebhookHandler(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	validator *validators.WebhookHandlerValidator,
+	service *services.WebhookService,
+) (h *WebhookHandler) {
+	return &WebhookHandler{
+		logger:    logger.WithService(fmt.Sprintf("%T", h)),
+		tracer:    tracer,
+		validator: validator,
+		service:   service,
+	}
+}
+
+// RegisterRoutes registers the routes for the WebhookHandler
+func (h *WebhookHandler) RegisterRoutes(app *fiber.App, middlewares ...fiber.Handler) {
+	router := app.Group("/v1/webhooks")
+	router.Get("/", h.computeRoute(middlewares, h.Index)...)
+	router.Post("/", h.computeRoute(middlewares, h.Store)...)
+	router.Delete("/:webhookID", h.computeRoute(middlewares, h.Delete)...)
+	router.Get("/:webhookID/deliveries", h.computeRoute(middlewares, h.IndexDeliveries)...)
+}
+
+// RegisterDeliveryRoutes registers the routes of the queue tasks which deliver events to webhooks
+func (h *WebhookHandler) RegisterDeliveryRoutes(app *fiber.App, middlewares ...fiber.Handler) {
+	router := app.Group("/v1/webhook-deliveries")
+	router.Post("/deliver", h.computeRoute(middlewares, h.Deliver)...)
+}
+
+// Index returns the webhooks of the API key
+// @Summary      Get webhooks
+// @Description  Get the webhooks which receive events for the API key. Admins can see all the webhooks.
+// @Security	 ApiKeyAuth
+// @Tags         Webhooks
+// @Produce      json
+// @Param        skip		query  int  	false	"number of webhooks to skip"		minimum(0)
+// @Param        query		query  string  	false 	"filter webhooks by URL"
+// @Param        limit		query  int  	false	"number of webhooks to return"		minimum(1)	maximum(100)
+// @Success      200 		{object}	responses.Ok[[]entities.Webhook]
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401    	{object}	responses.Unauthorized
+// @Failure 	 403    	{object}	responses.Forbidden
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /webhooks [get]
+func (h *WebhookHandler) Index(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.AdminIndexRequest
+	if err := c.QueryParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall params [%s] into %T", c.OriginalURL(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	if errors := h.validator.ValidateIndex(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while fetching webhooks [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while fetching webhooks")
+	}
+
+	var apiKeyID *uuid.UUID
+	if principal := h.principal(c); !principal.HasAnyRole(entities.RoleAdmin) {
+		apiKeyID = &principal.APIKeyID
+	}
+
+	webhooks, err := h.service.Index(ctx, apiKeyID, request.ToIndexParams())
+	if err != nil {
+		ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot index webhooks with request [%+#v]", request)))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, fmt.Sprintf("fetched %d %s", len(*webhooks), h.pluralize("webhook", len(*webhooks))), webhooks)
+}
+
+// Store creates a new webhook
+// @Summary      Create a webhook
+// @Description  Register a URL which receives CloudEvents signed with HMAC-SHA256 in the X-Signature-256 header. The signature covers "<timestamp>.<body>" where the timestamp is the unix time in the X-Signature-Timestamp header, and requests with a timestamp more than 5 minutes from your clock should be rejected. The signing key is returned only once in the response.
+// @Security	 ApiKeyAuth
+// @Tags         Webhooks
+// @Accept       json
+// @Produce      json
+// @Param        payload	body 		requests.WebhookStoreRequest  	true 	"Webhook request payload"
+// @Success      201 		{object}	responses.Created[map[string]interface{}]
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401    	{object}	responses.Unauthorized
+// @Failure 	 403    	{object}	responses.Forbidden
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /webhooks [post]
+func (h *WebhookHandler) Store(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.WebhookStoreRequest
+	if err := c.BodyParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall [%s] into %T", telemetry.RedactBody(string(c.Body())), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	if errors := h.validator.ValidateStore(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while creating webhook [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while creating webhook")
+	}
+
+	webhook, signingKey, err := h.service.Store(ctx, request.ToStoreParams(h.principal(c)))
+	if err != nil {
+		ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot create webhook with request [%+#v]", request)))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseCreated(c, "webhook created successfully", fiber.Map{
+		"webhook":     webhook,
+		"signing_key": signingKey,
+	})
+}
+
+// Delete removes a webhook
+// @Summary      Delete a webhook
+// @Description  Delete a webhook so that it no longer receives events
+// @Security	 ApiKeyAuth
+// @Tags         Webhooks
+// @Produce      json
+// @Param 		 webhookID 	path		string 							true 	"ID of the webhook" 	default(32343a19-da5e-4b1b-a767-3298a73703ca)
+// @Success      204 		{object}	responses.NoContent
+// @Failure 	 401    	{object}	responses.Unauthorized
+// @Failure 	 403    	{object}	responses.Forbidden
+// @Failure      404		{object}	responses.NotFound
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /webhooks/{webhookID} [delete]
+func (h *WebhookHandler) Delete(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	if errors := h.validateUUID(c, "webhookID"); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while deleting webhook [%s]", spew.Sdump(errors), c.Params("webhookID"))
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while deleting webhook")
+	}
+
+	webhookID := uuid.MustParse(c.Params("webhookID"))
+	webhook, err := h.service.Load(ctx, webhookID)
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound || (err == nil && !h.canAccess(c, webhook)) {
+		return h.responseNotFound(c, fmt.Sprintf("cannot find webhook with ID [%s]", webhookID))
+	}
+	if err != nil {
+		ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot load webhook with ID [%s]", webhookID)))
+		return h.responseInternalServerError(c)
+	}
+
+	if err = h.service.Delete(ctx, webhook); err != nil {
+		ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot delete webhook with ID [%s]", webhookID)))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseNoContent(c, "webhook deleted successfully")
+}
+
+// IndexDeliveries returns the attempts to send events to a webhook
+// @Summary      Get webhook deliveries
+// @Description  Get the attempts to send events to a webhook including the response status code and error
+// @Security	 ApiKeyAuth
+// @Tags         Webhooks
+// @Produce      json
+// @Param 		 webhookID 	path		string 	true 	"ID of the webhook" 	default(32343a19-da5e-4b1b-a767-3298a73703ca)
+// @Param        skip		query  int  	false	"number of deliveries to skip"		minimum(0)
+// @Param        query		query  string  	false 	"filter deliveries by event type"
+// @Param        limit		query  int  	false	"number of deliveries to return"	minimum(1)	maximum(100)
+// @Success      200 		{object}	responses.Ok[[]entities.WebhookDelivery]
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401    	{object}	responses.Unauthorized
+// @Failure 	 403    	{object}	responses.Forbidden
+// @Failure      404		{object}	responses.NotFound
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /webhooks/{webhookID}/deliveries [get]
+func (h *WebhookHandler) IndexDeliveries(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.AdminIndexRequest
+	if err := c.QueryParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall params [%s] into %T", c.OriginalURL(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	errors := h.mergeErrors(h.validateUUID(c, "webhookID"), h.validator.ValidateIndex(ctx, request.Sanitize()))
+	if len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while fetching webhook deliveries [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while fetching webhook deliveries")
+	}
+
+	webhookID := uuid.MustParse(c.Params("webhookID"))
+	webhook, err := h.service.Load(ctx, webhookID)
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound || (err == nil && !h.canAccess(c, webhook)) {
+		return h.responseNotFound(c, fmt.Sprintf("cannot find webhook with ID [%s]", webhookID))
+	}
+	if err != nil {
+		ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot load webhook with ID [%s]", webhookID)))
+		return h.responseInternalServerError(c)
+	}
+
+	deliveries, err := h.service.IndexDeliveries(ctx, webhook.ID, request.ToIndexParams())
+	if err != nil {
+		ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot index deliveries for webhook [%s] with request [%+#v]", webhookID, request)))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, fmt.Sprintf("fetched %d webhook deliveries", len(*deliveries)), deliveries)
+}
+
+// canAccess checks if the webhook belongs to the API key. Admins can access all the webhooks.
+func (h *WebhookHandler) canAccess(c *fiber.Ctx, webhook *entities.Webhook) bool {
+	principal := h.principal(c)
+	return principal.HasAnyRole(entities.RoleAdmin) || principal.APIKeyID == webhook.APIKeyID
+}
+
+// Deliver attempts to send an event to a webhook
+// @Summary      Deliver a webhook event
+// @Description  Attempt to send an event to a webhook and enqueue the next attempt when it fails
+// @Security	 ApiKeyAuth
+// @Tags         Webhooks
+// @Accept       json
+// @Produce      json
+// @Param        payload	body 		requests.WebhookDeliverRequest  	true 	"Deliver webhook event request payload"
+// @Success      204 		{object}	responses.NoContent
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401    	{object}	responses.Unauthorized
+// @Failure 	 403    	{object}	responses.Forbidden
+// @Failure      404		{object}	responses.NotFound
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /webhook-deliveries/deliver [post]
+func (h *WebhookHandler) Deliver(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.WebhookDeliverRequest
+	if err := c.BodyParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall [%s] into %T", telemetry.RedactBody(string(c.Body())), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	if errors := h.validator.ValidateDeliver(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while delivering webhook delivery [%s]", spew.Sdump(errors), request.DeliveryID)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while delivering webhook event")
+	}
+
+	err := h.service.Deliver(ctx, request.DeliveryUUID())
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseNotFound(c, fmt.Sprintf("cannot find webhook delivery with ID [%s]", request.DeliveryID))
+	}
+	if err != nil {
+		ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot deliver webhook delivery with ID [%s]", request.DeliveryID)))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseNoContent(c, "webhook event delivered successfully")
+}
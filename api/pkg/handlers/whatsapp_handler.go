@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/NdoleStudio/discusswithai/pkg/entities"
 	"github.com/NdoleStudio/discusswithai/pkg/requests"
 	"github.com/NdoleStudio/discusswithai/pkg/whatsapp"
 	"github.com/palantir/stacktrace"
@@ -16,9 +17,10 @@ import (
 // WhatsappHandler handles nexmo events
 type WhatsappHandler struct {
 	handler
-	logger  telemetry.Logger
-	tracer  telemetry.Tracer
-	service *services.WhatsappService
+	logger        telemetry.Logger
+	tracer        telemetry.Tracer
+	service       *services.WhatsappService
+	tenantService *services.TenantService
 }
 
 // NewWhatsappHandler creates a new WhatsappHandler
@@ -26,11 +28,13 @@ func NewWhatsappHandler(
 	logger telemetry.Logger,
 	tracer telemetry.Tracer,
 	service *services.WhatsappService,
+	tenantService *services.TenantService,
 ) (h *WhatsappHandler) {
 	return &WhatsappHandler{
-		logger:  logger.WithService(fmt.Sprintf("%T", h)),
-		tracer:  tracer,
-		service: service,
+		logger:        logger.WithService(fmt.Sprintf("%T", h)),
+		tracer:        tracer,
+		service:       service,
+		tenantService: tenantService,
 	}
 }
 
@@ -102,7 +106,7 @@ func (h *WhatsappHandler) Event(c *fiber.Ctx) error {
 			ctxLogger.Info(fmt.Sprintf("request [%s] is a status update for messageID [%s]", request.Entry[0].ID, messageId))
 			return h.responseAccepted(c, "Status update processed successfully")
 		}
-		ctxLogger.Error(stacktrace.NewError(fmt.Sprintf("cannot parse webhook request [%s]", c.Body())))
+		ctxLogger.Error(stacktrace.NewError(fmt.Sprintf("cannot parse webhook request [%s]", telemetry.RedactBody(string(c.Body())))))
 		return h.responseAccepted(c, "Could not process webhook request. exception swallowed")
 	}
 
@@ -111,9 +115,16 @@ func (h *WhatsappHandler) Event(c *fiber.Ctx) error {
 		text = (*messages)[0].Text.Body
 	}
 
+	phoneNumberID := request.Entry[0].Changes[0].Value.Metadata.PhoneNumberID
+	ctx, err := h.tenantService.Resolve(ctx, entities.ChannelWhatsapp, phoneNumberID)
+	if err != nil {
+		ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot resolve the tenant of phone number ID [%s]", phoneNumberID)))
+		return h.responseInternalServerError(c)
+	}
+
 	h.service.Receive(ctx, &services.WhatsappReceiveParams{
 		From:        (*messages)[0].From,
-		To:          request.Entry[0].Changes[0].Value.Metadata.PhoneNumberID,
+		To:          phoneNumberID,
 		MessageText: text,
 		Type:        (*messages)[0].Type,
 		MessageID:   (*messages)[0].ID,
@@ -0,0 +1,158 @@
+package i18n
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Key identifies a message in the Catalog
+type Key string
+
+const (
+	// KeyCompletionError is sent when we cannot generate a completion
+	KeyCompletionError = Key("completion.error")
+
+	// KeySMSCharacterLimit is sent when the completion is too long to be sent via SMS
+	KeySMSCharacterLimit = Key("sms.character_limit")
+
+	// KeySMSMultipart is sent when we receive a multipart SMS
+	KeySMSMultipart = Key("sms.multipart")
+
+	// KeyWhatsappUnsupportedType is sent when we receive a whatsapp message which is not text
+	KeyWhatsappUnsupportedType = Key("whatsapp.unsupported_type")
+
+	// KeyContentPolicy is sent when a prompt or completion is flagged by the moderation
+	KeyContentPolicy = Key("moderation.content_policy")
+
+	// KeyLocaleUpdated is sent when a user changes their language
+	KeyLocaleUpdated = Key("locale.updated")
+
+	// KeyLocaleNotSupported is sent when a user chooses a language which we don't support
+	KeyLocaleNotSupported = Key("locale.not_supported")
+
+	// KeyReminderScheduled is sent when a reminder is scheduled for a user
+	KeyReminderScheduled = Key("reminder.scheduled")
+
+	// KeyReminderInPast is sent when a user asks for a reminder at a time which has already passed
+	KeyReminderInPast = Key("reminder.in_past")
+
+	// KeyReminderDelivery is the message which is sent to a user when a reminder is due
+	KeyReminderDelivery = Key("reminder.delivery")
+
+	// KeyReminderList is sent when a user lists their scheduled reminders
+	KeyReminderList = Key("reminder.list")
+
+	// KeyReminderListEmpty is sent when a user lists their reminders and has none scheduled
+	KeyReminderListEmpty = Key("reminder.list_empty")
+
+	// KeyReminderCancelled is sent when a user cancels a reminder
+	KeyReminderCancelled = Key("reminder.cancelled")
+
+	// KeyReminderNotFound is sent when a user cancels a reminder which does not exist
+	KeyReminderNotFound = Key("reminder.not_found")
+
+	// KeyDigestSubscribed is sent when a user subscribes to a daily digest
+	KeyDigestSubscribed = Key("digest.subscribed")
+
+	// KeyDigestUnsubscribed is sent when a user unsubscribes from a daily digest
+	KeyDigestUnsubscribed = Key("digest.unsubscribed")
+
+	// KeyDigestNotSubscribed is sent when a user unsubscribes from a daily digest which they are not subscribed to
+	KeyDigestNotSubscribed = Key("digest.not_subscribed")
+
+	// KeyDigestList is sent when a user lists their daily digests
+	KeyDigestList = Key("digest.list")
+
+	// KeyDigestUsage explains how to subscribe to a daily digest
+	KeyDigestUsage = Key("digest.usage")
+
+	// KeyQuotaExceeded is sent when the business which owns the number has sent its daily limit of messages
+	KeyQuotaExceeded = Key("quota.exceeded")
+)
+
+var translations = map[Locale]map[Key]string{
+	LocaleEnglish: {
+		KeyCompletionError:         "We could not generate the completion using chatGPT. Please try again later.",
+		KeySMSCharacterLimit:       "The response text contains %d characters. Contact us at arnold@discusswithai.com to receive responses with more than %d characters via sms.",
+		KeySMSMultipart:            "We don't yet support text prompts with more than 160 characters.",
+		KeyWhatsappUnsupportedType: "We only support text messages at the moment we plan to support %s content in the future.",
+		KeyContentPolicy:           "We cannot respond to this message because it violates our content policy.",
+		KeyLocaleUpdated:           "Your language has been set to English.",
+		KeyLocaleNotSupported:      "The language [%s] is not supported. The supported languages are %s.",
+		KeyReminderScheduled:       "I will remind you to \"%s\" on %s.",
+		KeyReminderInPast:          "I cannot schedule a reminder on %s because that time has already passed.",
+		KeyReminderDelivery:        "Reminder: %s",
+		KeyReminderList:            "Your reminders:\n%s\nSend \"/reminders cancel 1\" to cancel the first reminder.",
+		KeyReminderListEmpty:       "You don't have any scheduled reminders.",
+		KeyReminderCancelled:       "The reminder \"%s\" has been cancelled.",
+		KeyReminderNotFound:        "The reminder [%s] does not exist. Send \"/reminders\" to see your reminders.",
+		KeyDigestSubscribed:        "You will receive the %s digest every day at %s (%s). Send \"/digest stop %s\" to unsubscribe.",
+		KeyDigestUnsubscribed:      "You have unsubscribed from the %s digest.",
+		KeyDigestNotSubscribed:     "You are not subscribed to the %s digest.",
+		KeyDigestList:              "Your daily digests:\n%s\nSend \"/digest stop\" to unsubscribe from all of them.",
+		KeyDigestUsage:             "Send \"/digest <topic> <HH:MM>\" to receive a daily message at that time e.g \"/digest word 08:00\". The topics are %s.",
+		KeyQuotaExceeded:           "We have reached our daily limit of messages. Please try again tomorrow.",
+	},
+	LocaleFrench: {
+		KeyCompletionError:         "Nous n'avons pas pu générer la réponse avec chatGPT. Veuillez réessayer plus tard.",
+		KeySMSCharacterLimit:       "La réponse contient %d caractères. Contactez-nous à arnold@discusswithai.com pour recevoir par SMS des réponses de plus de %d caractères.",
+		KeySMSMultipart:            "Nous ne prenons pas encore en charge les messages de plus de 160 caractères.",
+		KeyWhatsappUnsupportedType: "Nous ne prenons en charge que les messages texte pour le moment. Nous prévoyons de prendre en charge le contenu %s à l'avenir.",
+		KeyContentPolicy:           "Nous ne pouvons pas répondre à ce message car il enfreint notre politique de contenu.",
+		KeyLocaleUpdated:           "Votre langue est désormais le français.",
+		KeyLocaleNotSupported:      "La langue [%s] n'est pas prise en charge. Les langues prises en charge sont %s.",
+		KeyReminderScheduled:       "Je vous rappellerai « %s » le %s.",
+		KeyReminderInPast:          "Je ne peux pas programmer un rappel le %s car cette heure est déjà passée.",
+		KeyReminderDelivery:        "Rappel : %s",
+		KeyReminderList:            "Vos rappels :\n%s\nEnvoyez « /reminders cancel 1 » pour annuler le premier rappel.",
+		KeyReminderListEmpty:       "Vous n'avez aucun rappel programmé.",
+		KeyReminderCancelled:       "Le rappel « %s » a été annulé.",
+		KeyReminderNotFound:        "Le rappel [%s] n'existe pas. Envoyez « /reminders » pour voir vos rappels.",
+		KeyDigestSubscribed:        "Vous recevrez le message quotidien %s tous les jours à %s (%s). Envoyez « /digest stop %s » pour vous désabonner.",
+		KeyDigestUnsubscribed:      "Vous êtes désabonné du message quotidien %s.",
+		KeyDigestNotSubscribed:     "Vous n'êtes pas abonné au message quotidien %s.",
+		KeyDigestList:              "Vos messages quotidiens :\n%s\nEnvoyez « /digest stop » pour tous les arrêter.",
+		KeyDigestUsage:             "Envoyez « /digest <sujet> <HH:MM> » pour recevoir un message quotidien à cette heure, par exemple « /digest word 08:00 ». Les sujets sont %s.",
+		KeyQuotaExceeded:           "Nous avons atteint notre limite quotidienne de messages. Veuillez réessayer demain.",
+	},
+}
+
+// Catalog contains the translations of the messages we send to users
+type Catalog struct {
+	translations map[Locale]map[Key]string
+}
+
+// NewCatalog creates a new Catalog with all the supported translations
+func NewCatalog() *Catalog {
+	return &Catalog{
+		translations: translations,
+	}
+}
+
+// Translate returns the message for a Key in the given Locale formatted with args.
+// It falls back to the DefaultLocale when there is no translation for the Locale.
+func (catalog *Catalog) Translate(locale Locale, key Key, args ...any) string {
+	message, ok := catalog.translations[locale][key]
+	if !ok {
+		message, ok = catalog.translations[DefaultLocale][key]
+	}
+
+	if !ok {
+		return string(key)
+	}
+
+	if len(args) == 0 {
+		return message
+	}
+
+	return fmt.Sprintf(message, args...)
+}
+
+// SupportedLocales returns a human-readable list of the supported locales
+func (catalog *Catalog) SupportedLocales() string {
+	var values []string
+	for _, locale := range Locales() {
+		values = append(values, locale.String())
+	}
+	return strings.Join(values, ", ")
+}
@@ -0,0 +1,122 @@
+package i18n
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocaleFromPhoneNumber(t *testing.T) {
+	t.Run("french is used for cameroon phone numbers", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+
+		// Act
+		locale := LocaleFromPhoneNumber("+237670000000")
+
+		// Assert
+		assert.Equal(t, LocaleFrench, locale)
+	})
+
+	t.Run("whatsapp phone numbers without a + are supported", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+
+		// Act
+		locale := LocaleFromPhoneNumber("237670000000")
+
+		// Assert
+		assert.Equal(t, LocaleFrench, locale)
+	})
+
+	t.Run("the default locale is used for invalid phone numbers", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+
+		// Act
+		locale := LocaleFromPhoneNumber("invalid")
+
+		// Assert
+		assert.Equal(t, DefaultLocale, locale)
+	})
+}
+
+func TestParseLocale(t *testing.T) {
+	t.Run("the region is removed from the locale", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+
+		// Act
+		locale, ok := ParseLocale("FR-cm")
+
+		// Assert
+		assert.True(t, ok)
+		assert.Equal(t, LocaleFrench, locale)
+	})
+
+	t.Run("unsupported locales are not parsed", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+
+		// Act
+		_, ok := ParseLocale("de")
+
+		// Assert
+		assert.False(t, ok)
+	})
+}
+
+func TestCatalog_Translate(t *testing.T) {
+	t.Run("the message is formatted with the arguments", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+
+		// Arrange
+		catalog := NewCatalog()
+
+		// Act
+		message := catalog.Translate(LocaleFrench, KeyWhatsappUnsupportedType, "image")
+
+		// Assert
+		assert.Contains(t, message, "image")
+		assert.NotEqual(t, catalog.Translate(LocaleEnglish, KeyWhatsappUnsupportedType, "image"), message)
+	})
+
+	t.Run("the default locale is used when there is no translation", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+
+		// Arrange
+		catalog := NewCatalog()
+
+		// Act
+		message := catalog.Translate(Locale("de"), KeyCompletionError)
+
+		// Assert
+		assert.Equal(t, catalog.Translate(DefaultLocale, KeyCompletionError), message)
+	})
+}
+
+func TestTimezoneFromPhoneNumber(t *testing.T) {
+	t.Run("the timezone of the region is used", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+
+		// Act
+		location := TimezoneFromPhoneNumber("237670000000")
+
+		// Assert
+		assert.Equal(t, "Africa/Douala", location.String())
+	})
+
+	t.Run("UTC is used for invalid phone numbers", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+
+		// Act
+		location := TimezoneFromPhoneNumber("invalid")
+
+		// Assert
+		assert.Equal(t, "UTC", location.String())
+	})
+}
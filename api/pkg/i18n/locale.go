@@ -0,0 +1,104 @@
+package i18n
+
+import (
+	"strings"
+
+	"github.com/nyaruka/phonenumbers"
+)
+
+// Locale is the language used when replying to a user
+type Locale string
+
+// String converts Locale to string
+func (locale Locale) String() string {
+	return string(locale)
+}
+
+const (
+	// LocaleEnglish is the english language
+	LocaleEnglish = Locale("en")
+
+	// LocaleFrench is the french language
+	LocaleFrench = Locale("fr")
+
+	// DefaultLocale is used when we cannot determine the locale of a user
+	DefaultLocale = LocaleEnglish
+)
+
+// Locales returns all the supported locales
+func Locales() []Locale {
+	return []Locale{LocaleEnglish, LocaleFrench}
+}
+
+// ParseLocale converts a string like "fr" or "fr-CM" into a supported Locale
+func ParseLocale(value string) (Locale, bool) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if index := strings.IndexAny(value, "-_"); index > 0 {
+		value = value[:index]
+	}
+
+	for _, locale := range Locales() {
+		if locale.String() == value {
+			return locale, true
+		}
+	}
+
+	return DefaultLocale, false
+}
+
+// regionLocales maps the region code of a phone number to its Locale.
+// Regions which are not in this map use the DefaultLocale
+var regionLocales = map[string]Locale{
+	"BF": LocaleFrench,
+	"BI": LocaleFrench,
+	"BJ": LocaleFrench,
+	"BL": LocaleFrench,
+	"CD": LocaleFrench,
+	"CF": LocaleFrench,
+	"CG": LocaleFrench,
+	"CI": LocaleFrench,
+	"CM": LocaleFrench,
+	"DJ": LocaleFrench,
+	"FR": LocaleFrench,
+	"GA": LocaleFrench,
+	"GF": LocaleFrench,
+	"GN": LocaleFrench,
+	"GP": LocaleFrench,
+	"HT": LocaleFrench,
+	"KM": LocaleFrench,
+	"MC": LocaleFrench,
+	"MF": LocaleFrench,
+	"MG": LocaleFrench,
+	"ML": LocaleFrench,
+	"MQ": LocaleFrench,
+	"NC": LocaleFrench,
+	"NE": LocaleFrench,
+	"PF": LocaleFrench,
+	"PM": LocaleFrench,
+	"RE": LocaleFrench,
+	"SN": LocaleFrench,
+	"TD": LocaleFrench,
+	"TG": LocaleFrench,
+	"WF": LocaleFrench,
+	"YT": LocaleFrench,
+}
+
+// LocaleFromPhoneNumber determines the Locale from the region of a phone number.
+// The phone number may be in E.164 format or without the leading "+" as sent by whatsapp.
+func LocaleFromPhoneNumber(phoneNumber string) Locale {
+	phoneNumber = strings.TrimSpace(phoneNumber)
+	if !strings.HasPrefix(phoneNumber, "+") {
+		phoneNumber = "+" + phoneNumber
+	}
+
+	number, err := phonenumbers.Parse(phoneNumber, phonenumbers.UNKNOWN_REGION)
+	if err != nil {
+		return DefaultLocale
+	}
+
+	if locale, ok := regionLocales[phonenumbers.GetRegionCodeForNumber(number)]; ok {
+		return locale
+	}
+
+	return DefaultLocale
+}
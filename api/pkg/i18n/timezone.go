@@ -0,0 +1,36 @@
+package i18n
+
+import (
+	"strings"
+	"time"
+	// embed the timezone database so that reminders work on hosts without it
+	_ "time/tzdata"
+
+	"github.com/nyaruka/phonenumbers"
+)
+
+// TimezoneFromPhoneNumber determines the timezone from the region of a phone number.
+// It returns time.UTC when the phone number is invalid or its region has more than one timezone.
+func TimezoneFromPhoneNumber(phoneNumber string) *time.Location {
+	phoneNumber = strings.TrimSpace(phoneNumber)
+	if !strings.HasPrefix(phoneNumber, "+") {
+		phoneNumber = "+" + phoneNumber
+	}
+
+	number, err := phonenumbers.Parse(phoneNumber, phonenumbers.UNKNOWN_REGION)
+	if err != nil {
+		return time.UTC
+	}
+
+	timezones, err := phonenumbers.GetTimezonesForNumber(number)
+	if err != nil || len(timezones) != 1 {
+		return time.UTC
+	}
+
+	location, err := time.LoadLocation(timezones[0])
+	if err != nil {
+		return time.UTC
+	}
+
+	return location
+}
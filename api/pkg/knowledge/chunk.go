@@ -0,0 +1,87 @@
+package knowledge
+
+import (
+	"strings"
+
+	"github.com/NdoleStudio/discusswithai/pkg/completion"
+)
+
+// piece is a paragraph or a part of a long paragraph which is never split between chunks
+type piece struct {
+	text   string
+	tokens int
+}
+
+// Chunk splits a text into chunks of at most size tokens along the paragraphs.
+// Consecutive chunks share the last paragraphs of at most overlap tokens so that the context of a chunk is not lost.
+func Chunk(tokenizer completion.Tokenizer, text string, size int, overlap int) []string {
+	var pieces []piece
+	for _, paragraph := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n\n") {
+		if paragraph = strings.TrimSpace(paragraph); paragraph != "" {
+			pieces = append(pieces, split(tokenizer, paragraph, size)...)
+		}
+	}
+
+	var chunks []string
+	var current []piece
+	tokens := 0
+	for _, p := range pieces {
+		if tokens+p.tokens > size && len(current) > 0 {
+			chunks = append(chunks, join(current))
+			current, tokens = tail(current, overlap, size-p.tokens)
+		}
+		current = append(current, p)
+		tokens += p.tokens
+	}
+	if len(current) > 0 {
+		chunks = append(chunks, join(current))
+	}
+	return chunks
+}
+
+// split splits a paragraph which has more than size tokens into pieces of words
+func split(tokenizer completion.Tokenizer, paragraph string, size int) []piece {
+	if tokens := tokenizer.Count(paragraph); tokens <= size {
+		return []piece{{text: paragraph, tokens: tokens}}
+	}
+
+	var pieces []piece
+	var words []string
+	tokens := 0
+	for _, word := range strings.Fields(paragraph) {
+		count := tokenizer.Count(" " + word)
+		if tokens+count > size && len(words) > 0 {
+			pieces = append(pieces, piece{text: strings.Join(words, " "), tokens: tokens})
+			words, tokens = nil, 0
+		}
+		words = append(words, word)
+		tokens += count
+	}
+	if len(words) > 0 {
+		pieces = append(pieces, piece{text: strings.Join(words, " "), tokens: tokens})
+	}
+	return pieces
+}
+
+// tail returns the last pieces which have at most limit tokens and fit in the space which is left in the next chunk
+func tail(pieces []piece, limit int, space int) ([]piece, int) {
+	if space < limit {
+		limit = space
+	}
+
+	tokens := 0
+	start := len(pieces)
+	for start > 0 && tokens+pieces[start-1].tokens <= limit {
+		start--
+		tokens += pieces[start].tokens
+	}
+	return append([]piece(nil), pieces[start:]...), tokens
+}
+
+func join(pieces []piece) string {
+	texts := make([]string, 0, len(pieces))
+	for _, p := range pieces {
+		texts = append(texts, p.text)
+	}
+	return strings.Join(texts, "\n\n")
+}
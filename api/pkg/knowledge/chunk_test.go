@@ -0,0 +1,45 @@
+package knowledge
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/NdoleStudio/discusswithai/pkg/completion"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChunk(t *testing.T) {
+	t.Run("it keeps the paragraphs together and overlaps the chunks", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+
+		// Arrange
+		text := strings.Join([]string{strings.Repeat("a", 40), strings.Repeat("b", 40), strings.Repeat("c", 40)}, "\r\n\r\n")
+
+		// Act
+		chunks := Chunk(completion.ApproximateTokenizer{}, text, 20, 10)
+
+		// Assert
+		assert.Equal(t, []string{
+			strings.Repeat("a", 40) + "\n\n" + strings.Repeat("b", 40),
+			strings.Repeat("b", 40) + "\n\n" + strings.Repeat("c", 40),
+		}, chunks)
+	})
+
+	t.Run("it splits long paragraphs into words", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+
+		// Arrange
+		text := strings.TrimSpace(strings.Repeat("word ", 30))
+
+		// Act
+		chunks := Chunk(completion.ApproximateTokenizer{}, text, 20, 0)
+
+		// Assert
+		assert.Equal(t, 3, len(chunks))
+		for _, chunk := range chunks {
+			assert.LessOrEqual(t, completion.ApproximateTokenizer{}.Count(chunk), 20)
+		}
+	})
+}
@@ -0,0 +1,93 @@
+package knowledge
+
+import (
+	"bytes"
+	"io"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/ledongthuc/pdf"
+	"github.com/palantir/stacktrace"
+)
+
+// Format is the format of a document in a knowledge base
+type Format string
+
+const (
+	// FormatText is a plain text document
+	FormatText = Format("text")
+
+	// FormatMarkdown is a Markdown document which is stored as it is because the language model understands Markdown
+	FormatMarkdown = Format("markdown")
+
+	// FormatPDF is a PDF document whose text is extracted
+	FormatPDF = Format("pdf")
+)
+
+// ErrCodeUnsupportedFormat is returned when a document is not text, Markdown or PDF
+const ErrCodeUnsupportedFormat = stacktrace.ErrorCode(5000)
+
+// DetectFormat determines the Format of a document from its file name and falls back to its content type
+func DetectFormat(name string, contentType string) (Format, error) {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".txt", ".text":
+		return FormatText, nil
+	case ".md", ".markdown":
+		return FormatMarkdown, nil
+	case ".pdf":
+		return FormatPDF, nil
+	}
+
+	mediaType, _, _ := strings.Cut(strings.ToLower(contentType), ";")
+	switch strings.TrimSpace(mediaType) {
+	case "text/plain":
+		return FormatText, nil
+	case "text/markdown", "text/x-markdown":
+		return FormatMarkdown, nil
+	case "application/pdf":
+		return FormatPDF, nil
+	}
+
+	return "", stacktrace.NewErrorWithCode(ErrCodeUnsupportedFormat, "the document [%s] with content type [%s] is not a text, Markdown or PDF document", name, contentType)
+}
+
+// Extract returns the text of a document
+func Extract(format Format, content []byte) (string, error) {
+	switch format {
+	case FormatText, FormatMarkdown:
+		if !utf8.Valid(content) {
+			return "", stacktrace.NewErrorWithCode(ErrCodeUnsupportedFormat, "the %s document is not encoded in UTF-8", format)
+		}
+		return strings.ReplaceAll(string(content), "\r\n", "\n"), nil
+	case FormatPDF:
+		return extractPDF(content)
+	default:
+		return "", stacktrace.NewErrorWithCode(ErrCodeUnsupportedFormat, "cannot extract the text of a document with format [%s]", format)
+	}
+}
+
+func extractPDF(content []byte) (text string, err error) {
+	// the PDF reader panics on some malformed documents
+	defer func() {
+		if r := recover(); r != nil {
+			err = stacktrace.NewErrorWithCode(ErrCodeUnsupportedFormat, "cannot read the PDF document: %v", r)
+		}
+	}()
+
+	reader, err := pdf.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return "", stacktrace.PropagateWithCode(err, ErrCodeUnsupportedFormat, "cannot open the PDF document")
+	}
+
+	plainText, err := reader.GetPlainText()
+	if err != nil {
+		return "", stacktrace.PropagateWithCode(err, ErrCodeUnsupportedFormat, "cannot extract the text of the PDF document")
+	}
+
+	result, err := io.ReadAll(plainText)
+	if err != nil {
+		return "", stacktrace.Propagate(err, "cannot read the text of the PDF document")
+	}
+	return string(result), nil
+}
@@ -0,0 +1,62 @@
+package knowledge
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/palantir/stacktrace"
+	"github.com/sashabaranov/go-openai"
+)
+
+// maxEmbeddingInputs is the maximum number of texts which are embedded in one request
+const maxEmbeddingInputs = 100
+
+// Embedder converts texts into embeddings
+type Embedder interface {
+	// Embed returns the embeddings of the texts in the same order
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+}
+
+// OpenAIEmbedder creates embeddings with the embeddings endpoint of the OpenAI API
+type OpenAIEmbedder struct {
+	client *openai.Client
+	model  openai.EmbeddingModel
+}
+
+// NewOpenAIEmbedder creates an OpenAIEmbedder which uses the embedding model
+func NewOpenAIEmbedder(client *openai.Client, model openai.EmbeddingModel) *OpenAIEmbedder {
+	return &OpenAIEmbedder{client: client, model: model}
+}
+
+// Embed returns the embeddings of the texts in batches of at most maxEmbeddingInputs texts
+func (embedder *OpenAIEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	embeddings := make([][]float32, len(texts))
+	for start := 0; start < len(texts); start += maxEmbeddingInputs {
+		end := start + maxEmbeddingInputs
+		if end > len(texts) {
+			end = len(texts)
+		}
+
+		response, err := embedder.client.CreateEmbeddings(ctx, openai.EmbeddingRequest{
+			Input: texts[start:end],
+			Model: embedder.model,
+		})
+		if err != nil {
+			return nil, stacktrace.Propagate(err, fmt.Sprintf("cannot create embeddings of texts [%d] to [%d] with model [%s]", start, end, embedder.model))
+		}
+
+		for _, embedding := range response.Data {
+			if embedding.Index < 0 || start+embedding.Index >= end {
+				return nil, stacktrace.NewError("the embedding has index [%d] which is not in a batch of [%d] texts", embedding.Index, end-start)
+			}
+			embeddings[start+embedding.Index] = embedding.Embedding
+		}
+	}
+
+	for i, embedding := range embeddings {
+		if len(embedding) == 0 {
+			return nil, stacktrace.NewError("the embedding of text [%d] is missing in the response", i)
+		}
+	}
+	return embeddings, nil
+}
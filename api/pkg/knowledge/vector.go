@@ -0,0 +1,53 @@
+package knowledge
+
+import (
+	"encoding/binary"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Cosine returns the cosine similarity of two embeddings or 0 when they have different dimensions
+func Cosine(a []float32, b []float32) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
+
+// EncodeEmbedding encodes an embedding into little endian float32 values so that it can be stored without pgvector
+func EncodeEmbedding(embedding []float32) []byte {
+	result := make([]byte, 4*len(embedding))
+	for i, value := range embedding {
+		binary.LittleEndian.PutUint32(result[4*i:], math.Float32bits(value))
+	}
+	return result
+}
+
+// DecodeEmbedding decodes an embedding which was encoded with EncodeEmbedding
+func DecodeEmbedding(content []byte) []float32 {
+	result := make([]float32, len(content)/4)
+	for i := range result {
+		result[i] = math.Float32frombits(binary.LittleEndian.Uint32(content[4*i:]))
+	}
+	return result
+}
+
+// VectorLiteral formats an embedding as a pgvector literal e.g. "[0.1,0.2]"
+func VectorLiteral(embedding []float32) string {
+	values := make([]string, len(embedding))
+	for i, value := range embedding {
+		values[i] = strconv.FormatFloat(float64(value), 'f', -1, 32)
+	}
+	return "[" + strings.Join(values, ",") + "]"
+}
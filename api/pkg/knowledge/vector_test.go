@@ -0,0 +1,32 @@
+package knowledge
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCosine(t *testing.T) {
+	// Setup
+	t.Parallel()
+
+	// Act & Assert
+	assert.InDelta(t, 1, Cosine([]float32{1, 2}, []float32{2, 4}), 0.000001)
+	assert.InDelta(t, 0, Cosine([]float32{1, 0}, []float32{0, 1}), 0.000001)
+	assert.Equal(t, float64(0), Cosine([]float32{1}, []float32{1, 2}))
+}
+
+func TestEncodeEmbedding(t *testing.T) {
+	// Setup
+	t.Parallel()
+
+	// Arrange
+	embedding := []float32{0.25, -1.5, 3}
+
+	// Act
+	decoded := DecodeEmbedding(EncodeEmbedding(embedding))
+
+	// Assert
+	assert.Equal(t, embedding, decoded)
+	assert.Equal(t, "[0.25,-1.5,3]", VectorLiteral(embedding))
+}
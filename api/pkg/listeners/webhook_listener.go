@@ -0,0 +1,64 @@
+package listeners
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/NdoleStudio/discusswithai/pkg/events"
+	"github.com/NdoleStudio/discusswithai/pkg/services"
+	"github.com/NdoleStudio/discusswithai/pkg/telemetry"
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/palantir/stacktrace"
+)
+
+// WebhookListener sends events to the webhooks of integrators
+type WebhookListener struct {
+	logger  telemetry.Logger
+	tracer  telemetry.Tracer
+	service *services.WebhookService
+}
+
+// NewWebhookListener creates a new WebhookListener and the queued listeners for each event type
+func NewWebhookListener(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	service *services.WebhookService,
+) (l *WebhookListener, routes map[string]events.Listener) {
+	l = &WebhookListener{
+		logger:  logger.WithService(fmt.Sprintf("%T", l)),
+		tracer:  tracer,
+		service: service,
+	}
+
+	routes = map[string]events.Listener{
+		events.MessageReceived:     events.Typed(webhookRoute[events.MessagePayload](l)),
+		events.CompletionGenerated: events.Typed(webhookRoute[events.CompletionPayload](l)),
+		events.MessageSent:         events.Typed(webhookRoute[events.MessagePayload](l)),
+		events.MessageFailed:       events.Typed(webhookRoute[events.MessagePayload](l)),
+		events.TenantQuotaExceeded: events.Typed(webhookRoute[events.TenantQuotaPayload](l)),
+		events.SubscriptionChanged: events.Typed(webhookRoute[events.SubscriptionPayload](l)),
+	}
+
+	return l, routes
+}
+
+// webhookRoute sends an event whose data is the payload T to webhooks.
+// An event whose data cannot be decoded into the payload of its type is not sent to integrators.
+func webhookRoute[T any](listener *WebhookListener) func(ctx context.Context, event cloudevents.Event, payload *T) error {
+	return func(ctx context.Context, event cloudevents.Event, _ *T) error {
+		return listener.OnEvent(ctx, event)
+	}
+}
+
+// OnEvent sends the cloudevents.Event to the webhooks which are subscribed to its type
+func (listener *WebhookListener) OnEvent(ctx context.Context, event cloudevents.Event) error {
+	ctx, span := listener.tracer.Start(ctx)
+	defer span.End()
+
+	if err := listener.service.Send(ctx, event); err != nil {
+		msg := fmt.Sprintf("cannot send event [%s] with type [%s] to webhooks", event.ID(), event.Type())
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
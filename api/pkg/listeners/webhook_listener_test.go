@@ -0,0 +1,51 @@
+package listeners
+
+import (
+	"context"
+	"testing"
+
+	"github.com/NdoleStudio/discusswithai/pkg/events"
+	"github.com/NdoleStudio/discusswithai/pkg/telemetry"
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/google/uuid"
+	"github.com/hirosassa/zerodriver"
+	"github.com/stretchr/testify/assert"
+)
+
+// testLogger is created once because zerodriver.NewDevelopmentLogger sets the global log level
+var testLogger = telemetry.NewZerologLogger("test", map[string]string{}, zerodriver.NewDevelopmentLogger(), nil)
+
+func TestNewWebhookListener(t *testing.T) {
+	t.Run("every event type which can be sent to webhooks has a route", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+
+		// Act
+		_, routes := NewWebhookListener(testLogger, telemetry.NewOtelLogger("test", testLogger), nil)
+
+		// Assert
+		assert.Equal(t, len(events.Types()), len(routes))
+		for _, eventType := range events.Types() {
+			assert.Contains(t, routes, eventType)
+		}
+	})
+
+	t.Run("an event whose data does not match the payload of its type is not sent", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		_, routes := NewWebhookListener(testLogger, telemetry.NewOtelLogger("test", testLogger), nil)
+
+		// Arrange
+		event := cloudevents.NewEvent()
+		event.SetID(uuid.New().String())
+		event.SetSource("test")
+		event.SetType(events.TenantQuotaExceeded)
+		assert.Nil(t, event.SetData(cloudevents.ApplicationJSON, map[string]any{"messages_sent": "ten"}))
+
+		// Act
+		err := routes[events.TenantQuotaExceeded](context.Background(), event)
+
+		// Assert
+		assert.NotNil(t, err)
+	})
+}
@@ -0,0 +1,92 @@
+package middlewares
+
+import (
+	"context"
+
+	"github.com/NdoleStudio/discusswithai/pkg/entities"
+	"github.com/NdoleStudio/discusswithai/pkg/services"
+	"github.com/NdoleStudio/discusswithai/pkg/telemetry"
+	"github.com/NdoleStudio/discusswithai/pkg/tenancy"
+	"github.com/gofiber/fiber/v2"
+	"github.com/palantir/stacktrace"
+)
+
+const (
+	apiKeyHeader = "X-API-Key"
+
+	// PrincipalContextKey is the fiber.Ctx locals key of the authenticated entities.Principal
+	PrincipalContextKey = "auth.principal"
+)
+
+// APIKeyAuth authenticates a request using the API key in the X-API-Key header.
+// The context of the request is scoped to the tenant of the API key unless the entities.Principal can access all the tenants.
+func APIKeyAuth(logger telemetry.Logger, tracer telemetry.Tracer, service *services.APIKeyService, tenantService *services.TenantService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, span := tracer.StartFromFiberCtx(c, "middlewares.APIKeyAuth")
+		defer span.End()
+
+		ctxLogger := tracer.CtxLogger(logger, span)
+
+		key := c.Get(apiKeyHeader)
+		if key == "" {
+			ctxLogger.Warn(stacktrace.NewError("the [%s] header is not set for [%s] [%s]", apiKeyHeader, c.Method(), c.OriginalURL()))
+			return responseUnauthorized(c)
+		}
+
+		principal, err := service.Authenticate(ctx, key)
+		if err != nil {
+			ctxLogger.Warn(stacktrace.Propagate(err, "the API key in the [%s] header is not valid for [%s] [%s]", apiKeyHeader, c.Method(), c.OriginalURL()))
+			return responseUnauthorized(c)
+		}
+
+		parentCtx, ok := c.Locals(telemetry.TracerContextKey).(context.Context)
+		if !ok {
+			parentCtx = context.Background()
+		}
+
+		if principal.CanAccessAllTenants() {
+			parentCtx = tenancy.WithAllTenants(parentCtx)
+		} else if parentCtx, err = tenantService.Scope(parentCtx, principal.TenantID); err != nil {
+			ctxLogger.Error(stacktrace.Propagate(err, "cannot scope [%s] [%s] to tenant [%s] of API key [%s]", c.Method(), c.OriginalURL(), principal.TenantID, principal.APIKeyID))
+			return responseUnauthorized(c)
+		}
+
+		c.Locals(telemetry.TracerContextKey, parentCtx)
+		c.Locals(PrincipalContextKey, principal)
+		return c.Next()
+	}
+}
+
+// RequireRoles allows only requests by an entities.Principal which has at least one of the roles
+func RequireRoles(logger telemetry.Logger, tracer telemetry.Tracer, roles ...entities.Role) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		_, span := tracer.StartFromFiberCtx(c, "middlewares.RequireRoles")
+		defer span.End()
+
+		ctxLogger := tracer.CtxLogger(logger, span)
+
+		principal, ok := c.Locals(PrincipalContextKey).(*entities.Principal)
+		if !ok {
+			ctxLogger.Warn(stacktrace.NewError("no principal is set for [%s] [%s]", c.Method(), c.OriginalURL()))
+			return responseUnauthorized(c)
+		}
+
+		if !principal.HasAnyRole(roles...) {
+			ctxLogger.Warn(stacktrace.NewError("API key [%s] with roles %v needs one of %v for [%s] [%s]", principal.APIKeyID, principal.Roles, roles, c.Method(), c.OriginalURL()))
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"status":  "error",
+				"message": fiber.ErrForbidden.Message,
+			})
+		}
+
+		return c.Next()
+	}
+}
+
+func responseUnauthorized(c *fiber.Ctx) error {
+	return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+		"status":  "error",
+		"message": "You are not authorized to carry out this request.",
+		"data":    "Make sure your API key is set in the [X-API-Key] header in the request",
+	})
+}
@@ -1,10 +1,7 @@
 package middlewares
 
 import (
-	"context"
 	"fmt"
-	"strconv"
-	"strings"
 
 	"github.com/gofiber/fiber/v2"
 
@@ -12,6 +9,9 @@ import (
 	"github.com/palantir/stacktrace"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.18.0"
 	"go.opentelemetry.io/otel/trace"
 )
 
@@ -19,98 +19,82 @@ const (
 	clientVersionHeader = "X-Client-Version"
 )
 
-// OtelTraceContext adds a trace for an HTTP request
-func OtelTraceContext(tracer telemetry.Tracer, logger telemetry.Logger, header string, namespace string) fiber.Handler {
+// OtelTraceContext adds a trace for an HTTP request.
+// The trace joins the remote trace which is extracted from the request headers with the propagator.
+func OtelTraceContext(tracer telemetry.Tracer, logger telemetry.Logger, propagator propagation.TextMapPropagator, namespace string) fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		// Get the Context from the request
-		spanContext, errors := spanContextFromHeader(c.Get(header))
-		if len(errors) != 0 {
-			logger.Error(stacktrace.NewError(strings.Join(errors, "\n")))
-		}
-
-		if !spanContext.IsValid() {
-			if c.Get(header) != "" {
-				logger.Error(stacktrace.NewError("invalid trace context %s creating new context", c.Get(header)))
+		carrier := fiberCarrier{c: c}
+		parentCtx := propagator.Extract(c.UserContext(), carrier)
+		if !trace.SpanContextFromContext(parentCtx).IsValid() {
+			for _, field := range propagator.Fields() {
+				if c.Get(field) != "" {
+					logger.Warn(stacktrace.NewError("invalid trace context in header [%s: %s] creating new context", field, c.Get(field)))
+				}
 			}
-			otelTracer := otel.Tracer(namespace)
-			_, span := otelTracer.Start(context.Background(), fmt.Sprintf("%s %s", c.Method(), c.OriginalURL()))
-			defer span.End()
-			spanContext = span.SpanContext()
 		}
 
+		ctx, span := otel.Tracer(namespace).Start(
+			parentCtx,
+			fmt.Sprintf("%s %s", c.Method(), c.Path()),
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPMethodKey.String(c.Method()),
+				semconv.HTTPTargetKey.String(c.Path()),
+				attribute.Key("clientVersion").String(c.Get(clientVersionHeader)),
+			),
+		)
+		defer span.End()
+
+		spanContext := span.SpanContext()
 		logger.WithSpan(spanContext).
 			WithString("http.method", c.Method()).
 			WithString("client.version", c.Get(clientVersionHeader)).
-			Trace(c.OriginalURL())
-
-		newCtx, span := otel.Tracer(namespace).Start(trace.ContextWithRemoteSpanContext(context.Background(), spanContext), "middlewares.OtelTraceContext")
-		defer span.End()
+			Trace(c.Path())
 
 		ctxLogger := tracer.CtxLogger(logger, span)
 		traceID := spanContext.TraceID().String()
-		span.SetAttributes(attribute.Key("traceID").String(traceID))
-		span.SetAttributes(attribute.Key("SpanID").String(span.SpanContext().SpanID().String()))
-		span.SetAttributes(attribute.Key("traceFlags").String(spanContext.TraceFlags().String()))
-		span.SetAttributes(attribute.Key("clientVersion").String(c.Get(clientVersionHeader)))
 
-		c.Locals(telemetry.TracerContextKey, trace.ContextWithSpan(newCtx, span))
+		c.SetUserContext(ctx)
+		c.Locals(telemetry.TracerContextKey, ctx)
 
 		// Go to next middleware:
 		response := c.Next()
 
 		statusCode := c.Response().StatusCode()
+		span.SetAttributes(semconv.HTTPStatusCodeKey.Int(statusCode))
+		if statusCode >= 500 {
+			span.SetStatus(codes.Error, fmt.Sprintf("http.status [%d]", statusCode))
+		}
 		span.AddEvent(fmt.Sprintf("finished handling request with traceID: [%s], statusCode: [%d]", traceID, statusCode))
 
 		if statusCode >= 300 && len(c.Request().Body()) > 0 {
-			ctxLogger.Warn(stacktrace.NewError(fmt.Sprintf("http.status [%d], body [%s]", statusCode, string(c.Request().Body()))))
+			ctxLogger.Warn(stacktrace.NewError(fmt.Sprintf("http.status [%d], body [%s]", statusCode, telemetry.RedactBody(string(c.Request().Body())))))
 		}
 
 		return response
 	}
 }
 
-func spanContextFromHeader(parentContext string) (trace.SpanContext, []string) {
-	result := trace.NewSpanContext(trace.SpanContextConfig{
-		TraceID:    trace.TraceID{},
-		SpanID:     trace.SpanID{},
-		TraceState: trace.TraceState{},
-		Remote:     true,
-	})
-
-	parts := strings.Split(parentContext, "/")
-	var errors []string
-	if len(parts) == 2 {
-		traceID, err := trace.TraceIDFromHex(parts[0])
-		if err != nil {
-			errors = append(errors, fmt.Sprintf("could not get trace id %v", err))
-			return result, errors
-		}
-		result = result.WithTraceID(traceID)
-
-		spanParts := strings.Split(parts[1], ";")
-		if len(spanParts) == 1 {
-			spanParts = append(spanParts, "")
-		}
-
-		if len(spanParts) == 2 {
-			val, err := strconv.ParseUint(spanParts[0], 10, 64)
-			if err != nil {
-				errors = append(errors, fmt.Sprintf("could not get trace id %v", err))
-				return result, errors
-			}
+// fiberCarrier adapts the request headers of a fiber.Ctx to a propagation.TextMapCarrier
+type fiberCarrier struct {
+	c *fiber.Ctx
+}
 
-			spanID, err := trace.SpanIDFromHex(fmt.Sprintf("%016x", val))
-			if err != nil {
-				errors = append(errors, fmt.Sprintf("could not get span trace id %v", err))
-				return result, errors
-			}
-			result = result.WithSpanID(spanID)
+// Get returns the value of the request header
+func (carrier fiberCarrier) Get(key string) string {
+	return carrier.c.Get(key)
+}
 
-			if spanParts[1] == "o=1" {
-				result = result.WithTraceFlags(trace.FlagsSampled)
-			}
-		}
-	}
+// Set sets the value of the request header
+func (carrier fiberCarrier) Set(key string, value string) {
+	carrier.c.Request().Header.Set(key, value)
+}
 
-	return result, errors
+// Keys lists the keys of the request headers
+func (carrier fiberCarrier) Keys() []string {
+	var keys []string
+	carrier.c.Request().Header.VisitAll(func(key, _ []byte) {
+		keys = append(keys, string(key))
+	})
+	return keys
 }
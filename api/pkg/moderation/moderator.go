@@ -0,0 +1,37 @@
+package moderation
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Moderator checks if content violates our usage policies
+type Moderator interface {
+	// Moderate checks if the input is harmful
+	Moderate(ctx context.Context, input string) (*Result, error)
+}
+
+// Result is the outcome of moderating a piece of content
+type Result struct {
+	Flagged        bool
+	Categories     []string
+	CategoryScores map[string]float64
+}
+
+// Scores returns the category scores as a sorted string e.g "hate=0.0001, violence=0.9831"
+func (result *Result) Scores() string {
+	var categories []string
+	for category := range result.CategoryScores {
+		categories = append(categories, category)
+	}
+	sort.Strings(categories)
+
+	var scores []string
+	for _, category := range categories {
+		scores = append(scores, fmt.Sprintf("%s=%.4f", category, result.CategoryScores[category]))
+	}
+
+	return strings.Join(scores, ", ")
+}
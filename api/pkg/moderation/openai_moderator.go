@@ -0,0 +1,75 @@
+package moderation
+
+import (
+	"context"
+	"sort"
+
+	"github.com/NdoleStudio/discusswithai/pkg/telemetry"
+	"github.com/palantir/stacktrace"
+	"github.com/sashabaranov/go-openai"
+)
+
+// openAIModerator moderates content using the OpenAI moderation endpoint
+type openAIModerator struct {
+	tracer telemetry.Tracer
+	client *openai.Client
+}
+
+// NewOpenAIModerator creates a Moderator which uses the OpenAI moderation endpoint
+func NewOpenAIModerator(tracer telemetry.Tracer, client *openai.Client) Moderator {
+	return &openAIModerator{
+		tracer: tracer,
+		client: client,
+	}
+}
+
+// Moderate checks if the input is harmful using the OpenAI moderation endpoint
+func (moderator *openAIModerator) Moderate(ctx context.Context, input string) (*Result, error) {
+	ctx, span := moderator.tracer.Start(ctx)
+	defer span.End()
+
+	response, err := moderator.client.Moderations(ctx, openai.ModerationRequest{Input: input})
+	if err != nil {
+		return nil, moderator.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, "cannot moderate input"))
+	}
+
+	if len(response.Results) == 0 {
+		return nil, moderator.tracer.WrapErrorSpan(span, stacktrace.NewError("the moderation response [%s] has no results", response.ID))
+	}
+
+	return moderator.result(response.Results[0]), nil
+}
+
+func (moderator *openAIModerator) result(result openai.Result) *Result {
+	categories := map[string]bool{
+		"hate":             result.Categories.Hate,
+		"hate/threatening": result.Categories.HateThreatening,
+		"self-harm":        result.Categories.SelfHarm,
+		"sexual":           result.Categories.Sexual,
+		"sexual/minors":    result.Categories.SexualMinors,
+		"violence":         result.Categories.Violence,
+		"violence/graphic": result.Categories.ViolenceGraphic,
+	}
+
+	response := &Result{
+		Flagged: result.Flagged,
+		CategoryScores: map[string]float64{
+			"hate":             float64(result.CategoryScores.Hate),
+			"hate/threatening": float64(result.CategoryScores.HateThreatening),
+			"self-harm":        float64(result.CategoryScores.SelfHarm),
+			"sexual":           float64(result.CategoryScores.Sexual),
+			"sexual/minors":    float64(result.CategoryScores.SexualMinors),
+			"violence":         float64(result.CategoryScores.Violence),
+			"violence/graphic": float64(result.CategoryScores.ViolenceGraphic),
+		},
+	}
+
+	for category, flagged := range categories {
+		if flagged {
+			response.Categories = append(response.Categories, category)
+		}
+	}
+	sort.Strings(response.Categories)
+
+	return response
+}
@@ -0,0 +1,120 @@
+package moderation
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/NdoleStudio/discusswithai/pkg/telemetry"
+	"github.com/hirosassa/zerodriver"
+	"github.com/sashabaranov/go-openai"
+	"github.com/stretchr/testify/assert"
+)
+
+// testLogger is created once because zerodriver.NewDevelopmentLogger sets the global log level
+var testLogger = telemetry.NewZerologLogger("test", map[string]string{}, zerodriver.NewDevelopmentLogger(), nil)
+
+func TestOpenAIModerator_Moderate(t *testing.T) {
+	t.Run("it returns the flagged categories and the category scores", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+
+		// Arrange
+		moderator := newTestOpenAIModerator(t, `{
+			"id": "modr-1",
+			"model": "text-moderation-latest",
+			"results": [{
+				"flagged": true,
+				"categories": {"hate": false, "violence": true, "violence/graphic": true},
+				"category_scores": {"hate": 0.0001, "violence": 0.9831, "violence/graphic": 0.5}
+			}]
+		}`)
+
+		// Act
+		result, err := moderator.Moderate(context.Background(), "harmful prompt")
+
+		// Assert
+		assert.Nil(t, err)
+		assert.True(t, result.Flagged)
+		assert.Equal(t, []string{"violence", "violence/graphic"}, result.Categories)
+		assert.InDelta(t, 0.9831, result.CategoryScores["violence"], 0.0001)
+	})
+
+	t.Run("it returns no categories when the input is not flagged", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+
+		// Arrange
+		moderator := newTestOpenAIModerator(t, `{
+			"id": "modr-2",
+			"model": "text-moderation-latest",
+			"results": [{"flagged": false, "categories": {}, "category_scores": {"hate": 0.0001}}]
+		}`)
+
+		// Act
+		result, err := moderator.Moderate(context.Background(), "hello")
+
+		// Assert
+		assert.Nil(t, err)
+		assert.False(t, result.Flagged)
+		assert.Empty(t, result.Categories)
+	})
+
+	t.Run("it returns an error when the response has no results", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+
+		// Arrange
+		moderator := newTestOpenAIModerator(t, `{"id": "modr-3", "model": "text-moderation-latest", "results": []}`)
+
+		// Act
+		result, err := moderator.Moderate(context.Background(), "hello")
+
+		// Assert
+		assert.Nil(t, result)
+		assert.NotNil(t, err)
+	})
+}
+
+func TestResult_Scores(t *testing.T) {
+	t.Run("it sorts the scores by category", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+
+		// Arrange
+		result := &Result{CategoryScores: map[string]float64{"violence": 0.98312, "hate": 0.0001, "self-harm": 0}}
+
+		// Act
+		scores := result.Scores()
+
+		// Assert
+		assert.Equal(t, "hate=0.0001, self-harm=0.0000, violence=0.9831", scores)
+	})
+
+	t.Run("it returns an empty string without scores", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+
+		// Act
+		scores := new(Result).Scores()
+
+		// Assert
+		assert.Equal(t, "", scores)
+	})
+}
+
+// newTestOpenAIModerator creates an openAIModerator which receives the body from a test server
+func newTestOpenAIModerator(t *testing.T, body string) Moderator {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+
+	config := openai.DefaultConfig("test")
+	config.BaseURL = server.URL
+	config.HTTPClient = server.Client()
+
+	return NewOpenAIModerator(telemetry.NewOtelLogger("test", testLogger), openai.NewClientWithConfig(config))
+}
@@ -0,0 +1,56 @@
+// Package netguard prevents server side request forgery when the API sends requests to URLs chosen by integrators
+// e.g. webhooks and callbacks. A URL is rejected when it is validated and the connection is refused when the host
+// resolves to a private address at dial time so that a DNS record cannot be changed after the validation.
+package netguard
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"syscall"
+)
+
+// IsPublicIP checks if the ip can be reached on the public internet
+func IsPublicIP(ip net.IP) bool {
+	return !(ip.IsLoopback() ||
+		ip.IsPrivate() ||
+		ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() ||
+		ip.IsMulticast())
+}
+
+// IsPublicURL checks if the raw URL is an http or https URL which does not point to localhost or to a private IP address
+func IsPublicURL(raw string) bool {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return false
+	}
+
+	host := strings.ToLower(strings.TrimSuffix(u.Hostname(), "."))
+	if host == "" || host == "localhost" || strings.HasSuffix(host, ".localhost") || strings.HasSuffix(host, ".internal") {
+		return false
+	}
+
+	if ip := net.ParseIP(host); ip != nil {
+		return IsPublicIP(ip)
+	}
+
+	return true
+}
+
+// DialControl is a net.Dialer Control function which refuses connections to the IP addresses which are not public
+func DialControl(_ string, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return fmt.Errorf("cannot parse the address [%s]: %w", address, err)
+	}
+
+	if ip := net.ParseIP(host); ip == nil || !IsPublicIP(ip) {
+		return fmt.Errorf("the address [%s] is not a public IP address", address)
+	}
+
+	return nil
+}
@@ -0,0 +1,73 @@
+package netguard
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsPublicURL(t *testing.T) {
+	t.Run("URLs of public hosts are allowed", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+
+		for _, value := range []string{"https://example.com/webhooks", "http://93.184.216.34/callback", "https://[2606:2800:220:1:248:1893:25c8:1946]/callback"} {
+			// Act
+			ok := IsPublicURL(value)
+
+			// Assert
+			assert.True(t, ok, value)
+		}
+	})
+
+	t.Run("URLs of private and loopback hosts are rejected", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+
+		for _, value := range []string{
+			"http://localhost:8000/v1/admin",
+			"http://api.localhost/callback",
+			"http://metadata.google.internal/computeMetadata/v1",
+			"http://127.0.0.1/callback",
+			"http://10.0.0.4/callback",
+			"http://192.168.1.1/callback",
+			"http://169.254.169.254/latest/meta-data",
+			"http://[::1]/callback",
+			"http://0.0.0.0/callback",
+			"ftp://example.com/callback",
+			"example.com/callback",
+		} {
+			// Act
+			ok := IsPublicURL(value)
+
+			// Assert
+			assert.False(t, ok, value)
+		}
+	})
+}
+
+func TestDialControl(t *testing.T) {
+	t.Run("connections to private addresses are refused", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+
+		for _, address := range []string{"127.0.0.1:80", "10.1.2.3:443", "[::1]:80", "169.254.169.254:80"} {
+			// Act
+			err := DialControl("tcp", address, nil)
+
+			// Assert
+			assert.NotNil(t, err, address)
+		}
+	})
+
+	t.Run("connections to public addresses are allowed", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+
+		// Act
+		err := DialControl("tcp", "93.184.216.34:443", nil)
+
+		// Assert
+		assert.Nil(t, err)
+	})
+}
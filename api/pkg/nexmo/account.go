@@ -0,0 +1,7 @@
+package nexmo
+
+// AccountBalance is the balance of the Vonage account
+type AccountBalance struct {
+	Value      float64 `json:"value"`
+	AutoReload bool    `json:"autoReload"`
+}
@@ -0,0 +1,35 @@
+package nexmo
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+)
+
+// AccountService is the API client for the `/account` endpoint
+type AccountService service
+
+// Balance retrieves the current balance of your Vonage account
+//
+// API Docs: https://developer.vonage.com/en/api/account#getAccountBalance
+func (service *AccountService) Balance(ctx context.Context) (*AccountBalance, *Response, error) {
+	request, err := service.client.newRequest(ctx, http.MethodGet, "/account/get-balance", nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// the credentials are not in the query string so that they are not leaked in the errors which contain the URL
+	request.SetBasicAuth(service.client.apiKey, service.client.apiSecret)
+
+	response, err := service.client.do(request)
+	if err != nil {
+		return nil, response, err
+	}
+
+	balance := new(AccountBalance)
+	if err = json.Unmarshal(*response.Body, balance); err != nil {
+		return nil, response, err
+	}
+
+	return balance, response, nil
+}
@@ -22,7 +22,8 @@ type Client struct {
 	apiKey     string
 	apiSecret  string
 
-	Sms *SMSService
+	Sms     *SMSService
+	Account *AccountService
 }
 
 // New creates and returns a new campay.Client from a slice of campay.ClientOption.
@@ -42,6 +43,7 @@ func New(options ...Option) *Client {
 
 	client.common.client = client
 	client.Sms = (*SMSService)(&client.common)
+	client.Account = (*AccountService)(&client.common)
 	return client
 }
 
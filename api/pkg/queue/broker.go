@@ -0,0 +1,37 @@
+package queue
+
+import (
+	"context"
+	"time"
+)
+
+// Message is a Task which is stored by a Broker until it is processed by a Worker
+type Message struct {
+	ID         string    `json:"id"`
+	Task       Task      `json:"task"`
+	Attempts   uint      `json:"attempts"`
+	LastError  string    `json:"last_error,omitempty"`
+	EnqueuedAt time.Time `json:"enqueued_at"`
+
+	// payload is the serialized Message as it was dequeued. It is used by the broker to acknowledge the Message.
+	payload string
+}
+
+// Broker stores tasks which are processed by a Worker in the same deployment
+type Broker interface {
+	Client
+
+	// Dequeue waits until a Message is available or the timeout expires.
+	// A nil Message is returned when the timeout expires.
+	Dequeue(ctx context.Context, timeout time.Duration) (*Message, error)
+
+	// Ack removes a Message which was dequeued from the in-progress tasks.
+	// It is called after the Message is processed, retried or moved to the dead-letter list.
+	Ack(ctx context.Context, message *Message) error
+
+	// Retry adds the Message back to the queue after the delay
+	Retry(ctx context.Context, message *Message, delay time.Duration) error
+
+	// DeadLetter stores a Message which failed after all the attempts
+	DeadLetter(ctx context.Context, message *Message) error
+}
@@ -9,6 +9,10 @@ import (
 	"cloud.google.com/go/cloudtasks/apiv2/cloudtaskspb"
 	"github.com/NdoleStudio/discusswithai/pkg/telemetry"
 	"github.com/palantir/stacktrace"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 type googlePushQueue struct {
@@ -43,21 +47,24 @@ func (queue *googlePushQueue) Enqueue(ctx context.Context, task *Task) (queueID
 
 	ctxLogger := queue.tracer.CtxLogger(queue.logger, span)
 
+	method, err := queue.httpMethodToProtoHTTPMethod(task.Method)
+	if err != nil {
+		return queueID, queue.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, fmt.Sprintf("cannot schedule task to URL: %s", task.URL)))
+	}
+
 	req := &cloudtaskspb.CreateTaskRequest{
 		Parent: queue.queueName,
 		Task: &cloudtaskspb.Task{
 			MessageType: &cloudtaskspb.Task_HttpRequest{
 				HttpRequest: &cloudtaskspb.HttpRequest{
-					HttpMethod: queue.httpMethodToProtoHTTPMethod(task.Method),
+					HttpMethod: method,
 					Url:        task.URL,
 					AuthorizationHeader: &cloudtaskspb.HttpRequest_OidcToken{
 						OidcToken: &cloudtaskspb.OidcToken{
 							ServiceAccountEmail: queue.authEmail,
 						},
 					},
-					Headers: map[string]string{
-						"Content-Type": "application/json",
-					},
+					Headers: queue.headers(ctx, task),
 				},
 			},
 		},
@@ -66,9 +73,25 @@ func (queue *googlePushQueue) Enqueue(ctx context.Context, task *Task) (queueID
 	// Add a payload message if one is present.
 	req.Task.GetHttpRequest().Body = task.Body
 
+	if name := task.name(); name != "" {
+		req.Task.Name = fmt.Sprintf("%s/tasks/%s", queue.queueName, name)
+	}
+
+	if task.ScheduleTime != nil {
+		req.Task.ScheduleTime = timestamppb.New(*task.ScheduleTime)
+	}
+
+	if task.Timeout > 0 {
+		req.Task.DispatchDeadline = durationpb.New(task.Timeout)
+	}
+
 	queueTask, err := queue.client.CreateTask(ctx, req)
+	if status.Code(err) == codes.AlreadyExists {
+		ctxLogger.Info(fmt.Sprintf("task [%s] already exists in [%s] queue with deduplication key [%s]", req.Task.Name, queue.queueName, task.DeduplicationKey))
+		return req.Task.Name, nil
+	}
 	if err != nil {
-		msg := fmt.Sprintf("cannot schedule task %s to URL: %s", string(task.Body), task.URL)
+		msg := fmt.Sprintf("cannot schedule task [%s] to URL: %s", telemetry.RedactBody(string(task.Body)), task.URL)
 		return queueID, queue.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
 	}
 
@@ -82,16 +105,32 @@ func (queue *googlePushQueue) Enqueue(ctx context.Context, task *Task) (queueID
 	return queueTask.Name, nil
 }
 
-func (queue *googlePushQueue) httpMethodToProtoHTTPMethod(httpMethod string) cloudtaskspb.HttpMethod {
+func (queue *googlePushQueue) headers(ctx context.Context, task *Task) map[string]string {
+	headers := withTraceContext(ctx, task.Headers)
+	if _, ok := headers["Content-Type"]; !ok {
+		headers["Content-Type"] = "application/json"
+	}
+	return headers
+}
+
+func (queue *googlePushQueue) httpMethodToProtoHTTPMethod(httpMethod string) (cloudtaskspb.HttpMethod, error) {
+	if httpMethod == "" {
+		return cloudtaskspb.HttpMethod_POST, nil
+	}
+
 	method, ok := map[string]cloudtaskspb.HttpMethod{
-		http.MethodGet:  cloudtaskspb.HttpMethod_GET,
-		http.MethodPost: cloudtaskspb.HttpMethod_POST,
-		http.MethodPut:  cloudtaskspb.HttpMethod_PUT,
+		http.MethodGet:     cloudtaskspb.HttpMethod_GET,
+		http.MethodPost:    cloudtaskspb.HttpMethod_POST,
+		http.MethodPut:     cloudtaskspb.HttpMethod_PUT,
+		http.MethodPatch:   cloudtaskspb.HttpMethod_PATCH,
+		http.MethodDelete:  cloudtaskspb.HttpMethod_DELETE,
+		http.MethodHead:    cloudtaskspb.HttpMethod_HEAD,
+		http.MethodOptions: cloudtaskspb.HttpMethod_OPTIONS,
 	}[httpMethod]
 
 	if !ok {
-		return cloudtaskspb.HttpMethod_POST
+		return cloudtaskspb.HttpMethod_HTTP_METHOD_UNSPECIFIED, stacktrace.NewError(fmt.Sprintf("the HTTP method [%s] is not supported by cloud tasks", httpMethod))
 	}
 
-	return method
+	return method, nil
 }
@@ -0,0 +1,134 @@
+package queue
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MemoryBroker is a Broker which stores tasks in memory. It is used locally and in tests.
+type MemoryBroker struct {
+	mutex       sync.Mutex
+	messages    chan *Message
+	deadLetters []*Message
+	names       map[string]memoryDeduplication
+	prunedAt    time.Time
+}
+
+// memoryDeduplication is the ID of the first task with a name and when the name is forgotten
+type memoryDeduplication struct {
+	id        string
+	expiresAt time.Time
+}
+
+// NewMemoryBroker creates a new MemoryBroker which can hold up to size pending tasks
+func NewMemoryBroker(size int) *MemoryBroker {
+	return &MemoryBroker{
+		messages: make(chan *Message, size),
+		names:    map[string]memoryDeduplication{},
+	}
+}
+
+// Enqueue a task to the queue
+func (broker *MemoryBroker) Enqueue(ctx context.Context, task *Task) (string, error) {
+	message := &Message{
+		ID:         uuid.New().String(),
+		Task:       *task,
+		EnqueuedAt: time.Now().UTC(),
+	}
+	message.Task.Headers = withTraceContext(ctx, task.Headers)
+
+	if name := task.name(); name != "" {
+		if id := broker.deduplicate(name, message.ID); id != message.ID {
+			return id, nil
+		}
+	}
+
+	if !task.isDue() {
+		return message.ID, broker.Retry(ctx, message, time.Until(*task.ScheduleTime))
+	}
+
+	select {
+	case broker.messages <- message:
+		return message.ID, nil
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+// deduplicate stores the ID of the first task with the name for the deduplicationTTL and returns it
+func (broker *MemoryBroker) deduplicate(name string, id string) string {
+	broker.mutex.Lock()
+	defer broker.mutex.Unlock()
+
+	now := time.Now()
+	broker.prune(now)
+
+	if existing, ok := broker.names[name]; ok && now.Before(existing.expiresAt) {
+		return existing.id
+	}
+
+	broker.names[name] = memoryDeduplication{id: id, expiresAt: now.Add(deduplicationTTL)}
+	return id
+}
+
+// prune removes the expired names at most once per minute
+func (broker *MemoryBroker) prune(now time.Time) {
+	if now.Sub(broker.prunedAt) < time.Minute {
+		return
+	}
+	broker.prunedAt = now
+
+	for name, deduplication := range broker.names {
+		if !now.Before(deduplication.expiresAt) {
+			delete(broker.names, name)
+		}
+	}
+}
+
+// Dequeue waits for the next Message in the queue
+func (broker *MemoryBroker) Dequeue(ctx context.Context, timeout time.Duration) (*Message, error) {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case message := <-broker.messages:
+		return message, nil
+	case <-timer.C:
+		return nil, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Ack is a no-op because tasks in memory are lost when the process stops
+func (broker *MemoryBroker) Ack(_ context.Context, _ *Message) error {
+	return nil
+}
+
+// Retry adds the Message back to the queue after the delay
+func (broker *MemoryBroker) Retry(_ context.Context, message *Message, delay time.Duration) error {
+	time.AfterFunc(delay, func() {
+		broker.messages <- message
+	})
+	return nil
+}
+
+// DeadLetter stores a Message which failed after all the attempts
+func (broker *MemoryBroker) DeadLetter(_ context.Context, message *Message) error {
+	broker.mutex.Lock()
+	defer broker.mutex.Unlock()
+
+	broker.deadLetters = append(broker.deadLetters, message)
+	return nil
+}
+
+// DeadLetters returns the messages which failed after all the attempts
+func (broker *MemoryBroker) DeadLetters() []*Message {
+	broker.mutex.Lock()
+	defer broker.mutex.Unlock()
+
+	return append([]*Message{}, broker.deadLetters...)
+}
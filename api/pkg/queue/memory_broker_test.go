@@ -0,0 +1,73 @@
+package queue
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryBroker_Enqueue(t *testing.T) {
+	t.Run("tasks with the same deduplication key are enqueued once", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		broker := NewMemoryBroker(10)
+		ctx := context.Background()
+
+		// Arrange
+		task := &Task{URL: "https://example.com/reminders", DeduplicationKey: "reminder-1"}
+
+		// Act
+		first, err1 := broker.Enqueue(ctx, task)
+		second, err2 := broker.Enqueue(ctx, task)
+
+		// Assert
+		assert.Nil(t, err1)
+		assert.Nil(t, err2)
+		assert.Equal(t, first, second)
+		assert.Equal(t, 1, len(broker.messages))
+	})
+
+	t.Run("expired deduplication keys are removed", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		broker := NewMemoryBroker(10)
+		ctx := context.Background()
+
+		// Arrange
+		first, _ := broker.Enqueue(ctx, &Task{URL: "https://example.com/reminders", DeduplicationKey: "reminder-1"})
+		broker.names[(&Task{DeduplicationKey: "reminder-1"}).name()] = memoryDeduplication{id: first, expiresAt: time.Now()}
+		broker.prunedAt = time.Time{}
+
+		// Act
+		second, err := broker.Enqueue(ctx, &Task{URL: "https://example.com/reminders", DeduplicationKey: "reminder-2"})
+
+		// Assert
+		assert.Nil(t, err)
+		assert.NotEqual(t, first, second)
+		assert.Equal(t, 1, len(broker.names))
+	})
+
+	t.Run("scheduled tasks are not delivered before the schedule time", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		broker := NewMemoryBroker(10)
+		ctx := context.Background()
+
+		// Arrange
+		scheduleTime := time.Now().Add(50 * time.Millisecond)
+
+		// Act
+		_, err := broker.Enqueue(ctx, &Task{URL: "https://example.com/reminders", ScheduleTime: &scheduleTime})
+
+		// Assert
+		assert.Nil(t, err)
+		message, _ := broker.Dequeue(ctx, time.Millisecond)
+		assert.Nil(t, message)
+
+		message, _ = broker.Dequeue(ctx, time.Second)
+		assert.NotNil(t, message)
+		assert.False(t, time.Now().Before(scheduleTime))
+	})
+}
@@ -1,8 +1,42 @@
 package queue
 
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+)
+
 // Task represents a push queue task
 type Task struct {
-	Method string
-	URL    string
-	Body   []byte
+	Method  string
+	URL     string
+	Body    []byte
+	Headers map[string]string
+
+	// ScheduleTime is the time when the task is delivered. The task is delivered immediately when it is nil.
+	ScheduleTime *time.Time
+
+	// DeduplicationKey is used to create a deterministic task name so that a task with the same key is enqueued only once
+	DeduplicationKey string
+
+	// MaxAttempts is the number of times the task is tried before it fails.
+	// The default of the queue is used when it is 0. Google Cloud Tasks only supports retries configured on the queue.
+	MaxAttempts uint
+
+	// Timeout is the maximum duration of a delivery. The default of the queue is used when it is 0.
+	Timeout time.Duration
+}
+
+// name is the deterministic name of a task with a DeduplicationKey
+func (task *Task) name() string {
+	if task.DeduplicationKey == "" {
+		return ""
+	}
+	hash := sha256.Sum256([]byte(task.DeduplicationKey))
+	return hex.EncodeToString(hash[:])
+}
+
+// isDue checks if the task can be delivered now
+func (task *Task) isDue() bool {
+	return task.ScheduleTime == nil || !task.ScheduleTime.After(time.Now())
 }
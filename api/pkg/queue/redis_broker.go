@@ -0,0 +1,345 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/NdoleStudio/discusswithai/pkg/telemetry"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// deduplicationTTL is how long the name of a task with a DeduplicationKey is remembered
+	deduplicationTTL = 24 * time.Hour
+
+	// redisHeartbeatTTL is how long the in-progress tasks of a consumer are kept after it stops sending heartbeats
+	redisHeartbeatTTL = time.Minute
+
+	// redisHeartbeatInterval is how often the heartbeat of a consumer is refreshed while it is processing tasks.
+	// It is a third of redisHeartbeatTTL so that a single failed heartbeat does not expire the consumer.
+	redisHeartbeatInterval = redisHeartbeatTTL / 3
+
+	// redisPromoteLimit is the maximum number of due retries which are moved to the queue at once
+	redisPromoteLimit = 100
+)
+
+// promoteScript moves the tasks in the sorted set which are due to the list in a single step so that a task is never
+// removed from the sorted set without being added to the list
+var promoteScript = redis.NewScript(`
+local payloads = redis.call("ZRANGEBYSCORE", KEYS[1], "-inf", ARGV[1], "LIMIT", 0, ARGV[2])
+for _, payload in ipairs(payloads) do
+	redis.call("ZREM", KEYS[1], payload)
+	redis.call("LPUSH", KEYS[2], payload)
+end
+return #payloads
+`)
+
+// redisBroker is a Broker which stores tasks in a redis list.
+// Scheduled tasks and retries are stored in a sorted set by the time they are due, and failed tasks in a dead-letter list.
+// Dequeued tasks are moved to a processing list of the consumer until they are acknowledged, and the processing lists
+// of consumers which stopped without acknowledging their tasks are moved back to the queue.
+// The heartbeat of the consumer is refreshed in the background while it has tasks in progress so that long tasks are not
+// recovered by other consumers.
+type redisBroker struct {
+	tracer   telemetry.Tracer
+	client   *redis.Client
+	name     string
+	consumer string
+
+	mutex         sync.Mutex
+	recoveredAt   time.Time
+	inProgress    int
+	stopHeartbeat context.CancelFunc
+}
+
+// NewRedisBroker creates a Broker which stores tasks in redis with keys prefixed by the queue name
+func NewRedisBroker(tracer telemetry.Tracer, client *redis.Client, name string) Broker {
+	return &redisBroker{
+		tracer:   tracer,
+		client:   client,
+		name:     name,
+		consumer: uuid.New().String(),
+	}
+}
+
+// Enqueue a task to the queue
+func (broker *redisBroker) Enqueue(ctx context.Context, task *Task) (string, error) {
+	ctx, span := broker.tracer.Start(ctx)
+	defer span.End()
+
+	message := &Message{
+		ID:         uuid.New().String(),
+		Task:       *task,
+		EnqueuedAt: time.Now().UTC(),
+	}
+	message.Task.Headers = withTraceContext(ctx, task.Headers)
+
+	if name := task.name(); name != "" {
+		id, err := broker.deduplicate(ctx, name, message.ID)
+		if err != nil {
+			return "", broker.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, fmt.Sprintf("cannot deduplicate task to [%s]", task.URL)))
+		}
+		if id != message.ID {
+			return id, nil
+		}
+	}
+
+	if !task.isDue() {
+		if err := broker.schedule(ctx, message, *task.ScheduleTime); err != nil {
+			return "", broker.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, fmt.Sprintf("cannot schedule task to [%s]", task.URL)))
+		}
+		return message.ID, nil
+	}
+
+	payload, err := json.Marshal(message)
+	if err != nil {
+		return "", broker.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, fmt.Sprintf("cannot marshal task to [%s]", task.URL)))
+	}
+
+	if err = broker.client.LPush(ctx, broker.name, payload).Err(); err != nil {
+		msg := fmt.Sprintf("cannot push task [%s] to redis list [%s]", message.ID, broker.name)
+		return "", broker.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return message.ID, nil
+}
+
+// deduplicate stores the ID of the first task with the name and returns it
+func (broker *redisBroker) deduplicate(ctx context.Context, name string, id string) (string, error) {
+	key := broker.name + ":dedup:" + name
+	stored, err := broker.client.SetNX(ctx, key, id, deduplicationTTL).Result()
+	if err != nil {
+		return "", stacktrace.Propagate(err, fmt.Sprintf("cannot set deduplication key [%s]", key))
+	}
+	if stored {
+		return id, nil
+	}
+
+	existing, err := broker.client.Get(ctx, key).Result()
+	if err != nil {
+		return "", stacktrace.Propagate(err, fmt.Sprintf("cannot get deduplication key [%s]", key))
+	}
+	return existing, nil
+}
+
+// Dequeue moves the next Message in the queue to the processing list of the consumer
+func (broker *redisBroker) Dequeue(ctx context.Context, timeout time.Duration) (*Message, error) {
+	if err := broker.heartbeat(ctx); err != nil {
+		return nil, stacktrace.Propagate(err, fmt.Sprintf("cannot register consumer [%s] of queue [%s]", broker.consumer, broker.name))
+	}
+
+	if err := broker.recoverAbandoned(ctx); err != nil {
+		return nil, stacktrace.Propagate(err, fmt.Sprintf("cannot recover abandoned tasks in queue [%s]", broker.name))
+	}
+
+	if err := broker.promoteDueRetries(ctx); err != nil {
+		return nil, stacktrace.Propagate(err, fmt.Sprintf("cannot promote retries in queue [%s]", broker.name))
+	}
+
+	payload, err := broker.client.BLMove(ctx, broker.name, broker.processingKey(broker.consumer), "RIGHT", "LEFT", timeout).Result()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, stacktrace.Propagate(err, fmt.Sprintf("cannot move task from redis list [%s]", broker.name))
+	}
+
+	message := new(Message)
+	if err = json.Unmarshal([]byte(payload), message); err != nil {
+		return nil, stacktrace.Propagate(err, fmt.Sprintf("cannot unmarshal task from redis list [%s]", broker.name))
+	}
+	message.payload = payload
+
+	broker.track(1)
+	return message, nil
+}
+
+// Ack removes the Message from the processing list of the consumer
+func (broker *redisBroker) Ack(ctx context.Context, message *Message) error {
+	ctx, span := broker.tracer.Start(ctx)
+	defer span.End()
+
+	defer broker.track(-1)
+
+	if err := broker.client.LRem(ctx, broker.processingKey(broker.consumer), 1, message.payload).Err(); err != nil {
+		msg := fmt.Sprintf("cannot remove task [%s] from processing list [%s]", message.ID, broker.processingKey(broker.consumer))
+		return broker.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// heartbeat marks the consumer as alive so that its processing list is not recovered by other consumers
+func (broker *redisBroker) heartbeat(ctx context.Context) error {
+	if err := broker.client.SAdd(ctx, broker.consumersKey(), broker.consumer).Err(); err != nil {
+		return stacktrace.Propagate(err, fmt.Sprintf("cannot add consumer to [%s]", broker.consumersKey()))
+	}
+
+	if err := broker.client.Set(ctx, broker.heartbeatKey(broker.consumer), time.Now().UTC().Unix(), redisHeartbeatTTL).Err(); err != nil {
+		return stacktrace.Propagate(err, fmt.Sprintf("cannot set heartbeat [%s]", broker.heartbeatKey(broker.consumer)))
+	}
+
+	return nil
+}
+
+// track changes the number of tasks in progress and keeps the heartbeat of the consumer alive while there are any.
+// Tasks which are never acknowledged are recovered by other consumers when this consumer stops.
+func (broker *redisBroker) track(delta int) {
+	broker.mutex.Lock()
+	defer broker.mutex.Unlock()
+
+	broker.inProgress += delta
+	if broker.inProgress > 0 && broker.stopHeartbeat == nil {
+		var ctx context.Context
+		ctx, broker.stopHeartbeat = context.WithCancel(context.Background())
+		go broker.keepAlive(ctx)
+	}
+
+	if broker.inProgress <= 0 && broker.stopHeartbeat != nil {
+		broker.inProgress = 0
+		broker.stopHeartbeat()
+		broker.stopHeartbeat = nil
+	}
+}
+
+// keepAlive refreshes the heartbeat of the consumer every redisHeartbeatInterval until the context is cancelled.
+// A heartbeat which fails is sent again on the next tick before the heartbeat expires.
+func (broker *redisBroker) keepAlive(ctx context.Context) {
+	ticker := time.NewTicker(redisHeartbeatInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			_ = broker.heartbeat(ctx)
+		}
+	}
+}
+
+// recoverAbandoned moves the tasks of consumers which stopped without acknowledging them back to the queue.
+// It runs at most once per redisHeartbeatTTL on each consumer.
+func (broker *redisBroker) recoverAbandoned(ctx context.Context) error {
+	broker.mutex.Lock()
+	if time.Since(broker.recoveredAt) < redisHeartbeatTTL {
+		broker.mutex.Unlock()
+		return nil
+	}
+	broker.recoveredAt = time.Now()
+	broker.mutex.Unlock()
+
+	consumers, err := broker.client.SMembers(ctx, broker.consumersKey()).Result()
+	if err != nil {
+		return stacktrace.Propagate(err, fmt.Sprintf("cannot fetch consumers from [%s]", broker.consumersKey()))
+	}
+
+	for _, consumer := range consumers {
+		alive, err := broker.client.Exists(ctx, broker.heartbeatKey(consumer)).Result()
+		if err != nil {
+			return stacktrace.Propagate(err, fmt.Sprintf("cannot check heartbeat [%s]", broker.heartbeatKey(consumer)))
+		}
+		if alive > 0 {
+			continue
+		}
+
+		for {
+			err = broker.client.LMove(ctx, broker.processingKey(consumer), broker.name, "RIGHT", "RIGHT").Err()
+			if err == redis.Nil {
+				break
+			}
+			if err != nil {
+				return stacktrace.Propagate(err, fmt.Sprintf("cannot move task from processing list [%s]", broker.processingKey(consumer)))
+			}
+		}
+
+		if err = broker.client.SRem(ctx, broker.consumersKey(), consumer).Err(); err != nil {
+			return stacktrace.Propagate(err, fmt.Sprintf("cannot remove consumer [%s] from [%s]", consumer, broker.consumersKey()))
+		}
+	}
+
+	return nil
+}
+
+// Retry adds the Message to the sorted set of retries with the time when it is due
+func (broker *redisBroker) Retry(ctx context.Context, message *Message, delay time.Duration) error {
+	ctx, span := broker.tracer.Start(ctx)
+	defer span.End()
+
+	if err := broker.schedule(ctx, message, time.Now().Add(delay)); err != nil {
+		return broker.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, fmt.Sprintf("cannot retry task [%s]", message.ID)))
+	}
+
+	return nil
+}
+
+// schedule adds the Message to the sorted set of scheduled tasks with the time when it is due
+func (broker *redisBroker) schedule(ctx context.Context, message *Message, due time.Time) error {
+	payload, err := json.Marshal(message)
+	if err != nil {
+		return stacktrace.Propagate(err, fmt.Sprintf("cannot marshal task [%s]", message.ID))
+	}
+
+	err = broker.client.ZAdd(ctx, broker.retryKey(), redis.Z{
+		Score:  float64(due.Unix()),
+		Member: payload,
+	}).Err()
+	if err != nil {
+		return stacktrace.Propagate(err, fmt.Sprintf("cannot schedule task [%s] in [%s]", message.ID, broker.retryKey()))
+	}
+
+	return nil
+}
+
+// DeadLetter stores a Message which failed after all the attempts
+func (broker *redisBroker) DeadLetter(ctx context.Context, message *Message) error {
+	ctx, span := broker.tracer.Start(ctx)
+	defer span.End()
+
+	payload, err := json.Marshal(message)
+	if err != nil {
+		return broker.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, fmt.Sprintf("cannot marshal task [%s]", message.ID)))
+	}
+
+	if err = broker.client.LPush(ctx, broker.deadLetterKey(), payload).Err(); err != nil {
+		msg := fmt.Sprintf("cannot push task [%s] to dead-letter list [%s]", message.ID, broker.deadLetterKey())
+		return broker.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// promoteDueRetries moves the retries which are due from the sorted set to the list
+func (broker *redisBroker) promoteDueRetries(ctx context.Context) error {
+	err := promoteScript.Run(ctx, broker.client, []string{broker.retryKey(), broker.name}, time.Now().Unix(), redisPromoteLimit).Err()
+	if err != nil {
+		return stacktrace.Propagate(err, fmt.Sprintf("cannot move due retries from [%s] to [%s]", broker.retryKey(), broker.name))
+	}
+
+	return nil
+}
+
+func (broker *redisBroker) retryKey() string {
+	return broker.name + ":retries"
+}
+
+func (broker *redisBroker) deadLetterKey() string {
+	return broker.name + ":dead"
+}
+
+func (broker *redisBroker) consumersKey() string {
+	return broker.name + ":consumers"
+}
+
+func (broker *redisBroker) processingKey(consumer string) string {
+	return broker.name + ":processing:" + consumer
+}
+
+func (broker *redisBroker) heartbeatKey(consumer string) string {
+	return broker.name + ":heartbeat:" + consumer
+}
@@ -0,0 +1,25 @@
+package queue
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// withTraceContext copies the headers of the task and injects the trace context of ctx with the global propagator
+// so that the trace of the worker joins the trace which enqueued the task.
+func withTraceContext(ctx context.Context, headers map[string]string) map[string]string {
+	result := map[string]string{}
+	for key, value := range headers {
+		result[key] = value
+	}
+
+	otel.GetTextMapPropagator().Inject(ctx, propagation.MapCarrier(result))
+	return result
+}
+
+// contextFromHeaders returns a context with the remote trace context in the headers of the task
+func contextFromHeaders(ctx context.Context, headers map[string]string) context.Context {
+	return otel.GetTextMapPropagator().Extract(ctx, propagation.MapCarrier(headers))
+}
@@ -0,0 +1,246 @@
+package queue
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/NdoleStudio/discusswithai/pkg/telemetry"
+	"github.com/palantir/stacktrace"
+)
+
+const (
+	workerDequeueTimeout = 5 * time.Second
+)
+
+// Handler processes a Task in the same process instead of sending it to the task URL
+type Handler func(ctx context.Context, task *Task) error
+
+// WorkerConfig configures how a Worker processes tasks
+type WorkerConfig struct {
+	// Concurrency is the number of tasks which are processed at the same time
+	Concurrency int
+
+	// MaxAttempts is the number of times a task is tried before it is moved to the dead-letter list
+	MaxAttempts uint
+
+	// Backoff is the delay before the first retry. It doubles after every failed attempt.
+	Backoff time.Duration
+}
+
+// Worker delivers tasks from a Broker to the task URL or to a registered Handler
+type Worker struct {
+	logger     telemetry.Logger
+	tracer     telemetry.Tracer
+	broker     Broker
+	httpClient *http.Client
+	config     WorkerConfig
+	mutex      sync.RWMutex
+	handlers   map[string]Handler
+}
+
+// NewWorker creates a new Worker
+func NewWorker(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	broker Broker,
+	httpClient *http.Client,
+	config WorkerConfig,
+) (w *Worker) {
+	if config.Concurrency < 1 {
+		config.Concurrency = 1
+	}
+	if config.MaxAttempts < 1 {
+		config.MaxAttempts = 1
+	}
+
+	return &Worker{
+		logger:     logger.WithService(fmt.Sprintf("%T", w)),
+		tracer:     tracer,
+		broker:     broker,
+		httpClient: httpClient,
+		config:     config,
+		handlers:   map[string]Handler{},
+	}
+}
+
+// Handle registers a Handler for tasks which are sent to the URL
+func (worker *Worker) Handle(url string, handler Handler) {
+	worker.mutex.Lock()
+	defer worker.mutex.Unlock()
+	worker.handlers[url] = handler
+}
+
+// Run processes tasks until the context is cancelled and waits for the tasks in progress to complete
+func (worker *Worker) Run(ctx context.Context) {
+	worker.logger.Info(fmt.Sprintf("starting queue worker with concurrency [%d]", worker.config.Concurrency))
+
+	var wg sync.WaitGroup
+	for i := 0; i < worker.config.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			worker.loop(ctx)
+		}()
+	}
+	wg.Wait()
+
+	worker.logger.Info("stopped queue worker")
+}
+
+func (worker *Worker) loop(ctx context.Context) {
+	for ctx.Err() == nil {
+		message, err := worker.broker.Dequeue(ctx, workerDequeueTimeout)
+		if err != nil && ctx.Err() == nil {
+			worker.logger.Error(stacktrace.Propagate(err, "cannot dequeue task"))
+			time.Sleep(time.Second)
+			continue
+		}
+
+		if message != nil {
+			// tasks in progress are not cancelled when the worker is stopped
+			worker.process(contextFromHeaders(context.Background(), message.Task.Headers), message)
+		}
+	}
+}
+
+// process runs a Message once and schedules a retry or moves it to the dead-letter list when it fails
+func (worker *Worker) process(ctx context.Context, message *Message) {
+	ctx, span, ctxLogger := worker.tracer.StartWithLogger(ctx, worker.logger)
+	defer span.End()
+
+	message.Attempts++
+	err := worker.execute(ctx, &message.Task)
+	if err == nil {
+		ctxLogger.Info(fmt.Sprintf("processed task [%s] to [%s] after [%d] attempts", message.ID, message.Task.URL, message.Attempts))
+		worker.ack(ctx, ctxLogger, message)
+		return
+	}
+
+	message.LastError = telemetry.Redact(err.Error())
+	if message.Attempts >= worker.maxAttempts(&message.Task) {
+		ctxLogger.Error(worker.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, fmt.Sprintf("task [%s] to [%s] failed after [%d] attempts", message.ID, message.Task.URL, message.Attempts))))
+		if err = worker.broker.DeadLetter(ctx, message); err != nil {
+			// the task is not acknowledged so that it is delivered again when the in-progress tasks are recovered
+			ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot move task [%s] to the dead-letter list", message.ID)))
+			return
+		}
+		worker.ack(ctx, ctxLogger, message)
+		return
+	}
+
+	delay := worker.config.Backoff * time.Duration(1<<(message.Attempts-1))
+	if retryAfter, ok := retryDelay(err); ok {
+		delay = retryAfter
+	}
+	ctxLogger.Warn(stacktrace.Propagate(err, fmt.Sprintf("retrying task [%s] to [%s] in [%s]", message.ID, message.Task.URL, delay)))
+	if err = worker.broker.Retry(ctx, message, delay); err != nil {
+		ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot retry task [%s]", message.ID)))
+		return
+	}
+	worker.ack(ctx, ctxLogger, message)
+}
+
+// ack removes the Message from the in-progress tasks of the broker
+func (worker *Worker) ack(ctx context.Context, ctxLogger telemetry.Logger, message *Message) {
+	if err := worker.broker.Ack(ctx, message); err != nil {
+		ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot acknowledge task [%s]", message.ID)))
+	}
+}
+
+// maxAttempts is the number of times the Task is tried before it is moved to the dead-letter list
+func (worker *Worker) maxAttempts(task *Task) uint {
+	if task.MaxAttempts > 0 {
+		return task.MaxAttempts
+	}
+	return worker.config.MaxAttempts
+}
+
+// execute sends the Task to its Handler or to the task URL
+func (worker *Worker) execute(ctx context.Context, task *Task) error {
+	if task.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, task.Timeout)
+		defer cancel()
+	}
+
+	worker.mutex.RLock()
+	handler, ok := worker.handlers[task.URL]
+	worker.mutex.RUnlock()
+
+	if ok {
+		return handler(ctx, task)
+	}
+
+	method := task.Method
+	if method == "" {
+		method = http.MethodPost
+	}
+
+	request, err := http.NewRequestWithContext(ctx, method, task.URL, bytes.NewReader(task.Body))
+	if err != nil {
+		return stacktrace.Propagate(err, fmt.Sprintf("cannot create [%s] request to [%s]", task.Method, task.URL))
+	}
+
+	request.Header.Set("Content-Type", "application/json")
+	for key, value := range task.Headers {
+		request.Header.Set(key, value)
+	}
+
+	response, err := worker.httpClient.Do(request)
+	if err != nil {
+		return stacktrace.Propagate(err, fmt.Sprintf("cannot send [%s] request to [%s]", task.Method, task.URL))
+	}
+	defer func() {
+		_, _ = io.Copy(io.Discard, response.Body)
+		_ = response.Body.Close()
+	}()
+
+	if response.StatusCode < http.StatusOK || response.StatusCode >= http.StatusMultipleChoices {
+		err = stacktrace.NewError(fmt.Sprintf("[%s] request to [%s] failed with status [%d]", task.Method, task.URL, response.StatusCode))
+		if seconds, parseErr := strconv.Atoi(response.Header.Get("Retry-After")); parseErr == nil && seconds > 0 {
+			return RetryAfter(err, time.Duration(seconds)*time.Second)
+		}
+		return err
+	}
+
+	return nil
+}
+
+// retryError is an error which retries a Task after a delay instead of the backoff of the Worker
+type retryError struct {
+	err   error
+	delay time.Duration
+}
+
+// RetryAfter returns an error which makes the Worker retry the Task after the delay e.g. when a provider rate limits
+// the requests of a Handler. The Task still fails after the maximum number of attempts.
+func RetryAfter(err error, delay time.Duration) error {
+	return &retryError{err: err, delay: delay}
+}
+
+// Error returns the message of the wrapped error
+func (e *retryError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap returns the wrapped error
+func (e *retryError) Unwrap() error {
+	return e.err
+}
+
+// retryDelay returns the delay of the error which was created with RetryAfter in err.
+// The errors which are wrapped with stacktrace.Propagate cannot be unwrapped so their root cause is checked as well.
+func retryDelay(err error) (time.Duration, bool) {
+	var retry *retryError
+	if (errors.As(err, &retry) || errors.As(stacktrace.RootCause(err), &retry)) && retry.delay > 0 {
+		return retry.delay, true
+	}
+	return 0, false
+}
@@ -0,0 +1,149 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/NdoleStudio/discusswithai/pkg/telemetry"
+	"github.com/hirosassa/zerodriver"
+	"github.com/palantir/stacktrace"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWorker_Run(t *testing.T) {
+	t.Run("tasks are sent to the registered handler", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		broker := NewMemoryBroker(10)
+		worker := newTestWorker(broker, WorkerConfig{Concurrency: 2, MaxAttempts: 1})
+
+		// Arrange
+		processed := make(chan []byte, 1)
+		worker.Handle("https://example.com/v1/events/consume", func(ctx context.Context, task *Task) error {
+			processed <- task.Body
+			return nil
+		})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go worker.Run(ctx)
+
+		// Act
+		_, err := broker.Enqueue(ctx, &Task{Method: http.MethodPost, URL: "https://example.com/v1/events/consume", Body: []byte("{}")})
+
+		// Assert
+		assert.Nil(t, err)
+		select {
+		case body := <-processed:
+			assert.Equal(t, []byte("{}"), body)
+		case <-time.After(time.Second):
+			assert.Fail(t, "the task was not processed")
+		}
+	})
+
+	t.Run("failed tasks are retried and moved to the dead-letter list", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		broker := NewMemoryBroker(10)
+		worker := newTestWorker(broker, WorkerConfig{Concurrency: 1, MaxAttempts: 3, Backoff: time.Millisecond})
+
+		// Arrange
+		var attempts int32
+		worker.Handle("https://example.com/fail", func(ctx context.Context, task *Task) error {
+			atomic.AddInt32(&attempts, 1)
+			return errors.New("cannot process task")
+		})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go worker.Run(ctx)
+
+		// Act
+		_, err := broker.Enqueue(ctx, &Task{Method: http.MethodPost, URL: "https://example.com/fail"})
+
+		// Assert
+		assert.Nil(t, err)
+		assert.Eventually(t, func() bool { return len(broker.DeadLetters()) == 1 }, time.Second, 5*time.Millisecond)
+		assert.Equal(t, int32(3), atomic.LoadInt32(&attempts))
+		assert.Equal(t, uint(3), broker.DeadLetters()[0].Attempts)
+	})
+
+	t.Run("failed tasks are retried after the delay of the handler", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		broker := NewMemoryBroker(10)
+		worker := newTestWorker(broker, WorkerConfig{Concurrency: 1, MaxAttempts: 2, Backoff: time.Hour})
+
+		// Arrange
+		var attempts int32
+		worker.Handle("https://example.com/rate-limited", func(ctx context.Context, task *Task) error {
+			if atomic.AddInt32(&attempts, 1) == 1 {
+				return stacktrace.Propagate(RetryAfter(errors.New("rate limit hit"), 10*time.Millisecond), "cannot process task")
+			}
+			return nil
+		})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go worker.Run(ctx)
+
+		// Act
+		_, err := broker.Enqueue(ctx, &Task{Method: http.MethodPost, URL: "https://example.com/rate-limited"})
+
+		// Assert
+		assert.Nil(t, err)
+		assert.Eventually(t, func() bool { return atomic.LoadInt32(&attempts) == 2 }, time.Second, 5*time.Millisecond)
+		assert.Empty(t, broker.DeadLetters())
+	})
+
+	t.Run("tasks are acknowledged after they are processed", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		broker := &ackRecordingBroker{MemoryBroker: NewMemoryBroker(10), acked: make(chan string, 1)}
+		worker := newTestWorker(broker, WorkerConfig{Concurrency: 1, MaxAttempts: 1})
+
+		// Arrange
+		worker.Handle("https://example.com/v1/events/consume", func(ctx context.Context, task *Task) error {
+			return nil
+		})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go worker.Run(ctx)
+
+		// Act
+		id, err := broker.Enqueue(ctx, &Task{Method: http.MethodPost, URL: "https://example.com/v1/events/consume"})
+
+		// Assert
+		assert.Nil(t, err)
+		select {
+		case acked := <-broker.acked:
+			assert.Equal(t, id, acked)
+		case <-time.After(time.Second):
+			assert.Fail(t, "the task was not acknowledged")
+		}
+	})
+}
+
+// ackRecordingBroker records the IDs of the messages which are acknowledged
+type ackRecordingBroker struct {
+	*MemoryBroker
+	acked chan string
+}
+
+func (broker *ackRecordingBroker) Ack(_ context.Context, message *Message) error {
+	broker.acked <- message.ID
+	return nil
+}
+
+// testLogger is created once because zerodriver.NewDevelopmentLogger sets the global log level which races with
+// workers which are already logging in parallel tests.
+var testLogger = telemetry.NewZerologLogger("test", map[string]string{}, zerodriver.NewDevelopmentLogger(), nil)
+
+func newTestWorker(broker Broker, config WorkerConfig) *Worker {
+	return NewWorker(testLogger, telemetry.NewOtelLogger("test", testLogger), broker, http.DefaultClient, config)
+}
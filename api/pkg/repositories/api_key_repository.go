@@ -0,0 +1,26 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/NdoleStudio/discusswithai/pkg/entities"
+	"github.com/google/uuid"
+)
+
+// APIKeyRepository loads and persists an entities.APIKey
+type APIKeyRepository interface {
+	// Store a new entities.APIKey
+	Store(ctx context.Context, apiKey *entities.APIKey) error
+
+	// Load an entities.APIKey by ID
+	Load(ctx context.Context, apiKeyID uuid.UUID) (*entities.APIKey, error)
+
+	// LoadByHash fetches an entities.APIKey by the hash of the key
+	LoadByHash(ctx context.Context, hash string) (*entities.APIKey, error)
+
+	// Index entities.APIKey by IndexParams
+	Index(ctx context.Context, params IndexParams) (*[]entities.APIKey, error)
+
+	// Delete an entities.APIKey by ID
+	Delete(ctx context.Context, apiKeyID uuid.UUID) error
+}
@@ -0,0 +1,19 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/NdoleStudio/discusswithai/pkg/entities"
+)
+
+// ConversationRepository loads and persists an entities.Conversation
+type ConversationRepository interface {
+	// Update an entities.Conversation
+	Update(ctx context.Context, conversation *entities.Conversation) error
+
+	// LoadOrStore an entities.Conversation by entities.Channel, channel ID and owner
+	LoadOrStore(ctx context.Context, conversation *entities.Conversation) (*entities.Conversation, error)
+
+	// Index entities.Conversation by IndexParams and IndexFilters
+	Index(ctx context.Context, params IndexParams, filters IndexFilters) (*[]entities.Conversation, error)
+}
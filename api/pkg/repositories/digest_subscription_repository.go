@@ -0,0 +1,38 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/NdoleStudio/discusswithai/pkg/entities"
+	"github.com/google/uuid"
+)
+
+// DigestSubscriptionRepository loads and persists an entities.DigestSubscription
+type DigestSubscriptionRepository interface {
+	// Save creates or updates an entities.DigestSubscription without changing the state of its delivery
+	Save(ctx context.Context, subscription *entities.DigestSubscription) error
+
+	// Load an entities.DigestSubscription by ID
+	Load(ctx context.Context, subscriptionID uuid.UUID) (*entities.DigestSubscription, error)
+
+	// LoadByTopic fetches the entities.DigestSubscription of a user to an entities.DigestTopic
+	LoadByTopic(ctx context.Context, userID uuid.UUID, topic entities.DigestTopic) (*entities.DigestSubscription, error)
+
+	// Index fetches the entities.DigestSubscription of a user
+	Index(ctx context.Context, userID uuid.UUID) (*[]entities.DigestSubscription, error)
+
+	// Due fetches the entities.DigestSubscription which should be delivered before a time
+	Due(ctx context.Context, before time.Time, limit int) (*[]entities.DigestSubscription, error)
+
+	// Claim an entities.DigestSubscription for the delivery of the digest which was due at deliveryAt so that it is
+	// delivered by a single worker. Claims from before staleBefore are claimed again because their worker stopped.
+	// It returns false when the digest was already delivered or it is claimed by another worker.
+	Claim(ctx context.Context, subscriptionID uuid.UUID, deliveryAt time.Time, staleBefore time.Time) (bool, error)
+
+	// UpdateDelivery stores the state of the delivery of an entities.DigestSubscription
+	UpdateDelivery(ctx context.Context, subscription *entities.DigestSubscription) error
+
+	// Delete an entities.DigestSubscription
+	Delete(ctx context.Context, subscription *entities.DigestSubscription) error
+}
@@ -0,0 +1,120 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/NdoleStudio/discusswithai/pkg/entities"
+	"github.com/NdoleStudio/discusswithai/pkg/telemetry"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+	"gorm.io/gorm"
+)
+
+// gormAPIKeyRepository is responsible for persisting entities.APIKey
+type gormAPIKeyRepository struct {
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+	db     *gorm.DB
+}
+
+// NewGormAPIKeyRepository creates the GORM version of the APIKeyRepository
+func NewGormAPIKeyRepository(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	db *gorm.DB,
+) APIKeyRepository {
+	return &gormAPIKeyRepository{
+		logger: logger.WithService(fmt.Sprintf("%T", &gormAPIKeyRepository{})),
+		tracer: tracer,
+		db:     db,
+	}
+}
+
+func (repository *gormAPIKeyRepository) Store(ctx context.Context, apiKey *entities.APIKey) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	apiKey.TenantID = tenantID(ctx, apiKey.TenantID)
+	if err := repository.db.WithContext(ctx).Create(apiKey).Error; err != nil {
+		msg := fmt.Sprintf("cannot save API key with ID [%s]", apiKey.ID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+func (repository *gormAPIKeyRepository) Load(ctx context.Context, apiKeyID uuid.UUID) (*entities.APIKey, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	apiKey := new(entities.APIKey)
+	err := repository.db.WithContext(ctx).Scopes(scopeTenant(ctx)).First(apiKey, apiKeyID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		msg := fmt.Sprintf("API key with ID [%s] does not exist", apiKeyID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, ErrCodeNotFound, msg))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load API key with ID [%s]", apiKeyID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return apiKey, nil
+}
+
+func (repository *gormAPIKeyRepository) LoadByHash(ctx context.Context, hash string) (*entities.APIKey, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	apiKey := new(entities.APIKey)
+	err := repository.db.WithContext(ctx).Scopes(scopeTenant(ctx)).Where("hash = ?", hash).First(apiKey).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		msg := "API key with hash does not exist"
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, ErrCodeNotFound, msg))
+	}
+
+	if err != nil {
+		msg := "cannot load API key by hash"
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return apiKey, nil
+}
+
+func (repository *gormAPIKeyRepository) Index(ctx context.Context, params IndexParams) (*[]entities.APIKey, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	query := repository.db.WithContext(ctx).Scopes(scopeTenant(ctx))
+	if len(params.Query) > 0 {
+		query = query.Where("name ILIKE ?", "%"+params.Query+"%")
+	}
+
+	apiKeys := new([]entities.APIKey)
+	if err := query.Order("created_at DESC").Offset(params.Skip).Limit(params.Limit).Find(apiKeys).Error; err != nil {
+		msg := fmt.Sprintf("cannot index API keys with params [%+#v]", params)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return apiKeys, nil
+}
+
+func (repository *gormAPIKeyRepository) Delete(ctx context.Context, apiKeyID uuid.UUID) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	result := repository.db.WithContext(ctx).Scopes(scopeTenant(ctx)).Delete(&entities.APIKey{}, apiKeyID)
+	if result.Error != nil {
+		msg := fmt.Sprintf("cannot delete API key with ID [%s]", apiKeyID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(result.Error, msg))
+	}
+
+	if result.RowsAffected == 0 {
+		msg := fmt.Sprintf("API key with ID [%s] does not exist", apiKeyID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.NewErrorWithCode(ErrCodeNotFound, msg))
+	}
+
+	return nil
+}
@@ -0,0 +1,83 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/NdoleStudio/discusswithai/pkg/entities"
+	"github.com/NdoleStudio/discusswithai/pkg/telemetry"
+	"github.com/palantir/stacktrace"
+	"gorm.io/gorm"
+)
+
+// gormConversationRepository is responsible for persisting entities.Conversation
+type gormConversationRepository struct {
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+	db     *gorm.DB
+}
+
+// NewGormConversationRepository creates the GORM version of the ConversationRepository
+func NewGormConversationRepository(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	db *gorm.DB,
+) ConversationRepository {
+	return &gormConversationRepository{
+		logger: logger.WithService(fmt.Sprintf("%T", &gormConversationRepository{})),
+		tracer: tracer,
+		db:     db,
+	}
+}
+
+func (repository *gormConversationRepository) Update(ctx context.Context, conversation *entities.Conversation) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	if err := repository.db.WithContext(ctx).Save(conversation).Error; err != nil {
+		msg := fmt.Sprintf("cannot update conversation with ID [%s]", conversation.ID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+func (repository *gormConversationRepository) LoadOrStore(ctx context.Context, conversation *entities.Conversation) (*entities.Conversation, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	conversation.TenantID = tenantID(ctx, conversation.TenantID)
+
+	result := new(entities.Conversation)
+	err := repository.db.WithContext(ctx).
+		Where("tenant_id = ?", conversation.TenantID).
+		Where(entities.Conversation{Channel: conversation.Channel, ChannelID: conversation.ChannelID, Owner: conversation.Owner}).
+		Attrs(conversation).
+		FirstOrCreate(result).
+		Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot load or store conversation with channel [%s], channel ID [%s] and owner [%s]", conversation.Channel, conversation.ChannelID, conversation.Owner)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return result, nil
+}
+
+func (repository *gormConversationRepository) Index(ctx context.Context, params IndexParams, filters IndexFilters) (*[]entities.Conversation, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	query := repository.db.WithContext(ctx).Scopes(scopeTenant(ctx))
+	if len(params.Query) > 0 {
+		queryPattern := "%" + params.Query + "%"
+		query = query.Where("channel_id ILIKE ? OR owner ILIKE ?", queryPattern, queryPattern)
+	}
+
+	conversations := new([]entities.Conversation)
+	if err := applyIndexFilters(query, params, filters).Find(conversations).Error; err != nil {
+		msg := fmt.Sprintf("cannot index conversations with params [%+#v] and filters [%+#v]", params, filters)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return conversations, nil
+}
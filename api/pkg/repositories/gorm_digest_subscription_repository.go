@@ -0,0 +1,173 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/NdoleStudio/discusswithai/pkg/entities"
+	"github.com/NdoleStudio/discusswithai/pkg/telemetry"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+	"gorm.io/gorm"
+)
+
+// gormDigestSubscriptionRepository is responsible for persisting entities.DigestSubscription
+type gormDigestSubscriptionRepository struct {
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+	db     *gorm.DB
+}
+
+// NewGormDigestSubscriptionRepository creates the GORM version of the DigestSubscriptionRepository
+func NewGormDigestSubscriptionRepository(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	db *gorm.DB,
+) DigestSubscriptionRepository {
+	return &gormDigestSubscriptionRepository{
+		logger: logger.WithService(fmt.Sprintf("%T", &gormDigestSubscriptionRepository{})),
+		tracer: tracer,
+		db:     db,
+	}
+}
+
+func (repository *gormDigestSubscriptionRepository) Save(ctx context.Context, subscription *entities.DigestSubscription) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	subscription.TenantID = tenantID(ctx, subscription.TenantID)
+	if err := repository.db.WithContext(ctx).Omit("DeliveredAt", "ClaimedAt", "MessageID").Save(subscription).Error; err != nil {
+		msg := fmt.Sprintf("cannot save digest subscription with ID [%s]", subscription.ID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+func (repository *gormDigestSubscriptionRepository) Load(ctx context.Context, subscriptionID uuid.UUID) (*entities.DigestSubscription, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	subscription := new(entities.DigestSubscription)
+	err := repository.db.WithContext(ctx).Scopes(scopeTenant(ctx)).First(subscription, subscriptionID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		msg := fmt.Sprintf("digest subscription with ID [%s] does not exist", subscriptionID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, ErrCodeNotFound, msg))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load digest subscription with ID [%s]", subscriptionID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return subscription, nil
+}
+
+func (repository *gormDigestSubscriptionRepository) LoadByTopic(ctx context.Context, userID uuid.UUID, topic entities.DigestTopic) (*entities.DigestSubscription, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	subscription := new(entities.DigestSubscription)
+	err := repository.db.WithContext(ctx).
+		Scopes(scopeTenant(ctx)).
+		Where("user_id = ?", userID).
+		Where("topic = ?", topic).
+		First(subscription).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		msg := fmt.Sprintf("digest subscription of user [%s] to topic [%s] does not exist", userID, topic)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, ErrCodeNotFound, msg))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load digest subscription of user [%s] to topic [%s]", userID, topic)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return subscription, nil
+}
+
+func (repository *gormDigestSubscriptionRepository) Index(ctx context.Context, userID uuid.UUID) (*[]entities.DigestSubscription, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	subscriptions := new([]entities.DigestSubscription)
+	err := repository.db.WithContext(ctx).
+		Scopes(scopeTenant(ctx)).
+		Where("user_id = ?", userID).
+		Order("created_at ASC").
+		Find(subscriptions).Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot index digest subscriptions of user [%s]", userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return subscriptions, nil
+}
+
+func (repository *gormDigestSubscriptionRepository) Due(ctx context.Context, before time.Time, limit int) (*[]entities.DigestSubscription, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	subscriptions := new([]entities.DigestSubscription)
+	err := repository.db.WithContext(ctx).
+		Scopes(scopeTenant(ctx)).
+		Where("next_delivery_at <= ?", before).
+		Order("next_delivery_at ASC").
+		Limit(limit).
+		Find(subscriptions).Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot fetch digest subscriptions which are due before [%s]", before)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return subscriptions, nil
+}
+
+func (repository *gormDigestSubscriptionRepository) Claim(ctx context.Context, subscriptionID uuid.UUID, deliveryAt time.Time, staleBefore time.Time) (bool, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	result := repository.db.WithContext(ctx).
+		Model(&entities.DigestSubscription{}).
+		Scopes(scopeTenant(ctx)).
+		Where("id = ?", subscriptionID).
+		Where("delivered_at IS NULL OR delivered_at < ?", deliveryAt).
+		Where("claimed_at IS NULL OR claimed_at < ?", staleBefore).
+		Update("claimed_at", time.Now().UTC())
+	if result.Error != nil {
+		msg := fmt.Sprintf("cannot claim digest subscription with ID [%s] for the delivery at [%s]", subscriptionID, deliveryAt)
+		return false, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(result.Error, msg))
+	}
+
+	return result.RowsAffected == 1, nil
+}
+
+func (repository *gormDigestSubscriptionRepository) UpdateDelivery(ctx context.Context, subscription *entities.DigestSubscription) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	err := repository.db.WithContext(ctx).
+		Model(subscription).
+		Select("DeliveredAt", "ClaimedAt", "MessageID").
+		Updates(subscription).Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot update the delivery of digest subscription with ID [%s]", subscription.ID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+func (repository *gormDigestSubscriptionRepository) Delete(ctx context.Context, subscription *entities.DigestSubscription) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	if err := repository.db.WithContext(ctx).Delete(subscription).Error; err != nil {
+		msg := fmt.Sprintf("cannot delete digest subscription with ID [%s]", subscription.ID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
@@ -0,0 +1,273 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/NdoleStudio/discusswithai/pkg/entities"
+	"github.com/NdoleStudio/discusswithai/pkg/knowledge"
+	"github.com/NdoleStudio/discusswithai/pkg/telemetry"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+	"gorm.io/gorm"
+)
+
+// gormKnowledgeRepository is responsible for persisting entities.KnowledgeBase.
+// The similarity search uses the embedding_vector column when pgvector is enabled and compares all the
+// embeddings of the knowledge base in memory otherwise.
+type gormKnowledgeRepository struct {
+	logger   telemetry.Logger
+	tracer   telemetry.Tracer
+	db       *gorm.DB
+	pgvector bool
+}
+
+// NewGormKnowledgeRepository creates the GORM version of the KnowledgeRepository
+func NewGormKnowledgeRepository(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	db *gorm.DB,
+	pgvector bool,
+) KnowledgeRepository {
+	return &gormKnowledgeRepository{
+		logger:   logger.WithService(fmt.Sprintf("%T", &gormKnowledgeRepository{})),
+		tracer:   tracer,
+		db:       db,
+		pgvector: pgvector,
+	}
+}
+
+func (repository *gormKnowledgeRepository) StoreBase(ctx context.Context, base *entities.KnowledgeBase) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	base.TenantID = tenantID(ctx, base.TenantID)
+	if err := repository.db.WithContext(ctx).Create(base).Error; err != nil {
+		msg := fmt.Sprintf("cannot save knowledge base with ID [%s]", base.ID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+func (repository *gormKnowledgeRepository) LoadBase(ctx context.Context, baseID uuid.UUID) (*entities.KnowledgeBase, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	base := new(entities.KnowledgeBase)
+	err := repository.db.WithContext(ctx).Scopes(scopeTenant(ctx)).First(base, baseID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		msg := fmt.Sprintf("knowledge base with ID [%s] does not exist", baseID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, ErrCodeNotFound, msg))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load knowledge base with ID [%s]", baseID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return base, nil
+}
+
+func (repository *gormKnowledgeRepository) LoadBaseFor(ctx context.Context, owner string, persona string) (*entities.KnowledgeBase, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	base := new(entities.KnowledgeBase)
+	err := repository.db.WithContext(ctx).
+		Scopes(scopeTenant(ctx)).
+		Where("(owner = ? AND owner <> '') OR (persona = ? AND persona <> '')", owner, persona).
+		Order("created_at DESC").
+		First(base).
+		Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		msg := fmt.Sprintf("there is no knowledge base for owner [%s] or persona [%s]", owner, telemetry.RedactBody(persona))
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, ErrCodeNotFound, msg))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load knowledge base for owner [%s] or persona [%s]", owner, telemetry.RedactBody(persona))
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return base, nil
+}
+
+func (repository *gormKnowledgeRepository) IndexBases(ctx context.Context, params IndexParams) (*[]entities.KnowledgeBase, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	query := repository.db.WithContext(ctx).Scopes(scopeTenant(ctx))
+	if len(params.Query) > 0 {
+		queryPattern := "%" + params.Query + "%"
+		query = query.Where("name ILIKE ? OR owner ILIKE ?", queryPattern, queryPattern)
+	}
+
+	bases := new([]entities.KnowledgeBase)
+	if err := query.Order("created_at DESC").Offset(params.Skip).Limit(params.Limit).Find(bases).Error; err != nil {
+		msg := fmt.Sprintf("cannot index knowledge bases with params [%+#v]", params)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return bases, nil
+}
+
+func (repository *gormKnowledgeRepository) StoreDocument(ctx context.Context, document *entities.KnowledgeDocument, chunks []entities.KnowledgeChunk) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	document.TenantID = tenantID(ctx, document.TenantID)
+	for i := range chunks {
+		chunks[i].TenantID = document.TenantID
+	}
+
+	err := repository.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(document).Error; err != nil {
+			return stacktrace.Propagate(err, fmt.Sprintf("cannot save knowledge document with ID [%s]", document.ID))
+		}
+
+		if len(chunks) == 0 {
+			return nil
+		}
+
+		if err := tx.CreateInBatches(chunks, 100).Error; err != nil {
+			return stacktrace.Propagate(err, fmt.Sprintf("cannot save [%d] chunks of knowledge document [%s]", len(chunks), document.ID))
+		}
+
+		if !repository.pgvector {
+			return nil
+		}
+
+		for _, chunk := range chunks {
+			vector := knowledge.VectorLiteral(knowledge.DecodeEmbedding(chunk.Embedding))
+			if err := tx.Exec("UPDATE knowledge_chunks SET embedding_vector = ?::vector WHERE id = ?", vector, chunk.ID).Error; err != nil {
+				return stacktrace.Propagate(err, fmt.Sprintf("cannot save the vector of knowledge chunk [%s]", chunk.ID))
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return repository.tracer.WrapErrorSpan(span, err)
+	}
+
+	return nil
+}
+
+func (repository *gormKnowledgeRepository) LoadDocument(ctx context.Context, documentID uuid.UUID) (*entities.KnowledgeDocument, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	document := new(entities.KnowledgeDocument)
+	err := repository.db.WithContext(ctx).Scopes(scopeTenant(ctx)).First(document, documentID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		msg := fmt.Sprintf("knowledge document with ID [%s] does not exist", documentID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, ErrCodeNotFound, msg))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load knowledge document with ID [%s]", documentID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return document, nil
+}
+
+func (repository *gormKnowledgeRepository) IndexDocuments(ctx context.Context, baseID uuid.UUID, params IndexParams) (*[]entities.KnowledgeDocument, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	query := repository.db.WithContext(ctx).Scopes(scopeTenant(ctx)).Where("knowledge_base_id = ?", baseID)
+	if len(params.Query) > 0 {
+		query = query.Where("name ILIKE ?", "%"+params.Query+"%")
+	}
+
+	documents := new([]entities.KnowledgeDocument)
+	if err := query.Order("created_at DESC").Offset(params.Skip).Limit(params.Limit).Find(documents).Error; err != nil {
+		msg := fmt.Sprintf("cannot index documents of knowledge base [%s] with params [%+#v]", baseID, params)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return documents, nil
+}
+
+func (repository *gormKnowledgeRepository) DeleteDocument(ctx context.Context, document *entities.KnowledgeDocument) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	err := repository.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Scopes(scopeTenant(ctx)).Where("document_id = ?", document.ID).Delete(&entities.KnowledgeChunk{}).Error; err != nil {
+			return stacktrace.Propagate(err, fmt.Sprintf("cannot delete the chunks of knowledge document [%s]", document.ID))
+		}
+		if err := tx.Scopes(scopeTenant(ctx)).Delete(document).Error; err != nil {
+			return stacktrace.Propagate(err, fmt.Sprintf("cannot delete knowledge document [%s]", document.ID))
+		}
+		return nil
+	})
+	if err != nil {
+		return repository.tracer.WrapErrorSpan(span, err)
+	}
+
+	return nil
+}
+
+func (repository *gormKnowledgeRepository) Search(ctx context.Context, baseID uuid.UUID, embedding []float32, limit int) ([]KnowledgeMatch, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	if repository.pgvector {
+		return repository.searchVector(ctx, baseID, embedding, limit)
+	}
+
+	var chunks []KnowledgeMatch
+	err := repository.db.WithContext(ctx).
+		Model(&entities.KnowledgeChunk{}).
+		Scopes(scopeTenant(ctx)).
+		Select("knowledge_chunks.*, knowledge_documents.name AS document_name").
+		Joins("JOIN knowledge_documents ON knowledge_documents.id = knowledge_chunks.document_id").
+		Where("knowledge_chunks.knowledge_base_id = ?", baseID).
+		Scan(&chunks).
+		Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot load the chunks of knowledge base [%s]", baseID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	for i := range chunks {
+		chunks[i].Score = knowledge.Cosine(embedding, knowledge.DecodeEmbedding(chunks[i].Embedding))
+	}
+	sort.SliceStable(chunks, func(i, j int) bool {
+		return chunks[i].Score > chunks[j].Score
+	})
+
+	if len(chunks) > limit {
+		chunks = chunks[:limit]
+	}
+	return chunks, nil
+}
+
+func (repository *gormKnowledgeRepository) searchVector(ctx context.Context, baseID uuid.UUID, embedding []float32, limit int) ([]KnowledgeMatch, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	vector := knowledge.VectorLiteral(embedding)
+
+	var chunks []KnowledgeMatch
+	err := repository.db.WithContext(ctx).
+		Model(&entities.KnowledgeChunk{}).
+		Scopes(scopeTenant(ctx)).
+		Select("knowledge_chunks.*, knowledge_documents.name AS document_name, 1 - (knowledge_chunks.embedding_vector <=> ?::vector) AS score", vector).
+		Joins("JOIN knowledge_documents ON knowledge_documents.id = knowledge_chunks.document_id").
+		Where("knowledge_chunks.knowledge_base_id = ? AND knowledge_chunks.embedding_vector IS NOT NULL", baseID).
+		Order(gorm.Expr("knowledge_chunks.embedding_vector <=> ?::vector", vector)).
+		Limit(limit).
+		Scan(&chunks).
+		Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot search the chunks of knowledge base [%s] with pgvector", baseID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return chunks, nil
+}
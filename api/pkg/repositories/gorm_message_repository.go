@@ -0,0 +1,213 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/NdoleStudio/discusswithai/pkg/entities"
+	"github.com/NdoleStudio/discusswithai/pkg/telemetry"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+	"gorm.io/gorm"
+)
+
+// gormMessageRepository is responsible for persisting entities.Message
+type gormMessageRepository struct {
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+	db     *gorm.DB
+}
+
+// NewGormMessageRepository creates the GORM version of the MessageRepository
+func NewGormMessageRepository(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	db *gorm.DB,
+) MessageRepository {
+	return &gormMessageRepository{
+		logger: logger.WithService(fmt.Sprintf("%T", &gormMessageRepository{})),
+		tracer: tracer,
+		db:     db,
+	}
+}
+
+func (repository *gormMessageRepository) Store(ctx context.Context, message *entities.Message) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	message.TenantID = tenantID(ctx, message.TenantID)
+	if err := repository.db.WithContext(ctx).Create(message).Error; err != nil {
+		msg := fmt.Sprintf("cannot save message with ID [%s]", message.ID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+func (repository *gormMessageRepository) Update(ctx context.Context, message *entities.Message) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	if err := repository.db.WithContext(ctx).Save(message).Error; err != nil {
+		msg := fmt.Sprintf("cannot update message with ID [%s]", message.ID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+func (repository *gormMessageRepository) Load(ctx context.Context, messageID uuid.UUID) (*entities.Message, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	message := new(entities.Message)
+	err := repository.db.WithContext(ctx).Scopes(scopeTenant(ctx)).First(message, messageID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		msg := fmt.Sprintf("message with ID [%s] does not exist", messageID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, ErrCodeNotFound, msg))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load message with ID [%s]", messageID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return message, nil
+}
+
+func (repository *gormMessageRepository) LoadLatest(ctx context.Context, channel entities.Channel, channelID string, role entities.MessageRole) (*entities.Message, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	message := new(entities.Message)
+	err := repository.db.WithContext(ctx).
+		Scopes(scopeTenant(ctx)).
+		Where("channel = ?", channel).
+		Where("channel_id = ?", channelID).
+		Where("role = ?", role).
+		Order("created_at DESC").
+		First(message).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		msg := fmt.Sprintf("there is no [%s] message for channel [%s] and channel ID [%s]", role, channel, channelID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, ErrCodeNotFound, msg))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load latest [%s] message for channel [%s] and channel ID [%s]", role, channel, channelID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return message, nil
+}
+
+func (repository *gormMessageRepository) LoadLatestInConversation(ctx context.Context, conversationID uuid.UUID, role entities.MessageRole) (*entities.Message, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	message := new(entities.Message)
+	err := repository.db.WithContext(ctx).
+		Scopes(scopeTenant(ctx)).
+		Where("conversation_id = ?", conversationID).
+		Where("role = ?", role).
+		Order("created_at DESC").
+		First(message).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		msg := fmt.Sprintf("there is no [%s] message in conversation [%s]", role, conversationID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, ErrCodeNotFound, msg))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load latest [%s] message in conversation [%s]", role, conversationID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return message, nil
+}
+
+func (repository *gormMessageRepository) History(ctx context.Context, conversationID uuid.UUID, after time.Time, limit int) (*[]entities.Message, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	messages := new([]entities.Message)
+	err := repository.db.WithContext(ctx).
+		Scopes(scopeTenant(ctx)).
+		Where("conversation_id = ?", conversationID).
+		Where("role IN ?", []entities.MessageRole{entities.MessageRoleUser, entities.MessageRoleAssistant}).
+		Where("status <> ?", entities.MessageStatusFailed).
+		Where("created_at > ?", after).
+		Order("created_at DESC").
+		Limit(limit).
+		Find(messages).Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot load the history of conversation [%s] after [%s]", conversationID, after)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	for i, j := 0, len(*messages)-1; i < j; i, j = i+1, j-1 {
+		(*messages)[i], (*messages)[j] = (*messages)[j], (*messages)[i]
+	}
+
+	return messages, nil
+}
+
+func (repository *gormMessageRepository) HistoryPage(ctx context.Context, conversationID uuid.UUID, after time.Time, until time.Time, limit int) (*[]entities.Message, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	messages := new([]entities.Message)
+	err := repository.db.WithContext(ctx).
+		Scopes(scopeTenant(ctx)).
+		Where("conversation_id = ?", conversationID).
+		Where("role IN ?", []entities.MessageRole{entities.MessageRoleUser, entities.MessageRoleAssistant}).
+		Where("status <> ?", entities.MessageStatusFailed).
+		Where("created_at > ?", after).
+		Where("created_at <= ?", until).
+		Order("created_at ASC").
+		Limit(limit).
+		Find(messages).Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot load the history of conversation [%s] between [%s] and [%s]", conversationID, after, until)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return messages, nil
+}
+
+func (repository *gormMessageRepository) CountSince(ctx context.Context, role entities.MessageRole, since time.Time) (int64, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	var count int64
+	err := repository.db.WithContext(ctx).
+		Model(&entities.Message{}).
+		Scopes(scopeTenant(ctx)).
+		Where("role = ?", role).
+		Where("created_at >= ?", since).
+		Count(&count).Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot count [%s] messages since [%s]", role, since)
+		return 0, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return count, nil
+}
+
+func (repository *gormMessageRepository) Index(ctx context.Context, params IndexParams, filters IndexFilters) (*[]entities.Message, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	query := repository.db.WithContext(ctx).Scopes(scopeTenant(ctx))
+	if len(params.Query) > 0 {
+		query = query.Where("content ILIKE ?", "%"+params.Query+"%")
+	}
+
+	messages := new([]entities.Message)
+	if err := applyIndexFilters(query, params, filters).Find(messages).Error; err != nil {
+		msg := fmt.Sprintf("cannot index messages with params [%+#v] and filters [%+#v]", params, filters)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return messages, nil
+}
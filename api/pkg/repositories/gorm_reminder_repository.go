@@ -0,0 +1,115 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/NdoleStudio/discusswithai/pkg/entities"
+	"github.com/NdoleStudio/discusswithai/pkg/telemetry"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+	"gorm.io/gorm"
+)
+
+// gormReminderRepository is responsible for persisting entities.Reminder
+type gormReminderRepository struct {
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+	db     *gorm.DB
+}
+
+// NewGormReminderRepository creates the GORM version of the ReminderRepository
+func NewGormReminderRepository(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	db *gorm.DB,
+) ReminderRepository {
+	return &gormReminderRepository{
+		logger: logger.WithService(fmt.Sprintf("%T", &gormReminderRepository{})),
+		tracer: tracer,
+		db:     db,
+	}
+}
+
+func (repository *gormReminderRepository) Store(ctx context.Context, reminder *entities.Reminder) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	reminder.TenantID = tenantID(ctx, reminder.TenantID)
+	if err := repository.db.WithContext(ctx).Create(reminder).Error; err != nil {
+		msg := fmt.Sprintf("cannot save reminder with ID [%s]", reminder.ID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+func (repository *gormReminderRepository) Update(ctx context.Context, reminder *entities.Reminder) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	if err := repository.db.WithContext(ctx).Save(reminder).Error; err != nil {
+		msg := fmt.Sprintf("cannot update reminder with ID [%s]", reminder.ID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+func (repository *gormReminderRepository) Load(ctx context.Context, reminderID uuid.UUID) (*entities.Reminder, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	reminder := new(entities.Reminder)
+	err := repository.db.WithContext(ctx).Scopes(scopeTenant(ctx)).First(reminder, reminderID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		msg := fmt.Sprintf("reminder with ID [%s] does not exist", reminderID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, ErrCodeNotFound, msg))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load reminder with ID [%s]", reminderID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return reminder, nil
+}
+
+func (repository *gormReminderRepository) Claim(ctx context.Context, reminderID uuid.UUID, staleBefore time.Time) (bool, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	result := repository.db.WithContext(ctx).
+		Model(&entities.Reminder{}).
+		Scopes(scopeTenant(ctx)).
+		Where("id = ?", reminderID).
+		Where("status = ? OR (status = ? AND updated_at < ?)", entities.ReminderStatusScheduled, entities.ReminderStatusSending, staleBefore).
+		Updates(map[string]any{"status": entities.ReminderStatusSending, "updated_at": time.Now().UTC()})
+	if result.Error != nil {
+		msg := fmt.Sprintf("cannot claim reminder with ID [%s]", reminderID)
+		return false, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(result.Error, msg))
+	}
+
+	return result.RowsAffected == 1, nil
+}
+
+func (repository *gormReminderRepository) Scheduled(ctx context.Context, userID uuid.UUID) (*[]entities.Reminder, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	reminders := new([]entities.Reminder)
+	err := repository.db.WithContext(ctx).
+		Scopes(scopeTenant(ctx)).
+		Where("user_id = ?", userID).
+		Where("status = ?", entities.ReminderStatusScheduled).
+		Order("remind_at ASC").
+		Find(reminders).Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot fetch scheduled reminders for user [%s]", userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return reminders, nil
+}
@@ -0,0 +1,53 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/NdoleStudio/discusswithai/pkg/tenancy"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// applyIndexFilters adds the IndexFilters and IndexParams to a query
+func applyIndexFilters(query *gorm.DB, params IndexParams, filters IndexFilters) *gorm.DB {
+	if filters.Channel != "" {
+		query = query.Where("channel = ?", filters.Channel)
+	}
+	if filters.ChannelID != "" {
+		query = query.Where("channel_id = ?", filters.ChannelID)
+	}
+	if filters.From != nil {
+		query = query.Where("created_at >= ?", *filters.From)
+	}
+	if filters.To != nil {
+		query = query.Where("created_at <= ?", *filters.To)
+	}
+	return query.Order("created_at DESC").Offset(params.Skip).Limit(params.Limit)
+}
+
+// scopeTenant restricts a query to the rows of the tenant of the context.
+// The query is not restricted when the context can access all the tenants e.g. for admin requests, and it fails with
+// ErrCodeTenantNotScoped when the context has no tenant so that a missing scope never exposes the data of other tenants.
+func scopeTenant(ctx context.Context) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if tenantID, ok := tenancy.ID(ctx); ok {
+			return db.Where(clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: "tenant_id"}, Value: tenantID})
+		}
+
+		if !tenancy.IsAllTenants(ctx) {
+			_ = db.AddError(stacktrace.NewErrorWithCode(ErrCodeTenantNotScoped, "the context is not scoped to a tenant"))
+		}
+		return db
+	}
+}
+
+// tenantID returns the ID of the tenant of the context for an entity which is stored.
+// The current tenant ID of the entity is kept when the context is not scoped to a tenant.
+func tenantID(ctx context.Context, current uuid.UUID) uuid.UUID {
+	if id, ok := tenancy.ID(ctx); ok {
+		return id
+	}
+	return current
+}
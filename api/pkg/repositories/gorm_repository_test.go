@@ -0,0 +1,187 @@
+package repositories
+
+import (
+	"context"
+	"testing"
+
+	"github.com/NdoleStudio/discusswithai/pkg/entities"
+	"github.com/NdoleStudio/discusswithai/pkg/telemetry"
+	"github.com/NdoleStudio/discusswithai/pkg/tenancy"
+	"github.com/google/uuid"
+	"github.com/hirosassa/zerodriver"
+	"github.com/palantir/stacktrace"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// testLogger is created once because zerodriver.NewDevelopmentLogger sets the global log level
+var testLogger = telemetry.NewZerologLogger("test", map[string]string{}, zerodriver.NewDevelopmentLogger(), nil)
+
+func TestScopeTenant(t *testing.T) {
+	t.Run("an integrator of another tenant cannot load a message", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		db, query := newDryRunDB(t)
+		repository := NewGormMessageRepository(testLogger, telemetry.NewOtelLogger("test", testLogger), db)
+
+		// Arrange
+		otherTenant := &entities.Tenant{ID: uuid.New()}
+		ctx := tenancy.WithTenant(context.Background(), otherTenant)
+
+		// Act
+		_, err := repository.Load(ctx, uuid.New())
+
+		// Assert
+		assert.Nil(t, err)
+		assert.Contains(t, query.sql, `"messages"."tenant_id" = $2`)
+		assert.Equal(t, otherTenant.ID, query.vars[1])
+	})
+
+	t.Run("the webhooks of other tenants are not loaded for an event", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		db, query := newDryRunDB(t)
+		repository := NewGormWebhookRepository(testLogger, telemetry.NewOtelLogger("test", testLogger), db)
+
+		// Arrange
+		tenantID := uuid.New()
+
+		// Act
+		_, err := repository.LoadByEvent(tenancy.WithAllTenants(context.Background()), tenantID, "message.received")
+
+		// Assert
+		assert.Nil(t, err)
+		assert.Contains(t, query.sql, "tenant_id = $1")
+		assert.Equal(t, tenantID, query.vars[0])
+	})
+
+	t.Run("the API keys of other tenants are not listed", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		db, query := newDryRunDB(t)
+		repository := NewGormAPIKeyRepository(testLogger, telemetry.NewOtelLogger("test", testLogger), db)
+
+		// Arrange
+		ctx := tenancy.WithTenant(context.Background(), nil)
+
+		// Act
+		_, err := repository.Index(ctx, IndexParams{Limit: 10})
+
+		// Assert
+		assert.Nil(t, err)
+		assert.Contains(t, query.sql, `"api_keys"."tenant_id" = $1`)
+		assert.Equal(t, uuid.Nil, query.vars[0])
+	})
+
+	t.Run("the deliveries of other tenants are not listed", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		db, query := newDryRunDB(t)
+		repository := NewGormWebhookDeliveryRepository(testLogger, telemetry.NewOtelLogger("test", testLogger), db)
+
+		// Arrange
+		tenant := &entities.Tenant{ID: uuid.New()}
+		ctx := tenancy.WithTenant(context.Background(), tenant)
+
+		// Act
+		_, err := repository.Index(ctx, uuid.New(), IndexParams{Limit: 10})
+
+		// Assert
+		assert.Nil(t, err)
+		assert.Contains(t, query.sql, `"webhook_deliveries"."tenant_id" = $2`)
+		assert.Equal(t, tenant.ID, query.vars[1])
+	})
+
+	t.Run("the documents of knowledge bases of other tenants are not loaded", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		db, query := newDryRunDB(t)
+		repository := NewGormKnowledgeRepository(testLogger, telemetry.NewOtelLogger("test", testLogger), db, false)
+
+		// Arrange
+		tenant := &entities.Tenant{ID: uuid.New()}
+		ctx := tenancy.WithTenant(context.Background(), tenant)
+
+		// Act
+		_, err := repository.LoadDocument(ctx, uuid.New())
+
+		// Assert
+		assert.Nil(t, err)
+		assert.Contains(t, query.sql, `"knowledge_documents"."tenant_id" = $2`)
+		assert.Equal(t, tenant.ID, query.vars[1])
+	})
+
+	t.Run("the documents of knowledge bases of other tenants are not listed", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		db, query := newDryRunDB(t)
+		repository := NewGormKnowledgeRepository(testLogger, telemetry.NewOtelLogger("test", testLogger), db, false)
+
+		// Arrange
+		tenant := &entities.Tenant{ID: uuid.New()}
+		ctx := tenancy.WithTenant(context.Background(), tenant)
+
+		// Act
+		_, err := repository.IndexDocuments(ctx, uuid.New(), IndexParams{Limit: 10})
+
+		// Assert
+		assert.Nil(t, err)
+		assert.Contains(t, query.sql, `"knowledge_documents"."tenant_id" = $2`)
+		assert.Equal(t, tenant.ID, query.vars[1])
+	})
+
+	t.Run("a context for all the tenants is not restricted", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		db, query := newDryRunDB(t)
+		repository := NewGormMessageRepository(testLogger, telemetry.NewOtelLogger("test", testLogger), db)
+
+		// Act
+		_, err := repository.Load(tenancy.WithAllTenants(context.Background()), uuid.New())
+
+		// Assert
+		assert.Nil(t, err)
+		assert.NotContains(t, query.sql, "tenant_id")
+	})
+
+	t.Run("a context without a tenant cannot load a message", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		db, query := newDryRunDB(t)
+		repository := NewGormMessageRepository(testLogger, telemetry.NewOtelLogger("test", testLogger), db)
+
+		// Act
+		message, err := repository.Load(context.Background(), uuid.New())
+
+		// Assert
+		assert.Nil(t, message)
+		assert.Equal(t, ErrCodeTenantNotScoped, stacktrace.GetCode(err))
+		assert.Empty(t, query.sql)
+	})
+}
+
+// dryRunQuery is the last query which was built by a dry run gorm.DB
+type dryRunQuery struct {
+	sql  string
+	vars []any
+}
+
+// newDryRunDB creates a gorm.DB which builds the postgres queries without sending them to a database
+func newDryRunDB(t *testing.T) (*gorm.DB, *dryRunQuery) {
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost"}), &gorm.Config{DryRun: true, DisableAutomaticPing: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	query := new(dryRunQuery)
+	err = db.Callback().Query().After("gorm:query").Register("test:capture", func(db *gorm.DB) {
+		query.sql = db.Statement.SQL.String()
+		query.vars = db.Statement.Vars
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return db, query
+}
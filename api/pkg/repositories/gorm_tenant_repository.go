@@ -0,0 +1,146 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/NdoleStudio/discusswithai/pkg/entities"
+	"github.com/NdoleStudio/discusswithai/pkg/telemetry"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+	"gorm.io/gorm"
+)
+
+// gormTenantRepository is responsible for persisting entities.Tenant
+type gormTenantRepository struct {
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+	db     *gorm.DB
+}
+
+// NewGormTenantRepository creates the GORM version of the TenantRepository
+func NewGormTenantRepository(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	db *gorm.DB,
+) TenantRepository {
+	return &gormTenantRepository{
+		logger: logger.WithService(fmt.Sprintf("%T", &gormTenantRepository{})),
+		tracer: tracer,
+		db:     db,
+	}
+}
+
+func (repository *gormTenantRepository) Store(ctx context.Context, tenant *entities.Tenant) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	if err := repository.db.WithContext(ctx).Create(tenant).Error; err != nil {
+		msg := fmt.Sprintf("cannot save tenant with ID [%s]", tenant.ID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+func (repository *gormTenantRepository) Update(ctx context.Context, tenant *entities.Tenant) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	if err := repository.db.WithContext(ctx).Save(tenant).Error; err != nil {
+		msg := fmt.Sprintf("cannot update tenant with ID [%s]", tenant.ID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+func (repository *gormTenantRepository) Load(ctx context.Context, tenantID uuid.UUID) (*entities.Tenant, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	tenant := new(entities.Tenant)
+	err := repository.db.WithContext(ctx).First(tenant, tenantID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		msg := fmt.Sprintf("tenant with ID [%s] does not exist", tenantID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, ErrCodeNotFound, msg))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load tenant with ID [%s]", tenantID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return tenant, nil
+}
+
+func (repository *gormTenantRepository) LoadByNumber(ctx context.Context, channel entities.Channel, number string) (*entities.Tenant, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	tenant := new(entities.Tenant)
+	err := repository.db.WithContext(ctx).
+		Joins("JOIN tenant_numbers ON tenant_numbers.tenant_id = tenants.id").
+		Where("tenant_numbers.channel = ?", channel).
+		Where("tenant_numbers.number = ?", number).
+		First(tenant).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		msg := fmt.Sprintf("there is no tenant for number [%s] on channel [%s]", number, channel)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, ErrCodeNotFound, msg))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load tenant for number [%s] on channel [%s]", number, channel)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return tenant, nil
+}
+
+func (repository *gormTenantRepository) Index(ctx context.Context, params IndexParams) (*[]entities.Tenant, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	query := repository.db.WithContext(ctx)
+	if len(params.Query) > 0 {
+		query = query.Where("name ILIKE ?", "%"+params.Query+"%")
+	}
+
+	tenants := new([]entities.Tenant)
+	if err := query.Order("created_at DESC").Offset(params.Skip).Limit(params.Limit).Find(tenants).Error; err != nil {
+		msg := fmt.Sprintf("cannot index tenants with params [%+#v]", params)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return tenants, nil
+}
+
+func (repository *gormTenantRepository) StoreNumber(ctx context.Context, number *entities.TenantNumber) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	if err := repository.db.WithContext(ctx).Create(number).Error; err != nil {
+		msg := fmt.Sprintf("cannot save number [%s] on channel [%s] for tenant [%s]", number.Number, number.Channel, number.TenantID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+func (repository *gormTenantRepository) IndexNumbers(ctx context.Context, tenantID uuid.UUID) (*[]entities.TenantNumber, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	numbers := new([]entities.TenantNumber)
+	err := repository.db.WithContext(ctx).
+		Where("tenant_id = ?", tenantID).
+		Order("created_at ASC").
+		Find(numbers).Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot index the numbers of tenant [%s]", tenantID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return numbers, nil
+}
@@ -1,123 +1,123 @@
 package repositories
 
-//// gormUserRepository is responsible for persisting entities.User
-//type gormUserRepository struct {
-//	logger telemetry.Logger
-//	tracer telemetry.Tracer
-//	db     *gorm.DB
-//}
-//
-//// NewGormUserRepository creates the GORM version of the UserRepository
-//func NewGormUserRepository(
-//	logger telemetry.Logger,
-//	tracer telemetry.Tracer,
-//	db *gorm.DB,
-//) UserRepository {
-//	return &gormUserRepository{
-//		logger: logger.WithService(fmt.Sprintf("%T", &gormUserRepository{})),
-//		tracer: tracer,
-//		db:     db,
-//	}
-//}
-//
-//func (repository *gormUserRepository) LoadBySubscriptionID(ctx context.Context, subscriptionID string) (*entities.User, error) {
-//	ctx, span := repository.tracer.Start(ctx)
-//	defer span.End()
-//
-//	user := new(entities.User)
-//	err := repository.db.WithContext(ctx).
-//		Where("subscription_id = ?", subscriptionID).
-//		First(user).
-//		Error
-//	if errors.Is(err, gorm.ErrRecordNotFound) {
-//		msg := fmt.Sprintf("user with subscriptionID [%s] does not exist", subscriptionID)
-//		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, ErrCodeNotFound, msg))
-//	}
-//
-//	if err != nil {
-//		msg := fmt.Sprintf("cannot load subscription with ID [%s]", subscriptionID)
-//		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
-//	}
-//
-//	return user, nil
-//}
-//
-//func (repository *gormUserRepository) Store(ctx context.Context, user *entities.User) error {
-//	ctx, span := repository.tracer.Start(ctx)
-//	defer span.End()
-//
-//	if err := repository.db.WithContext(ctx).Create(user).Error; err != nil {
-//		msg := fmt.Sprintf("cannot save user with ID [%s]", user.ID)
-//		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
-//	}
-//
-//	return nil
-//}
-//
-//func (repository *gormUserRepository) Update(ctx context.Context, user *entities.User) error {
-//	ctx, span := repository.tracer.Start(ctx)
-//	defer span.End()
-//
-//	if err := repository.db.WithContext(ctx).Save(user).Error; err != nil {
-//		msg := fmt.Sprintf("cannot update user with ID [%s]", user.ID)
-//		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
-//	}
-//
-//	return nil
-//}
-//
-//func (repository *gormUserRepository) Load(ctx context.Context, userID entities.UserID) (*entities.User, error) {
-//	ctx, span := repository.tracer.Start(ctx)
-//	defer span.End()
-//
-//	user := new(entities.User)
-//	err := repository.db.WithContext(ctx).First(user, userID).Error
-//	if errors.Is(err, gorm.ErrRecordNotFound) {
-//		msg := fmt.Sprintf("user with ID [%s] does not exist", user.ID)
-//		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, ErrCodeNotFound, msg))
-//	}
-//
-//	if err != nil {
-//		msg := fmt.Sprintf("cannot load user with ID [%s]", userID)
-//		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
-//	}
-//
-//	return user, nil
-//}
-//
-//func (repository *gormUserRepository) LoadOrStore(ctx context.Context, authUser entities.AuthUser) (user *entities.User, created bool, err error) {
-//	ctx, span := repository.tracer.Start(ctx)
-//	defer span.End()
-//
-//	err = crdbgorm.ExecuteTx(ctx, repository.db, nil, func(tx *gorm.DB) error {
-//		user = new(entities.User)
-//		err = tx.First(user, authUser.ID).Error
-//		if err == nil {
-//			return nil
-//		}
-//
-//		if !errors.Is(err, gorm.ErrRecordNotFound) {
-//			msg := fmt.Sprintf("cannot check if user exists with ID [%s]", authUser.ID)
-//			return stacktrace.Propagate(err, msg)
-//		}
-//
-//		user = &entities.User{
-//			ID:               authUser.ID,
-//			Email:            authUser.Email,
-//			SubscriptionName: entities.SubscriptionNameFree,
-//			Name:             authUser.Name,
-//			CreatedAt:        time.Now().UTC(),
-//			UpdatedAt:        time.Now().UTC(),
-//		}
-//		created = true
-//
-//		return tx.Where(entities.User{ID: user.ID}).Create(user).Error
-//	})
-//
-//	if err != nil {
-//		msg := fmt.Sprintf("cannot lod or create user from auth user [%+#v]", authUser)
-//		return user, created, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
-//	}
-//
-//	return user, created, nil
-//}
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/NdoleStudio/discusswithai/pkg/entities"
+	"github.com/NdoleStudio/discusswithai/pkg/telemetry"
+	"github.com/palantir/stacktrace"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// gormUserRepository is responsible for persisting entities.User
+type gormUserRepository struct {
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+	db     *gorm.DB
+}
+
+// NewGormUserRepository creates the GORM version of the UserRepository
+func NewGormUserRepository(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	db *gorm.DB,
+) UserRepository {
+	return &gormUserRepository{
+		logger: logger.WithService(fmt.Sprintf("%T", &gormUserRepository{})),
+		tracer: tracer,
+		db:     db,
+	}
+}
+
+func (repository *gormUserRepository) Update(ctx context.Context, user *entities.User) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	if err := repository.db.WithContext(ctx).Save(user).Error; err != nil {
+		msg := fmt.Sprintf("cannot update user with ID [%s]", user.ID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+func (repository *gormUserRepository) RecordViolation(ctx context.Context, user *entities.User) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	err := repository.db.WithContext(ctx).
+		Model(user).
+		Clauses(clause.Returning{Columns: []clause.Column{{Name: "moderation_violations"}, {Name: "flagged_at"}, {Name: "updated_at"}}}).
+		UpdateColumns(map[string]any{
+			"moderation_violations": gorm.Expr("moderation_violations + 1"),
+			"updated_at":            time.Now().UTC(),
+		}).
+		Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot record moderation violation for user with ID [%s]", user.ID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+func (repository *gormUserRepository) Flag(ctx context.Context, user *entities.User) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	timestamp := time.Now().UTC()
+	result := repository.db.WithContext(ctx).
+		Model(user).
+		Where("flagged_at IS NULL").
+		UpdateColumns(map[string]any{"flagged_at": timestamp, "updated_at": timestamp})
+	if result.Error != nil {
+		msg := fmt.Sprintf("cannot flag user with ID [%s]", user.ID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(result.Error, msg))
+	}
+
+	user.FlaggedAt = &timestamp
+	return nil
+}
+
+func (repository *gormUserRepository) LoadOrStore(ctx context.Context, user *entities.User) (*entities.User, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	user.TenantID = tenantID(ctx, user.TenantID)
+
+	result := new(entities.User)
+	err := repository.db.WithContext(ctx).
+		Where("tenant_id = ?", user.TenantID).
+		Where(entities.User{Channel: user.Channel, ChannelID: user.ChannelID}).
+		Attrs(user).
+		FirstOrCreate(result).
+		Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot load or store user with channel [%s] and channel ID [%s]", user.Channel, user.ChannelID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return result, nil
+}
+
+func (repository *gormUserRepository) Index(ctx context.Context, params IndexParams, filters IndexFilters) (*[]entities.User, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	query := repository.db.WithContext(ctx).Scopes(scopeTenant(ctx))
+	if len(params.Query) > 0 {
+		queryPattern := "%" + params.Query + "%"
+		query = query.Where("name ILIKE ? OR channel_id ILIKE ?", queryPattern, queryPattern)
+	}
+
+	users := new([]entities.User)
+	if err := applyIndexFilters(query, params, filters).Find(users).Error; err != nil {
+		msg := fmt.Sprintf("cannot index users with params [%+#v] and filters [%+#v]", params, filters)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return users, nil
+}
@@ -0,0 +1,95 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/NdoleStudio/discusswithai/pkg/entities"
+	"github.com/NdoleStudio/discusswithai/pkg/telemetry"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+	"gorm.io/gorm"
+)
+
+// gormWebhookDeliveryRepository is responsible for persisting entities.WebhookDelivery
+type gormWebhookDeliveryRepository struct {
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+	db     *gorm.DB
+}
+
+// NewGormWebhookDeliveryRepository creates the GORM version of the WebhookDeliveryRepository
+func NewGormWebhookDeliveryRepository(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	db *gorm.DB,
+) WebhookDeliveryRepository {
+	return &gormWebhookDeliveryRepository{
+		logger: logger.WithService(fmt.Sprintf("%T", &gormWebhookDeliveryRepository{})),
+		tracer: tracer,
+		db:     db,
+	}
+}
+
+func (repository *gormWebhookDeliveryRepository) Store(ctx context.Context, delivery *entities.WebhookDelivery) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	delivery.TenantID = tenantID(ctx, delivery.TenantID)
+	if err := repository.db.WithContext(ctx).Create(delivery).Error; err != nil {
+		msg := fmt.Sprintf("cannot save webhook delivery with ID [%s]", delivery.ID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+func (repository *gormWebhookDeliveryRepository) Update(ctx context.Context, delivery *entities.WebhookDelivery) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	if err := repository.db.WithContext(ctx).Scopes(scopeTenant(ctx)).Save(delivery).Error; err != nil {
+		msg := fmt.Sprintf("cannot update webhook delivery with ID [%s]", delivery.ID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+func (repository *gormWebhookDeliveryRepository) Load(ctx context.Context, deliveryID uuid.UUID) (*entities.WebhookDelivery, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	delivery := new(entities.WebhookDelivery)
+	err := repository.db.WithContext(ctx).Scopes(scopeTenant(ctx)).First(delivery, deliveryID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		msg := fmt.Sprintf("webhook delivery with ID [%s] does not exist", deliveryID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, ErrCodeNotFound, msg))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load webhook delivery with ID [%s]", deliveryID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return delivery, nil
+}
+
+func (repository *gormWebhookDeliveryRepository) Index(ctx context.Context, webhookID uuid.UUID, params IndexParams) (*[]entities.WebhookDelivery, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	query := repository.db.WithContext(ctx).Scopes(scopeTenant(ctx)).Where("webhook_id = ?", webhookID)
+	if len(params.Query) > 0 {
+		query = query.Where("event_type ILIKE ?", "%"+params.Query+"%")
+	}
+
+	deliveries := new([]entities.WebhookDelivery)
+	if err := query.Order("created_at DESC").Offset(params.Skip).Limit(params.Limit).Find(deliveries).Error; err != nil {
+		msg := fmt.Sprintf("cannot index deliveries for webhook [%s] with params [%+#v]", webhookID, params)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return deliveries, nil
+}
@@ -0,0 +1,124 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/NdoleStudio/discusswithai/pkg/entities"
+	"github.com/NdoleStudio/discusswithai/pkg/telemetry"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+	"gorm.io/gorm"
+)
+
+// gormWebhookRepository is responsible for persisting entities.Webhook
+type gormWebhookRepository struct {
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+	db     *gorm.DB
+}
+
+// NewGormWebhookRepository creates the GORM version of the WebhookRepository
+func NewGormWebhookRepository(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	db *gorm.DB,
+) WebhookRepository {
+	return &gormWebhookRepository{
+		logger: logger.WithService(fmt.Sprintf("%T", &gormWebhookRepository{})),
+		tracer: tracer,
+		db:     db,
+	}
+}
+
+func (repository *gormWebhookRepository) Store(ctx context.Context, webhook *entities.Webhook) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	webhook.TenantID = tenantID(ctx, webhook.TenantID)
+	if err := repository.db.WithContext(ctx).Create(webhook).Error; err != nil {
+		msg := fmt.Sprintf("cannot save webhook with ID [%s]", webhook.ID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+func (repository *gormWebhookRepository) Load(ctx context.Context, webhookID uuid.UUID) (*entities.Webhook, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	webhook := new(entities.Webhook)
+	err := repository.db.WithContext(ctx).Scopes(scopeTenant(ctx)).First(webhook, webhookID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		msg := fmt.Sprintf("webhook with ID [%s] does not exist", webhookID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, ErrCodeNotFound, msg))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load webhook with ID [%s]", webhookID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return webhook, nil
+}
+
+func (repository *gormWebhookRepository) Index(ctx context.Context, apiKeyID *uuid.UUID, params IndexParams) (*[]entities.Webhook, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	query := repository.db.WithContext(ctx).Scopes(scopeTenant(ctx))
+	if apiKeyID != nil {
+		query = query.Where("api_key_id = ?", *apiKeyID)
+	}
+	if len(params.Query) > 0 {
+		query = query.Where("url ILIKE ?", "%"+params.Query+"%")
+	}
+
+	webhooks := new([]entities.Webhook)
+	if err := query.Order("created_at DESC").Offset(params.Skip).Limit(params.Limit).Find(webhooks).Error; err != nil {
+		msg := fmt.Sprintf("cannot index webhooks with params [%+#v]", params)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return webhooks, nil
+}
+
+func (repository *gormWebhookRepository) LoadByEvent(ctx context.Context, tenantID uuid.UUID, eventType string) (*[]entities.Webhook, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	events, err := json.Marshal([]string{eventType})
+	if err != nil {
+		msg := fmt.Sprintf("cannot marshal event [%s] into JSON", eventType)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	webhooks := new([]entities.Webhook)
+	err = repository.db.WithContext(ctx).
+		Scopes(scopeTenant(ctx)).
+		Where("tenant_id = ?", tenantID).
+		Where("events::jsonb @> ?::jsonb", string(events)).
+		Find(webhooks).
+		Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot load webhooks of tenant [%s] for event [%s]", tenantID, eventType)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return webhooks, nil
+}
+
+func (repository *gormWebhookRepository) Delete(ctx context.Context, webhook *entities.Webhook) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	if err := repository.db.WithContext(ctx).Scopes(scopeTenant(ctx)).Delete(webhook).Error; err != nil {
+		msg := fmt.Sprintf("cannot delete webhook with ID [%s]", webhook.ID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
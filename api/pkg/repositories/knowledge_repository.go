@@ -0,0 +1,45 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/NdoleStudio/discusswithai/pkg/entities"
+	"github.com/google/uuid"
+)
+
+// KnowledgeMatch is an entities.KnowledgeChunk which is similar to a query
+type KnowledgeMatch struct {
+	entities.KnowledgeChunk
+	DocumentName string
+	Score        float64
+}
+
+// KnowledgeRepository loads and persists an entities.KnowledgeBase with its documents and their chunks
+type KnowledgeRepository interface {
+	// StoreBase stores a new entities.KnowledgeBase
+	StoreBase(ctx context.Context, base *entities.KnowledgeBase) error
+
+	// LoadBase loads an entities.KnowledgeBase by ID
+	LoadBase(ctx context.Context, baseID uuid.UUID) (*entities.KnowledgeBase, error)
+
+	// LoadBaseFor loads the most recent entities.KnowledgeBase of the owner or the persona
+	LoadBaseFor(ctx context.Context, owner string, persona string) (*entities.KnowledgeBase, error)
+
+	// IndexBases fetches the entities.KnowledgeBase by IndexParams
+	IndexBases(ctx context.Context, params IndexParams) (*[]entities.KnowledgeBase, error)
+
+	// StoreDocument stores an entities.KnowledgeDocument with its chunks
+	StoreDocument(ctx context.Context, document *entities.KnowledgeDocument, chunks []entities.KnowledgeChunk) error
+
+	// LoadDocument loads an entities.KnowledgeDocument by ID
+	LoadDocument(ctx context.Context, documentID uuid.UUID) (*entities.KnowledgeDocument, error)
+
+	// IndexDocuments fetches the entities.KnowledgeDocument of a knowledge base by IndexParams
+	IndexDocuments(ctx context.Context, baseID uuid.UUID, params IndexParams) (*[]entities.KnowledgeDocument, error)
+
+	// DeleteDocument deletes an entities.KnowledgeDocument with its chunks
+	DeleteDocument(ctx context.Context, document *entities.KnowledgeDocument) error
+
+	// Search fetches the chunks of a knowledge base whose embedding is the most similar to the embedding
+	Search(ctx context.Context, baseID uuid.UUID, embedding []float32, limit int) ([]KnowledgeMatch, error)
+}
@@ -0,0 +1,41 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/NdoleStudio/discusswithai/pkg/entities"
+	"github.com/google/uuid"
+)
+
+// MessageRepository loads and persists an entities.Message
+type MessageRepository interface {
+	// Store a new entities.Message
+	Store(ctx context.Context, message *entities.Message) error
+
+	// Update an entities.Message
+	Update(ctx context.Context, message *entities.Message) error
+
+	// Load an entities.Message by ID
+	Load(ctx context.Context, messageID uuid.UUID) (*entities.Message, error)
+
+	// LoadLatest fetches the most recent entities.Message with the entities.MessageRole in a channel
+	LoadLatest(ctx context.Context, channel entities.Channel, channelID string, role entities.MessageRole) (*entities.Message, error)
+
+	// LoadLatestInConversation fetches the most recent entities.Message with the entities.MessageRole in a conversation
+	LoadLatestInConversation(ctx context.Context, conversationID uuid.UUID, role entities.MessageRole) (*entities.Message, error)
+
+	// History fetches the last user and assistant messages of a conversation which were created after a time, from the oldest to the newest.
+	// Messages which could not be delivered are not part of the history.
+	History(ctx context.Context, conversationID uuid.UUID, after time.Time, limit int) (*[]entities.Message, error)
+
+	// HistoryPage fetches the first user and assistant messages of a conversation which were created after a time and not after until,
+	// from the oldest to the newest. Messages which could not be delivered are not part of the history.
+	HistoryPage(ctx context.Context, conversationID uuid.UUID, after time.Time, until time.Time, limit int) (*[]entities.Message, error)
+
+	// CountSince counts the entities.Message with the entities.MessageRole which were created after a time
+	CountSince(ctx context.Context, role entities.MessageRole, since time.Time) (int64, error)
+
+	// Index entities.Message by IndexParams and IndexFilters
+	Index(ctx context.Context, params IndexParams, filters IndexFilters) (*[]entities.Message, error)
+}
@@ -0,0 +1,29 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/NdoleStudio/discusswithai/pkg/entities"
+	"github.com/google/uuid"
+)
+
+// ReminderRepository loads and persists an entities.Reminder
+type ReminderRepository interface {
+	// Store a new entities.Reminder
+	Store(ctx context.Context, reminder *entities.Reminder) error
+
+	// Update an entities.Reminder
+	Update(ctx context.Context, reminder *entities.Reminder) error
+
+	// Load an entities.Reminder by ID
+	Load(ctx context.Context, reminderID uuid.UUID) (*entities.Reminder, error)
+
+	// Claim changes the status of a scheduled entities.Reminder to entities.ReminderStatusSending so that it is sent by
+	// a single worker. Reminders which are sending since before staleBefore are claimed again because their worker stopped.
+	// It returns false when the reminder was not claimed.
+	Claim(ctx context.Context, reminderID uuid.UUID, staleBefore time.Time) (bool, error)
+
+	// Scheduled fetches the entities.Reminder of a user which have not been sent ordered by the time they are due
+	Scheduled(ctx context.Context, userID uuid.UUID) (*[]entities.Reminder, error)
+}
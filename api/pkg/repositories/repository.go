@@ -1,6 +1,10 @@
 package repositories
 
-import "github.com/palantir/stacktrace"
+import (
+	"time"
+
+	"github.com/palantir/stacktrace"
+)
 
 // IndexParams parameters for indexing a database table
 type IndexParams struct {
@@ -9,7 +13,19 @@ type IndexParams struct {
 	Limit int    `json:"take"`
 }
 
+// IndexFilters are filters for indexing entities which belong to a channel
+type IndexFilters struct {
+	Channel   string
+	ChannelID string
+	From      *time.Time
+	To        *time.Time
+}
+
 const (
 	// ErrCodeNotFound is thrown when an entity does not exist in storage
 	ErrCodeNotFound = stacktrace.ErrorCode(1000)
+
+	// ErrCodeTenantNotScoped is thrown when a tenant-scoped entity is queried with a context.Context which is neither
+	// scoped to a tenant nor explicitly allowed to access all the tenants
+	ErrCodeTenantNotScoped = stacktrace.ErrorCode(1001)
 )
@@ -0,0 +1,32 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/NdoleStudio/discusswithai/pkg/entities"
+	"github.com/google/uuid"
+)
+
+// TenantRepository loads and persists an entities.Tenant with its numbers
+type TenantRepository interface {
+	// Store a new entities.Tenant
+	Store(ctx context.Context, tenant *entities.Tenant) error
+
+	// Update an entities.Tenant
+	Update(ctx context.Context, tenant *entities.Tenant) error
+
+	// Load an entities.Tenant by ID
+	Load(ctx context.Context, tenantID uuid.UUID) (*entities.Tenant, error)
+
+	// LoadByNumber loads the entities.Tenant which owns a number on a channel
+	LoadByNumber(ctx context.Context, channel entities.Channel, number string) (*entities.Tenant, error)
+
+	// Index entities.Tenant by IndexParams
+	Index(ctx context.Context, params IndexParams) (*[]entities.Tenant, error)
+
+	// StoreNumber adds an entities.TenantNumber to a tenant
+	StoreNumber(ctx context.Context, number *entities.TenantNumber) error
+
+	// IndexNumbers fetches the entities.TenantNumber of a tenant
+	IndexNumbers(ctx context.Context, tenantID uuid.UUID) (*[]entities.TenantNumber, error)
+}
@@ -1,19 +1,25 @@
 package repositories
 
-//// UserRepository loads and persists an entities.User
-//type UserRepository interface {
-//	// Store a new entities.User
-//	Store(ctx context.Context, user *entities.User) error
-//
-//	// Update a new entities.User
-//	Update(ctx context.Context, user *entities.User) error
-//
-//	// Load an entities.User by entities.UserID
-//	Load(ctx context.Context, userID entities.UserID) (*entities.User, error)
-//
-//	// LoadBySubscriptionID fetches a user based on the subscriptionID
-//	LoadBySubscriptionID(ctx context.Context, subscriptionID string) (*entities.User, error)
-//
-//	// LoadOrStore an entities.User by entities.AuthUser
-//	LoadOrStore(ctx context.Context, user entities.AuthUser) (*entities.User, bool, error)
-//}
+import (
+	"context"
+
+	"github.com/NdoleStudio/discusswithai/pkg/entities"
+)
+
+// UserRepository loads and persists an entities.User
+type UserRepository interface {
+	// Update an entities.User
+	Update(ctx context.Context, user *entities.User) error
+
+	// RecordViolation atomically increments entities.User.ModerationViolations and loads the new count into the user
+	RecordViolation(ctx context.Context, user *entities.User) error
+
+	// Flag sets entities.User.FlaggedAt unless the user is already flagged
+	Flag(ctx context.Context, user *entities.User) error
+
+	// LoadOrStore an entities.User by entities.Channel and channel ID
+	LoadOrStore(ctx context.Context, user *entities.User) (*entities.User, error)
+
+	// Index entities.User by IndexParams and IndexFilters
+	Index(ctx context.Context, params IndexParams, filters IndexFilters) (*[]entities.User, error)
+}
@@ -0,0 +1,23 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/NdoleStudio/discusswithai/pkg/entities"
+	"github.com/google/uuid"
+)
+
+// WebhookDeliveryRepository loads and persists an entities.WebhookDelivery
+type WebhookDeliveryRepository interface {
+	// Store a new entities.WebhookDelivery
+	Store(ctx context.Context, delivery *entities.WebhookDelivery) error
+
+	// Update an entities.WebhookDelivery
+	Update(ctx context.Context, delivery *entities.WebhookDelivery) error
+
+	// Load an entities.WebhookDelivery by ID
+	Load(ctx context.Context, deliveryID uuid.UUID) (*entities.WebhookDelivery, error)
+
+	// Index entities.WebhookDelivery of an entities.Webhook by IndexParams
+	Index(ctx context.Context, webhookID uuid.UUID, params IndexParams) (*[]entities.WebhookDelivery, error)
+}
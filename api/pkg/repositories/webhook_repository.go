@@ -0,0 +1,26 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/NdoleStudio/discusswithai/pkg/entities"
+	"github.com/google/uuid"
+)
+
+// WebhookRepository loads and persists an entities.Webhook
+type WebhookRepository interface {
+	// Store a new entities.Webhook
+	Store(ctx context.Context, webhook *entities.Webhook) error
+
+	// Load an entities.Webhook by ID
+	Load(ctx context.Context, webhookID uuid.UUID) (*entities.Webhook, error)
+
+	// Index entities.Webhook by IndexParams. All webhooks are fetched when the apiKeyID is nil.
+	Index(ctx context.Context, apiKeyID *uuid.UUID, params IndexParams) (*[]entities.Webhook, error)
+
+	// LoadByEvent fetches the entities.Webhook of a tenant which are subscribed to an event type
+	LoadByEvent(ctx context.Context, tenantID uuid.UUID, eventType string) (*[]entities.Webhook, error)
+
+	// Delete an entities.Webhook
+	Delete(ctx context.Context, webhook *entities.Webhook) error
+}
@@ -0,0 +1,68 @@
+package requests
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/NdoleStudio/discusswithai/pkg/repositories"
+)
+
+// AdminIndexRequest is the payload for fetching users, conversations and messages as an admin
+type AdminIndexRequest struct {
+	request
+	Skip      string `json:"skip" query:"skip"`
+	Query     string `json:"query" query:"query"`
+	Limit     string `json:"limit" query:"limit"`
+	Channel   string `json:"channel" query:"channel"`
+	ChannelID string `json:"channel_id" query:"channel_id"`
+	From      string `json:"from" query:"from"`
+	To        string `json:"to" query:"to"`
+}
+
+// Sanitize sets defaults to AdminIndexRequest
+func (input *AdminIndexRequest) Sanitize() AdminIndexRequest {
+	if input.Limit == "" {
+		input.Limit = "20"
+	}
+	if input.Skip == "" {
+		input.Skip = "0"
+	}
+	input.Query = input.sanitizeString(input.Query)
+	input.Channel = input.sanitizeString(input.Channel)
+	input.ChannelID = input.sanitizeString(input.ChannelID)
+	input.From = input.sanitizeString(input.From)
+	input.To = input.sanitizeString(input.To)
+	return *input
+}
+
+// ToIndexParams converts AdminIndexRequest to repositories.IndexParams
+func (input *AdminIndexRequest) ToIndexParams() repositories.IndexParams {
+	return repositories.IndexParams{
+		Skip:  input.getInt(input.Skip),
+		Query: input.Query,
+		Limit: input.getInt(input.Limit),
+	}
+}
+
+// ToIndexFilters converts AdminIndexRequest to repositories.IndexFilters
+func (input *AdminIndexRequest) ToIndexFilters() repositories.IndexFilters {
+	return repositories.IndexFilters{
+		Channel:   input.Channel,
+		ChannelID: input.ChannelID,
+		From:      input.getTime(input.From),
+		To:        input.getTime(input.To),
+	}
+}
+
+func (input *AdminIndexRequest) getInt(value string) int {
+	val, _ := strconv.Atoi(value)
+	return val
+}
+
+func (input *AdminIndexRequest) getTime(value string) *time.Time {
+	timestamp, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil
+	}
+	return &timestamp
+}
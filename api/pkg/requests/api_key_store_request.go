@@ -0,0 +1,48 @@
+package requests
+
+import (
+	"github.com/NdoleStudio/discusswithai/pkg/entities"
+	"github.com/NdoleStudio/discusswithai/pkg/services"
+	"github.com/google/uuid"
+)
+
+// APIKeyStoreRequest is the payload for creating an entities.APIKey
+type APIKeyStoreRequest struct {
+	request
+	Name     string   `json:"name" example:"Support Dashboard"`
+	Roles    []string `json:"roles" example:"support"`
+	TenantID string   `json:"tenant_id" example:"6f1d2c3b-4a5e-4f60-8a7b-9c0d1e2f3a4b"`
+}
+
+// Sanitize sets defaults to APIKeyStoreRequest
+func (input *APIKeyStoreRequest) Sanitize() APIKeyStoreRequest {
+	input.Name = input.sanitizeString(input.Name)
+	input.TenantID = input.sanitizeString(input.TenantID)
+
+	var roles []string
+	for _, role := range input.Roles {
+		roles = append(roles, input.sanitizeString(role))
+	}
+	input.Roles = roles
+
+	return *input
+}
+
+// ToStoreParams converts APIKeyStoreRequest to services.APIKeyStoreParams
+func (input *APIKeyStoreRequest) ToStoreParams() *services.APIKeyStoreParams {
+	var roles []entities.Role
+	for _, role := range input.Roles {
+		roles = append(roles, entities.Role(role))
+	}
+
+	tenantID := uuid.Nil
+	if input.TenantID != "" {
+		tenantID = uuid.MustParse(input.TenantID)
+	}
+
+	return &services.APIKeyStoreParams{
+		TenantID: tenantID,
+		Name:     input.Name,
+		Roles:    roles,
+	}
+}
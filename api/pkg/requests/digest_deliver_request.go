@@ -0,0 +1,34 @@
+package requests
+
+import (
+	"time"
+
+	"github.com/NdoleStudio/discusswithai/pkg/services"
+	"github.com/google/uuid"
+)
+
+// DigestDeliverRequest is the payload of the queue task which delivers an entities.DigestSubscription
+type DigestDeliverRequest struct {
+	request
+	SubscriptionID string    `json:"subscription_id" example:"0f0e8a3c-7b1d-4c2e-9a5f-6b7c8d9e0f1a"`
+	DeliveryAt     time.Time `json:"delivery_at" example:"2022-06-06T08:00:00+01:00"`
+}
+
+// Sanitize sets defaults to DigestDeliverRequest
+func (input *DigestDeliverRequest) Sanitize() DigestDeliverRequest {
+	input.SubscriptionID = input.sanitizeString(input.SubscriptionID)
+	return *input
+}
+
+// SubscriptionUUID returns the SubscriptionID as a uuid.UUID
+func (input *DigestDeliverRequest) SubscriptionUUID() uuid.UUID {
+	return uuid.MustParse(input.SubscriptionID)
+}
+
+// ToDeliverParams converts DigestDeliverRequest to services.DigestDeliverParams
+func (input *DigestDeliverRequest) ToDeliverParams() *services.DigestDeliverParams {
+	return &services.DigestDeliverParams{
+		SubscriptionID: input.SubscriptionUUID(),
+		DeliveryAt:     input.DeliveryAt,
+	}
+}
@@ -0,0 +1,39 @@
+package requests
+
+import (
+	"github.com/NdoleStudio/discusswithai/pkg/services"
+	"github.com/google/uuid"
+)
+
+// KnowledgeBaseStoreRequest is the payload for creating an entities.KnowledgeBase
+type KnowledgeBaseStoreRequest struct {
+	request
+	Name     string `json:"name" example:"Online shop FAQ"`
+	Owner    string `json:"owner" example:"+18005550100"`
+	Persona  string `json:"persona" example:"You are a friendly support agent for an online shop"`
+	TenantID string `json:"tenant_id" example:"6f1d2c3b-4a5e-4f60-8a7b-9c0d1e2f3a4b"`
+}
+
+// Sanitize sets defaults to KnowledgeBaseStoreRequest
+func (input *KnowledgeBaseStoreRequest) Sanitize() KnowledgeBaseStoreRequest {
+	input.Name = input.sanitizeString(input.Name)
+	input.Owner = input.sanitizeString(input.Owner)
+	input.Persona = input.sanitizeString(input.Persona)
+	input.TenantID = input.sanitizeString(input.TenantID)
+	return *input
+}
+
+// ToStoreParams converts KnowledgeBaseStoreRequest to services.KnowledgeBaseStoreParams
+func (input *KnowledgeBaseStoreRequest) ToStoreParams() *services.KnowledgeBaseStoreParams {
+	tenantID := uuid.Nil
+	if input.TenantID != "" {
+		tenantID = uuid.MustParse(input.TenantID)
+	}
+
+	return &services.KnowledgeBaseStoreParams{
+		Name:     input.Name,
+		Owner:    input.Owner,
+		Persona:  input.Persona,
+		TenantID: tenantID,
+	}
+}
@@ -0,0 +1,58 @@
+package requests
+
+import (
+	"github.com/NdoleStudio/discusswithai/pkg/entities"
+	"github.com/NdoleStudio/discusswithai/pkg/services"
+	"github.com/google/uuid"
+)
+
+// MessageSendRequest is the payload for sending an AI generated message to a user
+type MessageSendRequest struct {
+	request
+	Channel     string `json:"channel" example:"whatsapp"`
+	From        string `json:"from" example:"+18005550100"`
+	To          string `json:"to" example:"+18005550199"`
+	Prompt      string `json:"prompt" example:"Write a short reminder that the order #1234 was shipped today"`
+	Persona     string `json:"persona" example:"You are a friendly support agent for an online shop"`
+	CallbackURL string `json:"callback_url" example:"https://example.com/webhooks/discusswithai"`
+}
+
+// Sanitize sets defaults to MessageSendRequest
+func (input *MessageSendRequest) Sanitize() MessageSendRequest {
+	input.Channel = input.sanitizeString(input.Channel)
+	input.To = input.sanitizePhoneNumber(input.To)
+	input.Prompt = input.sanitizeString(input.Prompt)
+	input.Persona = input.sanitizeString(input.Persona)
+	input.CallbackURL = input.sanitizeString(input.CallbackURL)
+
+	input.From = input.sanitizeString(input.From)
+	if entities.Channel(input.Channel) == entities.ChannelSMS {
+		input.From = input.sanitizePhoneNumber(input.From)
+	}
+
+	return *input
+}
+
+// ToSendParams converts MessageSendRequest to services.PromptSendParams.
+// The callback is signed with the signing key of the API key of the entities.Principal.
+func (input *MessageSendRequest) ToSendParams(principal *entities.Principal) *services.PromptSendParams {
+	var callbackURL *string
+	if input.CallbackURL != "" {
+		callbackURL = &input.CallbackURL
+	}
+
+	var apiKeyID *uuid.UUID
+	if principal.APIKeyID != uuid.Nil {
+		apiKeyID = &principal.APIKeyID
+	}
+
+	return &services.PromptSendParams{
+		Channel:     entities.Channel(input.Channel),
+		From:        input.From,
+		To:          input.To,
+		Prompt:      input.Prompt,
+		Persona:     input.Persona,
+		CallbackURL: callbackURL,
+		APIKeyID:    apiKeyID,
+	}
+}
@@ -37,6 +37,7 @@ func (request *NexmoReceiveRequest) ToReceiveParams() *services.NexmoReceivePara
 		From:        request.Msisdn,
 		To:          request.To,
 		Message:     request.Text,
+		MessageID:   request.MessageID,
 		IsMultipart: request.Concat == "true",
 		Reference:   request.ConcatRef,
 	}
@@ -0,0 +1,22 @@
+package requests
+
+import (
+	"github.com/google/uuid"
+)
+
+// ReminderDeliverRequest is the payload of the queue task which delivers an entities.Reminder
+type ReminderDeliverRequest struct {
+	request
+	ReminderID string `json:"reminder_id" example:"5b9a1c2e-3f4d-4e5a-9b6c-7d8e9f0a1b2c"`
+}
+
+// Sanitize sets defaults to ReminderDeliverRequest
+func (input *ReminderDeliverRequest) Sanitize() ReminderDeliverRequest {
+	input.ReminderID = input.sanitizeString(input.ReminderID)
+	return *input
+}
+
+// ReminderUUID returns the ReminderID as a uuid.UUID
+func (input *ReminderDeliverRequest) ReminderUUID() uuid.UUID {
+	return uuid.MustParse(input.ReminderID)
+}
@@ -0,0 +1,22 @@
+package requests
+
+import (
+	"github.com/NdoleStudio/discusswithai/pkg/entities"
+)
+
+// TenantNumberStoreRequest is the payload for adding an entities.TenantNumber to a tenant
+type TenantNumberStoreRequest struct {
+	request
+	Channel string `json:"channel" example:"sms"`
+	Number  string `json:"number" example:"+18005550100"`
+}
+
+// Sanitize sets defaults to TenantNumberStoreRequest
+func (input *TenantNumberStoreRequest) Sanitize() TenantNumberStoreRequest {
+	input.Channel = input.sanitizeString(input.Channel)
+	input.Number = input.sanitizeString(input.Number)
+	if entities.Channel(input.Channel) == entities.ChannelSMS {
+		input.Number = input.sanitizePhoneNumber(input.Number)
+	}
+	return *input
+}
@@ -0,0 +1,48 @@
+package requests
+
+import (
+	"github.com/NdoleStudio/discusswithai/pkg/services"
+	"github.com/google/uuid"
+)
+
+// TenantStoreRequest is the payload for creating or updating an entities.Tenant
+type TenantStoreRequest struct {
+	request
+	Name                string `json:"name" example:"Online Shop"`
+	Persona             string `json:"persona" example:"You are a friendly support agent for an online shop"`
+	KnowledgeBaseID     string `json:"knowledge_base_id" example:"8f9c71b8-b84e-4417-8408-a62274f65a08"`
+	WhatsappAccessToken string `json:"whatsapp_access_token" example:"EAAG..."`
+	NexmoAPIKey         string `json:"nexmo_api_key" example:"a1b2c3d4"`
+	NexmoAPISecret      string `json:"nexmo_api_secret" example:"e5f6g7h8"`
+	DailyMessageLimit   uint   `json:"daily_message_limit" example:"1000"`
+}
+
+// Sanitize sets defaults to TenantStoreRequest
+func (input *TenantStoreRequest) Sanitize() TenantStoreRequest {
+	input.Name = input.sanitizeString(input.Name)
+	input.Persona = input.sanitizeString(input.Persona)
+	input.KnowledgeBaseID = input.sanitizeString(input.KnowledgeBaseID)
+	input.WhatsappAccessToken = input.sanitizeString(input.WhatsappAccessToken)
+	input.NexmoAPIKey = input.sanitizeString(input.NexmoAPIKey)
+	input.NexmoAPISecret = input.sanitizeString(input.NexmoAPISecret)
+	return *input
+}
+
+// ToStoreParams converts TenantStoreRequest to services.TenantStoreParams
+func (input *TenantStoreRequest) ToStoreParams() *services.TenantStoreParams {
+	var knowledgeBaseID *uuid.UUID
+	if input.KnowledgeBaseID != "" {
+		id := uuid.MustParse(input.KnowledgeBaseID)
+		knowledgeBaseID = &id
+	}
+
+	return &services.TenantStoreParams{
+		Name:                input.Name,
+		Persona:             input.Persona,
+		KnowledgeBaseID:     knowledgeBaseID,
+		WhatsappAccessToken: input.WhatsappAccessToken,
+		NexmoAPIKey:         input.NexmoAPIKey,
+		NexmoAPISecret:      input.NexmoAPISecret,
+		DailyMessageLimit:   input.DailyMessageLimit,
+	}
+}
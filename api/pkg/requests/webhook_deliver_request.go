@@ -0,0 +1,22 @@
+package requests
+
+import (
+	"github.com/google/uuid"
+)
+
+// WebhookDeliverRequest is the payload of the queue task which attempts an entities.WebhookDelivery
+type WebhookDeliverRequest struct {
+	request
+	DeliveryID string `json:"delivery_id" example:"32343a19-da5e-4b1b-a767-3298a73703ca"`
+}
+
+// Sanitize sets defaults to WebhookDeliverRequest
+func (input *WebhookDeliverRequest) Sanitize() WebhookDeliverRequest {
+	input.DeliveryID = input.sanitizeString(input.DeliveryID)
+	return *input
+}
+
+// DeliveryUUID returns the DeliveryID as a uuid.UUID
+func (input *WebhookDeliverRequest) DeliveryUUID() uuid.UUID {
+	return uuid.MustParse(input.DeliveryID)
+}
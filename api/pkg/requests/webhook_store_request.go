@@ -0,0 +1,36 @@
+package requests
+
+import (
+	"github.com/NdoleStudio/discusswithai/pkg/entities"
+	"github.com/NdoleStudio/discusswithai/pkg/services"
+)
+
+// WebhookStoreRequest is the payload for creating an entities.Webhook
+type WebhookStoreRequest struct {
+	request
+	URL    string   `json:"url" example:"https://example.com/webhooks/discusswithai"`
+	Events []string `json:"events" example:"message.sent"`
+}
+
+// Sanitize sets defaults to WebhookStoreRequest
+func (input *WebhookStoreRequest) Sanitize() WebhookStoreRequest {
+	input.URL = input.sanitizeString(input.URL)
+
+	var events []string
+	for _, event := range input.Events {
+		events = append(events, input.sanitizeString(event))
+	}
+	input.Events = events
+
+	return *input
+}
+
+// ToStoreParams converts WebhookStoreRequest to services.WebhookStoreParams
+func (input *WebhookStoreRequest) ToStoreParams(principal *entities.Principal) *services.WebhookStoreParams {
+	return &services.WebhookStoreParams{
+		TenantID: principal.TenantID,
+		APIKeyID: principal.APIKeyID,
+		URL:      input.URL,
+		Events:   input.Events,
+	}
+}
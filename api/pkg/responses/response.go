@@ -56,3 +56,29 @@ type Ok[T any] struct {
 	Message string `json:"message" example:"Request handled successfully"`
 	Data    T      `json:"data"`
 }
+
+// Forbidden is the response with status code is 403
+type Forbidden struct {
+	Status  string `json:"status" example:"error"`
+	Message string `json:"message" example:"Forbidden"`
+}
+
+// Created is the response with status code is 201
+type Created[T any] struct {
+	Status  string `json:"status" example:"success"`
+	Message string `json:"message" example:"item created successfully"`
+	Data    T      `json:"data"`
+}
+
+// TooManyRequests is the response with status code is 429
+type TooManyRequests struct {
+	Status  string `json:"status" example:"error"`
+	Message string `json:"message" example:"the daily limit of messages was reached"`
+}
+
+// ServiceUnavailable is the response with status code is 503
+type ServiceUnavailable[T any] struct {
+	Status  string `json:"status" example:"error"`
+	Message string `json:"message" example:"The API is not ready to serve requests"`
+	Data    T      `json:"data"`
+}
@@ -0,0 +1,160 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/NdoleStudio/discusswithai/pkg/entities"
+	"github.com/NdoleStudio/discusswithai/pkg/repositories"
+	"github.com/NdoleStudio/discusswithai/pkg/telemetry"
+	"github.com/NdoleStudio/discusswithai/pkg/tenancy"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+)
+
+const (
+	apiKeyPrefix       = "dwai_"
+	apiKeyPrefixLength = len(apiKeyPrefix) + 8
+)
+
+// APIKeyService is responsible for managing entities.APIKey
+type APIKeyService struct {
+	logger       telemetry.Logger
+	tracer       telemetry.Tracer
+	repository   repositories.APIKeyRepository
+	bootstrapKey string
+	taskKey      string
+}
+
+// NewAPIKeyService creates a new APIKeyService.
+// The bootstrapKey has the entities.RoleAdmin role, and it is used to create the first API keys.
+// The taskKey has the entities.RoleTask role, and it is sent in the headers of the queued tasks.
+func NewAPIKeyService(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	repository repositories.APIKeyRepository,
+	bootstrapKey string,
+	taskKey string,
+) (s *APIKeyService) {
+	return &APIKeyService{
+		logger:       logger.WithService(fmt.Sprintf("%T", s)),
+		tracer:       tracer,
+		repository:   repository,
+		bootstrapKey: bootstrapKey,
+		taskKey:      taskKey,
+	}
+}
+
+// APIKeyStoreParams are parameters for creating an entities.APIKey.
+// The TenantID is uuid.Nil for the API keys of the default tenant.
+type APIKeyStoreParams struct {
+	TenantID uuid.UUID
+	Name     string
+	Roles    []entities.Role
+}
+
+// Store creates a new entities.APIKey. The plain text key is returned only once and only its hash is stored.
+// The entities.APIKey has a signing key for the callbacks of the messages which are sent with the key.
+func (service *APIKeyService) Store(ctx context.Context, params *APIKeyStoreParams) (*entities.APIKey, string, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	secret := make([]byte, 64)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, "", service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, "cannot generate random API key"))
+	}
+
+	key := apiKeyPrefix + hex.EncodeToString(secret[:32])
+	apiKey := &entities.APIKey{
+		ID:         uuid.New(),
+		TenantID:   params.TenantID,
+		Name:       params.Name,
+		Prefix:     key[:apiKeyPrefixLength],
+		Hash:       service.hash(key),
+		SigningKey: webhookSigningKeyPrefix + hex.EncodeToString(secret[32:]),
+		Roles:      params.Roles,
+		CreatedAt:  time.Now().UTC(),
+		UpdatedAt:  time.Now().UTC(),
+	}
+
+	if err := service.repository.Store(ctx, apiKey); err != nil {
+		msg := fmt.Sprintf("cannot store API key with name [%s]", params.Name)
+		return nil, "", service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("created API key [%s] with prefix [%s] and roles %v for tenant [%s]", apiKey.ID, apiKey.Prefix, apiKey.Roles, apiKey.TenantID))
+	return apiKey, key, nil
+}
+
+// Authenticate returns the entities.Principal which owns the API key.
+// The key is looked up in all the tenants because the tenant of the request is only known once it is authenticated.
+func (service *APIKeyService) Authenticate(ctx context.Context, key string) (*entities.Principal, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	if service.bootstrapKey != "" && subtle.ConstantTimeCompare([]byte(key), []byte(service.bootstrapKey)) == 1 {
+		return &entities.Principal{
+			APIKeyID: uuid.Nil,
+			Name:     "bootstrap",
+			Roles:    []entities.Role{entities.RoleAdmin},
+		}, nil
+	}
+
+	if service.taskKey != "" && subtle.ConstantTimeCompare([]byte(key), []byte(service.taskKey)) == 1 {
+		return &entities.Principal{
+			APIKeyID: uuid.Nil,
+			Name:     "task",
+			Roles:    []entities.Role{entities.RoleTask},
+		}, nil
+	}
+
+	apiKey, err := service.repository.LoadByHash(tenancy.WithAllTenants(ctx), service.hash(key))
+	if err != nil {
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, "cannot authenticate API key"))
+	}
+
+	return &entities.Principal{
+		APIKeyID: apiKey.ID,
+		TenantID: apiKey.TenantID,
+		Name:     apiKey.Name,
+		Roles:    apiKey.Roles,
+	}, nil
+}
+
+// Index fetches the entities.APIKey which match the params
+func (service *APIKeyService) Index(ctx context.Context, params repositories.IndexParams) (*[]entities.APIKey, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	apiKeys, err := service.repository.Index(ctx, params)
+	if err != nil {
+		msg := fmt.Sprintf("cannot index API keys with params [%+#v]", params)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return apiKeys, nil
+}
+
+// Delete revokes an entities.APIKey
+func (service *APIKeyService) Delete(ctx context.Context, apiKeyID uuid.UUID) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	if err := service.repository.Delete(ctx, apiKeyID); err != nil {
+		msg := fmt.Sprintf("cannot delete API key with ID [%s]", apiKeyID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("deleted API key with ID [%s]", apiKeyID))
+	return nil
+}
+
+func (service *APIKeyService) hash(key string) string {
+	hash := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(hash[:])
+}
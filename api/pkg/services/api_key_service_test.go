@@ -0,0 +1,39 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/NdoleStudio/discusswithai/pkg/entities"
+	"github.com/NdoleStudio/discusswithai/pkg/telemetry"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPIKeyService_Authenticate(t *testing.T) {
+	t.Run("the task key can only run queued tasks", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		service := NewAPIKeyService(testLogger, telemetry.NewOtelLogger("test", testLogger), nil, "admin-key", "task-key")
+
+		// Act
+		principal, err := service.Authenticate(context.Background(), "task-key")
+
+		// Assert
+		assert.Nil(t, err)
+		assert.Equal(t, []entities.Role{entities.RoleTask}, principal.Roles)
+		assert.False(t, principal.HasAnyRole(entities.RoleAdmin, entities.RoleSupport, entities.RoleIntegrator))
+	})
+
+	t.Run("the bootstrap key is an admin", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		service := NewAPIKeyService(testLogger, telemetry.NewOtelLogger("test", testLogger), nil, "admin-key", "task-key")
+
+		// Act
+		principal, err := service.Authenticate(context.Background(), "admin-key")
+
+		// Assert
+		assert.Nil(t, err)
+		assert.Equal(t, []entities.Role{entities.RoleAdmin}, principal.Roles)
+	})
+}
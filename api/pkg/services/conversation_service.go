@@ -0,0 +1,105 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/NdoleStudio/discusswithai/pkg/entities"
+	"github.com/NdoleStudio/discusswithai/pkg/repositories"
+	"github.com/NdoleStudio/discusswithai/pkg/telemetry"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+)
+
+// ConversationService is responsible for managing entities.Conversation
+type ConversationService struct {
+	logger      telemetry.Logger
+	tracer      telemetry.Tracer
+	userService *UserService
+	repository  repositories.ConversationRepository
+}
+
+// NewConversationService creates a new ConversationService
+func NewConversationService(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	userService *UserService,
+	repository repositories.ConversationRepository,
+) (s *ConversationService) {
+	return &ConversationService{
+		logger:      logger.WithService(fmt.Sprintf("%T", s)),
+		tracer:      tracer,
+		userService: userService,
+		repository:  repository,
+	}
+}
+
+// ConversationLoadOrStoreParams are parameters for loading or creating an entities.Conversation
+type ConversationLoadOrStoreParams struct {
+	Channel   entities.Channel
+	ChannelID string
+	Owner     string
+}
+
+// LoadOrStore fetches an entities.Conversation and creates it if it doesn't exist
+func (service *ConversationService) LoadOrStore(ctx context.Context, params *ConversationLoadOrStoreParams) (*entities.Conversation, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	user, err := service.userService.LoadOrStore(ctx, &UserLoadOrStoreParams{
+		Channel:   params.Channel,
+		ChannelID: params.ChannelID,
+	})
+	if err != nil {
+		msg := fmt.Sprintf("cannot load user for conversation with channel [%s] and owner [%s]", params.Channel, params.Owner)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	conversation, err := service.repository.LoadOrStore(ctx, &entities.Conversation{
+		ID:            uuid.New(),
+		UserID:        user.ID,
+		Channel:       params.Channel,
+		ChannelID:     params.ChannelID,
+		Owner:         params.Owner,
+		LastMessageAt: time.Now().UTC(),
+		CreatedAt:     time.Now().UTC(),
+		UpdatedAt:     time.Now().UTC(),
+	})
+	if err != nil {
+		msg := fmt.Sprintf("cannot load or store conversation for user [%s] with owner [%s]", user.ID, params.Owner)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return conversation, nil
+}
+
+// RecordMessage updates the time of the last message in an entities.Conversation
+func (service *ConversationService) RecordMessage(ctx context.Context, conversation *entities.Conversation, timestamp time.Time) error {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	conversation.LastMessageAt = timestamp
+	conversation.UpdatedAt = time.Now().UTC()
+
+	if err := service.repository.Update(ctx, conversation); err != nil {
+		msg := fmt.Sprintf("cannot record message at [%s] for conversation [%s]", timestamp, conversation.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// Index fetches the entities.Conversation which match the filters
+func (service *ConversationService) Index(ctx context.Context, params repositories.IndexParams, filters repositories.IndexFilters) (*[]entities.Conversation, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	conversations, err := service.repository.Index(ctx, params, filters)
+	if err != nil {
+		msg := fmt.Sprintf("cannot index conversations with params [%+#v]", params)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return conversations, nil
+}
@@ -0,0 +1,552 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/NdoleStudio/discusswithai/pkg/entities"
+	"github.com/NdoleStudio/discusswithai/pkg/events"
+	"github.com/NdoleStudio/discusswithai/pkg/i18n"
+	"github.com/NdoleStudio/discusswithai/pkg/queue"
+	"github.com/NdoleStudio/discusswithai/pkg/repositories"
+	"github.com/NdoleStudio/discusswithai/pkg/telemetry"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+)
+
+const (
+	// digestCommand is the prefix of a message which manages daily digests e.g "/digest word 08:00"
+	digestCommand = "/digest"
+
+	// digestTimeLayout is the layout of the local time of a entities.DigestSubscription
+	digestTimeLayout = "15:04"
+
+	// digestDispatchLimit is the maximum number of subscriptions which are dispatched at once
+	digestDispatchLimit = 500
+
+	// whatsappSessionWindow is the time after the last message of a user when we can send free-form whatsapp messages
+	whatsappSessionWindow = 24 * time.Hour
+)
+
+// digestTimeLayouts are the layouts which users can use to choose the time of a digest
+var digestTimeLayouts = []string{digestTimeLayout, "15h04", "15h", "3:04pm", "3pm"}
+
+// DefaultDigestPrompts are the prompts which are used to generate the message of each entities.DigestTopic
+func DefaultDigestPrompts() map[entities.DigestTopic]string {
+	return map[entities.DigestTopic]string{
+		entities.DigestTopicWord:     "Give me an interesting word of the day with its meaning and an example sentence.",
+		entities.DigestTopicBriefing: "Write a short news-style briefing with three interesting facts about science and technology.",
+		entities.DigestTopicQuestion: "Ask me a general knowledge study question and give the answer at the end.",
+	}
+}
+
+// DigestService is responsible for managing entities.DigestSubscription
+type DigestService struct {
+	service
+	logger           telemetry.Logger
+	tracer           telemetry.Tracer
+	dispatcher       events.Dispatcher
+	catalog          *i18n.Catalog
+	repository       repositories.DigestSubscriptionRepository
+	openAPIService   *OpenAPIService
+	userService      *UserService
+	messageService   *MessageService
+	promptService    *PromptService
+	tenantService    *TenantService
+	queueClient      queue.Client
+	prompts          map[entities.DigestTopic]string
+	whatsappTemplate string
+	deliveryURL      string
+	deliveryHeaders  map[string]string
+}
+
+// NewDigestService creates a new DigestService
+func NewDigestService(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	dispatcher events.Dispatcher,
+	catalog *i18n.Catalog,
+	repository repositories.DigestSubscriptionRepository,
+	openAPIService *OpenAPIService,
+	userService *UserService,
+	messageService *MessageService,
+	promptService *PromptService,
+	tenantService *TenantService,
+	queueClient queue.Client,
+	prompts map[entities.DigestTopic]string,
+	whatsappTemplate string,
+	deliveryURL string,
+	deliveryHeaders map[string]string,
+) (s *DigestService) {
+	return &DigestService{
+		logger:           logger.WithService(fmt.Sprintf("%T", s)),
+		tracer:           tracer,
+		dispatcher:       dispatcher,
+		catalog:          catalog,
+		repository:       repository,
+		openAPIService:   openAPIService,
+		userService:      userService,
+		messageService:   messageService,
+		promptService:    promptService,
+		tenantService:    tenantService,
+		queueClient:      queueClient,
+		prompts:          prompts,
+		whatsappTemplate: whatsappTemplate,
+		deliveryURL:      deliveryURL,
+		deliveryHeaders:  deliveryHeaders,
+	}
+}
+
+// DigestDeliverParams is the payload of the queue task which delivers an entities.DigestSubscription
+type DigestDeliverParams struct {
+	SubscriptionID uuid.UUID `json:"subscription_id"`
+
+	// DeliveryAt is the time when the digest was due. It is zero for tasks which were enqueued before it was added.
+	DeliveryAt time.Time `json:"delivery_at"`
+}
+
+// IsCommand checks if a message is a command to manage daily digests e.g "/digest word 08:00" or "/digest stop word"
+func (service *DigestService) IsCommand(message string) bool {
+	fields := strings.Fields(strings.ToLower(message))
+	return len(fields) > 0 && fields[0] == digestCommand
+}
+
+// HandleCommand subscribes, unsubscribes or lists the daily digests of a user and returns the reply for the user.
+// The owner is our phone number which sends the digest.
+func (service *DigestService) HandleCommand(ctx context.Context, user *entities.User, owner string, message string) (string, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	locale := service.userService.Locale(user)
+	fields := strings.Fields(strings.ToLower(message))
+
+	var (
+		reply string
+		err   error
+	)
+
+	switch {
+	case len(fields) == 1:
+		reply, err = service.list(ctx, user)
+	case len(fields) == 2 && fields[1] == "stop":
+		reply, err = service.unsubscribeAll(ctx, user)
+	case len(fields) == 3 && fields[1] == "stop" && service.isTopic(fields[2]):
+		reply, err = service.unsubscribe(ctx, user, entities.DigestTopic(fields[2]))
+	case len(fields) == 3 && service.isTopic(fields[1]):
+		localTime, ok := parseDigestTime(fields[2])
+		if !ok {
+			return service.usage(locale), nil
+		}
+		reply, err = service.subscribe(ctx, user, owner, entities.DigestTopic(fields[1]), localTime)
+	default:
+		return service.usage(locale), nil
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot handle digest command [%s] for user [%s]", message, user.ID)
+		return "", service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return reply, nil
+}
+
+// Dispatch enqueues a delivery task for each entities.DigestSubscription which is due and schedules its next delivery.
+// It returns the number of subscriptions which were dispatched.
+func (service *DigestService) Dispatch(ctx context.Context) (int, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	now := time.Now().UTC()
+	subscriptions, err := service.repository.Due(ctx, now, digestDispatchLimit)
+	if err != nil {
+		return 0, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, fmt.Sprintf("cannot fetch digest subscriptions which are due before [%s]", now)))
+	}
+
+	count := 0
+	for index := range *subscriptions {
+		subscription := &(*subscriptions)[index]
+		if err = service.enqueue(ctx, subscription); err != nil {
+			ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot enqueue digest subscription [%s]", subscription.ID)))
+			continue
+		}
+
+		subscription.NextDeliveryAt = nextDigestDelivery(now, subscription.LocalTime, service.location(subscription))
+		subscription.UpdatedAt = time.Now().UTC()
+		if err = service.repository.Save(ctx, subscription); err != nil {
+			ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot schedule next delivery of digest subscription [%s]", subscription.ID)))
+			continue
+		}
+		count++
+	}
+
+	if count > 0 {
+		ctxLogger.Info(fmt.Sprintf("dispatched [%d] digest subscriptions", count))
+	}
+	return count, nil
+}
+
+// Deliver generates the message of an entities.DigestSubscription and sends it to the user.
+// The subscription is claimed for the delivery so that a digest is sent once when the task is delivered to more than one worker.
+// Whatsapp users who have not messaged us in the last 24 hours receive the digest in a template message.
+// The digest is generated and sent with the quota and the credentials of the tenant of the subscription and the error has
+// the code ErrCodeRateLimited when the message was rate limited so that the digest is retried.
+func (service *DigestService) Deliver(ctx context.Context, params *DigestDeliverParams) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	subscription, err := service.repository.Load(ctx, params.SubscriptionID)
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		ctxLogger.Info(fmt.Sprintf("digest subscription [%s] is not delivered because the user unsubscribed", params.SubscriptionID))
+		return nil
+	}
+	if err != nil {
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, fmt.Sprintf("cannot load digest subscription [%s]", params.SubscriptionID)))
+	}
+
+	deliveryAt := params.DeliveryAt
+	if deliveryAt.IsZero() {
+		deliveryAt = time.Now().UTC()
+	}
+
+	if subscription.IsDelivered(deliveryAt) {
+		ctxLogger.Info(fmt.Sprintf("digest subscription [%s] was already delivered at [%s]", subscription.ID, deliveryAt))
+		return nil
+	}
+
+	claimed, err := service.repository.Claim(ctx, subscription.ID, deliveryAt, time.Now().UTC().Add(-deliveryClaimTimeout))
+	if err != nil {
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, fmt.Sprintf("cannot claim digest subscription [%s]", subscription.ID)))
+	}
+	if !claimed {
+		// the task is retried so that the digest is delivered if the worker which claimed it stops before sending it
+		return service.tracer.WrapErrorSpan(span, stacktrace.NewError(fmt.Sprintf("digest subscription [%s] is being delivered by another worker", subscription.ID)))
+	}
+	claimedAt := time.Now().UTC()
+	subscription.ClaimedAt = &claimedAt
+
+	message, err := service.send(ctx, subscription, deliveryAt)
+	subscription.ClaimedAt = nil
+	if err != nil {
+		if updateErr := service.repository.UpdateDelivery(ctx, subscription); updateErr != nil {
+			ctxLogger.Error(stacktrace.Propagate(updateErr, fmt.Sprintf("cannot release claim on digest subscription [%s]", subscription.ID)))
+		}
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, fmt.Sprintf("cannot send digest subscription [%s]", subscription.ID)))
+	}
+
+	subscription.DeliveredAt = &deliveryAt
+	subscription.MessageID = nil
+	if err = service.repository.UpdateDelivery(ctx, subscription); err != nil {
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, fmt.Sprintf("cannot store the delivery of digest subscription [%s]", subscription.ID)))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("[%s] digest message [%s] for subscription [%s] has status [%s]", subscription.Topic, message.ID, subscription.ID, message.Status))
+	return nil
+}
+
+// send generates the message of a claimed entities.DigestSubscription and sends it to the user.
+// The message is stored in the subscription before it is sent so that it is sent again instead of a new completion
+// when the delivery is retried.
+func (service *DigestService) send(ctx context.Context, subscription *entities.DigestSubscription, deliveryAt time.Time) (*entities.Message, error) {
+	ctx, err := service.tenantService.Scope(ctx, subscription.TenantID)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, fmt.Sprintf("cannot load tenant of digest subscription [%s]", subscription.ID))
+	}
+
+	user, err := service.userService.LoadOrStore(ctx, &UserLoadOrStoreParams{
+		Channel:   subscription.Channel,
+		ChannelID: subscription.ChannelID,
+	})
+	if err != nil {
+		return nil, stacktrace.Propagate(err, fmt.Sprintf("cannot load user of digest subscription [%s]", subscription.ID))
+	}
+	locale := service.userService.Locale(user)
+
+	message, err := service.message(ctx, subscription, locale, deliveryAt)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, fmt.Sprintf("cannot load the message of digest subscription [%s]", subscription.ID))
+	}
+	if message.Status != entities.MessageStatusPending {
+		return message, nil
+	}
+
+	useTemplate, err := service.requiresTemplate(ctx, subscription)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, fmt.Sprintf("cannot check the whatsapp session of digest subscription [%s]", subscription.ID))
+	}
+
+	template := &WhatsappTemplate{Name: service.whatsappTemplate, Language: locale.String()}
+	switch {
+	case useTemplate:
+		err = service.promptService.DeliverTemplate(ctx, message, template)
+	case subscription.Channel == entities.ChannelWhatsapp:
+		err = service.promptService.DeliverWithTemplate(ctx, message, template)
+	default:
+		err = service.promptService.Deliver(ctx, message)
+	}
+	if err != nil {
+		return nil, stacktrace.Propagate(err, fmt.Sprintf("cannot deliver message [%s] for digest subscription [%s]", message.ID, subscription.ID))
+	}
+
+	return message, nil
+}
+
+// message loads the entities.Message of a delivery which was retried or generates and stores a new pending message.
+// Messages which were stored before deliveryAt belong to an earlier delivery which was not completed.
+func (service *DigestService) message(ctx context.Context, subscription *entities.DigestSubscription, locale i18n.Locale, deliveryAt time.Time) (*entities.Message, error) {
+	if subscription.MessageID != nil {
+		message, err := service.messageService.Load(ctx, *subscription.MessageID)
+		if err != nil && stacktrace.GetCode(err) != repositories.ErrCodeNotFound {
+			return nil, stacktrace.Propagate(err, fmt.Sprintf("cannot load message [%s]", *subscription.MessageID))
+		}
+		if err == nil && !message.CreatedAt.Before(deliveryAt) {
+			return message, nil
+		}
+	}
+
+	completion, err := service.openAPIService.GetChatCompletion(ctx, &OpenAPICompletionParams{
+		Channel:   subscription.Channel,
+		ChannelID: subscription.ChannelID,
+		Persona:   fmt.Sprintf("You send a short daily message to a subscriber via %s. Write the message in the language with the ISO 639-1 code [%s] using less than %d characters.", subscription.Channel, locale, smsCharacterLimit),
+		Message:   service.prompts[subscription.Topic],
+	})
+	if err != nil {
+		return nil, stacktrace.Propagate(err, fmt.Sprintf("cannot generate [%s] digest for subscription [%s]", subscription.Topic, subscription.ID))
+	}
+
+	message, err := service.messageService.Store(ctx, &MessageStoreParams{
+		Channel:   subscription.Channel,
+		ChannelID: subscription.ChannelID,
+		Owner:     subscription.Owner,
+		Role:      entities.MessageRoleAssistant,
+		Content:   completion,
+		Status:    entities.MessageStatusPending,
+	})
+	if err != nil {
+		return nil, stacktrace.Propagate(err, fmt.Sprintf("cannot store message for digest subscription [%s]", subscription.ID))
+	}
+
+	subscription.MessageID = &message.ID
+	if err = service.repository.UpdateDelivery(ctx, subscription); err != nil {
+		return nil, stacktrace.Propagate(err, fmt.Sprintf("cannot store message [%s] in digest subscription [%s]", message.ID, subscription.ID))
+	}
+
+	return message, nil
+}
+
+func (service *DigestService) subscribe(ctx context.Context, user *entities.User, owner string, topic entities.DigestTopic, localTime string) (string, error) {
+	subscription, err := service.repository.LoadByTopic(ctx, user.ID, topic)
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		subscription = &entities.DigestSubscription{
+			ID:        uuid.New(),
+			UserID:    user.ID,
+			Topic:     topic,
+			CreatedAt: time.Now().UTC(),
+		}
+	} else if err != nil {
+		return "", stacktrace.Propagate(err, fmt.Sprintf("cannot load digest subscription of user [%s] to topic [%s]", user.ID, topic))
+	}
+
+	location := i18n.TimezoneFromPhoneNumber(user.ChannelID)
+
+	subscription.Channel = user.Channel
+	subscription.ChannelID = user.ChannelID
+	subscription.Owner = owner
+	subscription.LocalTime = localTime
+	subscription.Timezone = location.String()
+	subscription.NextDeliveryAt = nextDigestDelivery(time.Now(), localTime, location)
+	subscription.UpdatedAt = time.Now().UTC()
+
+	if err = service.repository.Save(ctx, subscription); err != nil {
+		return "", stacktrace.Propagate(err, fmt.Sprintf("cannot save digest subscription of user [%s] to topic [%s]", user.ID, topic))
+	}
+	service.publish(ctx, subscription, events.SubscriptionStatusActive)
+
+	return service.catalog.Translate(service.userService.Locale(user), i18n.KeyDigestSubscribed, topic, localTime, location, topic), nil
+}
+
+func (service *DigestService) unsubscribe(ctx context.Context, user *entities.User, topic entities.DigestTopic) (string, error) {
+	locale := service.userService.Locale(user)
+
+	subscription, err := service.repository.LoadByTopic(ctx, user.ID, topic)
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return service.catalog.Translate(locale, i18n.KeyDigestNotSubscribed, topic), nil
+	}
+	if err != nil {
+		return "", stacktrace.Propagate(err, fmt.Sprintf("cannot load digest subscription of user [%s] to topic [%s]", user.ID, topic))
+	}
+
+	if err = service.repository.Delete(ctx, subscription); err != nil {
+		return "", stacktrace.Propagate(err, fmt.Sprintf("cannot delete digest subscription [%s]", subscription.ID))
+	}
+	service.publish(ctx, subscription, events.SubscriptionStatusCancelled)
+
+	return service.catalog.Translate(locale, i18n.KeyDigestUnsubscribed, topic), nil
+}
+
+func (service *DigestService) unsubscribeAll(ctx context.Context, user *entities.User) (string, error) {
+	subscriptions, err := service.repository.Index(ctx, user.ID)
+	if err != nil {
+		return "", stacktrace.Propagate(err, fmt.Sprintf("cannot index digest subscriptions of user [%s]", user.ID))
+	}
+
+	var topics []string
+	for index := range *subscriptions {
+		if err = service.repository.Delete(ctx, &(*subscriptions)[index]); err != nil {
+			return "", stacktrace.Propagate(err, fmt.Sprintf("cannot delete digest subscription [%s]", (*subscriptions)[index].ID))
+		}
+		service.publish(ctx, &(*subscriptions)[index], events.SubscriptionStatusCancelled)
+		topics = append(topics, (*subscriptions)[index].Topic.String())
+	}
+
+	locale := service.userService.Locale(user)
+	if len(topics) == 0 {
+		return service.usage(locale), nil
+	}
+
+	return service.catalog.Translate(locale, i18n.KeyDigestUnsubscribed, strings.Join(topics, ", ")), nil
+}
+
+func (service *DigestService) list(ctx context.Context, user *entities.User) (string, error) {
+	subscriptions, err := service.repository.Index(ctx, user.ID)
+	if err != nil {
+		return "", stacktrace.Propagate(err, fmt.Sprintf("cannot index digest subscriptions of user [%s]", user.ID))
+	}
+
+	locale := service.userService.Locale(user)
+	if len(*subscriptions) == 0 {
+		return service.usage(locale), nil
+	}
+
+	var lines []string
+	for _, subscription := range *subscriptions {
+		lines = append(lines, fmt.Sprintf("- %s %s (%s)", subscription.Topic, subscription.LocalTime, subscription.Timezone))
+	}
+
+	return service.catalog.Translate(locale, i18n.KeyDigestList, strings.Join(lines, "\n")), nil
+}
+
+func (service *DigestService) usage(locale i18n.Locale) string {
+	var topics []string
+	for _, topic := range entities.DigestTopics() {
+		topics = append(topics, topic.String())
+	}
+	return service.catalog.Translate(locale, i18n.KeyDigestUsage, strings.Join(topics, ", "))
+}
+
+// publish the events.SubscriptionChanged event for the entities.DigestSubscription with the status
+func (service *DigestService) publish(ctx context.Context, subscription *entities.DigestSubscription, status string) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	payload := &events.SubscriptionPayload{
+		SubscriptionID: subscription.ID.String(),
+		UserID:         subscription.UserID.String(),
+		Channel:        subscription.Channel.String(),
+		ChannelID:      subscription.ChannelID,
+		Owner:          subscription.Owner,
+		Topic:          subscription.Topic.String(),
+		LocalTime:      subscription.LocalTime,
+		Timezone:       subscription.Timezone,
+		Status:         status,
+	}
+	if status == events.SubscriptionStatusActive {
+		payload.NextDeliveryAt = &subscription.NextDeliveryAt
+	}
+
+	event, err := service.createEvent(ctx, events.SubscriptionChanged, eventSource, payload)
+	if err != nil {
+		msg := fmt.Sprintf("cannot create [%s] event for digest subscription [%s]", events.SubscriptionChanged, subscription.ID)
+		ctxLogger.Error(service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg)))
+		return
+	}
+
+	if err = service.dispatcher.Publish(ctx, event); err != nil {
+		msg := fmt.Sprintf("cannot publish [%s] event for digest subscription [%s]", events.SubscriptionChanged, subscription.ID)
+		ctxLogger.Error(service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg)))
+	}
+}
+
+// enqueue the queue task which delivers the entities.DigestSubscription.
+// The deduplication key makes sure that a digest is delivered once even when it is dispatched twice.
+func (service *DigestService) enqueue(ctx context.Context, subscription *entities.DigestSubscription) error {
+	body, err := json.Marshal(&DigestDeliverParams{SubscriptionID: subscription.ID, DeliveryAt: subscription.NextDeliveryAt})
+	if err != nil {
+		return stacktrace.Propagate(err, fmt.Sprintf("cannot marshal payload for digest subscription [%s]", subscription.ID))
+	}
+
+	_, err = service.queueClient.Enqueue(ctx, &queue.Task{
+		Method:           http.MethodPost,
+		URL:              service.deliveryURL,
+		Body:             body,
+		Headers:          service.deliveryHeaders,
+		DeduplicationKey: fmt.Sprintf("digest:%s:%d", subscription.ID, subscription.NextDeliveryAt.Unix()),
+	})
+	if err != nil {
+		return stacktrace.Propagate(err, fmt.Sprintf("cannot enqueue task for digest subscription [%s]", subscription.ID))
+	}
+
+	return nil
+}
+
+// requiresTemplate checks if the digest is sent to a whatsapp user outside the 24-hour session window
+func (service *DigestService) requiresTemplate(ctx context.Context, subscription *entities.DigestSubscription) (bool, error) {
+	if subscription.Channel != entities.ChannelWhatsapp {
+		return false, nil
+	}
+
+	lastReceivedAt, err := service.messageService.LastReceivedAt(ctx, subscription.Channel, subscription.ChannelID)
+	if err != nil {
+		return false, stacktrace.Propagate(err, fmt.Sprintf("cannot load the last message of digest subscription [%s]", subscription.ID))
+	}
+
+	return lastReceivedAt == nil || time.Since(*lastReceivedAt) > whatsappSessionWindow, nil
+}
+
+func (service *DigestService) location(subscription *entities.DigestSubscription) *time.Location {
+	location, err := time.LoadLocation(subscription.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return location
+}
+
+func (service *DigestService) isTopic(value string) bool {
+	for _, topic := range entities.DigestTopics() {
+		if topic.String() == value {
+			return true
+		}
+	}
+	return false
+}
+
+// parseDigestTime converts a time chosen by a user like "8:00", "08h30" or "7pm" into the "15:04" layout
+func parseDigestTime(value string) (string, bool) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	for _, layout := range digestTimeLayouts {
+		if parsed, err := time.Parse(layout, value); err == nil {
+			return parsed.Format(digestTimeLayout), true
+		}
+	}
+
+	return "", false
+}
+
+// nextDigestDelivery returns the first time after now when the local time occurs in the location
+func nextDigestDelivery(now time.Time, localTime string, location *time.Location) time.Time {
+	parsed, err := time.Parse(digestTimeLayout, localTime)
+	if err != nil {
+		return now.Add(24 * time.Hour).UTC()
+	}
+
+	local := now.In(location)
+	next := time.Date(local.Year(), local.Month(), local.Day(), parsed.Hour(), parsed.Minute(), 0, 0, location)
+	if !next.After(now) {
+		next = time.Date(local.Year(), local.Month(), local.Day()+1, parsed.Hour(), parsed.Minute(), 0, 0, location)
+	}
+
+	return next.UTC()
+}
@@ -0,0 +1,258 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/NdoleStudio/discusswithai/pkg/entities"
+	"github.com/NdoleStudio/discusswithai/pkg/events"
+	"github.com/NdoleStudio/discusswithai/pkg/i18n"
+	"github.com/NdoleStudio/discusswithai/pkg/repositories"
+	"github.com/NdoleStudio/discusswithai/pkg/telemetry"
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDigestService_HandleCommand(t *testing.T) {
+	t.Run("a subscription changed event is published when a user subscribes", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		service, dispatcher, _ := newTestDigestService()
+
+		// Arrange
+		user := newTestReminderUser()
+		var published []events.SubscriptionPayload
+		dispatcher.Subscribe(events.SubscriptionChanged, func(_ context.Context, event cloudevents.Event) error {
+			payload := new(events.SubscriptionPayload)
+			assert.Nil(t, event.DataAs(payload))
+			published = append(published, *payload)
+			return nil
+		})
+
+		// Act
+		_, err := service.HandleCommand(context.Background(), user, "+18005550199", "/digest word 08:00")
+
+		// Assert
+		assert.Nil(t, err)
+		assert.Equal(t, 1, len(published))
+		assert.Equal(t, events.SubscriptionStatusActive, published[0].Status)
+		assert.Equal(t, "word", published[0].Topic)
+		assert.Equal(t, "08:00", published[0].LocalTime)
+		assert.NotNil(t, published[0].NextDeliveryAt)
+	})
+
+	t.Run("a subscription changed event is published for each subscription when a user unsubscribes", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		service, dispatcher, repository := newTestDigestService()
+
+		// Arrange
+		user := newTestReminderUser()
+		repository.subscriptions = []entities.DigestSubscription{
+			{ID: uuid.New(), UserID: user.ID, Topic: entities.DigestTopicWord},
+			{ID: uuid.New(), UserID: user.ID, Topic: entities.DigestTopicQuestion},
+		}
+		var published []events.SubscriptionPayload
+		dispatcher.Subscribe(events.SubscriptionChanged, func(_ context.Context, event cloudevents.Event) error {
+			payload := new(events.SubscriptionPayload)
+			assert.Nil(t, event.DataAs(payload))
+			published = append(published, *payload)
+			return nil
+		})
+
+		// Act
+		_, err := service.HandleCommand(context.Background(), user, "+18005550199", "/digest stop")
+
+		// Assert
+		assert.Nil(t, err)
+		assert.Equal(t, 2, len(published))
+		assert.Equal(t, events.SubscriptionStatusCancelled, published[0].Status)
+		assert.Equal(t, repository.subscriptions[1].ID.String(), published[1].SubscriptionID)
+		assert.Nil(t, published[1].NextDeliveryAt)
+	})
+}
+
+func TestParseDigestTime(t *testing.T) {
+	t.Run("times chosen by users are converted to the 24 hour layout", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+
+		for value, expected := range map[string]string{"08:00": "08:00", "8:30": "08:30", "18h15": "18:15", "7h": "07:00", "7pm": "19:00", "7:45AM": "07:45"} {
+			// Act
+			localTime, ok := parseDigestTime(value)
+
+			// Assert
+			assert.True(t, ok, value)
+			assert.Equal(t, expected, localTime, value)
+		}
+	})
+
+	t.Run("invalid times are rejected", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+
+		// Act
+		_, ok := parseDigestTime("25:00")
+
+		// Assert
+		assert.False(t, ok)
+	})
+}
+
+func TestNextDigestDelivery(t *testing.T) {
+	t.Run("the digest is delivered today when the local time has not passed", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		location, _ := time.LoadLocation("Africa/Douala")
+
+		// Arrange
+		now := time.Date(2023, 3, 10, 6, 0, 0, 0, time.UTC)
+
+		// Act
+		next := nextDigestDelivery(now, "08:00", location)
+
+		// Assert
+		assert.Equal(t, time.Date(2023, 3, 10, 7, 0, 0, 0, time.UTC), next)
+	})
+
+	t.Run("the digest is delivered tomorrow when the local time has passed", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		location, _ := time.LoadLocation("Africa/Douala")
+
+		// Arrange
+		now := time.Date(2023, 3, 10, 7, 0, 0, 0, time.UTC)
+
+		// Act
+		next := nextDigestDelivery(now, "08:00", location)
+
+		// Assert
+		assert.Equal(t, time.Date(2023, 3, 11, 7, 0, 0, 0, time.UTC), next)
+	})
+}
+
+func TestDigestService_Deliver(t *testing.T) {
+	t.Run("a digest which was rate limited sends its stored message without a new completion when it is retried", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(`{"messaging_product":"whatsapp","messages":[{"id":"wamid.digest"}]}`))
+		}))
+		defer server.Close()
+		messages := &memoryPromptMessageRepository{}
+		service, _, repository := newTestDigestService()
+		service.promptService = newTestPromptService(server, messages)
+		service.messageService = service.promptService.messageService
+		service.tenantService = service.promptService.tenantService
+		service.userService = NewUserService(testLogger, telemetry.NewOtelLogger("test", testLogger), service.catalog, &identityUserRepository{})
+
+		// Arrange
+		deliveryAt := time.Now().UTC().Add(-time.Minute)
+		message := newTestPromptMessage()
+		message.CreatedAt = deliveryAt.Add(time.Second)
+		messages.messages = []entities.Message{*message}
+		subscription := entities.DigestSubscription{ID: uuid.New(), Channel: entities.ChannelWhatsapp, ChannelID: message.ChannelID, Topic: entities.DigestTopicWord, MessageID: &message.ID}
+		repository.subscriptions = []entities.DigestSubscription{subscription}
+
+		// Act
+		err := service.Deliver(context.Background(), &DigestDeliverParams{SubscriptionID: subscription.ID, DeliveryAt: deliveryAt})
+
+		// Assert
+		assert.Nil(t, err)
+		assert.Equal(t, 1, len(messages.updated))
+		assert.Equal(t, message.ID, messages.updated[0].ID)
+		assert.Equal(t, entities.MessageStatusSent, messages.updated[0].Status)
+		assert.True(t, repository.subscriptions[0].IsDelivered(deliveryAt))
+		assert.Nil(t, repository.subscriptions[0].MessageID)
+	})
+
+	t.Run("a digest which was delivered is not delivered again", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		service, _, repository := newTestDigestService()
+
+		// Arrange
+		deliveryAt := time.Now().UTC().Add(-time.Minute)
+		subscription := entities.DigestSubscription{ID: uuid.New(), Topic: entities.DigestTopicWord, DeliveredAt: &deliveryAt}
+		repository.subscriptions = []entities.DigestSubscription{subscription}
+
+		// Act
+		err := service.Deliver(context.Background(), &DigestDeliverParams{SubscriptionID: subscription.ID, DeliveryAt: deliveryAt})
+
+		// Assert
+		assert.Nil(t, err)
+		assert.Nil(t, repository.subscriptions[0].ClaimedAt)
+	})
+}
+
+// memoryDigestSubscriptionRepository is a repositories.DigestSubscriptionRepository which keeps subscriptions in memory
+type memoryDigestSubscriptionRepository struct {
+	repositories.DigestSubscriptionRepository
+	subscriptions []entities.DigestSubscription
+}
+
+func (repository *memoryDigestSubscriptionRepository) Save(_ context.Context, subscription *entities.DigestSubscription) error {
+	repository.subscriptions = append(repository.subscriptions, *subscription)
+	return nil
+}
+
+func (repository *memoryDigestSubscriptionRepository) LoadByTopic(_ context.Context, _ uuid.UUID, _ entities.DigestTopic) (*entities.DigestSubscription, error) {
+	return nil, stacktrace.NewErrorWithCode(repositories.ErrCodeNotFound, "the subscription does not exist")
+}
+
+func (repository *memoryDigestSubscriptionRepository) Index(_ context.Context, _ uuid.UUID) (*[]entities.DigestSubscription, error) {
+	subscriptions := append([]entities.DigestSubscription{}, repository.subscriptions...)
+	return &subscriptions, nil
+}
+
+func (repository *memoryDigestSubscriptionRepository) Load(_ context.Context, subscriptionID uuid.UUID) (*entities.DigestSubscription, error) {
+	for _, subscription := range repository.subscriptions {
+		if subscription.ID == subscriptionID {
+			return &subscription, nil
+		}
+	}
+	return nil, stacktrace.NewErrorWithCode(repositories.ErrCodeNotFound, "the subscription does not exist")
+}
+
+func (repository *memoryDigestSubscriptionRepository) Claim(_ context.Context, subscriptionID uuid.UUID, deliveryAt time.Time, staleBefore time.Time) (bool, error) {
+	for index := range repository.subscriptions {
+		subscription := &repository.subscriptions[index]
+		if subscription.ID == subscriptionID && !subscription.IsDelivered(deliveryAt) && (subscription.ClaimedAt == nil || subscription.ClaimedAt.Before(staleBefore)) {
+			claimedAt := time.Now().UTC()
+			subscription.ClaimedAt = &claimedAt
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (repository *memoryDigestSubscriptionRepository) UpdateDelivery(_ context.Context, subscription *entities.DigestSubscription) error {
+	for index := range repository.subscriptions {
+		if repository.subscriptions[index].ID == subscription.ID {
+			repository.subscriptions[index].DeliveredAt = subscription.DeliveredAt
+			repository.subscriptions[index].ClaimedAt = subscription.ClaimedAt
+			repository.subscriptions[index].MessageID = subscription.MessageID
+		}
+	}
+	return nil
+}
+
+func (repository *memoryDigestSubscriptionRepository) Delete(_ context.Context, _ *entities.DigestSubscription) error {
+	return nil
+}
+
+func newTestDigestService() (*DigestService, events.Dispatcher, *memoryDigestSubscriptionRepository) {
+	tracer := telemetry.NewOtelLogger("test", testLogger)
+	catalog := i18n.NewCatalog()
+	dispatcher := events.NewMemoryDispatcher(testLogger, tracer)
+	repository := &memoryDigestSubscriptionRepository{}
+	userService := NewUserService(testLogger, tracer, catalog, nil)
+
+	service := NewDigestService(testLogger, tracer, dispatcher, catalog, repository, nil, userService, nil, nil, nil, nil, DefaultDigestPrompts(), "daily_digest", "", nil)
+	return service, dispatcher, repository
+}
@@ -0,0 +1,146 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/NdoleStudio/discusswithai/pkg/telemetry"
+	"github.com/palantir/stacktrace"
+)
+
+const (
+	// HealthStatusOK is the status when all the checks pass
+	HealthStatusOK = "ok"
+
+	// HealthStatusDegraded is the status when only checks which are not critical fail
+	HealthStatusDegraded = "degraded"
+
+	// HealthStatusUnavailable is the status when a critical check fails and the API cannot serve requests
+	HealthStatusUnavailable = "unavailable"
+)
+
+// HealthCheck checks if a dependency of the API is reachable.
+// The API is not ready to serve requests when a Critical check fails.
+type HealthCheck struct {
+	Name     string
+	Critical bool
+	Check    func(ctx context.Context) error
+}
+
+// HealthCheckResult is the result of a HealthCheck
+type HealthCheckResult struct {
+	Name      string    `json:"name" example:"postgres"`
+	Status    string    `json:"status" example:"ok"`
+	Critical  bool      `json:"critical" example:"true"`
+	LatencyMS int64     `json:"latency_ms" example:"12"`
+	Error     string    `json:"error,omitempty" example:"dial tcp 127.0.0.1:5432: connect: connection refused"`
+	CheckedAt time.Time `json:"checked_at" example:"2022-06-05T14:26:02.302718+03:00"`
+}
+
+// HealthReport is the result of all the HealthCheck of the API
+type HealthReport struct {
+	Status string              `json:"status" example:"ok"`
+	Checks []HealthCheckResult `json:"checks"`
+}
+
+// HealthService checks the dependencies of the API
+type HealthService struct {
+	logger   telemetry.Logger
+	tracer   telemetry.Tracer
+	checks   []HealthCheck
+	timeout  time.Duration
+	cacheTTL time.Duration
+
+	mutex   sync.Mutex
+	results map[string]HealthCheckResult
+	now     func() time.Time
+}
+
+// NewHealthService creates a new HealthService.
+// Each check is cancelled after the timeout and its result is reused until the cacheTTL has passed.
+func NewHealthService(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	timeout time.Duration,
+	cacheTTL time.Duration,
+	checks ...HealthCheck,
+) (s *HealthService) {
+	return &HealthService{
+		logger:   logger.WithService(fmt.Sprintf("%T", s)),
+		tracer:   tracer,
+		checks:   checks,
+		timeout:  timeout,
+		cacheTTL: cacheTTL,
+		results:  map[string]HealthCheckResult{},
+		now:      time.Now,
+	}
+}
+
+// Report runs the checks whose cached results have expired and returns the HealthReport.
+// Concurrent calls wait for the running checks so that the dependencies are not flooded by probes.
+func (service *HealthService) Report(ctx context.Context) *HealthReport {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	service.mutex.Lock()
+	defer service.mutex.Unlock()
+
+	var wg sync.WaitGroup
+	results := make([]HealthCheckResult, len(service.checks))
+	for i, check := range service.checks {
+		if result, ok := service.results[check.Name]; ok && service.now().Sub(result.CheckedAt) < service.cacheTTL {
+			results[i] = result
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, check HealthCheck) {
+			defer wg.Done()
+			results[i] = service.run(ctx, check)
+		}(i, check)
+	}
+	wg.Wait()
+
+	report := &HealthReport{Status: HealthStatusOK, Checks: results}
+	for _, result := range results {
+		service.results[result.Name] = result
+		if result.Status == HealthStatusOK {
+			continue
+		}
+
+		ctxLogger.Warn(stacktrace.NewError("health check [%s] failed with error [%s]", result.Name, result.Error))
+		if result.Critical {
+			report.Status = HealthStatusUnavailable
+		} else if report.Status == HealthStatusOK {
+			report.Status = HealthStatusDegraded
+		}
+	}
+
+	return report
+}
+
+func (service *HealthService) run(ctx context.Context, check HealthCheck) HealthCheckResult {
+	ctx, cancel := context.WithTimeout(ctx, service.timeout)
+	defer cancel()
+
+	start := service.now()
+	result := HealthCheckResult{
+		Name:     check.Name,
+		Status:   HealthStatusOK,
+		Critical: check.Critical,
+	}
+
+	if err := check.Check(ctx); err != nil {
+		result.Error = telemetry.Redact(stacktrace.RootCause(err).Error())
+		result.Status = HealthStatusUnavailable
+		if !check.Critical {
+			result.Status = HealthStatusDegraded
+		}
+	}
+
+	result.CheckedAt = service.now()
+	result.LatencyMS = result.CheckedAt.Sub(start).Milliseconds()
+	return result
+}
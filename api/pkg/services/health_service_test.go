@@ -0,0 +1,84 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/NdoleStudio/discusswithai/pkg/telemetry"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHealthService_Report(t *testing.T) {
+	t.Run("failing checks which are not critical degrade the status", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+
+		// Arrange
+		service := newTestHealthService(time.Minute,
+			HealthCheck{Name: "postgres", Critical: true, Check: func(ctx context.Context) error { return nil }},
+			HealthCheck{Name: "openai", Check: func(ctx context.Context) error { return errors.New("401 unauthorized") }},
+		)
+
+		// Act
+		report := service.Report(context.Background())
+
+		// Assert
+		assert.Equal(t, HealthStatusDegraded, report.Status)
+		assert.Equal(t, HealthStatusOK, report.Checks[0].Status)
+		assert.Equal(t, HealthStatusDegraded, report.Checks[1].Status)
+		assert.Equal(t, "401 unauthorized", report.Checks[1].Error)
+	})
+
+	t.Run("failing critical checks make the API unavailable", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+
+		// Arrange
+		service := newTestHealthService(time.Minute,
+			HealthCheck{Name: "postgres", Critical: true, Check: func(ctx context.Context) error {
+				<-ctx.Done()
+				return ctx.Err()
+			}},
+		)
+		service.timeout = time.Millisecond
+
+		// Act
+		report := service.Report(context.Background())
+
+		// Assert
+		assert.Equal(t, HealthStatusUnavailable, report.Status)
+		assert.Equal(t, context.DeadlineExceeded.Error(), report.Checks[0].Error)
+	})
+
+	t.Run("results are cached until the ttl expires", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+
+		// Arrange
+		var calls int32
+		service := newTestHealthService(time.Minute,
+			HealthCheck{Name: "redis", Critical: true, Check: func(ctx context.Context) error {
+				atomic.AddInt32(&calls, 1)
+				return nil
+			}},
+		)
+		now := time.Now()
+		service.now = func() time.Time { return now }
+
+		// Act
+		service.Report(context.Background())
+		service.Report(context.Background())
+		now = now.Add(2 * time.Minute)
+		service.Report(context.Background())
+
+		// Assert
+		assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+	})
+}
+
+func newTestHealthService(cacheTTL time.Duration, checks ...HealthCheck) *HealthService {
+	return NewHealthService(testLogger, telemetry.NewOtelLogger("test", testLogger), time.Second, cacheTTL, checks...)
+}
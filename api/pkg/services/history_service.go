@@ -0,0 +1,320 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/NdoleStudio/discusswithai/pkg/completion"
+	"github.com/NdoleStudio/discusswithai/pkg/entities"
+	"github.com/NdoleStudio/discusswithai/pkg/repositories"
+	"github.com/NdoleStudio/discusswithai/pkg/telemetry"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+	"github.com/sashabaranov/go-openai"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// maxSummaryPages is the maximum number of pages of messages which are summarised before a prompt is answered
+const maxSummaryPages = 3
+
+// HistoryService loads the history of a conversation which is sent with a prompt and summarises the older messages
+// which do not fit in the context window of the model.
+type HistoryService struct {
+	logger              telemetry.Logger
+	tracer              telemetry.Tracer
+	completer           completion.Completer
+	tokenizers          *completion.Tokenizers
+	conversationService *ConversationService
+	repository          repositories.MessageRepository
+	maxMessages         int
+	summaryTokens       int
+}
+
+// NewHistoryService creates a new HistoryService.
+// At most maxMessages are loaded from the conversation and the summaries have at most summaryTokens.
+func NewHistoryService(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	completer completion.Completer,
+	tokenizers *completion.Tokenizers,
+	conversationService *ConversationService,
+	repository repositories.MessageRepository,
+	maxMessages int,
+	summaryTokens int,
+) (s *HistoryService) {
+	return &HistoryService{
+		logger:              logger.WithService(fmt.Sprintf("%T", s)),
+		tracer:              tracer,
+		completer:           completer,
+		tokenizers:          tokenizers,
+		conversationService: conversationService,
+		repository:          repository,
+		maxMessages:         maxMessages,
+		summaryTokens:       summaryTokens,
+	}
+}
+
+// HistoryParams are parameters for loading the history of a conversation
+type HistoryParams struct {
+	Channel   entities.Channel
+	ChannelID string
+	Owner     string
+	Prompt    string
+	Model     string
+	Tokens    int
+}
+
+// Messages returns the summary and the most recent messages of the conversation which fit in params.Tokens.
+// The prompt is not part of the history when it was already stored as the last message of the user.
+// The messages which do not fit and the older messages which were not loaded are summarised in pages into an entities.Message
+// with entities.MessageRoleSummary and the history is returned with the latest stored summary when a page cannot be summarised.
+func (service *HistoryService) Messages(ctx context.Context, params *HistoryParams) ([]openai.ChatCompletionMessage, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	tokenizer := service.tokenizers.For(params.Model)
+	tokens := params.Tokens - service.summaryTokens - completion.CountMessage(tokenizer, service.summaryMessage(""))
+	span.SetAttributes(
+		attribute.String("history.tokenizer", tokenizer.Name()),
+		attribute.Int("history.budget", params.Tokens),
+	)
+	if tokens <= 0 || service.maxMessages == 0 {
+		return nil, nil
+	}
+
+	conversation, err := service.conversationService.LoadOrStore(ctx, &ConversationLoadOrStoreParams{
+		Channel:   params.Channel,
+		ChannelID: params.ChannelID,
+		Owner:     params.Owner,
+	})
+	if err != nil {
+		msg := fmt.Sprintf("cannot load conversation with [%s] in channel [%s]", telemetry.HashChannelID(params.ChannelID), params.Channel)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	summary, err := service.repository.LoadLatestInConversation(ctx, conversation.ID, entities.MessageRoleSummary)
+	if err != nil && stacktrace.GetCode(err) != repositories.ErrCodeNotFound {
+		msg := fmt.Sprintf("cannot load the summary of conversation [%s]", conversation.ID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	after := time.Time{}
+	if summary != nil {
+		after = summary.CreatedAt
+	}
+
+	messages, err := service.repository.History(ctx, conversation.ID, after, service.maxMessages)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load the history of conversation [%s]", conversation.ID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	var history []entities.Message
+	for _, message := range *messages {
+		if strings.TrimSpace(message.Content) != "" {
+			history = append(history, message)
+		}
+	}
+	if last := len(history) - 1; last >= 0 && history[last].Role == entities.MessageRoleUser && history[last].Content == params.Prompt {
+		history = history[:last]
+	}
+
+	kept, dropped := completion.Fit(tokenizer, service.chatMessages(history), tokens)
+	if len(*messages) == service.maxMessages && len(dropped) < len(history)/2 {
+		// older messages were not loaded, so the older half is summarised with them to catch up with the conversation
+		kept, dropped = service.chatMessages(history[len(history)/2:]), service.chatMessages(history[:len(history)/2])
+	}
+	span.SetAttributes(
+		attribute.Int("history.messages", len(history)),
+		attribute.Int("history.kept", len(kept)),
+		attribute.Int("history.dropped", len(dropped)),
+	)
+
+	if len(dropped) > 0 {
+		newSummary, err := service.summarise(ctx, conversation, summary, history[len(dropped)-1].CreatedAt)
+		if err != nil {
+			ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot summarise the messages of conversation [%s] until [%s]", conversation.ID, history[len(dropped)-1].CreatedAt)))
+		}
+		summary = newSummary
+	}
+
+	if summary == nil {
+		return kept, nil
+	}
+	return append([]openai.ChatCompletionMessage{service.summaryMessage(summary.Content)}, kept...), nil
+}
+
+// HistoryToolCallParams are parameters for recording a call of a tool by the language model
+type HistoryToolCallParams struct {
+	Channel   entities.Channel
+	ChannelID string
+	Owner     string
+	Name      string
+	Arguments string
+	Result    string
+}
+
+// RecordToolCall stores the call of a tool as an entities.Message with entities.MessageRoleFunction.
+// The message is internal and it is not part of the history which is sent with a prompt.
+func (service *HistoryService) RecordToolCall(ctx context.Context, params *HistoryToolCallParams) error {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	conversation, err := service.conversationService.LoadOrStore(ctx, &ConversationLoadOrStoreParams{
+		Channel:   params.Channel,
+		ChannelID: params.ChannelID,
+		Owner:     params.Owner,
+	})
+	if err != nil {
+		msg := fmt.Sprintf("cannot load conversation with [%s] in channel [%s]", telemetry.HashChannelID(params.ChannelID), params.Channel)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	message := &entities.Message{
+		ID:             uuid.New(),
+		ConversationID: conversation.ID,
+		ChannelID:      conversation.ChannelID,
+		Channel:        conversation.Channel,
+		Owner:          conversation.Owner,
+		Role:           entities.MessageRoleFunction,
+		Name:           params.Name,
+		Content:        fmt.Sprintf("%s(%s) = %s", params.Name, params.Arguments, params.Result),
+		Status:         entities.MessageStatusInternal,
+		CreatedAt:      time.Now().UTC(),
+		UpdatedAt:      time.Now().UTC(),
+	}
+	if err = service.repository.Store(ctx, message); err != nil {
+		msg := fmt.Sprintf("cannot store call of tool [%s] in conversation [%s]", params.Name, conversation.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+	return nil
+}
+
+// summarise pages through the messages which were created after the previous summary and not after until.
+// Each page of at most maxMessages is summarised with the latest summary and stored so that a failed summary is
+// retried from the last stored summary and at most maxSummaryPages are summarised before the prompt is answered.
+// The latest summary is returned with the error when a page cannot be summarised.
+func (service *HistoryService) summarise(ctx context.Context, conversation *entities.Conversation, previous *entities.Message, until time.Time) (*entities.Message, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	after := time.Time{}
+	if previous != nil {
+		after = previous.CreatedAt
+	}
+
+	summary := previous
+	for page := 0; page < maxSummaryPages; page++ {
+		messages, err := service.repository.HistoryPage(ctx, conversation.ID, after, until, service.maxMessages)
+		if err != nil {
+			msg := fmt.Sprintf("cannot load the messages of conversation [%s] after [%s]", conversation.ID, after)
+			return summary, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+		}
+		if len(*messages) == 0 {
+			break
+		}
+
+		if summary, err = service.summarisePage(ctx, conversation, summary, *messages); err != nil {
+			msg := fmt.Sprintf("cannot summarise page [%d] of conversation [%s]", page, conversation.ID)
+			return summary, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+		}
+		after = summary.CreatedAt
+
+		if len(*messages) < service.maxMessages {
+			break
+		}
+	}
+
+	return summary, nil
+}
+
+// summarisePage creates and stores the summary of the previous summary and the messages.
+// The summary is created at the time of the last message so that the newer messages are loaded with it.
+// The previous summary is returned when the summary cannot be created.
+func (service *HistoryService) summarisePage(ctx context.Context, conversation *entities.Conversation, previous *entities.Message, messages []entities.Message) (*entities.Message, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	var transcript []string
+	if previous != nil {
+		transcript = append(transcript, "summary: "+previous.Content)
+	}
+	for _, message := range messages {
+		if strings.TrimSpace(message.Content) != "" {
+			transcript = append(transcript, fmt.Sprintf("%s: %s", message.Role, message.Content))
+		}
+	}
+
+	response, err := service.completer.CreateChatCompletion(ctx, openai.ChatCompletionRequest{
+		MaxTokens:   service.summaryTokens,
+		Temperature: 0,
+		Messages: []openai.ChatCompletionMessage{
+			{
+				Role: openai.ChatMessageRoleSystem,
+				Content: fmt.Sprintf(
+					"Summarise the conversation between the user and the assistant in less than %d words. "+
+						"Keep the facts, names, preferences and open questions which are needed to continue the conversation.",
+					service.summaryTokens/2,
+				),
+			},
+			{
+				Role:    openai.ChatMessageRoleUser,
+				Content: strings.Join(transcript, "\n"),
+			},
+		},
+	})
+	if err != nil {
+		msg := fmt.Sprintf("cannot create summary of [%d] messages in conversation [%s]", len(messages), conversation.ID)
+		return previous, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if len(response.Choices) == 0 {
+		msg := fmt.Sprintf("the summary of [%d] messages in conversation [%s] has no choices", len(messages), conversation.ID)
+		return previous, service.tracer.WrapErrorSpan(span, stacktrace.NewError(msg))
+	}
+
+	summary := &entities.Message{
+		ID:             uuid.New(),
+		ConversationID: conversation.ID,
+		ChannelID:      conversation.ChannelID,
+		Channel:        conversation.Channel,
+		Owner:          conversation.Owner,
+		Role:           entities.MessageRoleSummary,
+		Content:        strings.TrimSpace(response.Choices[0].Message.Content),
+		Status:         entities.MessageStatusInternal,
+		CreatedAt:      messages[len(messages)-1].CreatedAt,
+		UpdatedAt:      time.Now().UTC(),
+	}
+	if err = service.repository.Store(ctx, summary); err != nil {
+		msg := fmt.Sprintf("cannot store summary of conversation [%s]", conversation.ID)
+		return previous, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	span.AddEvent("history.summarised", trace.WithAttributes(
+		attribute.String("summary.id", summary.ID.String()),
+		attribute.Int("summary.messages", len(messages)),
+		attribute.Int("summary.completion_tokens", response.Usage.CompletionTokens),
+	))
+	return summary, nil
+}
+
+func (service *HistoryService) summaryMessage(content string) openai.ChatCompletionMessage {
+	return openai.ChatCompletionMessage{
+		Role:    openai.ChatMessageRoleSystem,
+		Content: "Summary of the earlier conversation: " + content,
+	}
+}
+
+func (service *HistoryService) chatMessages(messages []entities.Message) []openai.ChatCompletionMessage {
+	result := make([]openai.ChatCompletionMessage, 0, len(messages))
+	for _, message := range messages {
+		result = append(result, openai.ChatCompletionMessage{
+			Role:    string(message.Role),
+			Content: message.Content,
+		})
+	}
+	return result
+}
@@ -0,0 +1,177 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/NdoleStudio/discusswithai/pkg/completion"
+	"github.com/NdoleStudio/discusswithai/pkg/entities"
+	"github.com/NdoleStudio/discusswithai/pkg/repositories"
+	"github.com/NdoleStudio/discusswithai/pkg/telemetry"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+	"github.com/sashabaranov/go-openai"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHistoryService_Messages(t *testing.T) {
+	t.Run("the messages which were not loaded are summarised in pages", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		service, repository, completer := newTestHistoryService(4)
+
+		// Arrange
+		repository.messages = newTestHistoryMessages(10)
+
+		// Act
+		history, err := service.Messages(context.Background(), newTestHistoryParams())
+
+		// Assert
+		assert.Nil(t, err)
+		assert.Equal(t, 2, len(completer.transcripts))
+		assert.Equal(t, 2, len(repository.summaries))
+		assert.Equal(t, repository.messages[7].CreatedAt, repository.summaries[1].CreatedAt)
+		assert.Equal(t, []string{"summary 2", "message 8", "message 9"}, newTestHistoryContents(history))
+	})
+
+	t.Run("a failed summary is retried from the last stored summary", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		service, repository, completer := newTestHistoryService(4)
+
+		// Arrange
+		repository.messages = newTestHistoryMessages(10)
+		completer.failAt = 2
+
+		// Act
+		history, err := service.Messages(context.Background(), newTestHistoryParams())
+		completer.failAt = 0
+		retried, retryErr := service.Messages(context.Background(), newTestHistoryParams())
+
+		// Assert
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"summary 1", "message 8", "message 9"}, newTestHistoryContents(history))
+
+		assert.Nil(t, retryErr)
+		assert.Equal(t, 2, len(completer.transcripts))
+		assert.Equal(t, "summary: summary 1\nuser: message 4\nassistant: message 5\nuser: message 6\nassistant: message 7", completer.transcripts[1])
+		assert.Equal(t, []string{"summary 2", "message 8", "message 9"}, newTestHistoryContents(retried))
+	})
+}
+
+// memoryMessageRepository is a repositories.MessageRepository which keeps the messages of a conversation in memory
+type memoryMessageRepository struct {
+	repositories.MessageRepository
+	messages  []entities.Message
+	summaries []entities.Message
+}
+
+func (repository *memoryMessageRepository) Store(_ context.Context, message *entities.Message) error {
+	repository.summaries = append(repository.summaries, *message)
+	return nil
+}
+
+func (repository *memoryMessageRepository) LoadLatestInConversation(_ context.Context, _ uuid.UUID, _ entities.MessageRole) (*entities.Message, error) {
+	if len(repository.summaries) == 0 {
+		return nil, stacktrace.NewErrorWithCode(repositories.ErrCodeNotFound, "the conversation has no summary")
+	}
+	summary := repository.summaries[len(repository.summaries)-1]
+	return &summary, nil
+}
+
+func (repository *memoryMessageRepository) History(_ context.Context, _ uuid.UUID, after time.Time, limit int) (*[]entities.Message, error) {
+	var messages []entities.Message
+	for _, message := range repository.messages {
+		if message.CreatedAt.After(after) {
+			messages = append(messages, message)
+		}
+	}
+	if len(messages) > limit {
+		messages = messages[len(messages)-limit:]
+	}
+	return &messages, nil
+}
+
+func (repository *memoryMessageRepository) HistoryPage(_ context.Context, _ uuid.UUID, after time.Time, until time.Time, limit int) (*[]entities.Message, error) {
+	var messages []entities.Message
+	for _, message := range repository.messages {
+		if message.CreatedAt.After(after) && !message.CreatedAt.After(until) && len(messages) < limit {
+			messages = append(messages, message)
+		}
+	}
+	return &messages, nil
+}
+
+// summaryCompleter is a completion.Completer which numbers the summaries and fails at the failAt call
+type summaryCompleter struct {
+	failAt      int
+	calls       int
+	transcripts []string
+}
+
+func (completer *summaryCompleter) CreateChatCompletion(_ context.Context, request openai.ChatCompletionRequest) (openai.ChatCompletionResponse, error) {
+	completer.calls++
+	if completer.calls == completer.failAt {
+		return openai.ChatCompletionResponse{}, errors.New("error, status code: 503")
+	}
+
+	completer.transcripts = append(completer.transcripts, request.Messages[1].Content)
+	content := fmt.Sprintf("summary %d", len(completer.transcripts))
+	return openai.ChatCompletionResponse{Choices: []openai.ChatCompletionChoice{{Message: openai.ChatCompletionMessage{Content: content}}}}, nil
+}
+
+// identityConversationRepository is a repositories.ConversationRepository which returns the conversation it is given
+type identityConversationRepository struct {
+	repositories.ConversationRepository
+}
+
+func (repository *identityConversationRepository) LoadOrStore(_ context.Context, conversation *entities.Conversation) (*entities.Conversation, error) {
+	return conversation, nil
+}
+
+// identityUserRepository is a repositories.UserRepository which returns the user it is given
+type identityUserRepository struct {
+	repositories.UserRepository
+}
+
+func (repository *identityUserRepository) LoadOrStore(_ context.Context, user *entities.User) (*entities.User, error) {
+	return user, nil
+}
+
+func newTestHistoryParams() *HistoryParams {
+	return &HistoryParams{Channel: entities.ChannelWhatsapp, ChannelID: "+237677777777", Owner: "+18005550199", Model: "test", Tokens: 10000}
+}
+
+func newTestHistoryMessages(count int) []entities.Message {
+	messages := make([]entities.Message, 0, count)
+	start := time.Date(2023, 3, 10, 8, 0, 0, 0, time.UTC)
+	for i := 0; i < count; i++ {
+		role := entities.MessageRoleUser
+		if i%2 == 1 {
+			role = entities.MessageRoleAssistant
+		}
+		messages = append(messages, entities.Message{ID: uuid.New(), Role: role, Content: fmt.Sprintf("message %d", i), CreatedAt: start.Add(time.Duration(i) * time.Minute)})
+	}
+	return messages
+}
+
+func newTestHistoryContents(history []openai.ChatCompletionMessage) []string {
+	contents := make([]string, 0, len(history))
+	for _, message := range history {
+		contents = append(contents, strings.TrimPrefix(message.Content, "Summary of the earlier conversation: "))
+	}
+	return contents
+}
+
+func newTestHistoryService(maxMessages int) (*HistoryService, *memoryMessageRepository, *summaryCompleter) {
+	tracer := telemetry.NewOtelLogger("test", testLogger)
+	repository := &memoryMessageRepository{}
+	completer := &summaryCompleter{}
+	userService := NewUserService(testLogger, tracer, nil, &identityUserRepository{})
+	conversationService := NewConversationService(testLogger, tracer, userService, &identityConversationRepository{})
+	return NewHistoryService(testLogger, tracer, completer, completion.NewTokenizers(), conversationService, repository, maxMessages, 100), repository, completer
+}
@@ -0,0 +1,301 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/NdoleStudio/discusswithai/pkg/completion"
+	"github.com/NdoleStudio/discusswithai/pkg/entities"
+	"github.com/NdoleStudio/discusswithai/pkg/knowledge"
+	"github.com/NdoleStudio/discusswithai/pkg/repositories"
+	"github.com/NdoleStudio/discusswithai/pkg/telemetry"
+	"github.com/NdoleStudio/discusswithai/pkg/tenancy"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// KnowledgeService manages the knowledge bases of businesses and finds the parts of their documents which answer a prompt
+type KnowledgeService struct {
+	logger       telemetry.Logger
+	tracer       telemetry.Tracer
+	embedder     knowledge.Embedder
+	tokenizer    completion.Tokenizer
+	repository   repositories.KnowledgeRepository
+	chunkTokens  int
+	chunkOverlap int
+	topK         int
+	minScore     float64
+}
+
+// NewKnowledgeService creates a new KnowledgeService.
+// The documents are split into chunks of chunkTokens which overlap by chunkOverlap tokens and at most topK chunks
+// with a similarity of at least minScore are returned by a search.
+func NewKnowledgeService(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	embedder knowledge.Embedder,
+	tokenizer completion.Tokenizer,
+	repository repositories.KnowledgeRepository,
+	chunkTokens int,
+	chunkOverlap int,
+	topK int,
+	minScore float64,
+) (s *KnowledgeService) {
+	return &KnowledgeService{
+		logger:       logger.WithService(fmt.Sprintf("%T", s)),
+		tracer:       tracer,
+		embedder:     embedder,
+		tokenizer:    tokenizer,
+		repository:   repository,
+		chunkTokens:  chunkTokens,
+		chunkOverlap: chunkOverlap,
+		topK:         topK,
+		minScore:     minScore,
+	}
+}
+
+// KnowledgeBaseStoreParams are parameters for creating an entities.KnowledgeBase.
+// The uuid.Nil TenantID is the default tenant.
+type KnowledgeBaseStoreParams struct {
+	Name     string
+	Owner    string
+	Persona  string
+	TenantID uuid.UUID
+}
+
+// StoreBase creates a new entities.KnowledgeBase
+func (service *KnowledgeService) StoreBase(ctx context.Context, params *KnowledgeBaseStoreParams) (*entities.KnowledgeBase, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	base := &entities.KnowledgeBase{
+		ID:        uuid.New(),
+		TenantID:  params.TenantID,
+		Name:      params.Name,
+		Owner:     service.optional(params.Owner),
+		Persona:   service.optional(params.Persona),
+		CreatedAt: time.Now().UTC(),
+		UpdatedAt: time.Now().UTC(),
+	}
+
+	if err := service.repository.StoreBase(ctx, base); err != nil {
+		msg := fmt.Sprintf("cannot store knowledge base [%s]", params.Name)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return base, nil
+}
+
+// LoadBase loads an entities.KnowledgeBase by ID
+func (service *KnowledgeService) LoadBase(ctx context.Context, baseID uuid.UUID) (*entities.KnowledgeBase, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	base, err := service.repository.LoadBase(ctx, baseID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load knowledge base [%s]", baseID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return base, nil
+}
+
+// IndexBases fetches the knowledge bases
+func (service *KnowledgeService) IndexBases(ctx context.Context, params repositories.IndexParams) (*[]entities.KnowledgeBase, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	bases, err := service.repository.IndexBases(ctx, params)
+	if err != nil {
+		msg := fmt.Sprintf("cannot index knowledge bases with params [%+#v]", params)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return bases, nil
+}
+
+// KnowledgeUploadParams are parameters for adding a document to an entities.KnowledgeBase
+type KnowledgeUploadParams struct {
+	Base        *entities.KnowledgeBase
+	Name        string
+	ContentType string
+	Content     []byte
+}
+
+// Upload extracts the text of a document, splits it into chunks and stores the chunks with their embeddings.
+// The error has the code knowledge.ErrCodeUnsupportedFormat when the document is not a text, Markdown or PDF document with text.
+func (service *KnowledgeService) Upload(ctx context.Context, params *KnowledgeUploadParams) (*entities.KnowledgeDocument, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	format, err := knowledge.DetectFormat(params.Name, params.ContentType)
+	if err != nil {
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, "cannot detect the format of the document"))
+	}
+
+	text, err := knowledge.Extract(format, params.Content)
+	if err != nil {
+		msg := fmt.Sprintf("cannot extract the text of document [%s]", params.Name)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	contents := knowledge.Chunk(service.tokenizer, text, service.chunkTokens, service.chunkOverlap)
+	if len(contents) == 0 {
+		msg := fmt.Sprintf("the document [%s] does not contain any text", params.Name)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.NewErrorWithCode(knowledge.ErrCodeUnsupportedFormat, msg))
+	}
+
+	embeddings, err := service.embedder.Embed(ctx, contents)
+	if err != nil {
+		msg := fmt.Sprintf("cannot embed [%d] chunks of document [%s]", len(contents), params.Name)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	document := &entities.KnowledgeDocument{
+		ID:              uuid.New(),
+		TenantID:        params.Base.TenantID,
+		KnowledgeBaseID: params.Base.ID,
+		Name:            params.Name,
+		Format:          string(format),
+		Size:            len(params.Content),
+		Chunks:          len(contents),
+		CreatedAt:       time.Now().UTC(),
+		UpdatedAt:       time.Now().UTC(),
+	}
+
+	chunks := make([]entities.KnowledgeChunk, 0, len(contents))
+	for i, content := range contents {
+		chunks = append(chunks, entities.KnowledgeChunk{
+			ID:              uuid.New(),
+			TenantID:        document.TenantID,
+			KnowledgeBaseID: params.Base.ID,
+			DocumentID:      document.ID,
+			Position:        i,
+			Content:         content,
+			Embedding:       knowledge.EncodeEmbedding(embeddings[i]),
+			CreatedAt:       document.CreatedAt,
+		})
+	}
+
+	if err = service.repository.StoreDocument(ctx, document, chunks); err != nil {
+		msg := fmt.Sprintf("cannot store document [%s] in knowledge base [%s]", params.Name, params.Base.ID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("stored document [%s] with [%d] chunks in knowledge base [%s]", document.ID, document.Chunks, params.Base.ID))
+	return document, nil
+}
+
+// LoadDocument loads an entities.KnowledgeDocument by ID
+func (service *KnowledgeService) LoadDocument(ctx context.Context, documentID uuid.UUID) (*entities.KnowledgeDocument, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	document, err := service.repository.LoadDocument(ctx, documentID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load knowledge document [%s]", documentID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return document, nil
+}
+
+// IndexDocuments fetches the documents of a knowledge base
+func (service *KnowledgeService) IndexDocuments(ctx context.Context, baseID uuid.UUID, params repositories.IndexParams) (*[]entities.KnowledgeDocument, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	documents, err := service.repository.IndexDocuments(ctx, baseID, params)
+	if err != nil {
+		msg := fmt.Sprintf("cannot index the documents of knowledge base [%s] with params [%+#v]", baseID, params)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return documents, nil
+}
+
+// DeleteDocument deletes a document and its chunks
+func (service *KnowledgeService) DeleteDocument(ctx context.Context, document *entities.KnowledgeDocument) error {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	if err := service.repository.DeleteDocument(ctx, document); err != nil {
+		msg := fmt.Sprintf("cannot delete knowledge document [%s]", document.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// KnowledgeSearchParams are parameters for finding the chunks which answer a prompt
+type KnowledgeSearchParams struct {
+	Owner   string
+	Persona string
+	Query   string
+}
+
+// Search returns the chunks of the knowledge base of the owner or the persona which are the most similar to the query.
+// The knowledge base of the tenant of ctx is searched when it is set.
+// It returns no chunks when there is no knowledge base for the tenant, the owner and the persona.
+func (service *KnowledgeService) Search(ctx context.Context, params *KnowledgeSearchParams) ([]repositories.KnowledgeMatch, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	tenant, _ := tenancy.FromContext(ctx)
+	if params.Owner == "" && params.Persona == "" && (tenant == nil || tenant.KnowledgeBaseID == nil) {
+		return nil, nil
+	}
+
+	base, err := service.loadBase(ctx, tenant, params)
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		msg := fmt.Sprintf("cannot load the knowledge base of owner [%s]", params.Owner)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	embeddings, err := service.embedder.Embed(ctx, []string{params.Query})
+	if err != nil {
+		msg := fmt.Sprintf("cannot embed query [%s]", telemetry.RedactBody(params.Query))
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	matches, err := service.repository.Search(ctx, base.ID, embeddings[0], service.topK)
+	if err != nil {
+		msg := fmt.Sprintf("cannot search knowledge base [%s]", base.ID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	var result []repositories.KnowledgeMatch
+	for _, match := range matches {
+		if match.Score >= service.minScore {
+			result = append(result, match)
+		}
+	}
+
+	span.SetAttributes(
+		attribute.String("knowledge.base_id", base.ID.String()),
+		attribute.Int("knowledge.candidates", len(matches)),
+		attribute.Int("knowledge.matches", len(result)),
+	)
+	return result, nil
+}
+
+// loadBase loads the knowledge base of the tenant or the most recent knowledge base of the owner or the persona
+func (service *KnowledgeService) loadBase(ctx context.Context, tenant *entities.Tenant, params *KnowledgeSearchParams) (*entities.KnowledgeBase, error) {
+	if tenant != nil && tenant.KnowledgeBaseID != nil {
+		return service.repository.LoadBase(ctx, *tenant.KnowledgeBaseID)
+	}
+	return service.repository.LoadBaseFor(ctx, params.Owner, params.Persona)
+}
+
+func (service *KnowledgeService) optional(value string) *string {
+	if value = strings.TrimSpace(value); value == "" {
+		return nil
+	}
+	return &value
+}
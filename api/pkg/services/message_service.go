@@ -0,0 +1,232 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/NdoleStudio/discusswithai/pkg/entities"
+	"github.com/NdoleStudio/discusswithai/pkg/events"
+	"github.com/NdoleStudio/discusswithai/pkg/repositories"
+	"github.com/NdoleStudio/discusswithai/pkg/telemetry"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+)
+
+// MessageService is responsible for managing entities.Message
+type MessageService struct {
+	service
+	logger              telemetry.Logger
+	tracer              telemetry.Tracer
+	conversationService *ConversationService
+	dispatcher          events.Dispatcher
+	repository          repositories.MessageRepository
+}
+
+// NewMessageService creates a new MessageService
+func NewMessageService(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	conversationService *ConversationService,
+	dispatcher events.Dispatcher,
+	repository repositories.MessageRepository,
+) (s *MessageService) {
+	return &MessageService{
+		logger:              logger.WithService(fmt.Sprintf("%T", s)),
+		tracer:              tracer,
+		conversationService: conversationService,
+		dispatcher:          dispatcher,
+		repository:          repository,
+	}
+}
+
+// MessageStoreParams are parameters for storing an entities.Message
+type MessageStoreParams struct {
+	Channel           entities.Channel
+	ChannelID         string
+	Owner             string
+	Role              entities.MessageRole
+	Name              string
+	Content           string
+	ProviderMessageID string
+	Status            entities.MessageStatus
+	CallbackURL       *string
+	APIKeyID          *uuid.UUID
+}
+
+// Store a new entities.Message in the conversation between the user and the owner
+func (service *MessageService) Store(ctx context.Context, params *MessageStoreParams) (*entities.Message, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	conversation, err := service.conversationService.LoadOrStore(ctx, &ConversationLoadOrStoreParams{
+		Channel:   params.Channel,
+		ChannelID: params.ChannelID,
+		Owner:     params.Owner,
+	})
+	if err != nil {
+		msg := fmt.Sprintf("cannot load conversation for [%s] message with channel [%s]", params.Role, params.Channel)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	message := &entities.Message{
+		ID:                uuid.New(),
+		ConversationID:    conversation.ID,
+		ChannelID:         params.ChannelID,
+		Channel:           params.Channel,
+		Owner:             params.Owner,
+		Role:              params.Role,
+		Name:              params.Name,
+		Content:           params.Content,
+		ProviderMessageID: params.ProviderMessageID,
+		Status:            service.status(params),
+		CallbackURL:       params.CallbackURL,
+		APIKeyID:          params.APIKeyID,
+		CreatedAt:         time.Now().UTC(),
+		UpdatedAt:         time.Now().UTC(),
+	}
+
+	if err = service.repository.Store(ctx, message); err != nil {
+		msg := fmt.Sprintf("cannot store [%s] message in conversation [%s]", params.Role, conversation.ID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err = service.conversationService.RecordMessage(ctx, conversation, message.CreatedAt); err != nil {
+		ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot record message [%s] in conversation [%s]", message.ID, conversation.ID)))
+	}
+
+	service.publish(ctx, message)
+	return message, nil
+}
+
+// Load an entities.Message by ID
+func (service *MessageService) Load(ctx context.Context, messageID uuid.UUID) (*entities.Message, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	message, err := service.repository.Load(ctx, messageID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load message with ID [%s]", messageID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return message, nil
+}
+
+// MarkAsSent updates the status of an entities.Message after it is delivered by the provider
+func (service *MessageService) MarkAsSent(ctx context.Context, message *entities.Message, providerMessageID string) error {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	message.Status = entities.MessageStatusSent
+	message.ProviderMessageID = providerMessageID
+	message.UpdatedAt = time.Now().UTC()
+
+	if err := service.repository.Update(ctx, message); err != nil {
+		msg := fmt.Sprintf("cannot mark message [%s] as [%s]", message.ID, message.Status)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	service.publish(ctx, message)
+	return nil
+}
+
+// MarkAsFailed updates the status of an entities.Message which could not be delivered by the provider
+func (service *MessageService) MarkAsFailed(ctx context.Context, message *entities.Message, reason string) error {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	message.Status = entities.MessageStatusFailed
+	message.FailureReason = &reason
+	message.UpdatedAt = time.Now().UTC()
+
+	if err := service.repository.Update(ctx, message); err != nil {
+		msg := fmt.Sprintf("cannot mark message [%s] as [%s]", message.ID, message.Status)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	service.publish(ctx, message)
+	return nil
+}
+
+// LastReceivedAt returns the time of the last message which the user sent in a channel.
+// It returns nil when the user has never sent a message in the channel.
+func (service *MessageService) LastReceivedAt(ctx context.Context, channel entities.Channel, channelID string) (*time.Time, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	message, err := service.repository.LoadLatest(ctx, channel, channelID, entities.MessageRoleUser)
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		msg := fmt.Sprintf("cannot load the last message received from [%s] in channel [%s]", telemetry.HashChannelID(channelID), channel)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return &message.CreatedAt, nil
+}
+
+// Index fetches the entities.Message which match the filters
+func (service *MessageService) Index(ctx context.Context, params repositories.IndexParams, filters repositories.IndexFilters) (*[]entities.Message, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	messages, err := service.repository.Index(ctx, params, filters)
+	if err != nil {
+		msg := fmt.Sprintf("cannot index messages with params [%+#v]", params)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return messages, nil
+}
+
+// status defaults to entities.MessageStatusReceived for prompts and entities.MessageStatusSent for replies
+func (service *MessageService) status(params *MessageStoreParams) entities.MessageStatus {
+	if params.Status != "" {
+		return params.Status
+	}
+	if params.Role == entities.MessageRoleUser {
+		return entities.MessageStatusReceived
+	}
+	return entities.MessageStatusSent
+}
+
+// publish the event for the status of the entities.Message
+func (service *MessageService) publish(ctx context.Context, message *entities.Message) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	eventTypes := map[entities.MessageStatus]string{
+		entities.MessageStatusReceived: events.MessageReceived,
+		entities.MessageStatusSent:     events.MessageSent,
+		entities.MessageStatusFailed:   events.MessageFailed,
+	}
+
+	eventType, ok := eventTypes[message.Status]
+	if !ok {
+		return
+	}
+
+	event, err := service.createEvent(ctx, eventType, eventSource, &events.MessagePayload{
+		MessageID:         message.ID.String(),
+		ConversationID:    message.ConversationID.String(),
+		Channel:           message.Channel.String(),
+		ChannelID:         message.ChannelID,
+		Owner:             message.Owner,
+		Content:           message.Content,
+		Status:            string(message.Status),
+		ProviderMessageID: message.ProviderMessageID,
+		FailureReason:     message.FailureReason,
+	})
+	if err != nil {
+		msg := fmt.Sprintf("cannot create [%s] event for message [%s]", eventType, message.ID)
+		ctxLogger.Error(service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg)))
+		return
+	}
+
+	if err = service.dispatcher.Publish(ctx, event); err != nil {
+		msg := fmt.Sprintf("cannot publish [%s] event for message [%s]", eventType, message.ID)
+		ctxLogger.Error(service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg)))
+	}
+}
@@ -0,0 +1,98 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/NdoleStudio/discusswithai/pkg/entities"
+	"github.com/NdoleStudio/discusswithai/pkg/moderation"
+	"github.com/NdoleStudio/discusswithai/pkg/repositories"
+	"github.com/NdoleStudio/discusswithai/pkg/telemetry"
+	"github.com/palantir/stacktrace"
+)
+
+const (
+	// moderationViolationsThreshold is the number of flagged prompts after which a user is flagged
+	moderationViolationsThreshold = 3
+)
+
+// ModerationService is responsible for checking that prompts and completions respect our usage policy
+type ModerationService struct {
+	logger         telemetry.Logger
+	tracer         telemetry.Tracer
+	moderator      moderation.Moderator
+	userRepository repositories.UserRepository
+}
+
+// NewModerationService creates a new ModerationService
+func NewModerationService(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	moderator moderation.Moderator,
+	userRepository repositories.UserRepository,
+) (s *ModerationService) {
+	return &ModerationService{
+		logger:         logger.WithService(fmt.Sprintf("%T", s)),
+		tracer:         tracer,
+		moderator:      moderator,
+		userRepository: userRepository,
+	}
+}
+
+// ModerationParams are parameters for moderating content
+type ModerationParams struct {
+	Channel   entities.Channel
+	ChannelID string
+	Source    string
+	Content   string
+}
+
+// Moderate checks if the content violates our usage policy. The category scores of flagged content are logged.
+func (service *ModerationService) Moderate(ctx context.Context, params *ModerationParams) (*moderation.Result, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	result, err := service.moderator.Moderate(ctx, params.Content)
+	if err != nil {
+		msg := fmt.Sprintf("cannot moderate %s for user [%s] and channel [%s]", params.Source, telemetry.HashChannelID(params.ChannelID), params.Channel)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if result.Flagged {
+		ctxLogger.Warn(stacktrace.NewError(fmt.Sprintf(
+			"%s for user [%s] and channel [%s] was flagged for categories [%s] with scores [%s]",
+			params.Source,
+			telemetry.HashChannelID(params.ChannelID),
+			params.Channel,
+			strings.Join(result.Categories, ", "),
+			result.Scores(),
+		)))
+	}
+
+	return result, nil
+}
+
+// RecordViolation increments the number of flagged prompts of an entities.User and flags repeat offenders.
+// The count is incremented in the database so that concurrent violations of the same user are all recorded.
+func (service *ModerationService) RecordViolation(ctx context.Context, user *entities.User) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	if err := service.userRepository.RecordViolation(ctx, user); err != nil {
+		msg := fmt.Sprintf("cannot record moderation violation for user [%s]", user.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if user.ModerationViolations < moderationViolationsThreshold || user.IsFlagged() {
+		return nil
+	}
+
+	if err := service.userRepository.Flag(ctx, user); err != nil {
+		msg := fmt.Sprintf("cannot flag user [%s] after [%d] moderation violations", user.ID, user.ModerationViolations)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Warn(stacktrace.NewError(fmt.Sprintf("user [%s] has been flagged after [%d] moderation violations", user.ID, user.ModerationViolations)))
+	return nil
+}
@@ -0,0 +1,108 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/NdoleStudio/discusswithai/pkg/entities"
+	"github.com/NdoleStudio/discusswithai/pkg/repositories"
+	"github.com/NdoleStudio/discusswithai/pkg/telemetry"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestModerationService_RecordViolation(t *testing.T) {
+	t.Run("a user is not flagged below the threshold", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		repository := newViolationUserRepository()
+		service := NewModerationService(testLogger, telemetry.NewOtelLogger("test", testLogger), nil, repository)
+
+		// Arrange
+		user := &entities.User{ID: uuid.New()}
+		repository.violations[user.ID] = moderationViolationsThreshold - 2
+
+		// Act
+		err := service.RecordViolation(context.Background(), user)
+
+		// Assert
+		assert.Nil(t, err)
+		assert.Equal(t, uint(moderationViolationsThreshold-1), user.ModerationViolations)
+		assert.False(t, user.IsFlagged())
+		assert.Equal(t, 0, repository.flagged)
+	})
+
+	t.Run("a user is flagged when the stored violations reach the threshold", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		repository := newViolationUserRepository()
+		service := NewModerationService(testLogger, telemetry.NewOtelLogger("test", testLogger), nil, repository)
+
+		// Arrange
+		user := &entities.User{ID: uuid.New()}
+		// another request recorded a violation after this user was loaded
+		repository.violations[user.ID] = moderationViolationsThreshold - 1
+
+		// Act
+		err := service.RecordViolation(context.Background(), user)
+
+		// Assert
+		assert.Nil(t, err)
+		assert.Equal(t, uint(moderationViolationsThreshold), user.ModerationViolations)
+		assert.True(t, user.IsFlagged())
+		assert.Equal(t, 1, repository.flagged)
+	})
+
+	t.Run("a flagged user is not flagged again", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		repository := newViolationUserRepository()
+		service := NewModerationService(testLogger, telemetry.NewOtelLogger("test", testLogger), nil, repository)
+
+		// Arrange
+		flaggedAt := time.Now().UTC()
+		user := &entities.User{ID: uuid.New(), FlaggedAt: &flaggedAt}
+		repository.violations[user.ID] = moderationViolationsThreshold
+
+		// Act
+		err := service.RecordViolation(context.Background(), user)
+
+		// Assert
+		assert.Nil(t, err)
+		assert.Equal(t, uint(moderationViolationsThreshold+1), user.ModerationViolations)
+		assert.Equal(t, 0, repository.flagged)
+	})
+}
+
+// violationUserRepository is a repositories.UserRepository which only counts moderation violations
+type violationUserRepository struct {
+	repositories.UserRepository
+	mutex      sync.Mutex
+	violations map[uuid.UUID]uint
+	flagged    int
+}
+
+func newViolationUserRepository() *violationUserRepository {
+	return &violationUserRepository{violations: map[uuid.UUID]uint{}}
+}
+
+func (repository *violationUserRepository) RecordViolation(_ context.Context, user *entities.User) error {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	repository.violations[user.ID]++
+	user.ModerationViolations = repository.violations[user.ID]
+	return nil
+}
+
+func (repository *violationUserRepository) Flag(_ context.Context, user *entities.User) error {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	timestamp := time.Now().UTC()
+	user.FlaggedAt = &timestamp
+	repository.flagged++
+	return nil
+}
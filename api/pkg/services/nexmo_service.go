@@ -7,9 +7,11 @@ import (
 
 	"github.com/NdoleStudio/discusswithai/pkg/cache"
 	"github.com/NdoleStudio/discusswithai/pkg/entities"
+	"github.com/NdoleStudio/discusswithai/pkg/i18n"
 	"github.com/NdoleStudio/discusswithai/pkg/nexmo"
 	"github.com/NdoleStudio/discusswithai/pkg/telemetry"
 	"github.com/palantir/stacktrace"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 const (
@@ -18,27 +20,48 @@ const (
 
 // NexmoService is responsible for managing nexmo events
 type NexmoService struct {
-	logger         telemetry.Logger
-	tracer         telemetry.Tracer
-	client         *nexmo.Client
-	openAPIService *OpenAPIService
-	cache          cache.Cache
+	logger            telemetry.Logger
+	tracer            telemetry.Tracer
+	metrics           *telemetry.Metrics
+	tenantService     *TenantService
+	openAPIService    *OpenAPIService
+	userService       *UserService
+	moderationService *ModerationService
+	messageService    *MessageService
+	reminderService   *ReminderService
+	digestService     *DigestService
+	catalog           *i18n.Catalog
+	cache             cache.Cache
 }
 
 // NewNexmoService creates a new NexmoService
 func NewNexmoService(
 	logger telemetry.Logger,
 	tracer telemetry.Tracer,
-	client *nexmo.Client,
+	metrics *telemetry.Metrics,
+	tenantService *TenantService,
 	cache cache.Cache,
+	catalog *i18n.Catalog,
 	openAPIService *OpenAPIService,
+	userService *UserService,
+	moderationService *ModerationService,
+	messageService *MessageService,
+	reminderService *ReminderService,
+	digestService *DigestService,
 ) (s *NexmoService) {
 	return &NexmoService{
-		logger:         logger.WithService(fmt.Sprintf("%T", s)),
-		tracer:         tracer,
-		client:         client,
-		cache:          cache,
-		openAPIService: openAPIService,
+		logger:            logger.WithService(fmt.Sprintf("%T", s)),
+		tracer:            tracer,
+		metrics:           metrics,
+		tenantService:     tenantService,
+		cache:             cache,
+		catalog:           catalog,
+		openAPIService:    openAPIService,
+		userService:       userService,
+		moderationService: moderationService,
+		messageService:    messageService,
+		reminderService:   reminderService,
+		digestService:     digestService,
 	}
 }
 
@@ -47,6 +70,7 @@ type NexmoReceiveParams struct {
 	From        string
 	To          string
 	Message     string
+	MessageID   string
 	IsMultipart bool
 	Reference   string
 }
@@ -56,37 +80,77 @@ func (service *NexmoService) Receive(ctx context.Context, params *NexmoReceivePa
 	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
 	defer span.End()
 
+	span.SetAttributes(
+		attribute.String("channel", entities.ChannelSMS.String()),
+		telemetry.ChannelIDAttribute("channel_id", params.From),
+	)
+
+	messageType := "text"
+	if params.IsMultipart {
+		messageType = "multipart"
+	}
+	service.metrics.MessageReceived(ctx, entities.ChannelSMS.String(), messageType)
+
+	user, locale := service.loadUser(ctx, params)
+	service.storeMessage(ctx, params, entities.MessageRoleUser, params.Message, params.MessageID)
+
 	if params.IsMultipart {
-		service.handleMultipartSMS(ctx, params)
+		service.handleMultipartSMS(ctx, locale, params)
+		return
+	}
+
+	if user != nil && service.userService.IsLocaleCommand(params.Message) {
+		service.handleLocaleCommand(ctx, user, params)
+		return
+	}
+
+	if user != nil && service.reminderService.IsCommand(params.Message) {
+		service.handleReminderCommand(ctx, user, params)
+		return
+	}
+
+	if user != nil && service.digestService.IsCommand(params.Message) {
+		service.handleDigestCommand(ctx, user, params)
+		return
+	}
+
+	if user != nil && service.handleReminder(ctx, user, locale, params) {
 		return
 	}
 
 	responseText, err := service.openAPIService.GetChatCompletion(ctx, &OpenAPICompletionParams{
 		Channel:   entities.ChannelSMS,
 		ChannelID: params.From,
+		Owner:     params.To,
 		Message:   params.Message,
 	})
+	if isFlaggedContentError(err) {
+		service.handleFlaggedContent(ctx, user, locale, err, params)
+		return
+	}
+	if stacktrace.GetCode(err) == ErrCodeTenantQuotaExceeded {
+		msg := fmt.Sprintf("the daily quota was exceeded for user [%s] and channel [%s]", params.From, entities.ChannelSMS)
+		service.handleCompletionError(ctx, stacktrace.Propagate(err, msg), service.catalog.Translate(locale, i18n.KeyQuotaExceeded), params)
+		return
+	}
 	if err != nil {
 		msg := fmt.Sprintf("cannot get completion for user [%s] and channel [%s]", params.From, entities.ChannelSMS)
-		responseSMS := "We could not generate the completion using chatGPT. Please try again later."
+		responseSMS := service.catalog.Translate(locale, i18n.KeyCompletionError)
 		service.handleCompletionError(ctx, stacktrace.Propagate(err, msg), responseSMS, params)
 		return
 	}
 
 	if len(responseText) > smsCharacterLimit {
-		msg := fmt.Sprintf("The response text [%s] to [%s] contains [%d] characters which is more than [%d] chracter limit", responseText, params.From, len(responseText), smsCharacterLimit)
-		responseSMS := fmt.Sprintf("The response text contains %d characters. Contact us at arnold@discusswithai.com to receive responses with more than %d characters via sms.", len(responseText), smsCharacterLimit)
+		service.metrics.QuotaRejected(ctx, entities.ChannelSMS.String(), "sms_character_limit")
+		msg := fmt.Sprintf("The response text [%s] to [%s] contains [%d] characters which is more than [%d] chracter limit", telemetry.RedactBody(responseText), params.From, len(responseText), smsCharacterLimit)
+		responseSMS := service.catalog.Translate(locale, i18n.KeySMSCharacterLimit, len(responseText), smsCharacterLimit)
 		service.handleCompletionError(ctx, stacktrace.NewError(msg), responseSMS, params)
 		return
 	}
 
-	response, _, err := service.client.Sms.Send(ctx, &nexmo.SmsSendParams{
-		From: params.To,
-		To:   params.From,
-		Text: responseText,
-	})
+	response, err := service.send(ctx, params, responseText)
 	if err != nil {
-		msg := fmt.Sprintf("cannot send SMS to user [%s] with response [%s]", params.From, responseText)
+		msg := fmt.Sprintf("cannot send SMS to user [%s] with response [%s]", params.From, telemetry.RedactBody(responseText))
 		ctxLogger.Error(service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg)))
 		return
 	}
@@ -94,48 +158,208 @@ func (service *NexmoService) Receive(ctx context.Context, params *NexmoReceivePa
 	ctxLogger.Info(fmt.Sprintf("sent response content SMS with id [%s] to [%s] with [%d] characters", response.Messages[0].MessageID, params.To, len(responseText)))
 }
 
+// handleFlaggedContent replies with the content policy and records a violation when the prompt of the user was flagged
+func (service *NexmoService) handleFlaggedContent(ctx context.Context, user *entities.User, locale i18n.Locale, err error, params *NexmoReceiveParams) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	if user != nil && stacktrace.GetCode(err) == ErrCodePromptFlagged {
+		if err := service.moderationService.RecordViolation(ctx, user); err != nil {
+			ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot record moderation violation for user [%s]", params.From)))
+		}
+	}
+
+	msg := fmt.Sprintf("content was flagged for user [%s] and channel [%s]", params.From, entities.ChannelSMS)
+	service.handleCompletionError(ctx, stacktrace.Propagate(err, msg), service.catalog.Translate(locale, i18n.KeyContentPolicy), params)
+}
+
 func (service *NexmoService) handleCompletionError(ctx context.Context, err error, message string, params *NexmoReceiveParams) {
 	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
 	defer span.End()
 
 	ctxLogger.Error(stacktrace.Propagate(err, message))
 
-	response, _, err := service.client.Sms.Send(ctx, &nexmo.SmsSendParams{
-		From: params.To,
-		To:   params.From,
-		Text: message,
-	})
+	response, err := service.send(ctx, params, message)
 	if err != nil {
 		msg := fmt.Sprintf("cannot send completion error SMS to [%s]", params.From)
 		ctxLogger.Error(service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg)))
 		return
 	}
 
-	ctxLogger.Info(fmt.Sprintf("sent completion error message id [%s] to [%s] becasue the text [%s] was [%d] characters", response.Messages[0].MessageID, params.To, params.Message, len(params.Message)))
+	ctxLogger.Info(fmt.Sprintf("sent completion error message id [%s] to [%s] becasue the text [%s] was [%d] characters", response.Messages[0].MessageID, params.To, telemetry.RedactBody(params.Message), len(params.Message)))
 }
 
-func (service *NexmoService) handleMultipartSMS(ctx context.Context, params *NexmoReceiveParams) {
+// loadUser fetches the entities.User who sent the SMS and the i18n.Locale for replying to the user.
+// The user is nil when it cannot be loaded so that we can still reply to the SMS.
+func (service *NexmoService) loadUser(ctx context.Context, params *NexmoReceiveParams) (*entities.User, i18n.Locale) {
 	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
 	defer span.End()
 
-	key := fmt.Sprintf("sms.multipart.%s:%s:%s", params.From, params.To, params.Reference)
-	if _, err := service.cache.Get(ctx, key); err == nil {
+	user, err := service.userService.LoadOrStore(ctx, &UserLoadOrStoreParams{
+		Channel:   entities.ChannelSMS,
+		ChannelID: params.From,
+	})
+	if err != nil {
+		msg := fmt.Sprintf("cannot load user [%s] for channel [%s]", params.From, entities.ChannelSMS)
+		ctxLogger.Error(service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg)))
+		return nil, i18n.LocaleFromPhoneNumber(params.From)
+	}
+
+	return user, service.userService.Locale(user)
+}
+
+func (service *NexmoService) handleReminderCommand(ctx context.Context, user *entities.User, params *NexmoReceiveParams) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	reply, err := service.reminderService.HandleCommand(ctx, user, params.Message)
+	if err != nil {
+		msg := fmt.Sprintf("cannot handle reminder command for user [%s]", params.From)
+		service.handleCompletionError(ctx, stacktrace.Propagate(err, msg), service.catalog.Translate(service.userService.Locale(user), i18n.KeyCompletionError), params)
 		return
 	}
 
-	response, _, err := service.client.Sms.Send(ctx, &nexmo.SmsSendParams{
-		From: params.To,
-		To:   params.From,
-		Text: "We don't yet support text prompts with more than 160 characters.",
+	response, err := service.send(ctx, params, reply)
+	if err != nil {
+		msg := fmt.Sprintf("cannot send reminder command reply SMS to [%s]", params.From)
+		ctxLogger.Error(service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg)))
+		return
+	}
+
+	ctxLogger.Info(fmt.Sprintf("sent reminder command reply SMS with id [%s] to [%s]", response.Messages[0].MessageID, params.From))
+}
+
+func (service *NexmoService) handleDigestCommand(ctx context.Context, user *entities.User, params *NexmoReceiveParams) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	reply, err := service.digestService.HandleCommand(ctx, user, params.To, params.Message)
+	if err != nil {
+		msg := fmt.Sprintf("cannot handle digest command for user [%s]", params.From)
+		service.handleCompletionError(ctx, stacktrace.Propagate(err, msg), service.catalog.Translate(service.userService.Locale(user), i18n.KeyCompletionError), params)
+		return
+	}
+
+	response, err := service.send(ctx, params, reply)
+	if err != nil {
+		msg := fmt.Sprintf("cannot send digest command reply SMS to [%s]", params.From)
+		ctxLogger.Error(service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg)))
+		return
+	}
+
+	ctxLogger.Info(fmt.Sprintf("sent digest command reply SMS with id [%s] to [%s]", response.Messages[0].MessageID, params.From))
+}
+
+// handleReminder schedules a reminder when the message is a request for a reminder and replies with a confirmation.
+// It returns false when the message is not a request for a reminder so that we reply with a completion.
+func (service *NexmoService) handleReminder(ctx context.Context, user *entities.User, locale i18n.Locale, params *NexmoReceiveParams) bool {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	reply, ok, err := service.reminderService.Schedule(ctx, &ReminderScheduleParams{
+		User:    user,
+		Owner:   params.To,
+		Message: params.Message,
 	})
+	if isFlaggedContentError(err) {
+		service.handleFlaggedContent(ctx, user, locale, err, params)
+		return true
+	}
+	if err != nil {
+		ctxLogger.Error(service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, fmt.Sprintf("cannot schedule reminder for user [%s]", params.From))))
+		return false
+	}
+	if !ok {
+		return false
+	}
+
+	response, err := service.send(ctx, params, reply)
+	if err != nil {
+		msg := fmt.Sprintf("cannot send reminder reply SMS to [%s]", params.From)
+		ctxLogger.Error(service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg)))
+		return true
+	}
+
+	ctxLogger.Info(fmt.Sprintf("sent reminder reply SMS with id [%s] to [%s]", response.Messages[0].MessageID, params.From))
+	return true
+}
+
+func (service *NexmoService) handleLocaleCommand(ctx context.Context, user *entities.User, params *NexmoReceiveParams) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	reply, err := service.userService.HandleLocaleCommand(ctx, user, params.Message)
+	if err != nil {
+		msg := fmt.Sprintf("cannot handle locale command for user [%s]", params.From)
+		service.handleCompletionError(ctx, stacktrace.Propagate(err, msg), service.catalog.Translate(service.userService.Locale(user), i18n.KeyCompletionError), params)
+		return
+	}
+
+	response, err := service.send(ctx, params, reply)
+	if err != nil {
+		msg := fmt.Sprintf("cannot send locale command reply SMS to [%s]", params.From)
+		ctxLogger.Error(service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg)))
+		return
+	}
+
+	ctxLogger.Info(fmt.Sprintf("sent locale command reply SMS with id [%s] to [%s]", response.Messages[0].MessageID, params.From))
+}
+
+func (service *NexmoService) handleMultipartSMS(ctx context.Context, locale i18n.Locale, params *NexmoReceiveParams) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	// we reply once to all the parts of a multipart SMS
+	key := fmt.Sprintf("sms.multipart.%s:%s:%s", params.From, params.To, params.Reference)
+	isFirstPart, err := service.cache.SetNX(ctx, key, "", time.Hour)
+	if err != nil {
+		ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot set item in cache with key [%s]", key)))
+	}
+	if err == nil && !isFirstPart {
+		service.metrics.DedupeHit(ctx, "sms_multipart")
+		return
+	}
+
+	response, err := service.send(ctx, params, service.catalog.Translate(locale, i18n.KeySMSMultipart))
 	if err != nil {
 		ctxLogger.Error(service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, fmt.Sprintf("cannot multipart content SMS to [%s]", params.From))))
 		return
 	}
 
-	if err = service.cache.Set(ctx, key, "", time.Hour); err != nil {
-		ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot set item in redis with key [%s]", key)))
+	ctxLogger.Info(fmt.Sprintf("sent invalid content SMS with id [%s] to [%s] becasue the text [%s] was [%d] characters", response.Messages[0].MessageID, params.To, telemetry.RedactBody(params.Message), len(params.Message)))
+}
+
+// send an SMS reply to the user and store it in the conversation
+func (service *NexmoService) send(ctx context.Context, params *NexmoReceiveParams, text string) (*nexmo.SmsSendResponse, error) {
+	response, _, err := service.tenantService.NexmoClient(ctx).Sms.Send(ctx, &nexmo.SmsSendParams{
+		From: params.To,
+		To:   params.From,
+		Text: text,
+	})
+	if err != nil {
+		service.metrics.SendFailed(ctx, "nexmo")
+		return nil, err
 	}
 
-	ctxLogger.Info(fmt.Sprintf("sent invalid content SMS with id [%s] to [%s] becasue the text [%s] was [%d] characters", response.Messages[0].MessageID, params.To, params.Message, len(params.Message)))
+	service.storeMessage(ctx, params, entities.MessageRoleAssistant, text, response.Messages[0].MessageID)
+	return response, nil
+}
+
+// storeMessage stores a message in the conversation between the user and our phone number
+func (service *NexmoService) storeMessage(ctx context.Context, params *NexmoReceiveParams, role entities.MessageRole, content string, providerMessageID string) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	_, err := service.messageService.Store(ctx, &MessageStoreParams{
+		Channel:           entities.ChannelSMS,
+		ChannelID:         params.From,
+		Owner:             params.To,
+		Role:              role,
+		Content:           content,
+		ProviderMessageID: providerMessageID,
+	})
+	if err != nil {
+		msg := fmt.Sprintf("cannot store [%s] message for user [%s] and channel [%s]", role, params.From, entities.ChannelSMS)
+		ctxLogger.Error(service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg)))
+	}
 }
@@ -2,72 +2,425 @@ package services
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"strings"
+	"time"
 
+	"github.com/NdoleStudio/discusswithai/pkg/completion"
 	"github.com/NdoleStudio/discusswithai/pkg/entities"
+	"github.com/NdoleStudio/discusswithai/pkg/events"
 	"github.com/NdoleStudio/discusswithai/pkg/telemetry"
+	"github.com/NdoleStudio/discusswithai/pkg/tenancy"
+	"github.com/NdoleStudio/discusswithai/pkg/tools"
 	"github.com/palantir/stacktrace"
 	"github.com/sashabaranov/go-openai"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// maxToolResultLength is the maximum number of characters of the result of a tool which is sent to the model
+const maxToolResultLength = 2000
+
 // OpenAPIService is responsible for managing openapi events
 type OpenAPIService struct {
-	logger telemetry.Logger
-	tracer telemetry.Tracer
-	client *openai.Client
+	service
+	logger            telemetry.Logger
+	tracer            telemetry.Tracer
+	chain             *completion.Chain
+	tokenizers        *completion.Tokenizers
+	windows           *completion.Windows
+	historyService    *HistoryService
+	knowledgeService  *KnowledgeService
+	tenantService     *TenantService
+	moderationService *ModerationService
+	registry          *tools.Registry
+	maxToolSteps      int
+	dispatcher        events.Dispatcher
 }
 
 // NewOpenAPIService creates a new OpenAPIService
 func NewOpenAPIService(
 	logger telemetry.Logger,
 	tracer telemetry.Tracer,
-	client *openai.Client,
+	chain *completion.Chain,
+	tokenizers *completion.Tokenizers,
+	windows *completion.Windows,
+	historyService *HistoryService,
+	knowledgeService *KnowledgeService,
+	tenantService *TenantService,
+	moderationService *ModerationService,
+	registry *tools.Registry,
+	maxToolSteps int,
+	dispatcher events.Dispatcher,
 ) (s *OpenAPIService) {
 	return &OpenAPIService{
-		logger: logger.WithService(fmt.Sprintf("%T", s)),
-		tracer: tracer,
-		client: client,
+		logger:            logger.WithService(fmt.Sprintf("%T", s)),
+		tracer:            tracer,
+		chain:             chain,
+		tokenizers:        tokenizers,
+		windows:           windows,
+		historyService:    historyService,
+		knowledgeService:  knowledgeService,
+		tenantService:     tenantService,
+		moderationService: moderationService,
+		registry:          registry,
+		maxToolSteps:      maxToolSteps,
+		dispatcher:        dispatcher,
 	}
 }
 
-// OpenAPICompletionParams are parameters for calling the completion api
+// OpenAPICompletionParams are parameters for calling the completion api.
+// The history of the conversation with the Owner is sent with the message when the Owner is set.
 type OpenAPICompletionParams struct {
 	ChannelID string
 	Channel   entities.Channel
+	Owner     string
 	Name      string
+	Persona   string
 	Message   string
 }
 
-// GetChatCompletion returns the chat completion using GPT
+// GetChatCompletion returns the chat completion using GPT.
+// The context window of the model is split between the history of the conversation, the message and the reply.
+// The model can call the tools in the tools.Registry at most maxToolSteps times before it must reply and the messages
+// of the tool calls shrink the reply and then the history so that the request always fits in the context window.
+// The excerpts of the knowledge base of the Owner or the Persona which answer the message are sent as a system message.
+// The persona of the tenant of ctx is used when the Persona is empty and the error has the code ErrCodeTenantQuotaExceeded
+// when the tenant has sent its daily limit of messages.
 func (service *OpenAPIService) GetChatCompletion(ctx context.Context, params *OpenAPICompletionParams) (string, error) {
-	ctx, span, _ := service.tracer.StartWithLogger(ctx, service.logger)
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
 	defer span.End()
 
+	if err := service.tenantService.CheckQuota(ctx, params.Channel); err != nil {
+		return "", service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, fmt.Sprintf("cannot check the quota for prompt from [%s]", telemetry.HashChannelID(params.ChannelID))))
+	}
+
+	if err := service.moderate(ctx, params, "prompt", params.Message, ErrCodePromptFlagged); err != nil {
+		return "", service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, fmt.Sprintf("cannot moderate prompt from [%s]", telemetry.HashChannelID(params.ChannelID))))
+	}
+
 	name := "a user"
 	if params.Name != "" {
 		name = params.Name
 	}
 
-	response, err := service.client.CreateChatCompletion(ctx, openai.ChatCompletionRequest{
-		Model:     openai.GPT3Dot5Turbo,
-		MaxTokens: 3000,
+	system := fmt.Sprintf("As %s chatting with the OpenAI language model via %s.", name, params.Channel)
+	if tenant, _ := tenancy.FromContext(ctx); params.Persona == "" && tenant != nil && tenant.Persona != nil {
+		system = *tenant.Persona
+	}
+	if params.Persona != "" {
+		system = params.Persona
+	}
+
+	systemMessage := openai.ChatCompletionMessage{Role: openai.ChatMessageRoleSystem, Content: system}
+	promptMessage := openai.ChatCompletionMessage{Role: openai.ChatMessageRoleUser, Name: params.Name, Content: params.Message}
+
+	knowledgeMessages := service.knowledgeMessages(ctx, params)
+	definitions := service.registry.Definitions()
+	models := service.chain.Models()
+	tokenizer := service.tokenizers.For(models[0])
+	promptTokens := completion.CountMessages(tokenizer, systemMessage, promptMessage) + service.countDefinitions(tokenizer, definitions)
+	for _, message := range knowledgeMessages {
+		promptTokens += completion.CountMessage(tokenizer, message)
+	}
+	budget, err := service.windows.Budget(promptTokens, models...)
+	if err != nil {
+		msg := fmt.Sprintf("cannot create token budget for prompt from [%s]", telemetry.HashChannelID(params.ChannelID))
+		return "", service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	var history []openai.ChatCompletionMessage
+	if params.Owner != "" {
+		history, err = service.historyService.Messages(ctx, &HistoryParams{
+			Channel:   params.Channel,
+			ChannelID: params.ChannelID,
+			Owner:     params.Owner,
+			Prompt:    params.Message,
+			Model:     budget.Model,
+			Tokens:    budget.History,
+		})
+		if err != nil {
+			ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot load history for [%s], sending prompt without history", telemetry.HashChannelID(params.ChannelID))))
+		}
+	}
+
+	span.SetAttributes(
+		attribute.String("budget.model", budget.Model),
+		attribute.Int("budget.window", budget.Window),
+		attribute.Int("budget.prompt", budget.Prompt),
+		attribute.Int("budget.history", budget.History),
+		attribute.Int("budget.reply", budget.Reply),
+		attribute.Int("history.messages", len(history)),
+	)
+
+	request := openai.ChatCompletionRequest{Functions: definitions}
+
+	var response openai.ChatCompletionResponse
+	var toolMessages []openai.ChatCompletionMessage
+	toolTokens := 0
+	for step := 0; ; step++ {
+		if step == service.maxToolSteps && len(definitions) > 0 {
+			request.FunctionCall = "none"
+		}
+
+		// the messages of the tool calls count against the budget so the reply and then the history shrink as they grow
+		history, request.MaxTokens, err = service.windows.Shrink(tokenizer, budget, budget.Prompt+toolTokens, history)
+		if err != nil {
+			msg := fmt.Sprintf("cannot fit [%d] tool messages for prompt from [%s] in the budget", len(toolMessages), telemetry.HashChannelID(params.ChannelID))
+			return "", service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+		}
+
+		messages := append(append([]openai.ChatCompletionMessage{systemMessage}, knowledgeMessages...), history...)
+		request.Messages = append(append(messages, promptMessage), toolMessages...)
+
+		response, err = service.chain.CreateChatCompletion(ctx, request)
+		if err != nil {
+			msg := fmt.Sprintf("cannot create completion for prompt [%s]", telemetry.RedactBody(params.Message))
+			return "", service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+		}
+
+		if len(response.Choices) == 0 {
+			msg := fmt.Sprintf("the completion for prompt from [%s] has no choices", telemetry.HashChannelID(params.ChannelID))
+			return "", service.tracer.WrapErrorSpan(span, stacktrace.NewError(msg))
+		}
+
+		message := response.Choices[0].Message
+		if message.FunctionCall == nil || step == service.maxToolSteps {
+			span.SetAttributes(
+				attribute.Int("tools.steps", step),
+				attribute.Int("tools.tokens", toolTokens),
+				attribute.Int("request.history", len(history)),
+				attribute.Int("request.reply", request.MaxTokens),
+			)
+			break
+		}
+
+		toolMessage := service.callTool(ctx, params, message.FunctionCall)
+		toolMessages = append(toolMessages, message, toolMessage)
+		toolTokens += completion.CountMessage(tokenizer, message) + completion.CountMessage(tokenizer, toolMessage)
+	}
+
+	completion := strings.TrimRight(response.Choices[0].Message.Content, "\n")
+	if err = service.moderate(ctx, params, "completion", completion, ErrCodeCompletionFlagged); err != nil {
+		return "", service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, fmt.Sprintf("cannot moderate completion for [%s]", telemetry.HashChannelID(params.ChannelID))))
+	}
+
+	service.publishCompletion(ctx, params, completion)
+	return completion, nil
+}
+
+// OpenAPIReminderParams are parameters for extracting a reminder from a message
+type OpenAPIReminderParams struct {
+	ChannelID string
+	Channel   entities.Channel
+	Message   string
+	Now       time.Time
+}
+
+// OpenAPIReminder is a reminder which was requested in a message
+type OpenAPIReminder struct {
+	IsReminder bool   `json:"is_reminder"`
+	Content    string `json:"content"`
+	RemindAt   string `json:"remind_at"`
+}
+
+// ExtractReminder uses GPT to detect if a message is a request for a reminder e.g. "remind me tomorrow at 8am to call mum".
+// The model replies with JSON and RemindAt is a local time in the timezone of params.Now with the layout "2006-01-02T15:04".
+// The message and the content of the reminder are moderated like the prompt and the completion of GetChatCompletion.
+func (service *OpenAPIService) ExtractReminder(ctx context.Context, params *OpenAPIReminderParams) (*OpenAPIReminder, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	moderationParams := &OpenAPICompletionParams{Channel: params.Channel, ChannelID: params.ChannelID}
+	if err := service.moderate(ctx, moderationParams, "prompt", params.Message, ErrCodePromptFlagged); err != nil {
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, fmt.Sprintf("cannot moderate reminder prompt from [%s]", telemetry.HashChannelID(params.ChannelID))))
+	}
+
+	system := fmt.Sprintf(
+		"The current local time is %s (%s). "+
+			"Decide if the message of the user asks to be reminded of something at a later time. "+
+			`Reply only with JSON like {"is_reminder": true, "content": "call mum", "remind_at": "2006-01-02T15:04"} `+
+			"where content is what the user should be reminded of in the language of the message and remind_at is the local time of the reminder. "+
+			`Reply with {"is_reminder": false} when the message is not a request for a reminder.`,
+		params.Now.Format("Monday 2006-01-02T15:04"),
+		params.Now.Location(),
+	)
+
+	response, err := service.chain.CreateChatCompletion(ctx, openai.ChatCompletionRequest{
+		MaxTokens:   200,
+		Temperature: 0,
 		Messages: []openai.ChatCompletionMessage{
 			{
 				Role:    "system",
-				Content: fmt.Sprintf("As %s chatting with the OpenAI language model via %s.", name, params.Channel),
+				Content: system,
 			},
 			{
 				Role:    "user",
-				Name:    params.Name,
 				Content: params.Message,
 			},
 		},
 	})
 	if err != nil {
-		msg := fmt.Sprintf("cannot create completion for prompt [%s]", params.Message)
-		return "", service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+		msg := fmt.Sprintf("cannot create reminder completion for [%s]", telemetry.HashChannelID(params.ChannelID))
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if len(response.Choices) == 0 {
+		msg := fmt.Sprintf("the reminder completion for [%s] has no choices", telemetry.HashChannelID(params.ChannelID))
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.NewError(msg))
+	}
+
+	content := strings.TrimSpace(response.Choices[0].Message.Content)
+	if start, end := strings.Index(content, "{"), strings.LastIndex(content, "}"); start >= 0 && end > start {
+		content = content[start : end+1]
+	}
+
+	reminder := new(OpenAPIReminder)
+	if err = json.Unmarshal([]byte(content), reminder); err != nil {
+		msg := fmt.Sprintf("cannot unmarshal reminder completion [%s] into %T", telemetry.RedactBody(content), reminder)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
 	}
 
-	return strings.TrimRight(response.Choices[0].Message.Content, "\n"), nil
+	if !reminder.IsReminder {
+		return reminder, nil
+	}
+
+	if err = service.moderate(ctx, moderationParams, "completion", reminder.Content, ErrCodeCompletionFlagged); err != nil {
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, fmt.Sprintf("cannot moderate reminder for [%s]", telemetry.HashChannelID(params.ChannelID))))
+	}
+
+	return reminder, nil
+}
+
+// knowledgeMessages returns a system message with the excerpts of the knowledge base of the owner or the persona
+// which answer the message. The message is answered without the knowledge base when it cannot be searched.
+func (service *OpenAPIService) knowledgeMessages(ctx context.Context, params *OpenAPICompletionParams) []openai.ChatCompletionMessage {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	matches, err := service.knowledgeService.Search(ctx, &KnowledgeSearchParams{
+		Owner:   params.Owner,
+		Persona: params.Persona,
+		Query:   params.Message,
+	})
+	if err != nil {
+		ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot search knowledge base for [%s], sending prompt without it", telemetry.HashChannelID(params.ChannelID))))
+		return nil
+	}
+	if len(matches) == 0 {
+		return nil
+	}
+
+	var builder strings.Builder
+	builder.WriteString(
+		"Answer with the following excerpts of the documents of the business when they are relevant. " +
+			"Cite the documents which you use with their name in square brackets e.g. [shipping.pdf]. " +
+			"Say that you don't know when the excerpts do not answer a question about the business.",
+	)
+	for _, match := range matches {
+		builder.WriteString(fmt.Sprintf("\n\n--- %s ---\n%s", match.DocumentName, match.Content))
+		span.AddEvent("knowledge.match", trace.WithAttributes(
+			attribute.String("knowledge.chunk_id", match.ID.String()),
+			attribute.String("knowledge.document_id", match.DocumentID.String()),
+			attribute.Float64("knowledge.score", match.Score),
+		))
+	}
+
+	return []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleSystem, Content: builder.String()}}
+}
+
+// callTool calls the tool which was requested by the model and returns the result as a message for the model.
+// The error is sent to the model when the tool fails so that it can fix the arguments or reply without the tool.
+func (service *OpenAPIService) callTool(ctx context.Context, params *OpenAPICompletionParams, call *openai.FunctionCall) openai.ChatCompletionMessage {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	start := time.Now()
+	result, err := service.registry.Call(ctx, &tools.Invocation{Name: call.Name, Arguments: call.Arguments, ChannelID: params.ChannelID})
+	attributes := []attribute.KeyValue{
+		attribute.String("tool.name", call.Name),
+		telemetry.BodyAttribute("tool.arguments", call.Arguments),
+		attribute.Int64("tool.duration_ms", time.Since(start).Milliseconds()),
+	}
+	if err != nil {
+		ctxLogger.Warn(stacktrace.Propagate(err, fmt.Sprintf("cannot call tool [%s] for [%s]", call.Name, telemetry.HashChannelID(params.ChannelID))))
+		attributes = append(attributes, attribute.String("tool.error", stacktrace.RootCause(err).Error()))
+		result = fmt.Sprintf("error: %s", stacktrace.RootCause(err))
+	}
+	span.AddEvent("tool.call", trace.WithAttributes(attributes...))
+
+	if len(result) > maxToolResultLength {
+		result = strings.ToValidUTF8(result[:maxToolResultLength], "")
+	}
+
+	if params.Owner != "" {
+		err = service.historyService.RecordToolCall(ctx, &HistoryToolCallParams{
+			Channel:   params.Channel,
+			ChannelID: params.ChannelID,
+			Owner:     params.Owner,
+			Name:      call.Name,
+			Arguments: call.Arguments,
+			Result:    result,
+		})
+		if err != nil {
+			ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot record call of tool [%s] for [%s]", call.Name, telemetry.HashChannelID(params.ChannelID))))
+		}
+	}
+
+	return openai.ChatCompletionMessage{Role: openai.ChatMessageRoleFunction, Name: call.Name, Content: result}
+}
+
+// countDefinitions counts the tokens of the definitions of the tools which are sent with the prompt
+func (service *OpenAPIService) countDefinitions(tokenizer completion.Tokenizer, definitions []openai.FunctionDefinition) int {
+	if len(definitions) == 0 {
+		return 0
+	}
+	content, err := json.Marshal(definitions)
+	if err != nil {
+		return 0
+	}
+	return tokenizer.Count(string(content))
+}
+
+// moderate returns an error with the errorCode when the content is flagged by the moderation
+func (service *OpenAPIService) moderate(ctx context.Context, params *OpenAPICompletionParams, source string, content string, errorCode stacktrace.ErrorCode) error {
+	result, err := service.moderationService.Moderate(ctx, &ModerationParams{
+		Channel:   params.Channel,
+		ChannelID: params.ChannelID,
+		Source:    source,
+		Content:   content,
+	})
+	if err != nil {
+		return stacktrace.Propagate(err, fmt.Sprintf("cannot moderate %s", source))
+	}
+
+	if result.Flagged {
+		return stacktrace.NewErrorWithCode(errorCode, fmt.Sprintf("the %s was flagged for categories [%s]", source, strings.Join(result.Categories, ", ")))
+	}
+
+	return nil
+}
+
+// publishCompletion publishes the events.CompletionGenerated event
+func (service *OpenAPIService) publishCompletion(ctx context.Context, params *OpenAPICompletionParams, completion string) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	event, err := service.createEvent(ctx, events.CompletionGenerated, eventSource, &events.CompletionPayload{
+		Channel:    params.Channel.String(),
+		ChannelID:  params.ChannelID,
+		Prompt:     params.Message,
+		Completion: completion,
+	})
+	if err != nil {
+		msg := fmt.Sprintf("cannot create [%s] event for [%s]", events.CompletionGenerated, telemetry.HashChannelID(params.ChannelID))
+		ctxLogger.Error(service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg)))
+		return
+	}
+
+	if err = service.dispatcher.Publish(ctx, event); err != nil {
+		msg := fmt.Sprintf("cannot publish [%s] event for [%s]", events.CompletionGenerated, telemetry.HashChannelID(params.ChannelID))
+		ctxLogger.Error(service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg)))
+	}
 }
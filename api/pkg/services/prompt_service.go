@@ -0,0 +1,249 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/NdoleStudio/discusswithai/pkg/entities"
+	"github.com/NdoleStudio/discusswithai/pkg/nexmo"
+	"github.com/NdoleStudio/discusswithai/pkg/telemetry"
+	"github.com/NdoleStudio/discusswithai/pkg/whatsapp"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// PromptService sends AI generated messages which are triggered through the API
+type PromptService struct {
+	logger         telemetry.Logger
+	tracer         telemetry.Tracer
+	metrics        *telemetry.Metrics
+	webhookService *WebhookService
+	tenantService  *TenantService
+	openAPIService *OpenAPIService
+	messageService *MessageService
+}
+
+// NewPromptService creates a new PromptService
+func NewPromptService(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	metrics *telemetry.Metrics,
+	webhookService *WebhookService,
+	tenantService *TenantService,
+	openAPIService *OpenAPIService,
+	messageService *MessageService,
+) (s *PromptService) {
+	return &PromptService{
+		logger:         logger.WithService(fmt.Sprintf("%T", s)),
+		tracer:         tracer,
+		metrics:        metrics,
+		webhookService: webhookService,
+		tenantService:  tenantService,
+		openAPIService: openAPIService,
+		messageService: messageService,
+	}
+}
+
+// PromptSendParams are parameters for sending an AI generated message to a user
+type PromptSendParams struct {
+	Channel     entities.Channel
+	From        string
+	To          string
+	Prompt      string
+	Persona     string
+	CallbackURL *string
+	APIKeyID    *uuid.UUID
+}
+
+// Send generates a completion for the prompt and delivers it to the user.
+// The entities.Message is returned with entities.MessageStatusFailed when the provider cannot deliver it.
+// A message which was rate limited is also failed and the error has the code ErrCodeRateLimited.
+func (service *PromptService) Send(ctx context.Context, params *PromptSendParams) (*entities.Message, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("channel", params.Channel.String()),
+		telemetry.ChannelIDAttribute("channel_id", params.To),
+	)
+
+	completion, err := service.openAPIService.GetChatCompletion(ctx, &OpenAPICompletionParams{
+		Channel:   params.Channel,
+		ChannelID: params.To,
+		Persona:   params.Persona,
+		Message:   params.Prompt,
+	})
+	if err != nil {
+		msg := fmt.Sprintf("cannot get completion for [%s] message to [%s]", params.Channel, params.To)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	message, err := service.messageService.Store(ctx, &MessageStoreParams{
+		Channel:     params.Channel,
+		ChannelID:   params.To,
+		Owner:       params.From,
+		Role:        entities.MessageRoleAssistant,
+		Content:     completion,
+		Status:      entities.MessageStatusPending,
+		CallbackURL: params.CallbackURL,
+		APIKeyID:    params.APIKeyID,
+	})
+	if err != nil {
+		msg := fmt.Sprintf("cannot store [%s] message to [%s]", params.Channel, params.To)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	err = service.Deliver(ctx, message)
+	if _, ok := RateLimitDelay(err); ok {
+		// the message is not sent again because the integrator sends a new request after the delay
+		if failErr := service.fail(ctx, message, err); failErr != nil {
+			ctxLogger.Error(stacktrace.Propagate(failErr, fmt.Sprintf("cannot fail rate limited message [%s]", message.ID)))
+		}
+	}
+	if err != nil {
+		msg := fmt.Sprintf("cannot deliver [%s] message to [%s]", params.Channel, params.To)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("message [%s] to [%s] over [%s] has status [%s]", message.ID, params.To, params.Channel, message.Status))
+	return message, nil
+}
+
+// Deliver sends a pending entities.Message with the provider of the channel and enqueues its callback.
+// The entities.Message is updated with entities.MessageStatusFailed when the provider cannot deliver it.
+// A message which the provider rejected because of a rate limit stays pending without a callback and the error has the
+// code ErrCodeRateLimited so that the same message is sent again later.
+func (service *PromptService) Deliver(ctx context.Context, message *entities.Message) error {
+	return service.deliverWith(ctx, message, service.deliver)
+}
+
+// WhatsappTemplate is a whatsapp template message which can be sent outside the 24-hour session window of a user
+type WhatsappTemplate struct {
+	Name     string
+	Language string
+}
+
+// DeliverTemplate sends the entities.Message in a whatsapp template message and updates its status
+func (service *PromptService) DeliverTemplate(ctx context.Context, message *entities.Message, template *WhatsappTemplate) error {
+	return service.deliverWith(ctx, message, func(ctx context.Context, message *entities.Message) (string, error) {
+		return service.deliverTemplate(ctx, message, template)
+	})
+}
+
+// DeliverWithTemplate sends the entities.Message like Deliver and updates its status.
+// The message is sent again in the whatsapp template when whatsapp rejects it because the session window of the user is closed.
+func (service *PromptService) DeliverWithTemplate(ctx context.Context, message *entities.Message, template *WhatsappTemplate) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	return service.deliverWith(ctx, message, func(ctx context.Context, message *entities.Message) (string, error) {
+		providerMessageID, err := service.deliver(ctx, message)
+		if whatsapp.IsReengagementWindowClosed(err) {
+			ctxLogger.Info(fmt.Sprintf("sending message [%s] in a whatsapp template because the session window of [%s] is closed", message.ID, message.ChannelID))
+			return service.deliverTemplate(ctx, message, template)
+		}
+		return providerMessageID, err
+	})
+}
+
+// deliverWith sends the entities.Message with the send function, updates its status and sends its callback
+func (service *PromptService) deliverWith(ctx context.Context, message *entities.Message, send func(context.Context, *entities.Message) (string, error)) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	providerMessageID, err := send(ctx, message)
+	if whatsapp.IsRateLimited(err) {
+		msg := fmt.Sprintf("whatsapp rate limited message [%s] to [%s]", message.ID, telemetry.HashChannelID(message.ChannelID))
+		return service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, ErrCodeRateLimited, msg))
+	}
+
+	if err != nil {
+		ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot deliver message [%s]", message.ID)))
+		if err = service.fail(ctx, message, err); err != nil {
+			return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, fmt.Sprintf("cannot fail message [%s]", message.ID)))
+		}
+		return nil
+	}
+
+	if err = service.messageService.MarkAsSent(ctx, message, providerMessageID); err != nil {
+		msg := fmt.Sprintf("cannot update status of message [%s]", message.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	service.sendCallback(ctx, message)
+	return nil
+}
+
+// fail updates the entities.Message with entities.MessageStatusFailed and sends its callback
+func (service *PromptService) fail(ctx context.Context, message *entities.Message, sendErr error) error {
+	if err := service.messageService.MarkAsFailed(ctx, message, telemetry.Redact(sendErr.Error())); err != nil {
+		return stacktrace.Propagate(err, fmt.Sprintf("cannot update status of message [%s]", message.ID))
+	}
+
+	service.sendCallback(ctx, message)
+	return nil
+}
+
+// sendCallback sends the callback of the entities.Message and logs the error because the message was already delivered
+func (service *PromptService) sendCallback(ctx context.Context, message *entities.Message) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	if err := service.webhookService.SendCallback(ctx, message); err != nil {
+		ctxLogger.Error(service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, fmt.Sprintf("cannot send callback of message [%s]", message.ID))))
+	}
+}
+
+// deliverTemplate sends the entities.Message in a whatsapp template message and returns the ID of the provider message
+func (service *PromptService) deliverTemplate(ctx context.Context, message *entities.Message, template *WhatsappTemplate) (string, error) {
+	response, _, err := service.tenantService.WhatsappClient(ctx).Message.SendTemplate(ctx, &whatsapp.MessageSendTemplateParams{
+		From:     message.Owner,
+		To:       message.ChannelID,
+		Template: template.Name,
+		Language: template.Language,
+		// whatsapp does not allow new lines in the parameters of a template
+		Parameters: []string{strings.Join(strings.Fields(message.Content), " ")},
+	})
+	if err != nil {
+		service.metrics.SendFailed(ctx, "whatsapp")
+		return "", stacktrace.Propagate(err, fmt.Sprintf("cannot send whatsapp template [%s] to [%s]", template.Name, message.ChannelID))
+	}
+	return response.Messages[0].ID, nil
+}
+
+// deliver sends the entities.Message with the provider of the channel and returns the ID of the provider message
+func (service *PromptService) deliver(ctx context.Context, message *entities.Message) (string, error) {
+	switch message.Channel {
+	case entities.ChannelSMS:
+		if len(message.Content) > smsCharacterLimit {
+			service.metrics.QuotaRejected(ctx, entities.ChannelSMS.String(), "sms_character_limit")
+			return "", stacktrace.NewError(fmt.Sprintf("the completion contains [%d] characters which is more than [%d] chracter limit", len(message.Content), smsCharacterLimit))
+		}
+
+		response, _, err := service.tenantService.NexmoClient(ctx).Sms.Send(ctx, &nexmo.SmsSendParams{
+			From: message.Owner,
+			To:   message.ChannelID,
+			Text: message.Content,
+		})
+		if err != nil {
+			service.metrics.SendFailed(ctx, "nexmo")
+			return "", stacktrace.Propagate(err, fmt.Sprintf("cannot send SMS to [%s]", message.ChannelID))
+		}
+		return response.Messages[0].MessageID, nil
+	case entities.ChannelWhatsapp:
+		response, _, err := service.tenantService.WhatsappClient(ctx).Message.Send(ctx, &whatsapp.MessageSendParams{
+			From: message.Owner,
+			To:   message.ChannelID,
+			Body: message.Content,
+		})
+		if err != nil {
+			service.metrics.SendFailed(ctx, "whatsapp")
+			return "", stacktrace.Propagate(err, fmt.Sprintf("cannot send whatsapp message to [%s]", message.ChannelID))
+		}
+		return response.Messages[0].ID, nil
+	default:
+		return "", stacktrace.NewError(fmt.Sprintf("the channel [%s] is not supported", message.Channel))
+	}
+}
@@ -0,0 +1,159 @@
+package services
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/NdoleStudio/discusswithai/pkg/entities"
+	"github.com/NdoleStudio/discusswithai/pkg/events"
+	"github.com/NdoleStudio/discusswithai/pkg/repositories"
+	"github.com/NdoleStudio/discusswithai/pkg/telemetry"
+	"github.com/NdoleStudio/discusswithai/pkg/whatsapp"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/metric"
+)
+
+func TestPromptService_Deliver(t *testing.T) {
+	t.Run("a message which is rate limited by whatsapp stays pending to be retried after the delay of whatsapp", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Retry-After", "30")
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":{"message":"(#130429) Rate limit hit","type":"OAuthException","code":130429,"fbtrace_id":"AbCdEf123"}}`))
+		}))
+		defer server.Close()
+		repository := &memoryPromptMessageRepository{}
+		service := newTestPromptService(server, repository)
+
+		// Arrange
+		message := newTestPromptMessage()
+
+		// Act
+		err := service.Deliver(context.Background(), message)
+
+		// Assert
+		assert.Equal(t, ErrCodeRateLimited, stacktrace.GetCode(err))
+		delay, ok := RateLimitDelay(stacktrace.Propagate(err, "cannot deliver reminder"))
+		assert.True(t, ok)
+		assert.Equal(t, 30*time.Second, delay)
+		assert.Equal(t, entities.MessageStatusPending, message.Status)
+		assert.Empty(t, repository.updated)
+	})
+
+	t.Run("a message which is rejected by whatsapp is marked as failed", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":{"message":"(#100) Invalid parameter","type":"OAuthException","code":100,"fbtrace_id":"AbCdEf123"}}`))
+		}))
+		defer server.Close()
+		service := newTestPromptService(server, &memoryPromptMessageRepository{})
+
+		// Arrange
+		message := newTestPromptMessage()
+
+		// Act
+		err := service.Deliver(context.Background(), message)
+
+		// Assert
+		assert.Nil(t, err)
+		_, ok := RateLimitDelay(err)
+		assert.False(t, ok)
+		assert.Equal(t, entities.MessageStatusFailed, message.Status)
+	})
+}
+
+func TestPromptService_DeliverWithTemplate(t *testing.T) {
+	t.Run("a message which is rejected because the session window is closed is sent in the template", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		var bodies []string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			bodies = append(bodies, string(body))
+			if len(bodies) == 1 {
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write([]byte(`{"error":{"message":"(#131047) Re-engagement message","type":"OAuthException","code":131047,"fbtrace_id":"AbCdEf123"}}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"messaging_product":"whatsapp","messages":[{"id":"wamid.template"}]}`))
+		}))
+		defer server.Close()
+		service := newTestPromptService(server, &memoryPromptMessageRepository{})
+
+		// Arrange
+		message := newTestPromptMessage()
+
+		// Act
+		err := service.DeliverWithTemplate(context.Background(), message, &WhatsappTemplate{Name: "reminder", Language: "en"})
+
+		// Assert
+		assert.Nil(t, err)
+		assert.Equal(t, 2, len(bodies))
+		assert.Contains(t, bodies[1], `"name":"reminder"`)
+		assert.Equal(t, entities.MessageStatusSent, message.Status)
+		assert.Equal(t, "wamid.template", message.ProviderMessageID)
+	})
+}
+
+// memoryPromptMessageRepository is a repositories.MessageRepository which keeps the messages which are loaded and updated
+type memoryPromptMessageRepository struct {
+	repositories.MessageRepository
+	messages []entities.Message
+	updated  []entities.Message
+}
+
+func (repository *memoryPromptMessageRepository) Load(_ context.Context, messageID uuid.UUID) (*entities.Message, error) {
+	for _, message := range repository.messages {
+		if message.ID == messageID {
+			return &message, nil
+		}
+	}
+	return nil, stacktrace.NewErrorWithCode(repositories.ErrCodeNotFound, "the message does not exist")
+}
+
+func (repository *memoryPromptMessageRepository) LoadLatest(_ context.Context, _ entities.Channel, _ string, _ entities.MessageRole) (*entities.Message, error) {
+	return nil, stacktrace.NewErrorWithCode(repositories.ErrCodeNotFound, "the user has not sent a message")
+}
+
+func (repository *memoryPromptMessageRepository) Update(_ context.Context, message *entities.Message) error {
+	repository.updated = append(repository.updated, *message)
+	return nil
+}
+
+func newTestPromptMessage() *entities.Message {
+	return &entities.Message{
+		ID:        uuid.New(),
+		Channel:   entities.ChannelWhatsapp,
+		ChannelID: "+237677777777",
+		Owner:     "102290129340398",
+		Role:      entities.MessageRoleAssistant,
+		Content:   "Reminder: call mum",
+		Status:    entities.MessageStatusPending,
+	}
+}
+
+// newTestPromptService creates a PromptService which sends whatsapp messages to the server
+func newTestPromptService(server *httptest.Server, repository repositories.MessageRepository) *PromptService {
+	tracer := telemetry.NewOtelLogger("test", testLogger)
+	metrics, err := telemetry.NewMetrics(metric.NewNoopMeterProvider().Meter("test"))
+	if err != nil {
+		panic(err)
+	}
+
+	dispatcher := events.NewMemoryDispatcher(testLogger, tracer)
+	whatsappClient := whatsapp.New(whatsapp.WithBaseURL(server.URL), whatsapp.WithHTTPClient(server.Client()))
+	tenantService := NewTenantService(testLogger, tracer, metrics, dispatcher, nil, nil, nil, whatsappClient, nil, nil, nil)
+	messageService := NewMessageService(testLogger, tracer, nil, dispatcher, repository)
+	webhookService, _, _, _ := newTestWebhookService(server.Client())
+
+	return NewPromptService(testLogger, tracer, metrics, webhookService, tenantService, nil, messageService)
+}
@@ -0,0 +1,377 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/NdoleStudio/discusswithai/pkg/entities"
+	"github.com/NdoleStudio/discusswithai/pkg/i18n"
+	"github.com/NdoleStudio/discusswithai/pkg/queue"
+	"github.com/NdoleStudio/discusswithai/pkg/repositories"
+	"github.com/NdoleStudio/discusswithai/pkg/telemetry"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+)
+
+const (
+	// reminderCommand is the prefix of a message which lists or cancels reminders e.g "/reminders cancel 1"
+	reminderCommand = "/reminders"
+
+	// reminderTimeLayout is the layout of the time of a reminder in the messages we send to users
+	reminderTimeLayout = "2006-01-02 15:04 MST"
+
+	// reminderCompletionLayout is the layout of the local time of a reminder in the completion
+	reminderCompletionLayout = "2006-01-02T15:04"
+)
+
+// reminderKeywords are used to detect messages which may be a request for a reminder before calling the completion API
+var reminderKeywords = []string{"remind", "rappel"}
+
+// ReminderService is responsible for managing entities.Reminder
+type ReminderService struct {
+	logger           telemetry.Logger
+	tracer           telemetry.Tracer
+	catalog          *i18n.Catalog
+	repository       repositories.ReminderRepository
+	openAPIService   *OpenAPIService
+	userService      *UserService
+	messageService   *MessageService
+	promptService    *PromptService
+	tenantService    *TenantService
+	queueClient      queue.Client
+	whatsappTemplate string
+	deliveryURL      string
+	deliveryHeaders  map[string]string
+}
+
+// NewReminderService creates a new ReminderService
+func NewReminderService(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	catalog *i18n.Catalog,
+	repository repositories.ReminderRepository,
+	openAPIService *OpenAPIService,
+	userService *UserService,
+	messageService *MessageService,
+	promptService *PromptService,
+	tenantService *TenantService,
+	queueClient queue.Client,
+	whatsappTemplate string,
+	deliveryURL string,
+	deliveryHeaders map[string]string,
+) (s *ReminderService) {
+	return &ReminderService{
+		logger:           logger.WithService(fmt.Sprintf("%T", s)),
+		tracer:           tracer,
+		catalog:          catalog,
+		repository:       repository,
+		openAPIService:   openAPIService,
+		userService:      userService,
+		messageService:   messageService,
+		promptService:    promptService,
+		tenantService:    tenantService,
+		queueClient:      queueClient,
+		whatsappTemplate: whatsappTemplate,
+		deliveryURL:      deliveryURL,
+		deliveryHeaders:  deliveryHeaders,
+	}
+}
+
+// ReminderDeliverParams is the payload of the queue task which delivers an entities.Reminder
+type ReminderDeliverParams struct {
+	ReminderID uuid.UUID `json:"reminder_id"`
+}
+
+// ReminderScheduleParams are parameters for scheduling a reminder from a message
+type ReminderScheduleParams struct {
+	User    *entities.User
+	Owner   string
+	Message string
+}
+
+// Schedule detects if a message is a request for a reminder and schedules it on the channel of the user.
+// It returns the reply for the user and false when the message is not a request for a reminder.
+func (service *ReminderService) Schedule(ctx context.Context, params *ReminderScheduleParams) (string, bool, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	if !service.mayBeReminder(params.Message) {
+		return "", false, nil
+	}
+
+	location := i18n.TimezoneFromPhoneNumber(params.User.ChannelID)
+	result, err := service.openAPIService.ExtractReminder(ctx, &OpenAPIReminderParams{
+		ChannelID: params.User.ChannelID,
+		Channel:   params.User.Channel,
+		Message:   params.Message,
+		Now:       time.Now().In(location),
+	})
+	if err != nil {
+		msg := fmt.Sprintf("cannot extract reminder from message of user [%s]", params.User.ID)
+		return "", false, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if !result.IsReminder || strings.TrimSpace(result.Content) == "" {
+		return "", false, nil
+	}
+
+	remindAt, err := time.ParseInLocation(reminderCompletionLayout, result.RemindAt, location)
+	if err != nil {
+		msg := fmt.Sprintf("cannot parse reminder time [%s] for user [%s]", result.RemindAt, params.User.ID)
+		return "", false, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	locale := service.userService.Locale(params.User)
+	if !remindAt.After(time.Now()) {
+		return service.catalog.Translate(locale, i18n.KeyReminderInPast, remindAt.Format(reminderTimeLayout)), true, nil
+	}
+
+	reminder := &entities.Reminder{
+		ID:        uuid.New(),
+		UserID:    params.User.ID,
+		Channel:   params.User.Channel,
+		ChannelID: params.User.ChannelID,
+		Owner:     params.Owner,
+		Content:   strings.TrimSpace(result.Content),
+		Timezone:  location.String(),
+		RemindAt:  remindAt.UTC(),
+		Status:    entities.ReminderStatusScheduled,
+		CreatedAt: time.Now().UTC(),
+		UpdatedAt: time.Now().UTC(),
+	}
+
+	if err = service.repository.Store(ctx, reminder); err != nil {
+		msg := fmt.Sprintf("cannot store reminder for user [%s]", params.User.ID)
+		return "", false, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err = service.enqueue(ctx, reminder); err != nil {
+		msg := fmt.Sprintf("cannot enqueue reminder [%s]", reminder.ID)
+		return "", false, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("scheduled reminder [%s] for user [%s] at [%s]", reminder.ID, params.User.ID, reminder.RemindAt))
+	return service.catalog.Translate(locale, i18n.KeyReminderScheduled, reminder.Content, service.localTime(reminder)), true, nil
+}
+
+// Deliver sends a scheduled entities.Reminder to the user on the channel where it was requested.
+// Reminders which were cancelled are not sent and the reminder is sent with the credentials of its tenant.
+// The reminder is claimed before it is sent so that it is sent once when the task is delivered to more than one worker.
+// Whatsapp reminders are sent in a template message when the session window of the user has closed since the reminder was scheduled.
+// The reminder stays scheduled and the error has the code ErrCodeRateLimited when the message was rate limited so that it is retried.
+func (service *ReminderService) Deliver(ctx context.Context, reminderID uuid.UUID) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	reminder, err := service.repository.Load(ctx, reminderID)
+	if err != nil {
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, fmt.Sprintf("cannot load reminder [%s]", reminderID)))
+	}
+
+	if !reminder.IsScheduled() && !reminder.IsSending() {
+		ctxLogger.Info(fmt.Sprintf("reminder [%s] is not delivered because it has status [%s]", reminder.ID, reminder.Status))
+		return nil
+	}
+
+	claimed, err := service.repository.Claim(ctx, reminder.ID, time.Now().UTC().Add(-deliveryClaimTimeout))
+	if err != nil {
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, fmt.Sprintf("cannot claim reminder [%s]", reminder.ID)))
+	}
+	if !claimed {
+		// the task is retried so that the reminder is delivered if the worker which claimed it stops before sending it
+		return service.tracer.WrapErrorSpan(span, stacktrace.NewError(fmt.Sprintf("reminder [%s] is being delivered by another worker", reminder.ID)))
+	}
+	reminder.Status = entities.ReminderStatusSending
+
+	if err = service.send(ctx, reminder); err != nil {
+		reminder.Status = entities.ReminderStatusScheduled
+		if updateErr := service.update(ctx, reminder); updateErr != nil {
+			ctxLogger.Error(stacktrace.Propagate(updateErr, fmt.Sprintf("cannot release claim on reminder [%s]", reminder.ID)))
+		}
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, fmt.Sprintf("cannot send reminder [%s]", reminder.ID)))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("reminder [%s] with message [%s] has status [%s]", reminder.ID, reminder.MessageID, reminder.Status))
+	return nil
+}
+
+// send the message of a claimed entities.Reminder and store the status of the reminder.
+// The message is stored in the reminder before it is sent so that it is sent again instead of a new message when the
+// reminder is retried.
+func (service *ReminderService) send(ctx context.Context, reminder *entities.Reminder) error {
+	ctx, err := service.tenantService.Scope(ctx, reminder.TenantID)
+	if err != nil {
+		return stacktrace.Propagate(err, fmt.Sprintf("cannot load tenant of reminder [%s]", reminder.ID))
+	}
+
+	user, err := service.userService.LoadOrStore(ctx, &UserLoadOrStoreParams{
+		Channel:   reminder.Channel,
+		ChannelID: reminder.ChannelID,
+	})
+	if err != nil {
+		return stacktrace.Propagate(err, fmt.Sprintf("cannot load user of reminder [%s]", reminder.ID))
+	}
+
+	locale := service.userService.Locale(user)
+	message, err := service.message(ctx, reminder, locale)
+	if err != nil {
+		return stacktrace.Propagate(err, fmt.Sprintf("cannot load the message of reminder [%s]", reminder.ID))
+	}
+
+	if message.Status == entities.MessageStatusPending {
+		if reminder.Channel == entities.ChannelWhatsapp {
+			err = service.promptService.DeliverWithTemplate(ctx, message, &WhatsappTemplate{Name: service.whatsappTemplate, Language: locale.String()})
+		} else {
+			err = service.promptService.Deliver(ctx, message)
+		}
+	}
+	if err != nil {
+		return stacktrace.Propagate(err, fmt.Sprintf("cannot deliver message [%s] for reminder [%s]", message.ID, reminder.ID))
+	}
+
+	reminder.Status = entities.ReminderStatusSent
+	if message.Status == entities.MessageStatusFailed {
+		reminder.Status = entities.ReminderStatusFailed
+	}
+
+	if err = service.update(ctx, reminder); err != nil {
+		return stacktrace.Propagate(err, fmt.Sprintf("cannot update status of reminder [%s]", reminder.ID))
+	}
+
+	return nil
+}
+
+// message loads the entities.Message of a reminder which was retried or stores a new pending message for the reminder
+func (service *ReminderService) message(ctx context.Context, reminder *entities.Reminder, locale i18n.Locale) (*entities.Message, error) {
+	if reminder.MessageID != nil {
+		return service.messageService.Load(ctx, *reminder.MessageID)
+	}
+
+	message, err := service.messageService.Store(ctx, &MessageStoreParams{
+		Channel:   reminder.Channel,
+		ChannelID: reminder.ChannelID,
+		Owner:     reminder.Owner,
+		Role:      entities.MessageRoleAssistant,
+		Content:   service.catalog.Translate(locale, i18n.KeyReminderDelivery, reminder.Content),
+		Status:    entities.MessageStatusPending,
+	})
+	if err != nil {
+		return nil, stacktrace.Propagate(err, fmt.Sprintf("cannot store message for reminder [%s]", reminder.ID))
+	}
+
+	reminder.MessageID = &message.ID
+	if err = service.update(ctx, reminder); err != nil {
+		return nil, stacktrace.Propagate(err, fmt.Sprintf("cannot store message [%s] in reminder [%s]", message.ID, reminder.ID))
+	}
+
+	return message, nil
+}
+
+// IsCommand checks if a message is a command to list or cancel reminders e.g "/reminders" or "/reminders cancel 1"
+func (service *ReminderService) IsCommand(message string) bool {
+	fields := strings.Fields(strings.ToLower(message))
+	return (len(fields) == 1 && fields[0] == reminderCommand) ||
+		(len(fields) == 3 && fields[0] == reminderCommand && fields[1] == "cancel")
+}
+
+// HandleCommand lists or cancels the reminders of a user and returns the reply for the user.
+// Reminders are cancelled by their position in the list e.g "/reminders cancel 1" cancels the first reminder.
+func (service *ReminderService) HandleCommand(ctx context.Context, user *entities.User, message string) (string, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	locale := service.userService.Locale(user)
+
+	reminders, err := service.repository.Scheduled(ctx, user.ID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot fetch scheduled reminders for user [%s]", user.ID)
+		return "", service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	fields := strings.Fields(message)
+	if len(fields) == 1 {
+		return service.list(locale, *reminders), nil
+	}
+
+	position, err := strconv.Atoi(fields[2])
+	if err != nil || position < 1 || position > len(*reminders) {
+		return service.catalog.Translate(locale, i18n.KeyReminderNotFound, fields[2]), nil
+	}
+
+	reminder := &(*reminders)[position-1]
+	reminder.Status = entities.ReminderStatusCancelled
+	if err = service.update(ctx, reminder); err != nil {
+		msg := fmt.Sprintf("cannot cancel reminder [%s] for user [%s]", reminder.ID, user.ID)
+		return "", service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return service.catalog.Translate(locale, i18n.KeyReminderCancelled, reminder.Content), nil
+}
+
+func (service *ReminderService) list(locale i18n.Locale, reminders []entities.Reminder) string {
+	if len(reminders) == 0 {
+		return service.catalog.Translate(locale, i18n.KeyReminderListEmpty)
+	}
+
+	var lines []string
+	for index, reminder := range reminders {
+		lines = append(lines, fmt.Sprintf("%d. %s - %s", index+1, service.localTime(&reminder), reminder.Content))
+	}
+
+	return service.catalog.Translate(locale, i18n.KeyReminderList, strings.Join(lines, "\n"))
+}
+
+// enqueue schedules the queue task which delivers the entities.Reminder when it is due
+func (service *ReminderService) enqueue(ctx context.Context, reminder *entities.Reminder) error {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	body, err := json.Marshal(&ReminderDeliverParams{ReminderID: reminder.ID})
+	if err != nil {
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, fmt.Sprintf("cannot marshal payload for reminder [%s]", reminder.ID)))
+	}
+
+	_, err = service.queueClient.Enqueue(ctx, &queue.Task{
+		Method:           http.MethodPost,
+		URL:              service.deliveryURL,
+		Body:             body,
+		Headers:          service.deliveryHeaders,
+		ScheduleTime:     &reminder.RemindAt,
+		DeduplicationKey: "reminder:" + reminder.ID.String(),
+	})
+	if err != nil {
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, fmt.Sprintf("cannot enqueue task for reminder [%s]", reminder.ID)))
+	}
+
+	return nil
+}
+
+func (service *ReminderService) update(ctx context.Context, reminder *entities.Reminder) error {
+	reminder.UpdatedAt = time.Now().UTC()
+	return service.repository.Update(ctx, reminder)
+}
+
+// localTime formats the time of the entities.Reminder in the timezone of the user
+func (service *ReminderService) localTime(reminder *entities.Reminder) string {
+	location, err := time.LoadLocation(reminder.Timezone)
+	if err != nil {
+		location = time.UTC
+	}
+	return reminder.RemindAt.In(location).Format(reminderTimeLayout)
+}
+
+// mayBeReminder checks if a message contains a keyword which is used when asking for a reminder
+func (service *ReminderService) mayBeReminder(message string) bool {
+	message = strings.ToLower(message)
+	for _, keyword := range reminderKeywords {
+		if strings.Contains(message, keyword) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,278 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/NdoleStudio/discusswithai/pkg/completion"
+	"github.com/NdoleStudio/discusswithai/pkg/entities"
+	"github.com/NdoleStudio/discusswithai/pkg/i18n"
+	"github.com/NdoleStudio/discusswithai/pkg/moderation"
+	"github.com/NdoleStudio/discusswithai/pkg/repositories"
+	"github.com/NdoleStudio/discusswithai/pkg/telemetry"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+	"github.com/sashabaranov/go-openai"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/metric"
+)
+
+func TestReminderService_IsCommand(t *testing.T) {
+	t.Run("the commands to list and cancel reminders are detected", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		service, _ := newTestReminderService("")
+
+		for _, message := range []string{"/reminders", " /REMINDERS ", "/reminders cancel 1", "/Reminders Cancel 2"} {
+			// Act
+			isCommand := service.IsCommand(message)
+
+			// Assert
+			assert.True(t, isCommand, message)
+		}
+	})
+
+	t.Run("other messages are not commands", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		service, _ := newTestReminderService("")
+
+		for _, message := range []string{"", "remind me to call mum", "/reminders list", "/reminders cancel", "/reminders cancel 1 2", "/digest word 08:00"} {
+			// Act
+			isCommand := service.IsCommand(message)
+
+			// Assert
+			assert.False(t, isCommand, message)
+		}
+	})
+}
+
+func TestReminderService_HandleCommand(t *testing.T) {
+	t.Run("a reminder is cancelled by its position in the list", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		service, repository := newTestReminderService("")
+
+		// Arrange
+		user := newTestReminderUser()
+		repository.scheduled = []entities.Reminder{
+			{ID: uuid.New(), UserID: user.ID, Content: "call mum", Status: entities.ReminderStatusScheduled},
+			{ID: uuid.New(), UserID: user.ID, Content: "buy bread", Status: entities.ReminderStatusScheduled},
+		}
+
+		// Act
+		reply, err := service.HandleCommand(context.Background(), user, "/reminders cancel 2")
+
+		// Assert
+		assert.Nil(t, err)
+		assert.Equal(t, service.catalog.Translate(i18n.LocaleEnglish, i18n.KeyReminderCancelled, "buy bread"), reply)
+		assert.Equal(t, 1, len(repository.updated))
+		assert.Equal(t, repository.scheduled[1].ID, repository.updated[0].ID)
+		assert.Equal(t, entities.ReminderStatusCancelled, repository.updated[0].Status)
+	})
+
+	t.Run("a position which is not in the list is not found", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		service, repository := newTestReminderService("")
+
+		// Arrange
+		user := newTestReminderUser()
+		repository.scheduled = []entities.Reminder{{ID: uuid.New(), UserID: user.ID, Content: "call mum", Status: entities.ReminderStatusScheduled}}
+
+		for _, position := range []string{"0", "2", "first"} {
+			// Act
+			reply, err := service.HandleCommand(context.Background(), user, "/reminders cancel "+position)
+
+			// Assert
+			assert.Nil(t, err)
+			assert.Equal(t, service.catalog.Translate(i18n.LocaleEnglish, i18n.KeyReminderNotFound, position), reply)
+		}
+		assert.Empty(t, repository.updated)
+	})
+}
+
+func TestReminderService_Schedule(t *testing.T) {
+	t.Run("a reminder at a time which has passed is not scheduled", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		service, repository := newTestReminderService(`{"is_reminder": true, "content": "call mum", "remind_at": "2020-01-01T08:00"}`)
+
+		// Arrange
+		user := newTestReminderUser()
+		remindAt, _ := time.ParseInLocation(reminderCompletionLayout, "2020-01-01T08:00", i18n.TimezoneFromPhoneNumber(user.ChannelID))
+
+		// Act
+		reply, ok, err := service.Schedule(context.Background(), &ReminderScheduleParams{User: user, Owner: "+18005550199", Message: "remind me to call mum on the 1st of January 2020 at 8am"})
+
+		// Assert
+		assert.Nil(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, service.catalog.Translate(i18n.LocaleEnglish, i18n.KeyReminderInPast, remindAt.Format(reminderTimeLayout)), reply)
+		assert.Empty(t, repository.stored)
+	})
+}
+
+func TestReminderService_Deliver(t *testing.T) {
+	t.Run("a reminder which is claimed by another worker is not sent again", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		service, repository := newTestReminderService("")
+
+		// Arrange
+		user := newTestReminderUser()
+		reminder := entities.Reminder{ID: uuid.New(), UserID: user.ID, Content: "call mum", Status: entities.ReminderStatusSending, UpdatedAt: time.Now().UTC()}
+		repository.scheduled = []entities.Reminder{reminder}
+
+		// Act
+		err := service.Deliver(context.Background(), reminder.ID)
+
+		// Assert
+		assert.NotNil(t, err)
+		assert.Empty(t, repository.updated)
+	})
+
+	t.Run("a reminder which was rate limited sends its stored message when it is retried", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(`{"messaging_product":"whatsapp","messages":[{"id":"wamid.reminder"}]}`))
+		}))
+		defer server.Close()
+		messages := &memoryPromptMessageRepository{}
+		service, repository := newTestReminderService("")
+		service.promptService = newTestPromptService(server, messages)
+		service.messageService = service.promptService.messageService
+		service.tenantService = service.promptService.tenantService
+		service.userService = NewUserService(testLogger, telemetry.NewOtelLogger("test", testLogger), service.catalog, &identityUserRepository{})
+
+		// Arrange
+		message := newTestPromptMessage()
+		messages.messages = []entities.Message{*message}
+		reminder := entities.Reminder{ID: uuid.New(), Channel: entities.ChannelWhatsapp, ChannelID: message.ChannelID, Content: "call mum", Status: entities.ReminderStatusScheduled, MessageID: &message.ID}
+		repository.scheduled = []entities.Reminder{reminder}
+
+		// Act
+		err := service.Deliver(context.Background(), reminder.ID)
+
+		// Assert
+		assert.Nil(t, err)
+		assert.Equal(t, 1, len(messages.updated))
+		assert.Equal(t, message.ID, messages.updated[0].ID)
+		assert.Equal(t, entities.MessageStatusSent, messages.updated[0].Status)
+		assert.Equal(t, entities.ReminderStatusSent, repository.updated[len(repository.updated)-1].Status)
+	})
+
+	t.Run("a reminder which was sent is not sent again", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		service, repository := newTestReminderService("")
+
+		// Arrange
+		user := newTestReminderUser()
+		reminder := entities.Reminder{ID: uuid.New(), UserID: user.ID, Content: "call mum", Status: entities.ReminderStatusSent}
+		repository.scheduled = []entities.Reminder{reminder}
+
+		// Act
+		err := service.Deliver(context.Background(), reminder.ID)
+
+		// Assert
+		assert.Nil(t, err)
+		assert.Empty(t, repository.updated)
+	})
+}
+
+// memoryReminderRepository is a repositories.ReminderRepository which keeps reminders in memory
+type memoryReminderRepository struct {
+	repositories.ReminderRepository
+	scheduled []entities.Reminder
+	stored    []entities.Reminder
+	updated   []entities.Reminder
+}
+
+func (repository *memoryReminderRepository) Store(_ context.Context, reminder *entities.Reminder) error {
+	repository.stored = append(repository.stored, *reminder)
+	return nil
+}
+
+func (repository *memoryReminderRepository) Update(_ context.Context, reminder *entities.Reminder) error {
+	repository.updated = append(repository.updated, *reminder)
+	return nil
+}
+
+func (repository *memoryReminderRepository) Load(_ context.Context, reminderID uuid.UUID) (*entities.Reminder, error) {
+	for index := range repository.scheduled {
+		if repository.scheduled[index].ID == reminderID {
+			reminder := repository.scheduled[index]
+			return &reminder, nil
+		}
+	}
+	return nil, stacktrace.NewErrorWithCode(repositories.ErrCodeNotFound, "the reminder does not exist")
+}
+
+func (repository *memoryReminderRepository) Claim(_ context.Context, reminderID uuid.UUID, staleBefore time.Time) (bool, error) {
+	for index := range repository.scheduled {
+		reminder := &repository.scheduled[index]
+		if reminder.ID == reminderID && (reminder.IsScheduled() || (reminder.IsSending() && reminder.UpdatedAt.Before(staleBefore))) {
+			reminder.Status = entities.ReminderStatusSending
+			reminder.UpdatedAt = time.Now().UTC()
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (repository *memoryReminderRepository) Scheduled(_ context.Context, _ uuid.UUID) (*[]entities.Reminder, error) {
+	reminders := append([]entities.Reminder{}, repository.scheduled...)
+	return &reminders, nil
+}
+
+// contentCompleter is a completion.Completer which always replies with the same content
+type contentCompleter struct {
+	content string
+}
+
+func (completer *contentCompleter) CreateChatCompletion(_ context.Context, _ openai.ChatCompletionRequest) (openai.ChatCompletionResponse, error) {
+	return openai.ChatCompletionResponse{Choices: []openai.ChatCompletionChoice{{Message: openai.ChatCompletionMessage{Content: completer.content}}}}, nil
+}
+
+// allowModerator is a moderation.Moderator which does not flag any content
+type allowModerator struct{}
+
+func (moderator *allowModerator) Moderate(_ context.Context, _ string) (*moderation.Result, error) {
+	return &moderation.Result{}, nil
+}
+
+func newTestReminderUser() *entities.User {
+	locale := i18n.LocaleEnglish.String()
+	return &entities.User{ID: uuid.New(), Channel: entities.ChannelWhatsapp, ChannelID: "+237677777777", Locale: &locale}
+}
+
+// newTestReminderService creates a ReminderService whose completions reply with the content
+func newTestReminderService(content string) (*ReminderService, *memoryReminderRepository) {
+	tracer := telemetry.NewOtelLogger("test", testLogger)
+	metrics, err := telemetry.NewMetrics(metric.NewNoopMeterProvider().Meter("test"))
+	if err != nil {
+		panic(err)
+	}
+
+	chain := completion.NewChain(tracer, metrics, completion.Link{
+		Model: "gpt-3.5-turbo",
+		Provider: &completion.Provider{
+			Name:    "openai",
+			Client:  &contentCompleter{content: content},
+			Breaker: completion.NewCircuitBreaker(completion.BreakerConfig{ConsecutiveFailures: 2, ErrorRate: 1, MinRequests: 10, Window: time.Minute, Cooldown: time.Minute}),
+		},
+	})
+
+	catalog := i18n.NewCatalog()
+	repository := &memoryReminderRepository{}
+	moderationService := NewModerationService(testLogger, tracer, &allowModerator{}, nil)
+	openAPIService := NewOpenAPIService(testLogger, tracer, chain, nil, nil, nil, nil, nil, moderationService, nil, 0, nil)
+	userService := NewUserService(testLogger, tracer, catalog, nil)
+
+	return NewReminderService(testLogger, tracer, catalog, repository, openAPIService, userService, nil, nil, nil, nil, "reminder", "", nil), repository
+}
@@ -1,19 +1,81 @@
 package services
 
-//type service struct{}
-//
-//func (service *service) createEvent(eventType string, source string, payload any) (cloudevents.Event, error) {
-//	event := cloudevents.NewEvent()
-//
-//	event.SetSource(source)
-//	event.SetType(eventType)
-//	event.SetTime(time.Now().UTC())
-//	event.SetID(uuid.New().String())
-//
-//	if err := event.SetData(cloudevents.ApplicationJSON, payload); err != nil {
-//		msg := fmt.Sprintf("cannot encode %T [%#+v] as JSON", payload, payload)
-//		return event, stacktrace.Propagate(err, msg)
-//	}
-//
-//	return event, nil
-//}
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/NdoleStudio/discusswithai/pkg/events"
+	"github.com/NdoleStudio/discusswithai/pkg/tenancy"
+	"github.com/NdoleStudio/discusswithai/pkg/whatsapp"
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+)
+
+const (
+	// eventSource is the source of the events which are published by services
+	eventSource = "https://api.discusswithai.com"
+
+	// deliveryClaimTimeout is how long a reminder or a digest which was claimed by a worker is not claimed by other workers.
+	// It is longer than a delivery so that only the deliveries of workers which stopped are claimed again.
+	deliveryClaimTimeout = 10 * time.Minute
+
+	// ErrCodePromptFlagged is returned when a prompt is flagged by the moderation
+	ErrCodePromptFlagged = stacktrace.ErrorCode(2000)
+
+	// ErrCodeCompletionFlagged is returned when a generated completion is flagged by the moderation
+	ErrCodeCompletionFlagged = stacktrace.ErrorCode(2001)
+
+	// ErrCodeTenantQuotaExceeded is returned when a tenant has sent its daily limit of messages
+	ErrCodeTenantQuotaExceeded = stacktrace.ErrorCode(2002)
+
+	// ErrCodeTenantForbidden is returned when a request accesses a number or an entity of another tenant
+	ErrCodeTenantForbidden = stacktrace.ErrorCode(2003)
+
+	// ErrCodeRateLimited is returned when a message is rejected by the provider because a rate limit was reached
+	ErrCodeRateLimited = stacktrace.ErrorCode(2004)
+)
+
+// RateLimitDelay checks if err has the code ErrCodeRateLimited and returns the delay after which the request can be
+// sent again. The delay is zero when the provider did not return it.
+func RateLimitDelay(err error) (time.Duration, bool) {
+	if stacktrace.GetCode(err) != ErrCodeRateLimited {
+		return 0, false
+	}
+
+	if apiError, ok := whatsapp.AsError(err); ok && apiError.RetryAfter != nil {
+		return *apiError.RetryAfter, true
+	}
+	return 0, true
+}
+
+// isFlaggedContentError checks if an error was caused by content which was flagged by the moderation
+func isFlaggedContentError(err error) bool {
+	code := stacktrace.GetCode(err)
+	return code == ErrCodePromptFlagged || code == ErrCodeCompletionFlagged
+}
+
+// service contains helpers which are shared by services
+type service struct{}
+
+// createEvent creates a cloudevents.Event with a JSON payload.
+// The event belongs to the tenant of ctx so that it is only sent to the webhooks of the same tenant.
+func (service *service) createEvent(ctx context.Context, eventType string, source string, payload any) (cloudevents.Event, error) {
+	event := cloudevents.NewEvent()
+	if tenantID, ok := tenancy.ID(ctx); ok {
+		events.SetTenantID(&event, tenantID)
+	}
+
+	event.SetSource(source)
+	event.SetType(eventType)
+	event.SetTime(time.Now().UTC())
+	event.SetID(uuid.New().String())
+
+	if err := event.SetData(cloudevents.ApplicationJSON, payload); err != nil {
+		msg := fmt.Sprintf("cannot encode %T as JSON", payload)
+		return event, stacktrace.Propagate(err, msg)
+	}
+
+	return event, nil
+}
@@ -0,0 +1,364 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/NdoleStudio/discusswithai/pkg/cache"
+	"github.com/NdoleStudio/discusswithai/pkg/entities"
+	"github.com/NdoleStudio/discusswithai/pkg/events"
+	"github.com/NdoleStudio/discusswithai/pkg/nexmo"
+	"github.com/NdoleStudio/discusswithai/pkg/repositories"
+	"github.com/NdoleStudio/discusswithai/pkg/telemetry"
+	"github.com/NdoleStudio/discusswithai/pkg/tenancy"
+	"github.com/NdoleStudio/discusswithai/pkg/whatsapp"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// WhatsappClientFactory creates a whatsapp.Client which sends messages with an access token
+type WhatsappClientFactory func(accessToken string) *whatsapp.Client
+
+// NexmoClientFactory creates a nexmo.Client which sends SMS with an API key and secret
+type NexmoClientFactory func(apiKey string, apiSecret string) *nexmo.Client
+
+// TenantService is responsible for managing entities.Tenant and for sending messages with the credentials of the tenant.
+// The numbers which do not belong to a tenant are part of the default tenant which uses the credentials in the config.
+type TenantService struct {
+	service
+	logger            telemetry.Logger
+	tracer            telemetry.Tracer
+	metrics           *telemetry.Metrics
+	dispatcher        events.Dispatcher
+	cache             cache.Cache
+	repository        repositories.TenantRepository
+	messageRepository repositories.MessageRepository
+	whatsappClient    *whatsapp.Client
+	nexmoClient       *nexmo.Client
+	whatsappFactory   WhatsappClientFactory
+	nexmoFactory      NexmoClientFactory
+	mutex             sync.Mutex
+	whatsappClients   map[string]*whatsapp.Client
+	nexmoClients      map[string]*nexmo.Client
+}
+
+// NewTenantService creates a new TenantService.
+// The whatsappClient and the nexmoClient are used for the default tenant and for the tenants without credentials.
+func NewTenantService(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	metrics *telemetry.Metrics,
+	dispatcher events.Dispatcher,
+	cache cache.Cache,
+	repository repositories.TenantRepository,
+	messageRepository repositories.MessageRepository,
+	whatsappClient *whatsapp.Client,
+	nexmoClient *nexmo.Client,
+	whatsappFactory WhatsappClientFactory,
+	nexmoFactory NexmoClientFactory,
+) (s *TenantService) {
+	return &TenantService{
+		logger:            logger.WithService(fmt.Sprintf("%T", s)),
+		tracer:            tracer,
+		metrics:           metrics,
+		dispatcher:        dispatcher,
+		cache:             cache,
+		repository:        repository,
+		messageRepository: messageRepository,
+		whatsappClient:    whatsappClient,
+		nexmoClient:       nexmoClient,
+		whatsappFactory:   whatsappFactory,
+		nexmoFactory:      nexmoFactory,
+		whatsappClients:   map[string]*whatsapp.Client{},
+		nexmoClients:      map[string]*nexmo.Client{},
+	}
+}
+
+// TenantStoreParams are parameters for creating or updating an entities.Tenant.
+// The credentials are not changed when they are empty.
+type TenantStoreParams struct {
+	Name                string
+	Persona             string
+	KnowledgeBaseID     *uuid.UUID
+	WhatsappAccessToken string
+	NexmoAPIKey         string
+	NexmoAPISecret      string
+	DailyMessageLimit   uint
+}
+
+// Store creates a new entities.Tenant
+func (service *TenantService) Store(ctx context.Context, params *TenantStoreParams) (*entities.Tenant, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	tenant := &entities.Tenant{
+		ID:        uuid.New(),
+		CreatedAt: time.Now().UTC(),
+	}
+	service.apply(tenant, params)
+
+	if err := service.repository.Store(ctx, tenant); err != nil {
+		msg := fmt.Sprintf("cannot store tenant [%s]", params.Name)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("created tenant [%s] with name [%s]", tenant.ID, tenant.Name))
+	return tenant, nil
+}
+
+// Update changes the settings and the credentials of an entities.Tenant
+func (service *TenantService) Update(ctx context.Context, tenant *entities.Tenant, params *TenantStoreParams) (*entities.Tenant, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	service.apply(tenant, params)
+
+	if err := service.repository.Update(ctx, tenant); err != nil {
+		msg := fmt.Sprintf("cannot update tenant [%s]", tenant.ID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return tenant, nil
+}
+
+// Load fetches an entities.Tenant by ID
+func (service *TenantService) Load(ctx context.Context, tenantID uuid.UUID) (*entities.Tenant, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	tenant, err := service.repository.Load(ctx, tenantID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load tenant [%s]", tenantID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return tenant, nil
+}
+
+// Index fetches the tenants
+func (service *TenantService) Index(ctx context.Context, params repositories.IndexParams) (*[]entities.Tenant, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	tenants, err := service.repository.Index(ctx, params)
+	if err != nil {
+		msg := fmt.Sprintf("cannot index tenants with params [%+#v]", params)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return tenants, nil
+}
+
+// StoreNumber adds a number on a channel to an entities.Tenant
+func (service *TenantService) StoreNumber(ctx context.Context, tenant *entities.Tenant, channel entities.Channel, number string) (*entities.TenantNumber, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	tenantNumber := &entities.TenantNumber{
+		ID:        uuid.New(),
+		TenantID:  tenant.ID,
+		Channel:   channel,
+		Number:    number,
+		CreatedAt: time.Now().UTC(),
+	}
+
+	if err := service.repository.StoreNumber(ctx, tenantNumber); err != nil {
+		msg := fmt.Sprintf("cannot add number [%s] on channel [%s] to tenant [%s]", number, channel, tenant.ID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return tenantNumber, nil
+}
+
+// IndexNumbers fetches the numbers of an entities.Tenant
+func (service *TenantService) IndexNumbers(ctx context.Context, tenantID uuid.UUID) (*[]entities.TenantNumber, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	numbers, err := service.repository.IndexNumbers(ctx, tenantID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot index the numbers of tenant [%s]", tenantID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return numbers, nil
+}
+
+// Resolve returns a copy of ctx which is scoped to the tenant that owns the number on the channel.
+// The context is scoped to the default tenant when the number does not belong to a tenant.
+func (service *TenantService) Resolve(ctx context.Context, channel entities.Channel, number string) (context.Context, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	tenant, err := service.repository.LoadByNumber(ctx, channel, number)
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return tenancy.WithTenant(ctx, nil), nil
+	}
+	if err != nil {
+		msg := fmt.Sprintf("cannot resolve the tenant of number [%s] on channel [%s]", number, channel)
+		return ctx, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	span.SetAttributes(attribute.String("tenant.id", tenant.ID.String()))
+	return tenancy.WithTenant(ctx, tenant), nil
+}
+
+// ResolveSender returns a copy of ctx which is scoped to the tenant that owns the number which sends a message through the API.
+// When ctx is already scoped to the tenant of an API key, the number must belong to the same tenant otherwise an error
+// with the code ErrCodeTenantForbidden is returned so that an integrator cannot send with the number of another tenant.
+func (service *TenantService) ResolveSender(ctx context.Context, channel entities.Channel, number string) (context.Context, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	principalTenantID, scoped := tenancy.ID(ctx)
+
+	resolved, err := service.Resolve(ctx, channel, number)
+	if err != nil {
+		msg := fmt.Sprintf("cannot resolve the tenant of sender [%s] on channel [%s]", number, channel)
+		return ctx, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if tenantID, _ := tenancy.ID(resolved); scoped && tenantID != principalTenantID {
+		msg := fmt.Sprintf("number [%s] on channel [%s] belongs to tenant [%s] and not to tenant [%s]", number, channel, tenantID, principalTenantID)
+		return ctx, service.tracer.WrapErrorSpan(span, stacktrace.NewErrorWithCode(ErrCodeTenantForbidden, msg))
+	}
+
+	return resolved, nil
+}
+
+// Scope returns a copy of ctx which is scoped to a tenant ID. It is used by background jobs which load an entity
+// without a tenant and continue with the tenant of the entity. The uuid.Nil tenant ID is the default tenant.
+func (service *TenantService) Scope(ctx context.Context, tenantID uuid.UUID) (context.Context, error) {
+	if tenantID == uuid.Nil {
+		return tenancy.WithTenant(ctx, nil), nil
+	}
+
+	tenant, err := service.Load(ctx, tenantID)
+	if err != nil {
+		return ctx, stacktrace.Propagate(err, fmt.Sprintf("cannot scope context to tenant [%s]", tenantID))
+	}
+
+	return tenancy.WithTenant(ctx, tenant), nil
+}
+
+// CheckQuota returns an error with the code ErrCodeTenantQuotaExceeded when the tenant of ctx has already sent
+// its daily limit of AI generated messages since midnight UTC.
+func (service *TenantService) CheckQuota(ctx context.Context, channel entities.Channel) error {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	tenant, _ := tenancy.FromContext(ctx)
+	if tenant == nil || tenant.DailyMessageLimit == 0 {
+		return nil
+	}
+
+	since := time.Now().UTC().Truncate(24 * time.Hour)
+	count, err := service.messageRepository.CountSince(ctx, entities.MessageRoleAssistant, since)
+	if err != nil {
+		msg := fmt.Sprintf("cannot count the messages of tenant [%s] since [%s]", tenant.ID, since)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if count >= int64(tenant.DailyMessageLimit) {
+		service.metrics.QuotaRejected(ctx, channel.String(), "tenant_daily_limit")
+		service.publishQuotaExceeded(ctx, tenant, channel, count)
+		msg := fmt.Sprintf("tenant [%s] has sent [%d] messages which is the daily limit of [%d]", tenant.ID, count, tenant.DailyMessageLimit)
+		return service.tracer.WrapErrorSpan(span, stacktrace.NewErrorWithCode(ErrCodeTenantQuotaExceeded, msg))
+	}
+
+	return nil
+}
+
+// publishQuotaExceeded publishes the events.TenantQuotaExceeded event so that the tenant is notified on its webhooks.
+// The event is published once per tenant per day and not for every message which is rejected after the limit is reached.
+func (service *TenantService) publishQuotaExceeded(ctx context.Context, tenant *entities.Tenant, channel entities.Channel, count int64) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	key := fmt.Sprintf("quota_exceeded:%s:%s", tenant.ID, time.Now().UTC().Format("2006-01-02"))
+	isFirst, err := service.cache.SetNX(ctx, key, "", 24*time.Hour)
+	if err != nil {
+		ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot set item in cache with key [%s]", key)))
+	}
+	if err == nil && !isFirst {
+		return
+	}
+
+	event, err := service.createEvent(ctx, events.TenantQuotaExceeded, eventSource, &events.TenantQuotaPayload{
+		TenantID:          tenant.ID.String(),
+		Channel:           channel.String(),
+		DailyMessageLimit: tenant.DailyMessageLimit,
+		MessagesSent:      count,
+	})
+	if err != nil {
+		msg := fmt.Sprintf("cannot create [%s] event for tenant [%s]", events.TenantQuotaExceeded, tenant.ID)
+		ctxLogger.Error(service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg)))
+		return
+	}
+
+	if err = service.dispatcher.Publish(ctx, event); err != nil {
+		msg := fmt.Sprintf("cannot publish [%s] event for tenant [%s]", events.TenantQuotaExceeded, tenant.ID)
+		ctxLogger.Error(service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg)))
+	}
+}
+
+// WhatsappClient returns the whatsapp.Client which sends messages with the access token of the tenant of ctx
+func (service *TenantService) WhatsappClient(ctx context.Context) *whatsapp.Client {
+	tenant, _ := tenancy.FromContext(ctx)
+	if tenant == nil || !tenant.HasWhatsappCredentials() {
+		return service.whatsappClient
+	}
+
+	service.mutex.Lock()
+	defer service.mutex.Unlock()
+
+	key := *tenant.WhatsappAccessToken
+	if _, ok := service.whatsappClients[key]; !ok {
+		service.whatsappClients[key] = service.whatsappFactory(key)
+	}
+	return service.whatsappClients[key]
+}
+
+// NexmoClient returns the nexmo.Client which sends SMS with the API key of the tenant of ctx
+func (service *TenantService) NexmoClient(ctx context.Context) *nexmo.Client {
+	tenant, _ := tenancy.FromContext(ctx)
+	if tenant == nil || !tenant.HasNexmoCredentials() {
+		return service.nexmoClient
+	}
+
+	service.mutex.Lock()
+	defer service.mutex.Unlock()
+
+	key := *tenant.NexmoAPIKey + ":" + *tenant.NexmoAPISecret
+	if _, ok := service.nexmoClients[key]; !ok {
+		service.nexmoClients[key] = service.nexmoFactory(*tenant.NexmoAPIKey, *tenant.NexmoAPISecret)
+	}
+	return service.nexmoClients[key]
+}
+
+func (service *TenantService) apply(tenant *entities.Tenant, params *TenantStoreParams) {
+	tenant.Name = params.Name
+	tenant.Persona = service.optional(params.Persona)
+	tenant.KnowledgeBaseID = params.KnowledgeBaseID
+	tenant.DailyMessageLimit = params.DailyMessageLimit
+	tenant.UpdatedAt = time.Now().UTC()
+
+	if token := service.optional(params.WhatsappAccessToken); token != nil {
+		tenant.WhatsappAccessToken = token
+	}
+	if key, secret := service.optional(params.NexmoAPIKey), service.optional(params.NexmoAPISecret); key != nil && secret != nil {
+		tenant.NexmoAPIKey = key
+		tenant.NexmoAPISecret = secret
+	}
+}
+
+func (service *TenantService) optional(value string) *string {
+	if value = strings.TrimSpace(value); value == "" {
+		return nil
+	}
+	return &value
+}
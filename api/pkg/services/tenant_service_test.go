@@ -0,0 +1,206 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/NdoleStudio/discusswithai/pkg/cache"
+	"github.com/NdoleStudio/discusswithai/pkg/entities"
+	"github.com/NdoleStudio/discusswithai/pkg/events"
+	"github.com/NdoleStudio/discusswithai/pkg/repositories"
+	"github.com/NdoleStudio/discusswithai/pkg/telemetry"
+	"github.com/NdoleStudio/discusswithai/pkg/tenancy"
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/google/uuid"
+	"github.com/hirosassa/zerodriver"
+	"github.com/palantir/stacktrace"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// testLogger is created once because zerodriver.NewDevelopmentLogger sets the global log level
+var testLogger = telemetry.NewZerologLogger("test", map[string]string{}, zerodriver.NewDevelopmentLogger(), nil)
+
+func TestTenantService_ResolveSender(t *testing.T) {
+	t.Run("an integrator cannot send with the number of another tenant", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+
+		// Arrange
+		owner := &entities.Tenant{ID: uuid.New()}
+		integrator := &entities.Tenant{ID: uuid.New()}
+		service := newTestTenantService(map[string]*entities.Tenant{"+18005550199": owner})
+
+		// Act
+		_, err := service.ResolveSender(tenancy.WithTenant(context.Background(), integrator), entities.ChannelSMS, "+18005550199")
+
+		// Assert
+		assert.Equal(t, ErrCodeTenantForbidden, stacktrace.GetCode(err))
+	})
+
+	t.Run("an integrator of the default tenant cannot send with the number of a tenant", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+
+		// Arrange
+		owner := &entities.Tenant{ID: uuid.New()}
+		service := newTestTenantService(map[string]*entities.Tenant{"+18005550199": owner})
+
+		// Act
+		_, err := service.ResolveSender(tenancy.WithTenant(context.Background(), nil), entities.ChannelSMS, "+18005550199")
+
+		// Assert
+		assert.Equal(t, ErrCodeTenantForbidden, stacktrace.GetCode(err))
+	})
+
+	t.Run("an integrator can send with the number of its tenant", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+
+		// Arrange
+		owner := &entities.Tenant{ID: uuid.New()}
+		service := newTestTenantService(map[string]*entities.Tenant{"+18005550199": owner})
+
+		// Act
+		ctx, err := service.ResolveSender(tenancy.WithTenant(context.Background(), owner), entities.ChannelSMS, "+18005550199")
+
+		// Assert
+		assert.Nil(t, err)
+		tenantID, _ := tenancy.ID(ctx)
+		assert.Equal(t, owner.ID, tenantID)
+	})
+
+	t.Run("an administrator sends with the tenant which owns the number", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+
+		// Arrange
+		owner := &entities.Tenant{ID: uuid.New()}
+		service := newTestTenantService(map[string]*entities.Tenant{"+18005550199": owner})
+
+		// Act
+		ctx, err := service.ResolveSender(tenancy.WithAllTenants(context.Background()), entities.ChannelSMS, "+18005550199")
+
+		// Assert
+		assert.Nil(t, err)
+		tenantID, _ := tenancy.ID(ctx)
+		assert.Equal(t, owner.ID, tenantID)
+	})
+}
+
+func TestTenantService_CheckQuota(t *testing.T) {
+	t.Run("a quota exceeded event is published when the daily limit is reached", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		dispatcher := events.NewMemoryDispatcher(testLogger, telemetry.NewOtelLogger("test", testLogger))
+		service := newTestQuotaTenantService(dispatcher, 10)
+
+		// Arrange
+		tenant := &entities.Tenant{ID: uuid.New(), DailyMessageLimit: 10}
+		var published []cloudevents.Event
+		dispatcher.Subscribe(events.TenantQuotaExceeded, func(ctx context.Context, event cloudevents.Event) error {
+			published = append(published, event)
+			return nil
+		})
+
+		// Act
+		err := service.CheckQuota(tenancy.WithTenant(context.Background(), tenant), entities.ChannelSMS)
+
+		// Assert
+		assert.Equal(t, ErrCodeTenantQuotaExceeded, stacktrace.GetCode(err))
+		assert.Equal(t, 1, len(published))
+
+		tenantID, _ := events.TenantID(published[0])
+		assert.Equal(t, tenant.ID, tenantID)
+
+		payload := new(events.TenantQuotaPayload)
+		assert.Nil(t, published[0].DataAs(payload))
+		assert.Equal(t, int64(10), payload.MessagesSent)
+		assert.Equal(t, entities.ChannelSMS.String(), payload.Channel)
+	})
+
+	t.Run("a quota exceeded event is published once per day for a tenant", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		dispatcher := events.NewMemoryDispatcher(testLogger, telemetry.NewOtelLogger("test", testLogger))
+		service := newTestQuotaTenantService(dispatcher, 10)
+
+		// Arrange
+		tenant := &entities.Tenant{ID: uuid.New(), DailyMessageLimit: 10}
+		published := 0
+		dispatcher.Subscribe(events.TenantQuotaExceeded, func(ctx context.Context, event cloudevents.Event) error {
+			published++
+			return nil
+		})
+		ctx := tenancy.WithTenant(context.Background(), tenant)
+
+		// Act
+		firstErr := service.CheckQuota(ctx, entities.ChannelSMS)
+		secondErr := service.CheckQuota(ctx, entities.ChannelWhatsapp)
+
+		// Assert
+		assert.Equal(t, ErrCodeTenantQuotaExceeded, stacktrace.GetCode(firstErr))
+		assert.Equal(t, ErrCodeTenantQuotaExceeded, stacktrace.GetCode(secondErr))
+		assert.Equal(t, 1, published)
+	})
+
+	t.Run("no event is published below the daily limit", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		dispatcher := events.NewMemoryDispatcher(testLogger, telemetry.NewOtelLogger("test", testLogger))
+		service := newTestQuotaTenantService(dispatcher, 9)
+
+		// Arrange
+		tenant := &entities.Tenant{ID: uuid.New(), DailyMessageLimit: 10}
+		published := 0
+		dispatcher.Subscribe(events.TenantQuotaExceeded, func(ctx context.Context, event cloudevents.Event) error {
+			published++
+			return nil
+		})
+
+		// Act
+		err := service.CheckQuota(tenancy.WithTenant(context.Background(), tenant), entities.ChannelSMS)
+
+		// Assert
+		assert.Nil(t, err)
+		assert.Equal(t, 0, published)
+	})
+}
+
+// countMessageRepository is a repositories.MessageRepository which only counts messages
+type countMessageRepository struct {
+	repositories.MessageRepository
+	count int64
+}
+
+func (repository *countMessageRepository) CountSince(_ context.Context, _ entities.MessageRole, _ time.Time) (int64, error) {
+	return repository.count, nil
+}
+
+func newTestQuotaTenantService(dispatcher events.Dispatcher, count int64) *TenantService {
+	metrics, err := telemetry.NewMetrics(metric.NewNoopMeterProvider().Meter("test"))
+	if err != nil {
+		panic(err)
+	}
+	return NewTenantService(testLogger, telemetry.NewOtelLogger("test", testLogger), metrics, dispatcher, cache.NewMemoryCache(10), nil, &countMessageRepository{count: count}, nil, nil, nil, nil)
+}
+
+// numberTenantRepository is a repositories.TenantRepository which only resolves numbers
+type numberTenantRepository struct {
+	repositories.TenantRepository
+	numbers map[string]*entities.Tenant
+}
+
+func (repository *numberTenantRepository) LoadByNumber(_ context.Context, _ entities.Channel, number string) (*entities.Tenant, error) {
+	tenant, ok := repository.numbers[number]
+	if !ok {
+		return nil, stacktrace.NewErrorWithCode(repositories.ErrCodeNotFound, "number [%s] does not belong to a tenant", number)
+	}
+	return tenant, nil
+}
+
+func newTestTenantService(numbers map[string]*entities.Tenant) *TenantService {
+	repository := &numberTenantRepository{numbers: numbers}
+	return NewTenantService(testLogger, telemetry.NewOtelLogger("test", testLogger), nil, nil, nil, repository, nil, nil, nil, nil, nil)
+}
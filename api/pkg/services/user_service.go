@@ -0,0 +1,138 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/NdoleStudio/discusswithai/pkg/entities"
+	"github.com/NdoleStudio/discusswithai/pkg/i18n"
+	"github.com/NdoleStudio/discusswithai/pkg/repositories"
+	"github.com/NdoleStudio/discusswithai/pkg/telemetry"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+)
+
+const (
+	// localeCommand is the prefix of a message which changes the locale of a user e.g "/lang fr"
+	localeCommand = "/lang"
+)
+
+// UserService is responsible for managing entities.User
+type UserService struct {
+	logger     telemetry.Logger
+	tracer     telemetry.Tracer
+	catalog    *i18n.Catalog
+	repository repositories.UserRepository
+}
+
+// NewUserService creates a new UserService
+func NewUserService(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	catalog *i18n.Catalog,
+	repository repositories.UserRepository,
+) (s *UserService) {
+	return &UserService{
+		logger:     logger.WithService(fmt.Sprintf("%T", s)),
+		tracer:     tracer,
+		catalog:    catalog,
+		repository: repository,
+	}
+}
+
+// UserLoadOrStoreParams are parameters for loading or creating an entities.User
+type UserLoadOrStoreParams struct {
+	Channel   entities.Channel
+	ChannelID string
+	Name      string
+}
+
+// LoadOrStore fetches an entities.User and creates it if it doesn't exist
+func (service *UserService) LoadOrStore(ctx context.Context, params *UserLoadOrStoreParams) (*entities.User, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	user, err := service.repository.LoadOrStore(ctx, &entities.User{
+		ID:        uuid.New(),
+		Channel:   params.Channel,
+		ChannelID: params.ChannelID,
+		Name:      params.Name,
+		CreatedAt: time.Now().UTC(),
+		UpdatedAt: time.Now().UTC(),
+	})
+	if err != nil {
+		msg := fmt.Sprintf("cannot load or store user with channel [%s] and channel ID [%s]", params.Channel, params.ChannelID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return user, nil
+}
+
+// UpdateLocale sets the preferred i18n.Locale of an entities.User
+func (service *UserService) UpdateLocale(ctx context.Context, user *entities.User, locale i18n.Locale) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	value := locale.String()
+	user.Locale = &value
+	user.UpdatedAt = time.Now().UTC()
+
+	if err := service.repository.Update(ctx, user); err != nil {
+		msg := fmt.Sprintf("cannot update locale of user [%s] to [%s]", user.ID, locale)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("updated locale of user [%s] to [%s]", user.ID, locale))
+	return nil
+}
+
+// Locale returns the i18n.Locale which should be used when replying to an entities.User
+func (service *UserService) Locale(user *entities.User) i18n.Locale {
+	if user.Locale != nil {
+		if locale, ok := i18n.ParseLocale(*user.Locale); ok {
+			return locale
+		}
+	}
+	return i18n.LocaleFromPhoneNumber(user.ChannelID)
+}
+
+// IsLocaleCommand checks if a message is a command to change the locale of a user e.g "/lang fr"
+func (service *UserService) IsLocaleCommand(message string) bool {
+	fields := strings.Fields(strings.ToLower(message))
+	return len(fields) == 2 && fields[0] == localeCommand
+}
+
+// HandleLocaleCommand changes the locale of a user using a command like "/lang fr" and returns the reply for the user
+func (service *UserService) HandleLocaleCommand(ctx context.Context, user *entities.User, message string) (string, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	value := strings.Fields(message)[1]
+	locale, ok := i18n.ParseLocale(value)
+	if !ok {
+		return service.catalog.Translate(service.Locale(user), i18n.KeyLocaleNotSupported, value, service.catalog.SupportedLocales()), nil
+	}
+
+	if err := service.UpdateLocale(ctx, user, locale); err != nil {
+		msg := fmt.Sprintf("cannot handle locale command [%s] for user [%s]", message, user.ID)
+		return "", service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return service.catalog.Translate(locale, i18n.KeyLocaleUpdated), nil
+}
+
+// Index fetches the entities.User which match the filters
+func (service *UserService) Index(ctx context.Context, params repositories.IndexParams, filters repositories.IndexFilters) (*[]entities.User, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	users, err := service.repository.Index(ctx, params, filters)
+	if err != nil {
+		msg := fmt.Sprintf("cannot index users with params [%+#v]", params)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return users, nil
+}
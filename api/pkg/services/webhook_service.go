@@ -0,0 +1,450 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/NdoleStudio/discusswithai/pkg/entities"
+	"github.com/NdoleStudio/discusswithai/pkg/events"
+	"github.com/NdoleStudio/discusswithai/pkg/queue"
+	"github.com/NdoleStudio/discusswithai/pkg/repositories"
+	"github.com/NdoleStudio/discusswithai/pkg/telemetry"
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+)
+
+const (
+	webhookSignatureHeader   = "X-Signature-256"
+	webhookTimestampHeader   = "X-Signature-Timestamp"
+	webhookSigningKeyPrefix  = "whsec_"
+	webhookMaxAttempts       = 5
+	webhookInitialRetryDelay = 2 * time.Second
+)
+
+// WebhookService is responsible for managing entities.Webhook and sending events to them
+type WebhookService struct {
+	logger             telemetry.Logger
+	tracer             telemetry.Tracer
+	httpClient         *http.Client
+	repository         repositories.WebhookRepository
+	deliveryRepository repositories.WebhookDeliveryRepository
+	apiKeyRepository   repositories.APIKeyRepository
+	queueClient        queue.Client
+	deliveryURL        string
+	deliveryHeaders    map[string]string
+}
+
+// NewWebhookService creates a new WebhookService
+func NewWebhookService(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	httpClient *http.Client,
+	repository repositories.WebhookRepository,
+	deliveryRepository repositories.WebhookDeliveryRepository,
+	apiKeyRepository repositories.APIKeyRepository,
+	queueClient queue.Client,
+	deliveryURL string,
+	deliveryHeaders map[string]string,
+) (s *WebhookService) {
+	return &WebhookService{
+		logger:             logger.WithService(fmt.Sprintf("%T", s)),
+		tracer:             tracer,
+		httpClient:         httpClient,
+		repository:         repository,
+		deliveryRepository: deliveryRepository,
+		apiKeyRepository:   apiKeyRepository,
+		queueClient:        queueClient,
+		deliveryURL:        deliveryURL,
+		deliveryHeaders:    deliveryHeaders,
+	}
+}
+
+// WebhookStoreParams are parameters for creating an entities.Webhook which receives the events of a tenant
+type WebhookStoreParams struct {
+	TenantID uuid.UUID
+	APIKeyID uuid.UUID
+	URL      string
+	Events   []string
+}
+
+// Store creates a new entities.Webhook. The signing key is returned only once so that the integrator can verify events.
+func (service *WebhookService) Store(ctx context.Context, params *WebhookStoreParams) (*entities.Webhook, string, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, "", service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, "cannot generate random signing key"))
+	}
+
+	webhook := &entities.Webhook{
+		ID:         uuid.New(),
+		TenantID:   params.TenantID,
+		APIKeyID:   params.APIKeyID,
+		URL:        params.URL,
+		SigningKey: webhookSigningKeyPrefix + hex.EncodeToString(secret),
+		Events:     params.Events,
+		CreatedAt:  time.Now().UTC(),
+		UpdatedAt:  time.Now().UTC(),
+	}
+
+	if err := service.repository.Store(ctx, webhook); err != nil {
+		msg := fmt.Sprintf("cannot store webhook for API key [%s]", params.APIKeyID)
+		return nil, "", service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("created webhook [%s] for API key [%s] with events %v", webhook.ID, webhook.APIKeyID, webhook.Events))
+	return webhook, webhook.SigningKey, nil
+}
+
+// Load an entities.Webhook by ID
+func (service *WebhookService) Load(ctx context.Context, webhookID uuid.UUID) (*entities.Webhook, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	webhook, err := service.repository.Load(ctx, webhookID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load webhook with ID [%s]", webhookID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return webhook, nil
+}
+
+// Index fetches the entities.Webhook of an API key. All webhooks are fetched when the apiKeyID is nil.
+func (service *WebhookService) Index(ctx context.Context, apiKeyID *uuid.UUID, params repositories.IndexParams) (*[]entities.Webhook, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	webhooks, err := service.repository.Index(ctx, apiKeyID, params)
+	if err != nil {
+		msg := fmt.Sprintf("cannot index webhooks with params [%+#v]", params)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return webhooks, nil
+}
+
+// Delete an entities.Webhook
+func (service *WebhookService) Delete(ctx context.Context, webhook *entities.Webhook) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	if err := service.repository.Delete(ctx, webhook); err != nil {
+		msg := fmt.Sprintf("cannot delete webhook with ID [%s]", webhook.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("deleted webhook with ID [%s]", webhook.ID))
+	return nil
+}
+
+// IndexDeliveries fetches the entities.WebhookDelivery of an entities.Webhook
+func (service *WebhookService) IndexDeliveries(ctx context.Context, webhookID uuid.UUID, params repositories.IndexParams) (*[]entities.WebhookDelivery, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	deliveries, err := service.deliveryRepository.Index(ctx, webhookID, params)
+	if err != nil {
+		msg := fmt.Sprintf("cannot index deliveries for webhook [%s] with params [%+#v]", webhookID, params)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return deliveries, nil
+}
+
+// WebhookDeliverParams is the payload of the queue task which attempts an entities.WebhookDelivery
+type WebhookDeliverParams struct {
+	DeliveryID uuid.UUID `json:"delivery_id"`
+}
+
+// Send the cloudevents.Event to the entities.Webhook of its tenant which are subscribed to the event type.
+// Each attempt is a queue task and the failed attempts are retried with an exponential backoff.
+func (service *WebhookService) Send(ctx context.Context, event cloudevents.Event) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	tenantID, ok := events.TenantID(event)
+	if !ok {
+		ctxLogger.Warn(stacktrace.NewError(fmt.Sprintf("event [%s] with type [%s] is not sent to webhooks because it has no tenant", event.ID(), event.Type())))
+		return nil
+	}
+
+	webhooks, err := service.repository.LoadByEvent(ctx, tenantID, event.Type())
+	if err != nil {
+		msg := fmt.Sprintf("cannot load webhooks of tenant [%s] for event [%s]", tenantID, event.Type())
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if len(*webhooks) == 0 {
+		return nil
+	}
+
+	body, err := event.MarshalJSON()
+	if err != nil {
+		msg := fmt.Sprintf("cannot marshal event [%s] with type [%s] into JSON", event.ID(), event.Type())
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	for _, webhook := range *webhooks {
+		delivery := &entities.WebhookDelivery{
+			ID:        uuid.New(),
+			TenantID:  webhook.TenantID,
+			WebhookID: webhook.ID,
+			EventID:   event.ID(),
+			EventType: event.Type(),
+			Payload:   string(body),
+			Status:    entities.WebhookDeliveryStatusPending,
+			CreatedAt: time.Now().UTC(),
+			UpdatedAt: time.Now().UTC(),
+		}
+
+		if err = service.deliveryRepository.Store(ctx, delivery); err != nil {
+			msg := fmt.Sprintf("cannot store delivery of event [%s] to webhook [%s]", event.ID(), webhook.ID)
+			ctxLogger.Error(service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg)))
+			continue
+		}
+
+		if err = service.enqueue(ctx, delivery, time.Now().UTC()); err != nil {
+			msg := fmt.Sprintf("cannot enqueue delivery [%s] of event [%s] to webhook [%s]", delivery.ID, event.ID(), webhook.ID)
+			ctxLogger.Error(service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg)))
+		}
+	}
+
+	ctxLogger.Info(fmt.Sprintf("sending event [%s] with type [%s] to [%d] webhooks", event.ID(), event.Type(), len(*webhooks)))
+	return nil
+}
+
+// Deliver makes one attempt to send an entities.WebhookDelivery to its entities.Webhook.
+// The next attempt is enqueued with an exponential backoff until the delivery succeeds or all the attempts are exhausted.
+func (service *WebhookService) Deliver(ctx context.Context, deliveryID uuid.UUID) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	delivery, err := service.deliveryRepository.Load(ctx, deliveryID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load webhook delivery [%s]", deliveryID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if delivery.Status != entities.WebhookDeliveryStatusPending {
+		ctxLogger.Info(fmt.Sprintf("webhook delivery [%s] is already [%s]", delivery.ID, delivery.Status))
+		return nil
+	}
+
+	target, err := service.target(ctx, delivery)
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		delivery.Status = entities.WebhookDeliveryStatusFailed
+		service.recordError(delivery, "the webhook was deleted or the API key of the callback was revoked or has no signing key")
+		return service.update(ctx, delivery)
+	}
+	if err != nil {
+		msg := fmt.Sprintf("cannot load the target of delivery [%s]", delivery.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	service.attempt(ctx, target, delivery)
+	if delivery.Status == entities.WebhookDeliveryStatusPending && delivery.Attempts >= webhookMaxAttempts {
+		delivery.Status = entities.WebhookDeliveryStatusFailed
+	}
+
+	if err = service.update(ctx, delivery); err != nil {
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, fmt.Sprintf("cannot update delivery [%s]", delivery.ID)))
+	}
+
+	if delivery.Status == entities.WebhookDeliveryStatusPending {
+		retryAt := time.Now().UTC().Add(webhookInitialRetryDelay * time.Duration(1<<(delivery.Attempts-1)))
+		if err = service.enqueue(ctx, delivery, retryAt); err != nil {
+			return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, fmt.Sprintf("cannot retry delivery [%s]", delivery.ID)))
+		}
+	}
+
+	ctxLogger.Info(fmt.Sprintf("delivery [%s] of event [%s] to [%s] is [%s] after [%d] attempts", delivery.ID, delivery.EventID, target.name, delivery.Status, delivery.Attempts))
+	return nil
+}
+
+// SendCallback posts the entities.Message to its callback URL after it is sent or fails.
+// The callback is signed with the signing key of the entities.APIKey which sent the message and it is retried like an event.
+func (service *WebhookService) SendCallback(ctx context.Context, message *entities.Message) error {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	if message.CallbackURL == nil {
+		return nil
+	}
+
+	if message.APIKeyID == nil {
+		msg := fmt.Sprintf("cannot sign the callback of message [%s] because it was not sent with an API key", message.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.NewError(msg))
+	}
+
+	payload, err := json.Marshal(message)
+	if err != nil {
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, fmt.Sprintf("cannot marshal message [%s]", message.ID)))
+	}
+
+	delivery := &entities.WebhookDelivery{
+		ID:          uuid.New(),
+		TenantID:    message.TenantID,
+		APIKeyID:    message.APIKeyID,
+		CallbackURL: message.CallbackURL,
+		EventID:     message.ID.String(),
+		EventType:   "message." + string(message.Status),
+		Payload:     string(payload),
+		Status:      entities.WebhookDeliveryStatusPending,
+		CreatedAt:   time.Now().UTC(),
+		UpdatedAt:   time.Now().UTC(),
+	}
+
+	if err = service.deliveryRepository.Store(ctx, delivery); err != nil {
+		msg := fmt.Sprintf("cannot store the callback of message [%s]", message.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err = service.enqueue(ctx, delivery, time.Now().UTC()); err != nil {
+		msg := fmt.Sprintf("cannot enqueue the callback [%s] of message [%s]", delivery.ID, message.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// webhookTarget is where an entities.WebhookDelivery is sent and the key which signs it
+type webhookTarget struct {
+	name        string
+	url         string
+	signingKey  string
+	contentType string
+}
+
+// target loads the webhookTarget of an entities.WebhookDelivery from its entities.Webhook or from the
+// entities.APIKey of a callback. An error with the code repositories.ErrCodeNotFound is returned when the
+// webhook was deleted or the API key was revoked.
+func (service *WebhookService) target(ctx context.Context, delivery *entities.WebhookDelivery) (webhookTarget, error) {
+	if !delivery.IsCallback() {
+		webhook, err := service.repository.Load(ctx, delivery.WebhookID)
+		if err != nil {
+			return webhookTarget{}, stacktrace.Propagate(err, fmt.Sprintf("cannot load webhook [%s]", delivery.WebhookID))
+		}
+		return webhookTarget{
+			name:        "webhook " + webhook.ID.String(),
+			url:         webhook.URL,
+			signingKey:  webhook.SigningKey,
+			contentType: "application/cloudevents+json",
+		}, nil
+	}
+
+	if delivery.APIKeyID == nil {
+		return webhookTarget{}, stacktrace.NewErrorWithCode(repositories.ErrCodeNotFound, "the callback [%s] has no API key", delivery.ID)
+	}
+
+	apiKey, err := service.apiKeyRepository.Load(ctx, *delivery.APIKeyID)
+	if err != nil {
+		return webhookTarget{}, stacktrace.Propagate(err, fmt.Sprintf("cannot load API key [%s]", *delivery.APIKeyID))
+	}
+
+	if apiKey.SigningKey == "" {
+		msg := fmt.Sprintf("API key [%s] has no signing key, create a new API key to receive callbacks", apiKey.ID)
+		return webhookTarget{}, stacktrace.NewErrorWithCode(repositories.ErrCodeNotFound, msg)
+	}
+
+	return webhookTarget{
+		name:        "callback " + telemetry.Redact(*delivery.CallbackURL),
+		url:         *delivery.CallbackURL,
+		signingKey:  apiKey.SigningKey,
+		contentType: "application/json",
+	}, nil
+}
+
+// enqueue the next attempt of an entities.WebhookDelivery at a time
+func (service *WebhookService) enqueue(ctx context.Context, delivery *entities.WebhookDelivery, scheduleTime time.Time) error {
+	body, err := json.Marshal(&WebhookDeliverParams{DeliveryID: delivery.ID})
+	if err != nil {
+		return stacktrace.Propagate(err, fmt.Sprintf("cannot marshal payload for webhook delivery [%s]", delivery.ID))
+	}
+
+	_, err = service.queueClient.Enqueue(ctx, &queue.Task{
+		Method:           http.MethodPost,
+		URL:              service.deliveryURL,
+		Body:             body,
+		Headers:          service.deliveryHeaders,
+		ScheduleTime:     &scheduleTime,
+		DeduplicationKey: fmt.Sprintf("webhook-delivery:%s:%d", delivery.ID, delivery.Attempts),
+	})
+	if err != nil {
+		return stacktrace.Propagate(err, fmt.Sprintf("cannot enqueue task for webhook delivery [%s]", delivery.ID))
+	}
+
+	return nil
+}
+
+func (service *WebhookService) update(ctx context.Context, delivery *entities.WebhookDelivery) error {
+	delivery.UpdatedAt = time.Now().UTC()
+	if err := service.deliveryRepository.Update(ctx, delivery); err != nil {
+		return stacktrace.Propagate(err, fmt.Sprintf("cannot update webhook delivery [%s]", delivery.ID))
+	}
+	return nil
+}
+
+// attempt sends the event to the webhookTarget once and records the result in the entities.WebhookDelivery
+func (service *WebhookService) attempt(ctx context.Context, target webhookTarget, delivery *entities.WebhookDelivery) {
+	delivery.Attempts++
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, target.url, bytes.NewBufferString(delivery.Payload))
+	if err != nil {
+		service.recordError(delivery, fmt.Sprintf("cannot create request for [%s]: %s", target.name, err))
+		return
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	request.Header.Set("Content-Type", target.contentType)
+	request.Header.Set(webhookTimestampHeader, timestamp)
+	request.Header.Set(webhookSignatureHeader, "sha256="+service.sign(target.signingKey, timestamp, []byte(delivery.Payload)))
+
+	response, err := service.httpClient.Do(request)
+	if err != nil {
+		service.recordError(delivery, fmt.Sprintf("cannot send event [%s] to [%s]: %s", delivery.EventID, target.name, err))
+		return
+	}
+	defer func() {
+		_, _ = io.Copy(io.Discard, response.Body)
+		_ = response.Body.Close()
+	}()
+
+	delivery.ResponseStatusCode = &response.StatusCode
+	if response.StatusCode < http.StatusOK || response.StatusCode >= http.StatusMultipleChoices {
+		service.recordError(delivery, fmt.Sprintf("[%s] responded with status [%d]", target.name, response.StatusCode))
+		return
+	}
+
+	deliveredAt := time.Now().UTC()
+	delivery.Status = entities.WebhookDeliveryStatusSucceeded
+	delivery.DeliveredAt = &deliveredAt
+	delivery.Error = nil
+}
+
+func (service *WebhookService) recordError(delivery *entities.WebhookDelivery, message string) {
+	message = telemetry.Redact(message)
+	delivery.Error = &message
+}
+
+// sign computes the HMAC-SHA256 signature of "<timestamp>.<payload>" with the signing key of the webhookTarget.
+// The timestamp is the unix time of the attempt which is sent in the X-Signature-Timestamp header. Receivers should
+// reject requests whose timestamp is more than 5 minutes from their clock so that captured deliveries cannot be replayed.
+func (service *WebhookService) sign(signingKey string, timestamp string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(signingKey))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
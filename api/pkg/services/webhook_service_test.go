@@ -0,0 +1,316 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/NdoleStudio/discusswithai/pkg/entities"
+	"github.com/NdoleStudio/discusswithai/pkg/events"
+	"github.com/NdoleStudio/discusswithai/pkg/queue"
+	"github.com/NdoleStudio/discusswithai/pkg/repositories"
+	"github.com/NdoleStudio/discusswithai/pkg/telemetry"
+	"github.com/NdoleStudio/discusswithai/pkg/tenancy"
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebhookService_Send(t *testing.T) {
+	t.Run("the webhook of another integrator does not receive the event", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		service, webhooks, deliveries, tasks := newTestWebhookService(http.DefaultClient)
+
+		// Arrange
+		tenantA, tenantB := uuid.New(), uuid.New()
+		webhooks.store(entities.Webhook{ID: uuid.New(), TenantID: tenantA, URL: "https://a.example.com", Events: []string{events.MessageReceived}})
+		webhooks.store(entities.Webhook{ID: uuid.New(), TenantID: tenantB, URL: "https://b.example.com", Events: []string{events.MessageReceived}})
+		event := newTestEvent(events.MessageReceived)
+		events.SetTenantID(&event, tenantA)
+
+		// Act
+		err := service.Send(tenancy.WithAllTenants(context.Background()), event)
+
+		// Assert
+		assert.Nil(t, err)
+		assert.Equal(t, 1, len(deliveries.items))
+		assert.Equal(t, 1, len(tasks.items))
+		for _, delivery := range deliveries.items {
+			assert.Equal(t, tenantA, delivery.TenantID)
+		}
+	})
+
+	t.Run("an event without a tenant is not sent to webhooks", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		service, webhooks, deliveries, tasks := newTestWebhookService(http.DefaultClient)
+
+		// Arrange
+		webhooks.store(entities.Webhook{ID: uuid.New(), TenantID: uuid.Nil, URL: "https://a.example.com", Events: []string{events.MessageReceived}})
+
+		// Act
+		err := service.Send(tenancy.WithAllTenants(context.Background()), newTestEvent(events.MessageReceived))
+
+		// Assert
+		assert.Nil(t, err)
+		assert.Empty(t, deliveries.items)
+		assert.Empty(t, tasks.items)
+	})
+}
+
+func TestWebhookService_Deliver(t *testing.T) {
+	t.Run("a failed attempt is retried later in a queue task", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer server.Close()
+		service, webhooks, deliveries, tasks := newTestWebhookService(server.Client())
+
+		// Arrange
+		webhook := entities.Webhook{ID: uuid.New(), URL: server.URL, SigningKey: "whsec_test"}
+		webhooks.store(webhook)
+		delivery := &entities.WebhookDelivery{ID: uuid.New(), WebhookID: webhook.ID, Payload: "{}", Status: entities.WebhookDeliveryStatusPending}
+		deliveries.items[delivery.ID] = delivery
+
+		// Act
+		err := service.Deliver(tenancy.WithAllTenants(context.Background()), delivery.ID)
+
+		// Assert
+		assert.Nil(t, err)
+		assert.Equal(t, entities.WebhookDeliveryStatusPending, delivery.Status)
+		assert.Equal(t, uint(1), delivery.Attempts)
+		assert.Equal(t, 1, len(tasks.items))
+		assert.True(t, tasks.items[0].ScheduleTime.After(time.Now()))
+
+		params := new(WebhookDeliverParams)
+		assert.Nil(t, json.Unmarshal(tasks.items[0].Body, params))
+		assert.Equal(t, delivery.ID, params.DeliveryID)
+	})
+
+	t.Run("the delivery fails without a retry after the last attempt", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		defer server.Close()
+		service, webhooks, deliveries, tasks := newTestWebhookService(server.Client())
+
+		// Arrange
+		webhook := entities.Webhook{ID: uuid.New(), URL: server.URL, SigningKey: "whsec_test"}
+		webhooks.store(webhook)
+		delivery := &entities.WebhookDelivery{ID: uuid.New(), WebhookID: webhook.ID, Payload: "{}", Status: entities.WebhookDeliveryStatusPending, Attempts: webhookMaxAttempts - 1}
+		deliveries.items[delivery.ID] = delivery
+
+		// Act
+		err := service.Deliver(tenancy.WithAllTenants(context.Background()), delivery.ID)
+
+		// Assert
+		assert.Nil(t, err)
+		assert.Equal(t, entities.WebhookDeliveryStatusFailed, delivery.Status)
+		assert.Empty(t, tasks.items)
+	})
+
+	t.Run("a signed event is delivered", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		var signature, timestamp string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			signature = r.Header.Get(webhookSignatureHeader)
+			timestamp = r.Header.Get(webhookTimestampHeader)
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		defer server.Close()
+		service, webhooks, deliveries, tasks := newTestWebhookService(server.Client())
+
+		// Arrange
+		webhook := entities.Webhook{ID: uuid.New(), URL: server.URL, SigningKey: "whsec_test"}
+		webhooks.store(webhook)
+		delivery := &entities.WebhookDelivery{ID: uuid.New(), WebhookID: webhook.ID, Payload: "{}", Status: entities.WebhookDeliveryStatusPending}
+		deliveries.items[delivery.ID] = delivery
+
+		// Act
+		err := service.Deliver(tenancy.WithAllTenants(context.Background()), delivery.ID)
+
+		// Assert
+		assert.Nil(t, err)
+		assert.Equal(t, entities.WebhookDeliveryStatusSucceeded, delivery.Status)
+		sentAt, err := strconv.ParseInt(timestamp, 10, 64)
+		assert.Nil(t, err)
+		assert.WithinDuration(t, time.Now(), time.Unix(sentAt, 0), time.Minute)
+		assert.Equal(t, "sha256="+service.sign(webhook.SigningKey, timestamp, []byte("{}")), signature)
+		assert.NotEqual(t, "sha256="+service.sign(webhook.SigningKey, strconv.FormatInt(sentAt-1, 10), []byte("{}")), signature)
+		assert.Empty(t, tasks.items)
+	})
+}
+
+func TestWebhookService_SendCallback(t *testing.T) {
+	t.Run("the callback of a message is signed with the signing key of its API key", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		var signature, timestamp, contentType string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			signature = r.Header.Get(webhookSignatureHeader)
+			timestamp = r.Header.Get(webhookTimestampHeader)
+			contentType = r.Header.Get("Content-Type")
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer server.Close()
+		service, _, deliveries, apiKeys, tasks := newTestWebhookServiceWithAPIKeys(server.Client())
+
+		// Arrange
+		apiKey := &entities.APIKey{ID: uuid.New(), SigningKey: "whsec_callback"}
+		apiKeys.items[apiKey.ID] = apiKey
+		message := &entities.Message{ID: uuid.New(), Status: entities.MessageStatusSent, CallbackURL: &server.URL, APIKeyID: &apiKey.ID}
+
+		// Act
+		err := service.SendCallback(tenancy.WithAllTenants(context.Background()), message)
+
+		// Assert
+		assert.Nil(t, err)
+		assert.Equal(t, 1, len(tasks.items))
+		params := new(WebhookDeliverParams)
+		assert.Nil(t, json.Unmarshal(tasks.items[0].Body, params))
+		assert.Empty(t, signature)
+
+		// Act
+		err = service.Deliver(tenancy.WithAllTenants(context.Background()), params.DeliveryID)
+
+		// Assert
+		assert.Nil(t, err)
+		delivery := deliveries.items[params.DeliveryID]
+		assert.Equal(t, entities.WebhookDeliveryStatusSucceeded, delivery.Status)
+		assert.Equal(t, events.MessageSent, delivery.EventType)
+		assert.Equal(t, "application/json", contentType)
+		assert.Equal(t, "sha256="+service.sign(apiKey.SigningKey, timestamp, []byte(delivery.Payload)), signature)
+	})
+
+	t.Run("the callback fails when the API key was revoked", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		service, _, deliveries, _, tasks := newTestWebhookServiceWithAPIKeys(http.DefaultClient)
+
+		// Arrange
+		apiKeyID := uuid.New()
+		callbackURL := "https://example.com/callback"
+		message := &entities.Message{ID: uuid.New(), Status: entities.MessageStatusFailed, CallbackURL: &callbackURL, APIKeyID: &apiKeyID}
+		assert.Nil(t, service.SendCallback(tenancy.WithAllTenants(context.Background()), message))
+		params := new(WebhookDeliverParams)
+		assert.Nil(t, json.Unmarshal(tasks.items[0].Body, params))
+
+		// Act
+		err := service.Deliver(tenancy.WithAllTenants(context.Background()), params.DeliveryID)
+
+		// Assert
+		assert.Nil(t, err)
+		assert.Equal(t, entities.WebhookDeliveryStatusFailed, deliveries.items[params.DeliveryID].Status)
+		assert.Equal(t, uint(0), deliveries.items[params.DeliveryID].Attempts)
+	})
+}
+
+// memoryWebhookRepository is a repositories.WebhookRepository which keeps the entities.Webhook in memory
+type memoryWebhookRepository struct {
+	repositories.WebhookRepository
+	items []entities.Webhook
+}
+
+func (repository *memoryWebhookRepository) store(webhook entities.Webhook) {
+	repository.items = append(repository.items, webhook)
+}
+
+func (repository *memoryWebhookRepository) Load(_ context.Context, webhookID uuid.UUID) (*entities.Webhook, error) {
+	for _, webhook := range repository.items {
+		if webhook.ID == webhookID {
+			return &webhook, nil
+		}
+	}
+	return nil, stacktrace.NewErrorWithCode(repositories.ErrCodeNotFound, "webhook [%s] does not exist", webhookID)
+}
+
+func (repository *memoryWebhookRepository) LoadByEvent(_ context.Context, tenantID uuid.UUID, eventType string) (*[]entities.Webhook, error) {
+	var webhooks []entities.Webhook
+	for _, webhook := range repository.items {
+		if webhook.TenantID == tenantID && webhook.HasEvent(eventType) {
+			webhooks = append(webhooks, webhook)
+		}
+	}
+	return &webhooks, nil
+}
+
+// memoryWebhookDeliveryRepository is a repositories.WebhookDeliveryRepository which keeps the entities.WebhookDelivery in memory
+type memoryWebhookDeliveryRepository struct {
+	repositories.WebhookDeliveryRepository
+	items map[uuid.UUID]*entities.WebhookDelivery
+}
+
+func (repository *memoryWebhookDeliveryRepository) Store(_ context.Context, delivery *entities.WebhookDelivery) error {
+	repository.items[delivery.ID] = delivery
+	return nil
+}
+
+func (repository *memoryWebhookDeliveryRepository) Update(_ context.Context, delivery *entities.WebhookDelivery) error {
+	repository.items[delivery.ID] = delivery
+	return nil
+}
+
+func (repository *memoryWebhookDeliveryRepository) Load(_ context.Context, deliveryID uuid.UUID) (*entities.WebhookDelivery, error) {
+	delivery, ok := repository.items[deliveryID]
+	if !ok {
+		return nil, stacktrace.NewErrorWithCode(repositories.ErrCodeNotFound, "webhook delivery [%s] does not exist", deliveryID)
+	}
+	return delivery, nil
+}
+
+// recordingQueueClient is a queue.Client which records the enqueued tasks
+type recordingQueueClient struct {
+	items []*queue.Task
+}
+
+func (client *recordingQueueClient) Enqueue(_ context.Context, task *queue.Task) (string, error) {
+	client.items = append(client.items, task)
+	return uuid.New().String(), nil
+}
+
+func newTestEvent(eventType string) cloudevents.Event {
+	event := cloudevents.NewEvent()
+	event.SetID(uuid.New().String())
+	event.SetSource(eventSource)
+	event.SetType(eventType)
+	return event
+}
+
+// memoryAPIKeyRepository is a repositories.APIKeyRepository which keeps the entities.APIKey in memory
+type memoryAPIKeyRepository struct {
+	repositories.APIKeyRepository
+	items map[uuid.UUID]*entities.APIKey
+}
+
+func (repository *memoryAPIKeyRepository) Load(_ context.Context, apiKeyID uuid.UUID) (*entities.APIKey, error) {
+	apiKey, ok := repository.items[apiKeyID]
+	if !ok {
+		return nil, stacktrace.NewErrorWithCode(repositories.ErrCodeNotFound, "API key [%s] does not exist", apiKeyID)
+	}
+	return apiKey, nil
+}
+
+func newTestWebhookService(httpClient *http.Client) (*WebhookService, *memoryWebhookRepository, *memoryWebhookDeliveryRepository, *recordingQueueClient) {
+	service, webhooks, deliveries, _, tasks := newTestWebhookServiceWithAPIKeys(httpClient)
+	return service, webhooks, deliveries, tasks
+}
+
+func newTestWebhookServiceWithAPIKeys(httpClient *http.Client) (*WebhookService, *memoryWebhookRepository, *memoryWebhookDeliveryRepository, *memoryAPIKeyRepository, *recordingQueueClient) {
+	webhooks := &memoryWebhookRepository{}
+	deliveries := &memoryWebhookDeliveryRepository{items: map[uuid.UUID]*entities.WebhookDelivery{}}
+	apiKeys := &memoryAPIKeyRepository{items: map[uuid.UUID]*entities.APIKey{}}
+	tasks := &recordingQueueClient{}
+	service := NewWebhookService(testLogger, telemetry.NewOtelLogger("test", testLogger), httpClient, webhooks, deliveries, apiKeys, tasks, "http://localhost:8000/v1/webhook-deliveries/deliver", nil)
+	return service, webhooks, deliveries, apiKeys, tasks
+}
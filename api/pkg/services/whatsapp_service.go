@@ -3,33 +3,61 @@ package services
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/NdoleStudio/discusswithai/pkg/entities"
+	"github.com/NdoleStudio/discusswithai/pkg/i18n"
 	"github.com/NdoleStudio/discusswithai/pkg/telemetry"
 	"github.com/NdoleStudio/discusswithai/pkg/whatsapp"
 	"github.com/palantir/stacktrace"
+	"go.opentelemetry.io/otel/attribute"
 )
 
+// whatsappReplyRetryDelay is the longest delay which we wait for before sending a reply again when whatsapp rate limits it.
+// Replies which are rate limited for longer fail so that the webhook request of whatsapp is not held up.
+const whatsappReplyRetryDelay = 5 * time.Second
+
 // WhatsappService is responsible for managing whatsapp events
 type WhatsappService struct {
-	logger         telemetry.Logger
-	tracer         telemetry.Tracer
-	client         *whatsapp.Client
-	openAPIService *OpenAPIService
+	logger            telemetry.Logger
+	tracer            telemetry.Tracer
+	metrics           *telemetry.Metrics
+	tenantService     *TenantService
+	catalog           *i18n.Catalog
+	openAPIService    *OpenAPIService
+	userService       *UserService
+	moderationService *ModerationService
+	messageService    *MessageService
+	reminderService   *ReminderService
+	digestService     *DigestService
 }
 
 // NewWhatsappService creates a new WhatsappService
 func NewWhatsappService(
 	logger telemetry.Logger,
 	tracer telemetry.Tracer,
-	client *whatsapp.Client,
+	metrics *telemetry.Metrics,
+	tenantService *TenantService,
+	catalog *i18n.Catalog,
 	openAPIService *OpenAPIService,
+	userService *UserService,
+	moderationService *ModerationService,
+	messageService *MessageService,
+	reminderService *ReminderService,
+	digestService *DigestService,
 ) (s *WhatsappService) {
 	return &WhatsappService{
-		logger:         logger.WithService(fmt.Sprintf("%T", s)),
-		tracer:         tracer,
-		client:         client,
-		openAPIService: openAPIService,
+		logger:            logger.WithService(fmt.Sprintf("%T", s)),
+		tracer:            tracer,
+		metrics:           metrics,
+		tenantService:     tenantService,
+		catalog:           catalog,
+		openAPIService:    openAPIService,
+		userService:       userService,
+		moderationService: moderationService,
+		messageService:    messageService,
+		reminderService:   reminderService,
+		digestService:     digestService,
 	}
 }
 
@@ -48,32 +76,66 @@ func (service *WhatsappService) Receive(ctx context.Context, params *WhatsappRec
 	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
 	defer span.End()
 
+	span.SetAttributes(
+		attribute.String("channel", entities.ChannelWhatsapp.String()),
+		telemetry.ChannelIDAttribute("channel_id", params.From),
+	)
+
+	service.metrics.MessageReceived(ctx, entities.ChannelWhatsapp.String(), params.Type)
+
+	user, locale := service.loadUser(ctx, params)
+	service.storeMessage(ctx, params, entities.MessageRoleUser, params.MessageText, params.MessageID)
+
 	if params.Type != whatsapp.MessageWebhookMessageTypeText {
-		service.handleInvalidMessage(ctx, params)
+		service.handleInvalidMessage(ctx, locale, params)
+		return
+	}
+
+	if user != nil && service.userService.IsLocaleCommand(params.MessageText) {
+		service.handleLocaleCommand(ctx, user, params)
+		return
+	}
+
+	if user != nil && service.reminderService.IsCommand(params.MessageText) {
+		service.handleReminderCommand(ctx, user, params)
+		return
+	}
+
+	if user != nil && service.digestService.IsCommand(params.MessageText) {
+		service.handleDigestCommand(ctx, user, params)
+		return
+	}
+
+	if user != nil && service.handleReminder(ctx, user, locale, params) {
 		return
 	}
 
 	responseText, err := service.openAPIService.GetChatCompletion(ctx, &OpenAPICompletionParams{
 		Channel:   entities.ChannelWhatsapp,
 		ChannelID: params.From,
+		Owner:     params.To,
 		Name:      params.Name,
 		Message:   params.MessageText,
 	})
+	if isFlaggedContentError(err) {
+		service.handleFlaggedContent(ctx, user, locale, err, params)
+		return
+	}
+	if stacktrace.GetCode(err) == ErrCodeTenantQuotaExceeded {
+		msg := fmt.Sprintf("the daily quota was exceeded for user [%s] and channel [%s]", params.From, entities.ChannelWhatsapp)
+		service.handleCompletionError(ctx, stacktrace.Propagate(err, msg), service.catalog.Translate(locale, i18n.KeyQuotaExceeded), params)
+		return
+	}
 	if err != nil {
 		msg := fmt.Sprintf("cannot get completion for user [%s] and channel [%s]", params.From, entities.ChannelWhatsapp)
-		responseSMS := "We could not generate the completion using chatGPT. Please try again later."
+		responseSMS := service.catalog.Translate(locale, i18n.KeyCompletionError)
 		service.handleCompletionError(ctx, stacktrace.Propagate(err, msg), responseSMS, params)
 		return
 	}
 
-	response, _, err := service.client.Message.Send(ctx, &whatsapp.MessageSendParams{
-		From:              params.To,
-		To:                params.From,
-		PreviousMessageID: &params.MessageID,
-		Body:              responseText,
-	})
+	response, err := service.send(ctx, params, responseText)
 	if err != nil {
-		msg := fmt.Sprintf("cannot send whatsapp to user [%s] with response [%s]", params.From, responseText)
+		msg := fmt.Sprintf("cannot send whatsapp to user [%s] with response [%s]", params.From, telemetry.RedactBody(responseText))
 		ctxLogger.Error(service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg)))
 		return
 	}
@@ -81,18 +143,28 @@ func (service *WhatsappService) Receive(ctx context.Context, params *WhatsappRec
 	ctxLogger.Info(fmt.Sprintf("sent response via whatsapp with id [%s] to [%s] with [%d] characters", response.Messages[0].ID, params.To, len(responseText)))
 }
 
+// handleFlaggedContent replies with the content policy and records a violation when the prompt of the user was flagged
+func (service *WhatsappService) handleFlaggedContent(ctx context.Context, user *entities.User, locale i18n.Locale, err error, params *WhatsappReceiveParams) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	if user != nil && stacktrace.GetCode(err) == ErrCodePromptFlagged {
+		if err := service.moderationService.RecordViolation(ctx, user); err != nil {
+			ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot record moderation violation for user [%s]", params.From)))
+		}
+	}
+
+	msg := fmt.Sprintf("content was flagged for user [%s] and channel [%s]", params.From, entities.ChannelWhatsapp)
+	service.handleCompletionError(ctx, stacktrace.Propagate(err, msg), service.catalog.Translate(locale, i18n.KeyContentPolicy), params)
+}
+
 func (service *WhatsappService) handleCompletionError(ctx context.Context, err error, message string, params *WhatsappReceiveParams) {
 	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
 	defer span.End()
 
 	ctxLogger.Error(stacktrace.Propagate(err, message))
 
-	response, _, err := service.client.Message.Send(ctx, &whatsapp.MessageSendParams{
-		From:              params.To,
-		To:                params.From,
-		PreviousMessageID: &params.MessageID,
-		Body:              message,
-	})
+	response, err := service.send(ctx, params, message)
 	if err != nil {
 		msg := fmt.Sprintf("cannot send completion error SMS to [%s]", params.From)
 		ctxLogger.Error(service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg)))
@@ -102,16 +174,128 @@ func (service *WhatsappService) handleCompletionError(ctx context.Context, err e
 	ctxLogger.Info(fmt.Sprintf("sent completion error message id [%s] to [%s]", response.Messages[0].ID, params.From))
 }
 
-func (service *WhatsappService) handleInvalidMessage(ctx context.Context, params *WhatsappReceiveParams) {
+// loadUser fetches the entities.User who sent the message and the i18n.Locale for replying to the user.
+// The user is nil when it cannot be loaded so that we can still reply to the message.
+func (service *WhatsappService) loadUser(ctx context.Context, params *WhatsappReceiveParams) (*entities.User, i18n.Locale) {
 	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
 	defer span.End()
 
-	response, _, err := service.client.Message.Send(ctx, &whatsapp.MessageSendParams{
-		From:              params.To,
-		To:                params.From,
-		PreviousMessageID: &params.MessageID,
-		Body:              fmt.Sprintf("We only support text messages at the moment we plan to support %s content in the future.", params.Type),
+	user, err := service.userService.LoadOrStore(ctx, &UserLoadOrStoreParams{
+		Channel:   entities.ChannelWhatsapp,
+		ChannelID: params.From,
+		Name:      params.Name,
+	})
+	if err != nil {
+		msg := fmt.Sprintf("cannot load user [%s] for channel [%s]", params.From, entities.ChannelWhatsapp)
+		ctxLogger.Error(service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg)))
+		return nil, i18n.LocaleFromPhoneNumber(params.From)
+	}
+
+	return user, service.userService.Locale(user)
+}
+
+func (service *WhatsappService) handleReminderCommand(ctx context.Context, user *entities.User, params *WhatsappReceiveParams) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	reply, err := service.reminderService.HandleCommand(ctx, user, params.MessageText)
+	if err != nil {
+		msg := fmt.Sprintf("cannot handle reminder command for user [%s]", params.From)
+		service.handleCompletionError(ctx, stacktrace.Propagate(err, msg), service.catalog.Translate(service.userService.Locale(user), i18n.KeyCompletionError), params)
+		return
+	}
+
+	response, err := service.send(ctx, params, reply)
+	if err != nil {
+		msg := fmt.Sprintf("cannot send reminder command reply to [%s]", params.From)
+		ctxLogger.Error(service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg)))
+		return
+	}
+
+	ctxLogger.Info(fmt.Sprintf("sent reminder command reply with id [%s] to [%s]", response.Messages[0].ID, params.From))
+}
+
+func (service *WhatsappService) handleDigestCommand(ctx context.Context, user *entities.User, params *WhatsappReceiveParams) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	reply, err := service.digestService.HandleCommand(ctx, user, params.To, params.MessageText)
+	if err != nil {
+		msg := fmt.Sprintf("cannot handle digest command for user [%s]", params.From)
+		service.handleCompletionError(ctx, stacktrace.Propagate(err, msg), service.catalog.Translate(service.userService.Locale(user), i18n.KeyCompletionError), params)
+		return
+	}
+
+	response, err := service.send(ctx, params, reply)
+	if err != nil {
+		msg := fmt.Sprintf("cannot send digest command reply to [%s]", params.From)
+		ctxLogger.Error(service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg)))
+		return
+	}
+
+	ctxLogger.Info(fmt.Sprintf("sent digest command reply with id [%s] to [%s]", response.Messages[0].ID, params.From))
+}
+
+// handleReminder schedules a reminder when the message is a request for a reminder and replies with a confirmation.
+// It returns false when the message is not a request for a reminder so that we reply with a completion.
+func (service *WhatsappService) handleReminder(ctx context.Context, user *entities.User, locale i18n.Locale, params *WhatsappReceiveParams) bool {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	reply, ok, err := service.reminderService.Schedule(ctx, &ReminderScheduleParams{
+		User:    user,
+		Owner:   params.To,
+		Message: params.MessageText,
 	})
+	if isFlaggedContentError(err) {
+		service.handleFlaggedContent(ctx, user, locale, err, params)
+		return true
+	}
+	if err != nil {
+		ctxLogger.Error(service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, fmt.Sprintf("cannot schedule reminder for user [%s]", params.From))))
+		return false
+	}
+	if !ok {
+		return false
+	}
+
+	response, err := service.send(ctx, params, reply)
+	if err != nil {
+		msg := fmt.Sprintf("cannot send reminder reply to [%s]", params.From)
+		ctxLogger.Error(service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg)))
+		return true
+	}
+
+	ctxLogger.Info(fmt.Sprintf("sent reminder reply with id [%s] to [%s]", response.Messages[0].ID, params.From))
+	return true
+}
+
+func (service *WhatsappService) handleLocaleCommand(ctx context.Context, user *entities.User, params *WhatsappReceiveParams) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	reply, err := service.userService.HandleLocaleCommand(ctx, user, params.MessageText)
+	if err != nil {
+		msg := fmt.Sprintf("cannot handle locale command for user [%s]", params.From)
+		service.handleCompletionError(ctx, stacktrace.Propagate(err, msg), service.catalog.Translate(service.userService.Locale(user), i18n.KeyCompletionError), params)
+		return
+	}
+
+	response, err := service.send(ctx, params, reply)
+	if err != nil {
+		msg := fmt.Sprintf("cannot send locale command reply to [%s]", params.From)
+		ctxLogger.Error(service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg)))
+		return
+	}
+
+	ctxLogger.Info(fmt.Sprintf("sent locale command reply with id [%s] to [%s]", response.Messages[0].ID, params.From))
+}
+
+func (service *WhatsappService) handleInvalidMessage(ctx context.Context, locale i18n.Locale, params *WhatsappReceiveParams) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	response, err := service.send(ctx, params, service.catalog.Translate(locale, i18n.KeyWhatsappUnsupportedType, params.Type))
 	if err != nil {
 		ctxLogger.Error(service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, fmt.Sprintf("cannot send invalid whatsapp reply to [%s]", params.From))))
 		return
@@ -119,3 +303,53 @@ func (service *WhatsappService) handleInvalidMessage(ctx context.Context, params
 
 	ctxLogger.Info(fmt.Sprintf("sent invalid content whatsappp with id [%s] to [%s] becasue the content type was [%s]", response.Messages[0].ID, params.From, params.Type))
 }
+
+// send a whatsapp reply to the user and store it in the conversation
+func (service *WhatsappService) send(ctx context.Context, params *WhatsappReceiveParams, text string) (*whatsapp.MessageSendResponse, error) {
+	sendParams := &whatsapp.MessageSendParams{
+		From:              params.To,
+		To:                params.From,
+		PreviousMessageID: &params.MessageID,
+		Body:              text,
+	}
+
+	response, _, err := service.tenantService.WhatsappClient(ctx).Message.Send(ctx, sendParams)
+	if apiError, ok := whatsapp.AsError(err); ok && apiError.IsRateLimited() && apiError.RetryAfter != nil && *apiError.RetryAfter <= whatsappReplyRetryDelay {
+		service.metrics.SendFailed(ctx, "whatsapp")
+		service.logger.Warn(stacktrace.Propagate(err, fmt.Sprintf("sending reply to [%s] again in [%s] because it was rate limited", telemetry.HashChannelID(params.From), *apiError.RetryAfter)))
+
+		select {
+		case <-ctx.Done():
+			return nil, stacktrace.Propagate(ctx.Err(), fmt.Sprintf("cannot wait [%s] to send reply to [%s] again", *apiError.RetryAfter, telemetry.HashChannelID(params.From)))
+		case <-time.After(*apiError.RetryAfter):
+		}
+		response, _, err = service.tenantService.WhatsappClient(ctx).Message.Send(ctx, sendParams)
+	}
+	if err != nil {
+		service.metrics.SendFailed(ctx, "whatsapp")
+		return nil, err
+	}
+
+	service.storeMessage(ctx, params, entities.MessageRoleAssistant, text, response.Messages[0].ID)
+	return response, nil
+}
+
+// storeMessage stores a message in the conversation between the user and our phone number
+func (service *WhatsappService) storeMessage(ctx context.Context, params *WhatsappReceiveParams, role entities.MessageRole, content string, providerMessageID string) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	_, err := service.messageService.Store(ctx, &MessageStoreParams{
+		Channel:           entities.ChannelWhatsapp,
+		ChannelID:         params.From,
+		Owner:             params.To,
+		Role:              role,
+		Name:              params.Name,
+		Content:           content,
+		ProviderMessageID: providerMessageID,
+	})
+	if err != nil {
+		msg := fmt.Sprintf("cannot store [%s] message for user [%s] and channel [%s]", role, params.From, entities.ChannelWhatsapp)
+		ctxLogger.Error(service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg)))
+	}
+}
@@ -4,7 +4,8 @@ import (
 	"go.opentelemetry.io/otel/trace"
 )
 
-// Logger is an interface for creating customer logger implementations
+// Logger is an interface for creating customer logger implementations.
+// Implementations mask phone numbers in all messages, errors and fields using Redact.
 type Logger interface {
 	// Error logs an error
 	Error(err error)
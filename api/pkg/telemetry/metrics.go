@@ -0,0 +1,113 @@
+package telemetry
+
+import (
+	"context"
+	"time"
+
+	"github.com/palantir/stacktrace"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/instrument"
+)
+
+// Metrics records the business metrics of the API with open telemetry instruments
+type Metrics struct {
+	messagesReceived   instrument.Int64Counter
+	completionDuration instrument.Float64Histogram
+	completionTokens   instrument.Int64Counter
+	sendFailures       instrument.Int64Counter
+	quotaRejections    instrument.Int64Counter
+	dedupeHits         instrument.Int64Counter
+}
+
+// NewMetrics creates the instruments of the Metrics with the metric.Meter
+func NewMetrics(meter metric.Meter) (*Metrics, error) {
+	metrics := new(Metrics)
+
+	var err error
+	if metrics.messagesReceived, err = meter.Int64Counter(
+		"messages.received",
+		instrument.WithDescription("Number of messages received from users per channel and type"),
+		instrument.WithUnit("{message}"),
+	); err != nil {
+		return nil, stacktrace.Propagate(err, "cannot create the messages.received counter")
+	}
+
+	if metrics.completionDuration, err = meter.Float64Histogram(
+		"completion.duration",
+		instrument.WithDescription("Time taken by the language model to generate a completion per model"),
+		instrument.WithUnit("ms"),
+	); err != nil {
+		return nil, stacktrace.Propagate(err, "cannot create the completion.duration histogram")
+	}
+
+	if metrics.completionTokens, err = meter.Int64Counter(
+		"completion.tokens",
+		instrument.WithDescription("Number of prompt and completion tokens used per model"),
+		instrument.WithUnit("{token}"),
+	); err != nil {
+		return nil, stacktrace.Propagate(err, "cannot create the completion.tokens counter")
+	}
+
+	if metrics.sendFailures, err = meter.Int64Counter(
+		"messages.send_failures",
+		instrument.WithDescription("Number of messages which could not be sent per provider"),
+		instrument.WithUnit("{message}"),
+	); err != nil {
+		return nil, stacktrace.Propagate(err, "cannot create the messages.send_failures counter")
+	}
+
+	if metrics.quotaRejections, err = meter.Int64Counter(
+		"quota.rejections",
+		instrument.WithDescription("Number of messages which were rejected because a limit was exceeded per channel and reason"),
+		instrument.WithUnit("{message}"),
+	); err != nil {
+		return nil, stacktrace.Propagate(err, "cannot create the quota.rejections counter")
+	}
+
+	if metrics.dedupeHits, err = meter.Int64Counter(
+		"dedupe.hits",
+		instrument.WithDescription("Number of duplicate messages which were ignored per source"),
+		instrument.WithUnit("{message}"),
+	); err != nil {
+		return nil, stacktrace.Propagate(err, "cannot create the dedupe.hits counter")
+	}
+
+	return metrics, nil
+}
+
+// MessageReceived records a message which was received on a channel e.g. "sms" with a type e.g. "text"
+func (metrics *Metrics) MessageReceived(ctx context.Context, channel string, messageType string) {
+	metrics.messagesReceived.Add(ctx, 1, attribute.String("channel", channel), attribute.String("type", messageType))
+}
+
+// CompletionCreated records the duration and the tokens of a completion. The tokens are 0 when the completion failed.
+func (metrics *Metrics) CompletionCreated(ctx context.Context, model string, duration time.Duration, promptTokens int, completionTokens int, err error) {
+	status := "success"
+	if err != nil {
+		status = "error"
+	}
+
+	metrics.completionDuration.Record(ctx, float64(duration.Microseconds())/1000, attribute.String("model", model), attribute.String("status", status))
+	if promptTokens > 0 {
+		metrics.completionTokens.Add(ctx, int64(promptTokens), attribute.String("model", model), attribute.String("type", "prompt"))
+	}
+	if completionTokens > 0 {
+		metrics.completionTokens.Add(ctx, int64(completionTokens), attribute.String("model", model), attribute.String("type", "completion"))
+	}
+}
+
+// SendFailed records a message which could not be sent with a provider e.g. "nexmo"
+func (metrics *Metrics) SendFailed(ctx context.Context, provider string) {
+	metrics.sendFailures.Add(ctx, 1, attribute.String("provider", provider))
+}
+
+// QuotaRejected records a message which was rejected on a channel because of a limit e.g. "sms_character_limit"
+func (metrics *Metrics) QuotaRejected(ctx context.Context, channel string, reason string) {
+	metrics.quotaRejections.Add(ctx, 1, attribute.String("channel", channel), attribute.String("reason", reason))
+}
+
+// DedupeHit records a duplicate message from a source e.g. "sms_multipart" which was ignored
+func (metrics *Metrics) DedupeHit(ctx context.Context, source string) {
+	metrics.dedupeHits.Add(ctx, 1, attribute.String("source", source))
+}
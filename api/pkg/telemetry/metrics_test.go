@@ -0,0 +1,65 @@
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func TestMetrics(t *testing.T) {
+	t.Run("it records the tokens of a completion per model and type", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		reader := sdkmetric.NewManualReader()
+		metrics, err := NewMetrics(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)).Meter("test"))
+		assert.Nil(t, err)
+
+		// Act
+		metrics.CompletionCreated(context.Background(), "gpt-3.5-turbo", time.Second, 10, 25, nil)
+		metrics.CompletionCreated(context.Background(), "gpt-3.5-turbo", time.Second, 0, 0, errors.New("timeout"))
+
+		// Assert
+		tokens := collectSum(t, reader, "completion.tokens")
+		assert.Equal(t, int64(10), tokens[attribute.NewSet(attribute.String("model", "gpt-3.5-turbo"), attribute.String("type", "prompt"))])
+		assert.Equal(t, int64(25), tokens[attribute.NewSet(attribute.String("model", "gpt-3.5-turbo"), attribute.String("type", "completion"))])
+	})
+
+	t.Run("it counts the duplicate messages per source", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		reader := sdkmetric.NewManualReader()
+		metrics, err := NewMetrics(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)).Meter("test"))
+		assert.Nil(t, err)
+
+		// Act
+		metrics.DedupeHit(context.Background(), "sms_multipart")
+		metrics.DedupeHit(context.Background(), "sms_multipart")
+
+		// Assert
+		hits := collectSum(t, reader, "dedupe.hits")
+		assert.Equal(t, int64(2), hits[attribute.NewSet(attribute.String("source", "sms_multipart"))])
+	})
+}
+
+func collectSum(t *testing.T, reader sdkmetric.Reader, name string) map[attribute.Set]int64 {
+	data := metricdata.ResourceMetrics{}
+	assert.Nil(t, reader.Collect(context.Background(), &data))
+
+	result := map[attribute.Set]int64{}
+	for _, scope := range data.ScopeMetrics {
+		for _, metric := range scope.Metrics {
+			if sum, ok := metric.Data.(metricdata.Sum[int64]); ok && metric.Name == name {
+				for _, point := range sum.DataPoints {
+					result[point.Attributes] = point.Value
+				}
+			}
+		}
+	}
+	return result
+}
@@ -74,8 +74,9 @@ func (tracer *otelTracer) WrapErrorSpan(span trace.Span, err error) error {
 		return nil
 	}
 
-	span.RecordError(err)
-	span.SetStatus(codes.Error, strings.Split(err.Error(), "\n")[0])
+	redacted := RedactError(err)
+	span.RecordError(redacted)
+	span.SetStatus(codes.Error, strings.Split(redacted.Error(), "\n")[0])
 
 	return err
 }
@@ -0,0 +1,125 @@
+package telemetry
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/palantir/stacktrace"
+	"go.opentelemetry.io/contrib/propagators/b3"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// PropagatorTraceContext propagates the W3C traceparent and tracestate headers
+	PropagatorTraceContext = "tracecontext"
+
+	// PropagatorBaggage propagates the W3C baggage header
+	PropagatorBaggage = "baggage"
+
+	// PropagatorB3 propagates the single b3 header
+	PropagatorB3 = "b3"
+
+	// PropagatorB3Multi propagates the X-B3-* headers
+	PropagatorB3Multi = "b3multi"
+
+	// PropagatorCloudTrace propagates the X-Cloud-Trace-Context header of Google Cloud
+	PropagatorCloudTrace = "cloudtrace"
+
+	cloudTraceContextHeader = "X-Cloud-Trace-Context"
+)
+
+// NewPropagator creates a composite propagation.TextMapPropagator from the names of the propagators e.g. "tracecontext".
+// When a request has the headers of several propagators, the span context of the last propagator in names is used.
+func NewPropagator(names ...string) (propagation.TextMapPropagator, error) {
+	var propagators []propagation.TextMapPropagator
+	for _, name := range names {
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case PropagatorTraceContext:
+			propagators = append(propagators, propagation.TraceContext{})
+		case PropagatorBaggage:
+			propagators = append(propagators, propagation.Baggage{})
+		case PropagatorB3:
+			propagators = append(propagators, b3.New(b3.WithInjectEncoding(b3.B3SingleHeader)))
+		case PropagatorB3Multi:
+			propagators = append(propagators, b3.New(b3.WithInjectEncoding(b3.B3MultipleHeader)))
+		case PropagatorCloudTrace:
+			propagators = append(propagators, CloudTraceContext{})
+		case "":
+			continue
+		default:
+			return nil, stacktrace.NewError("unknown propagator [%s]", name)
+		}
+	}
+
+	if len(propagators) == 0 {
+		return nil, stacktrace.NewError("at least one propagator is required")
+	}
+
+	return propagation.NewCompositeTextMapPropagator(propagators...), nil
+}
+
+// CloudTraceContext propagates the span context with the X-Cloud-Trace-Context header which has the format "TRACE_ID/SPAN_ID;o=OPTIONS".
+// See: https://cloud.google.com/trace/docs/setup#force-trace
+type CloudTraceContext struct{}
+
+// Inject sets the X-Cloud-Trace-Context header from the span context of ctx
+func (propagator CloudTraceContext) Inject(ctx context.Context, carrier propagation.TextMapCarrier) {
+	spanContext := trace.SpanContextFromContext(ctx)
+	if !spanContext.IsValid() {
+		return
+	}
+
+	spanID, _ := strconv.ParseUint(spanContext.SpanID().String(), 16, 64)
+	options := "0"
+	if spanContext.IsSampled() {
+		options = "1"
+	}
+	carrier.Set(cloudTraceContextHeader, fmt.Sprintf("%s/%d;o=%s", spanContext.TraceID().String(), spanID, options))
+}
+
+// Extract returns a context with the remote span context of the X-Cloud-Trace-Context header.
+// ctx is returned unchanged when the header is missing or invalid.
+func (propagator CloudTraceContext) Extract(ctx context.Context, carrier propagation.TextMapCarrier) context.Context {
+	spanContext, err := propagator.spanContext(carrier.Get(cloudTraceContextHeader))
+	if err != nil || !spanContext.IsValid() {
+		return ctx
+	}
+	return trace.ContextWithRemoteSpanContext(ctx, spanContext)
+}
+
+// Fields returns the header used by the CloudTraceContext
+func (propagator CloudTraceContext) Fields() []string {
+	return []string{cloudTraceContextHeader}
+}
+
+func (propagator CloudTraceContext) spanContext(header string) (trace.SpanContext, error) {
+	config := trace.SpanContextConfig{Remote: true}
+
+	traceID, spanOptions, found := strings.Cut(header, "/")
+	if !found {
+		return trace.SpanContext{}, stacktrace.NewError("header [%s] does not have a span ID", header)
+	}
+
+	var err error
+	if config.TraceID, err = trace.TraceIDFromHex(traceID); err != nil {
+		return trace.SpanContext{}, stacktrace.Propagate(err, "could not get trace id from [%s]", header)
+	}
+
+	spanID, options, _ := strings.Cut(spanOptions, ";")
+	value, err := strconv.ParseUint(spanID, 10, 64)
+	if err != nil {
+		return trace.SpanContext{}, stacktrace.Propagate(err, "could not get span id from [%s]", header)
+	}
+	if config.SpanID, err = trace.SpanIDFromHex(fmt.Sprintf("%016x", value)); err != nil {
+		return trace.SpanContext{}, stacktrace.Propagate(err, "could not get span id from [%s]", header)
+	}
+
+	if options == "o=1" {
+		config.TraceFlags = trace.FlagsSampled
+	}
+
+	return trace.NewSpanContext(config), nil
+}
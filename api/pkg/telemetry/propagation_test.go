@@ -0,0 +1,109 @@
+package telemetry
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestCloudTraceContext(t *testing.T) {
+	t.Run("it extracts the span context which was injected", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+
+		// Arrange
+		propagator := CloudTraceContext{}
+		carrier := propagation.MapCarrier{}
+		spanContext := newTestSpanContext(t)
+
+		// Act
+		propagator.Inject(trace.ContextWithSpanContext(context.Background(), spanContext), carrier)
+		result := trace.SpanContextFromContext(propagator.Extract(context.Background(), carrier))
+
+		// Assert
+		assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736/67667974448284343;o=1", carrier.Get("X-Cloud-Trace-Context"))
+		assert.Equal(t, spanContext.TraceID(), result.TraceID())
+		assert.Equal(t, spanContext.SpanID(), result.SpanID())
+		assert.True(t, result.IsSampled())
+		assert.True(t, result.IsRemote())
+	})
+
+	t.Run("invalid headers are ignored", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+
+		// Arrange
+		carrier := propagation.MapCarrier{"X-Cloud-Trace-Context": "not-a-trace/abc;o=1"}
+
+		// Act
+		ctx := CloudTraceContext{}.Extract(context.Background(), carrier)
+
+		// Assert
+		assert.False(t, trace.SpanContextFromContext(ctx).IsValid())
+	})
+}
+
+func TestNewPropagator(t *testing.T) {
+	t.Run("it extracts the b3 headers", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+
+		// Arrange
+		propagator, err := NewPropagator("tracecontext", " b3 ")
+		carrier := propagation.MapCarrier{"b3": "4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-1"}
+
+		// Act
+		result := trace.SpanContextFromContext(propagator.Extract(context.Background(), carrier))
+
+		// Assert
+		assert.Nil(t, err)
+		assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", result.TraceID().String())
+		assert.Equal(t, "00f067aa0ba902b7", result.SpanID().String())
+	})
+
+	t.Run("it injects the headers of all the propagators", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+
+		// Arrange
+		propagator, err := NewPropagator("tracecontext", "b3multi", "cloudtrace")
+		carrier := propagation.MapCarrier{}
+
+		// Act
+		propagator.Inject(trace.ContextWithSpanContext(context.Background(), newTestSpanContext(t)), carrier)
+
+		// Assert
+		assert.Nil(t, err)
+		assert.Equal(t, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", carrier.Get("traceparent"))
+		assert.Equal(t, "00f067aa0ba902b7", carrier.Get("x-b3-spanid"))
+		assert.NotEmpty(t, carrier.Get("X-Cloud-Trace-Context"))
+	})
+
+	t.Run("it returns an error for unknown propagators", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+
+		// Act
+		_, err := NewPropagator("tracecontext", "jaeger")
+
+		// Assert
+		assert.NotNil(t, err)
+	})
+}
+
+func newTestSpanContext(t *testing.T) trace.SpanContext {
+	traceID, err := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	assert.Nil(t, err)
+
+	spanID, err := trace.SpanIDFromHex("00f067aa0ba902b7")
+	assert.Nil(t, err)
+
+	return trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: trace.FlagsSampled,
+	})
+}
@@ -0,0 +1,138 @@
+package telemetry
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync/atomic"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+var (
+	// redactionDisabled is true when personally identifiable information can be logged
+	redactionDisabled atomic.Bool
+
+	// msisdnRegex matches runs of digits which may be phone numbers in E.164 format with or without the leading "+".
+	// Candidates are confirmed by isPhoneNumber so that segments of UUIDs and other identifiers are not masked.
+	msisdnRegex = regexp.MustCompile(`\+?\d+`)
+)
+
+// ConfigureRedaction enables or disables the removal of personally identifiable information from logs and traces.
+// Redaction should only be disabled when debugging locally.
+func ConfigureRedaction(enabled bool) {
+	redactionDisabled.Store(!enabled)
+}
+
+// IsRedactionEnabled checks if personally identifiable information is removed from logs and traces
+func IsRedactionEnabled() bool {
+	return !redactionDisabled.Load()
+}
+
+// MaskPhoneNumber hides the digits in the middle of a phone number e.g +237670000000 becomes +237*******00
+func MaskPhoneNumber(phoneNumber string) string {
+	if !IsRedactionEnabled() {
+		return phoneNumber
+	}
+
+	prefix := 3
+	if strings.HasPrefix(phoneNumber, "+") {
+		prefix = 4
+	}
+
+	if len(phoneNumber) <= prefix+2 {
+		return strings.Repeat("*", len(phoneNumber))
+	}
+
+	return phoneNumber[:prefix] + strings.Repeat("*", len(phoneNumber)-prefix-2) + phoneNumber[len(phoneNumber)-2:]
+}
+
+// HashChannelID replaces a channel ID with a stable hash so that logs from the same user can still be correlated
+func HashChannelID(channelID string) string {
+	if !IsRedactionEnabled() {
+		return channelID
+	}
+
+	hash := sha256.Sum256([]byte(channelID))
+	return "sha256:" + hex.EncodeToString(hash[:])[:16]
+}
+
+// RedactBody omits the content of a message or request body and keeps only its length
+func RedactBody(body string) string {
+	if !IsRedactionEnabled() {
+		return body
+	}
+	return fmt.Sprintf("redacted %d characters", len(body))
+}
+
+// Redact masks all the phone numbers in a piece of text
+func Redact(text string) string {
+	if !IsRedactionEnabled() {
+		return text
+	}
+
+	var builder strings.Builder
+	last := 0
+	for _, match := range msisdnRegex.FindAllStringIndex(text, -1) {
+		if !isPhoneNumber(text, match[0], match[1]) {
+			continue
+		}
+		builder.WriteString(text[last:match[0]])
+		builder.WriteString(MaskPhoneNumber(text[match[0]:match[1]]))
+		last = match[1]
+	}
+
+	if last == 0 {
+		return text
+	}
+
+	builder.WriteString(text[last:])
+	return builder.String()
+}
+
+// isPhoneNumber checks if the digits in text[start:end] are a phone number. A number with a leading "+" is always a
+// phone number, without it the digits must not be part of a larger identifier like a UUID e.g 01782924-8f9a-...
+func isPhoneNumber(text string, start int, end int) bool {
+	digits := strings.TrimPrefix(text[start:end], "+")
+	if len(digits) < 8 || len(digits) > 15 {
+		return false
+	}
+
+	if strings.HasPrefix(text[start:end], "+") {
+		return true
+	}
+
+	return (start == 0 || !isIdentifierByte(text[start-1])) && (end == len(text) || !isIdentifierByte(text[end]))
+}
+
+// isIdentifierByte checks if a character can join digits into a larger identifier
+func isIdentifierByte(char byte) bool {
+	return char == '-' || char == '_' || char == '.' ||
+		(char >= '0' && char <= '9') || (char >= 'a' && char <= 'z') || (char >= 'A' && char <= 'Z')
+}
+
+// RedactError masks all the phone numbers in the message of an error
+func RedactError(err error) error {
+	if err == nil || !IsRedactionEnabled() {
+		return err
+	}
+	return errors.New(Redact(err.Error()))
+}
+
+// PhoneNumberAttribute creates a span attribute with a masked phone number
+func PhoneNumberAttribute(key string, phoneNumber string) attribute.KeyValue {
+	return attribute.String(key, MaskPhoneNumber(phoneNumber))
+}
+
+// ChannelIDAttribute creates a span attribute with a hashed channel ID
+func ChannelIDAttribute(key string, channelID string) attribute.KeyValue {
+	return attribute.String(key, HashChannelID(channelID))
+}
+
+// BodyAttribute creates a span attribute with a redacted message body
+func BodyAttribute(key string, body string) attribute.KeyValue {
+	return attribute.String(key, RedactBody(body))
+}
@@ -0,0 +1,86 @@
+package telemetry
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedact(t *testing.T) {
+	t.Run("phone numbers are masked in text", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+
+		// Act
+		text := Redact("cannot send SMS to user [+237670000000] from [18005550199]")
+
+		// Assert
+		assert.Equal(t, "cannot send SMS to user [+237*******00] from [180******99]", text)
+	})
+
+	t.Run("uuids and hex IDs are not masked", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+
+		// Arrange
+		text := "message [8f9c71b8-b84e-4417-8408-a62274f65a08] with ID [0A0000000123ABCD]"
+
+		// Act
+		result := Redact(text)
+
+		// Assert
+		assert.Equal(t, text, result)
+	})
+
+	t.Run("all digit uuid segments and numeric IDs are not masked", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+
+		// Arrange
+		text := "message [01782924-8f9a-4c1e-9d2b-3a6e5f7c8d90] for task [reminder-1697635200] with ID [1713968723456789012]"
+
+		// Act
+		result := Redact(text)
+
+		// Assert
+		assert.Equal(t, text, result)
+	})
+
+	t.Run("phone numbers next to identifiers are masked with a leading +", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+
+		// Act
+		text := Redact("message [01782924-8f9a-4c1e-9d2b-3a6e5f7c8d90] to [+237670000000]")
+
+		// Assert
+		assert.Equal(t, "message [01782924-8f9a-4c1e-9d2b-3a6e5f7c8d90] to [+237*******00]", text)
+	})
+}
+
+func TestRedactBody(t *testing.T) {
+	t.Run("the body is omitted", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+
+		// Act
+		body := RedactBody("what is the capital of Cameroon?")
+
+		// Assert
+		assert.Equal(t, "redacted 32 characters", body)
+	})
+}
+
+func TestHashChannelID(t *testing.T) {
+	t.Run("the same channel ID has the same hash", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+
+		// Act
+		hash := HashChannelID("237670000000")
+
+		// Assert
+		assert.Equal(t, HashChannelID("237670000000"), hash)
+		assert.NotContains(t, hash, "237670000000")
+	})
+}
@@ -51,37 +51,37 @@ func (logger *zerologLogger) WithString(key string, value string) Logger {
 
 // Info logs a new message with information level.
 func (logger *zerologLogger) Info(value string) {
-	logger.decorateEvent(logger.zerolog.Info()).Msg(value)
+	logger.decorateEvent(logger.zerolog.Info()).Msg(Redact(value))
 }
 
 // Printf logs a new message with information level.
 func (logger *zerologLogger) Printf(s string, i ...interface{}) {
-	logger.decorateEvent(logger.zerolog.Info()).Msg(fmt.Sprintf(s, i...))
+	logger.decorateEvent(logger.zerolog.Info()).Msg(Redact(fmt.Sprintf(s, i...)))
 }
 
 // Trace logs a new message with trace level.
 func (logger *zerologLogger) Trace(value string) {
-	logger.decorateEvent(logger.zerolog.Trace()).Msg(value)
+	logger.decorateEvent(logger.zerolog.Trace()).Msg(Redact(value))
 }
 
 // Warn logs a new message with warning level.
 func (logger *zerologLogger) Warn(err error) {
-	logger.decorateEvent(logger.zerolog.Warn()).Err(err).Send()
+	logger.decorateEvent(logger.zerolog.Warn()).Err(RedactError(err)).Send()
 }
 
 // Debug logs a new message with debug level.
 func (logger *zerologLogger) Debug(value string) {
-	logger.decorateEvent(logger.zerolog.Debug()).Msg(value)
+	logger.decorateEvent(logger.zerolog.Debug()).Msg(Redact(value))
 }
 
 // Fatal logs a new message with fatal level.
 func (logger *zerologLogger) Fatal(err error) {
-	logger.decorateEvent(logger.zerolog.Fatal()).Err(err).Send()
+	logger.decorateEvent(logger.zerolog.Fatal()).Err(RedactError(err)).Send()
 }
 
 // Error logs an error
 func (logger *zerologLogger) Error(err error) {
-	logger.decorateEvent(logger.zerolog.Error()).Err(err).Send()
+	logger.decorateEvent(logger.zerolog.Error()).Err(RedactError(err)).Send()
 }
 
 // WithSpan adds a spanContext to a logger
@@ -99,7 +99,7 @@ func (logger *zerologLogger) decorateEvent(event *zerodriver.Event) *zerolog.Eve
 		event.TraceContext(logger.spanContext.TraceID().String(), logger.spanContext.SpanID().String(), logger.spanContext.IsSampled(), logger.projectID)
 	}
 	for key, value := range logger.fields {
-		event.Str(key, value)
+		event.Str(key, Redact(value))
 	}
 	return event.Event
 }
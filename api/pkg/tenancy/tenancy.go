@@ -0,0 +1,61 @@
+// Package tenancy carries the entities.Tenant which owns a request in its context.Context.
+//
+// The tenant is resolved at the handler boundary from the number which received a message or from the API key of the
+// request, and the repositories only read and write the data of the tenant in the context. Admin requests and
+// background jobs which scope their context after loading the entity they work on must explicitly access all the
+// tenants with WithAllTenants. The repositories refuse to query with a context which is neither.
+package tenancy
+
+import (
+	"context"
+
+	"github.com/NdoleStudio/discusswithai/pkg/entities"
+	"github.com/google/uuid"
+)
+
+type contextKey struct{}
+
+// scope is the tenant of a context. The tenant is nil for the numbers of the default tenant.
+// A scope with all set to true can access the data of all the tenants.
+type scope struct {
+	tenant *entities.Tenant
+	all    bool
+}
+
+// WithTenant returns a copy of ctx which is scoped to the tenant.
+// A nil tenant scopes ctx to the default tenant whose data has the uuid.Nil tenant ID.
+func WithTenant(ctx context.Context, tenant *entities.Tenant) context.Context {
+	return context.WithValue(ctx, contextKey{}, scope{tenant: tenant})
+}
+
+// WithAllTenants returns a copy of ctx which can access the data of all the tenants.
+// It is used for admin requests and by background jobs before they load the entity they work on.
+func WithAllTenants(ctx context.Context) context.Context {
+	return context.WithValue(ctx, contextKey{}, scope{all: true})
+}
+
+// IsAllTenants checks if ctx can access the data of all the tenants
+func IsAllTenants(ctx context.Context) bool {
+	value, ok := ctx.Value(contextKey{}).(scope)
+	return ok && value.all
+}
+
+// FromContext returns the tenant of ctx. The tenant is nil for the default tenant.
+// It returns false when ctx is not scoped to a tenant.
+func FromContext(ctx context.Context) (*entities.Tenant, bool) {
+	value, ok := ctx.Value(contextKey{}).(scope)
+	if !ok || value.all {
+		return nil, false
+	}
+	return value.tenant, true
+}
+
+// ID returns the ID of the tenant of ctx which is uuid.Nil for the default tenant.
+// It returns false when ctx is not scoped to a tenant.
+func ID(ctx context.Context) (uuid.UUID, bool) {
+	tenant, ok := FromContext(ctx)
+	if !ok || tenant == nil {
+		return uuid.Nil, ok
+	}
+	return tenant.ID, true
+}
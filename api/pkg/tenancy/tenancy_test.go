@@ -0,0 +1,86 @@
+package tenancy
+
+import (
+	"context"
+	"testing"
+
+	"github.com/NdoleStudio/discusswithai/pkg/entities"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestID(t *testing.T) {
+	t.Run("a context without a tenant is not scoped", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+
+		// Act
+		id, ok := ID(context.Background())
+
+		// Assert
+		assert.False(t, ok)
+		assert.Equal(t, uuid.Nil, id)
+	})
+
+	t.Run("the default tenant has the nil ID", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+
+		// Arrange
+		ctx := WithTenant(context.Background(), nil)
+
+		// Act
+		id, ok := ID(ctx)
+
+		// Assert
+		assert.True(t, ok)
+		assert.Equal(t, uuid.Nil, id)
+	})
+
+	t.Run("the ID of the tenant is returned", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+
+		// Arrange
+		tenant := &entities.Tenant{ID: uuid.New()}
+		ctx := WithTenant(context.Background(), tenant)
+
+		// Act
+		id, ok := ID(ctx)
+
+		// Assert
+		assert.True(t, ok)
+		assert.Equal(t, tenant.ID, id)
+	})
+
+	t.Run("a context for all the tenants is not scoped", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+
+		// Arrange
+		ctx := WithAllTenants(WithTenant(context.Background(), &entities.Tenant{ID: uuid.New()}))
+
+		// Act
+		id, ok := ID(ctx)
+
+		// Assert
+		assert.False(t, ok)
+		assert.Equal(t, uuid.Nil, id)
+		assert.True(t, IsAllTenants(ctx))
+	})
+
+	t.Run("a tenant context cannot access all the tenants", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+
+		// Arrange
+		ctx := WithTenant(WithAllTenants(context.Background()), nil)
+
+		// Act
+		all := IsAllTenants(ctx)
+
+		// Assert
+		assert.False(t, all)
+		assert.False(t, IsAllTenants(context.Background()))
+	})
+}
@@ -0,0 +1,228 @@
+package tools
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/palantir/stacktrace"
+	"github.com/sashabaranov/go-openai"
+)
+
+// maxExpressionLength limits the depth of the recursion when evaluating an expression
+const maxExpressionLength = 500
+
+// Calculator evaluates arithmetic expressions because the language model makes mistakes with numbers
+type Calculator struct{}
+
+// NewCalculator creates a new Calculator
+func NewCalculator() *Calculator {
+	return &Calculator{}
+}
+
+// Definition describes the Calculator to the language model
+func (calculator *Calculator) Definition() openai.FunctionDefinition {
+	return openai.FunctionDefinition{
+		Name:        "calculator",
+		Description: "Evaluates an arithmetic expression with the operators + - * / % ^, parentheses and the sqrt function. Use it for every calculation.",
+		Parameters: objectSchema(schema{
+			"expression": schema{"type": "string", "description": "The expression e.g. (12.5 * 4) / sqrt(16)"},
+		}, "expression"),
+	}
+}
+
+// Call evaluates the expression in the arguments
+func (calculator *Calculator) Call(_ context.Context, invocation *Invocation) (string, error) {
+	arguments := new(struct {
+		Expression string `json:"expression"`
+	})
+	if err := decodeArguments(invocation, arguments); err != nil {
+		return "", err
+	}
+
+	if len(arguments.Expression) > maxExpressionLength {
+		return "", stacktrace.NewErrorWithCode(ErrCodeInvalidArguments, "the expression has more than [%d] characters", maxExpressionLength)
+	}
+
+	result, err := Evaluate(arguments.Expression)
+	if err != nil {
+		return "", stacktrace.PropagateWithCode(err, ErrCodeInvalidArguments, "cannot evaluate expression [%s]", arguments.Expression)
+	}
+	return strconv.FormatFloat(result, 'f', -1, 64), nil
+}
+
+// Evaluate computes the value of an arithmetic expression e.g. "2 + 3 * 4"
+func Evaluate(expression string) (float64, error) {
+	parser := &expressionParser{input: expression}
+	value, err := parser.expression()
+	if err != nil {
+		return 0, err
+	}
+
+	parser.skipSpaces()
+	if parser.position < len(parser.input) {
+		return 0, stacktrace.NewError("unexpected character [%c] at position [%d]", parser.input[parser.position], parser.position)
+	}
+	if math.IsInf(value, 0) || math.IsNaN(value) {
+		return 0, stacktrace.NewError("the expression does not have a finite value")
+	}
+	return value, nil
+}
+
+// expressionParser is a recursive descent parser of arithmetic expressions.
+//
+//	expression = term { ("+" | "-") term }
+//	term       = unary { ("*" | "/" | "%") unary }
+//	unary      = ( "+" | "-" ) unary | power
+//	power      = primary [ "^" unary ]
+//	primary    = number | "(" expression ")" | "sqrt" "(" expression ")"
+type expressionParser struct {
+	input    string
+	position int
+}
+
+func (parser *expressionParser) expression() (float64, error) {
+	value, err := parser.term()
+	for err == nil {
+		var right float64
+		switch parser.peek() {
+		case '+':
+			parser.position++
+			if right, err = parser.term(); err == nil {
+				value += right
+			}
+		case '-':
+			parser.position++
+			if right, err = parser.term(); err == nil {
+				value -= right
+			}
+		default:
+			return value, nil
+		}
+	}
+	return 0, err
+}
+
+func (parser *expressionParser) term() (float64, error) {
+	value, err := parser.unary()
+	for err == nil {
+		operator := parser.peek()
+		if operator != '*' && operator != '/' && operator != '%' {
+			return value, nil
+		}
+		parser.position++
+
+		var right float64
+		if right, err = parser.unary(); err != nil {
+			break
+		}
+		if right == 0 && operator != '*' {
+			return 0, stacktrace.NewError("division by zero")
+		}
+
+		switch operator {
+		case '*':
+			value *= right
+		case '/':
+			value /= right
+		default:
+			value = math.Mod(value, right)
+		}
+	}
+	return 0, err
+}
+
+func (parser *expressionParser) power() (float64, error) {
+	base, err := parser.primary()
+	if err != nil || parser.peek() != '^' {
+		return base, err
+	}
+	parser.position++
+
+	exponent, err := parser.unary()
+	if err != nil {
+		return 0, err
+	}
+	return math.Pow(base, exponent), nil
+}
+
+func (parser *expressionParser) unary() (float64, error) {
+	switch parser.peek() {
+	case '-':
+		parser.position++
+		value, err := parser.unary()
+		return -value, err
+	case '+':
+		parser.position++
+		return parser.unary()
+	default:
+		return parser.power()
+	}
+}
+
+func (parser *expressionParser) primary() (float64, error) {
+	switch character := parser.peek(); {
+	case character == '(':
+		parser.position++
+		return parser.parenthesis()
+	case strings.HasPrefix(parser.input[parser.position:], "sqrt"):
+		parser.position += len("sqrt")
+		if parser.peek() != '(' {
+			return 0, stacktrace.NewError("expected [(] after sqrt at position [%d]", parser.position)
+		}
+		parser.position++
+		value, err := parser.parenthesis()
+		if err == nil && value < 0 {
+			return 0, stacktrace.NewError("cannot compute the square root of the negative number [%g]", value)
+		}
+		return math.Sqrt(value), err
+	case unicode.IsDigit(rune(character)) || character == '.':
+		return parser.number()
+	case character == 0:
+		return 0, stacktrace.NewError("unexpected end of expression")
+	default:
+		return 0, stacktrace.NewError("unexpected character [%c] at position [%d]", character, parser.position)
+	}
+}
+
+func (parser *expressionParser) parenthesis() (float64, error) {
+	value, err := parser.expression()
+	if err != nil {
+		return 0, err
+	}
+	if parser.peek() != ')' {
+		return 0, stacktrace.NewError("expected [)] at position [%d]", parser.position)
+	}
+	parser.position++
+	return value, nil
+}
+
+func (parser *expressionParser) number() (float64, error) {
+	start := parser.position
+	for parser.position < len(parser.input) && (unicode.IsDigit(rune(parser.input[parser.position])) || parser.input[parser.position] == '.') {
+		parser.position++
+	}
+
+	value, err := strconv.ParseFloat(parser.input[start:parser.position], 64)
+	if err != nil {
+		return 0, stacktrace.Propagate(err, "cannot parse number [%s]", parser.input[start:parser.position])
+	}
+	return value, nil
+}
+
+// peek skips the spaces and returns the next character or 0 at the end of the input
+func (parser *expressionParser) peek() byte {
+	parser.skipSpaces()
+	if parser.position >= len(parser.input) {
+		return 0
+	}
+	return parser.input[parser.position]
+}
+
+func (parser *expressionParser) skipSpaces() {
+	for parser.position < len(parser.input) && unicode.IsSpace(rune(parser.input[parser.position])) {
+		parser.position++
+	}
+}
@@ -0,0 +1,47 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEvaluate(t *testing.T) {
+	tests := []struct {
+		expression string
+		expected   float64
+	}{
+		{"2 + 3 * 4", 14},
+		{"(2 + 3) * 4", 20},
+		{"-2^2", -4},
+		{"2^3^2", 512},
+		{"sqrt(16) / 0.5", 8},
+		{"10 % 4 - -1", 3},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.expression, func(t *testing.T) {
+			// Setup
+			t.Parallel()
+
+			// Act
+			result, err := Evaluate(tc.expression)
+
+			// Assert
+			assert.Nil(t, err)
+			assert.Equal(t, tc.expected, result)
+		})
+	}
+
+	t.Run("it returns an error for invalid expressions", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+
+		// Act & Assert
+		for _, expression := range []string{"1 / 0", "2 +", "(1 + 2", "sqrt(-1)", "2 $ 3", "10^400"} {
+			_, err := Evaluate(expression)
+			assert.NotNil(t, err, expression)
+		}
+	})
+}
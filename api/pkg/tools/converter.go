@@ -0,0 +1,156 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/palantir/stacktrace"
+	"github.com/sashabaranov/go-openai"
+)
+
+const (
+	unitCategoryTemperature = "temperature"
+)
+
+// unit is a unit of measurement in a category whose value is factor times the base unit of the category
+type unit struct {
+	category string
+	factor   float64
+}
+
+// units are the units of measurement by symbol. The base units are the metre, the kilogram and the litre.
+var units = map[string]unit{
+	"mm": {"length", 0.001},
+	"cm": {"length", 0.01},
+	"m":  {"length", 1},
+	"km": {"length", 1000},
+	"in": {"length", 0.0254},
+	"ft": {"length", 0.3048},
+	"yd": {"length", 0.9144},
+	"mi": {"length", 1609.344},
+
+	"mg": {"mass", 0.000001},
+	"g":  {"mass", 0.001},
+	"kg": {"mass", 1},
+	"t":  {"mass", 1000},
+	"oz": {"mass", 0.028349523125},
+	"lb": {"mass", 0.45359237},
+
+	"ml":  {"volume", 0.001},
+	"l":   {"volume", 1},
+	"cup": {"volume", 0.2365882365},
+	"gal": {"volume", 3.785411784},
+
+	"c": {unitCategoryTemperature, 0},
+	"f": {unitCategoryTemperature, 0},
+	"k": {unitCategoryTemperature, 0},
+}
+
+// Converter converts values between units of measurement and currencies using a local table of exchange rates
+type Converter struct {
+	rates map[string]float64
+}
+
+// NewConverter creates a Converter with the exchange rates of the currencies in units per US dollar e.g. {"EUR": 0.92}
+func NewConverter(rates map[string]float64) *Converter {
+	normalized := map[string]float64{"USD": 1}
+	for currency, rate := range rates {
+		normalized[strings.ToUpper(currency)] = rate
+	}
+	return &Converter{rates: normalized}
+}
+
+// Definition describes the Converter to the language model
+func (converter *Converter) Definition() openai.FunctionDefinition {
+	var symbols []string
+	for symbol := range units {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+
+	var currencies []string
+	for currency := range converter.rates {
+		currencies = append(currencies, currency)
+	}
+	sort.Strings(currencies)
+
+	return openai.FunctionDefinition{
+		Name: "convert",
+		Description: fmt.Sprintf(
+			"Converts a value between units of measurement [%s] or between currencies [%s]. The exchange rates are approximate.",
+			strings.Join(symbols, ", "),
+			strings.Join(currencies, ", "),
+		),
+		Parameters: objectSchema(schema{
+			"value": schema{"type": "number", "description": "The value to convert e.g. 12.5"},
+			"from":  schema{"type": "string", "description": "The unit or the ISO 4217 currency code of the value e.g. km or EUR"},
+			"to":    schema{"type": "string", "description": "The unit or the ISO 4217 currency code of the result e.g. mi or XAF"},
+		}, "value", "from", "to"),
+	}
+}
+
+// Call converts the value in the arguments
+func (converter *Converter) Call(_ context.Context, invocation *Invocation) (string, error) {
+	arguments := new(struct {
+		Value float64 `json:"value"`
+		From  string  `json:"from"`
+		To    string  `json:"to"`
+	})
+	if err := decodeArguments(invocation, arguments); err != nil {
+		return "", err
+	}
+
+	result, err := converter.Convert(arguments.Value, arguments.From, arguments.To)
+	if err != nil {
+		return "", stacktrace.PropagateWithCode(err, ErrCodeInvalidArguments, "cannot convert [%g] from [%s] to [%s]", arguments.Value, arguments.From, arguments.To)
+	}
+	return fmt.Sprintf("%s %s", strconv.FormatFloat(result, 'f', -1, 64), arguments.To), nil
+}
+
+// Convert converts a value from a unit or a currency to another one
+func (converter *Converter) Convert(value float64, from string, to string) (float64, error) {
+	fromRate, fromIsCurrency := converter.rates[strings.ToUpper(from)]
+	toRate, toIsCurrency := converter.rates[strings.ToUpper(to)]
+	if fromIsCurrency && toIsCurrency {
+		return value / fromRate * toRate, nil
+	}
+
+	fromUnit, fromOK := units[strings.ToLower(from)]
+	toUnit, toOK := units[strings.ToLower(to)]
+	if !fromOK || !toOK {
+		return 0, stacktrace.NewError("the unit [%s] or [%s] is not supported", from, to)
+	}
+	if fromUnit.category != toUnit.category {
+		return 0, stacktrace.NewError("cannot convert [%s] which is a %s into [%s] which is a %s", from, fromUnit.category, to, toUnit.category)
+	}
+
+	if fromUnit.category == unitCategoryTemperature {
+		return fromKelvin(toKelvin(value, strings.ToLower(from)), strings.ToLower(to)), nil
+	}
+	return value * fromUnit.factor / toUnit.factor, nil
+}
+
+func toKelvin(value float64, symbol string) float64 {
+	switch symbol {
+	case "c":
+		return value + 273.15
+	case "f":
+		return (value-32)*5/9 + 273.15
+	default:
+		return value
+	}
+}
+
+func fromKelvin(value float64, symbol string) float64 {
+	switch symbol {
+	case "c":
+		return value - 273.15
+	case "f":
+		return (value-273.15)*9/5 + 32
+	default:
+		return value
+	}
+}
@@ -0,0 +1,51 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConverter_Convert(t *testing.T) {
+	converter := NewConverter(map[string]float64{"EUR": 0.8, "xaf": 600})
+
+	tests := []struct {
+		name     string
+		value    float64
+		from     string
+		to       string
+		expected float64
+	}{
+		{"length", 5, "km", "m", 5000},
+		{"mass", 2, "kg", "g", 2000},
+		{"temperature", 100, "C", "F", 212},
+		{"currency", 40, "EUR", "XAF", 30000},
+		{"currency to dollars", 600, "XAF", "usd", 1},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			// Setup
+			t.Parallel()
+
+			// Act
+			result, err := converter.Convert(tc.value, tc.from, tc.to)
+
+			// Assert
+			assert.Nil(t, err)
+			assert.InDelta(t, tc.expected, result, 0.000001)
+		})
+	}
+
+	t.Run("it returns an error for units of different categories", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+
+		// Act
+		_, err := converter.Convert(1, "kg", "km")
+
+		// Assert
+		assert.NotNil(t, err)
+	})
+}
@@ -0,0 +1,53 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/NdoleStudio/discusswithai/pkg/i18n"
+	"github.com/palantir/stacktrace"
+	"github.com/sashabaranov/go-openai"
+)
+
+// DateTime returns the current date and time because the language model does not know it
+type DateTime struct {
+	now func() time.Time
+}
+
+// NewDateTime creates a DateTime which uses the clock to get the current time
+func NewDateTime(now func() time.Time) *DateTime {
+	return &DateTime{now: now}
+}
+
+// Definition describes the DateTime tool to the language model
+func (tool *DateTime) Definition() openai.FunctionDefinition {
+	return openai.FunctionDefinition{
+		Name:        "current_datetime",
+		Description: "Returns the current date, time and day of the week. It uses the timezone of the user when no timezone is given.",
+		Parameters: objectSchema(schema{
+			"timezone": schema{"type": "string", "description": "An optional IANA timezone e.g. Africa/Douala"},
+		}),
+	}
+}
+
+// Call returns the current time in the timezone of the arguments or in the timezone of the user
+func (tool *DateTime) Call(_ context.Context, invocation *Invocation) (string, error) {
+	arguments := new(struct {
+		Timezone string `json:"timezone"`
+	})
+	if err := decodeArguments(invocation, arguments); err != nil {
+		return "", err
+	}
+
+	location := i18n.TimezoneFromPhoneNumber(invocation.ChannelID)
+	if arguments.Timezone != "" {
+		var err error
+		if location, err = time.LoadLocation(arguments.Timezone); err != nil {
+			return "", stacktrace.PropagateWithCode(err, ErrCodeInvalidArguments, fmt.Sprintf("cannot load timezone [%s]", arguments.Timezone))
+		}
+	}
+
+	now := tool.now().In(location)
+	return fmt.Sprintf("%s (%s, %s)", now.Format(time.RFC3339), now.Weekday(), location), nil
+}
@@ -0,0 +1,146 @@
+package tools
+
+import (
+	"context"
+	// embed the default FAQ so that the tool works without any configuration
+	_ "embed"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/palantir/stacktrace"
+	"github.com/sashabaranov/go-openai"
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed faq.yaml
+var defaultFAQ []byte
+
+// maxFAQResults is the maximum number of entries returned by a lookup
+const maxFAQResults = 3
+
+// FAQEntry is an answer to a question which users frequently ask
+type FAQEntry struct {
+	Question string   `yaml:"question"`
+	Answer   string   `yaml:"answer"`
+	Keywords []string `yaml:"keywords"`
+}
+
+// LoadFAQ loads the FAQ entries from a YAML file or the default FAQ when the path is empty
+func LoadFAQ(path string) ([]FAQEntry, error) {
+	content := defaultFAQ
+	if path != "" {
+		var err error
+		if content, err = os.ReadFile(path); err != nil {
+			return nil, stacktrace.Propagate(err, fmt.Sprintf("cannot read FAQ file [%s]", path))
+		}
+	}
+
+	var entries []FAQEntry
+	if err := yaml.Unmarshal(content, &entries); err != nil {
+		return nil, stacktrace.Propagate(err, fmt.Sprintf("cannot decode FAQ file [%s]", path))
+	}
+	return entries, nil
+}
+
+// FAQ looks up answers about our own service which the language model cannot know
+type FAQ struct {
+	entries []FAQEntry
+	terms   []map[string]bool
+}
+
+// NewFAQ creates a FAQ tool with the entries
+func NewFAQ(entries []FAQEntry) *FAQ {
+	terms := make([]map[string]bool, len(entries))
+	for i, entry := range entries {
+		terms[i] = tokenize(entry.Question + " " + strings.Join(entry.Keywords, " "))
+	}
+	return &FAQ{entries: entries, terms: terms}
+}
+
+// Definition describes the FAQ tool to the language model
+func (faq *FAQ) Definition() openai.FunctionDefinition {
+	return openai.FunctionDefinition{
+		Name:        "faq",
+		Description: "Looks up answers about Discuss With AI e.g. commands, languages, reminders, daily digests, SMS limits and support. Use it for every question about the service.",
+		Parameters: objectSchema(schema{
+			"query": schema{"type": "string", "description": "The question of the user e.g. how do I cancel a reminder"},
+		}, "query"),
+	}
+}
+
+// Call returns the entries which match the query in the arguments
+func (faq *FAQ) Call(_ context.Context, invocation *Invocation) (string, error) {
+	arguments := new(struct {
+		Query string `json:"query"`
+	})
+	if err := decodeArguments(invocation, arguments); err != nil {
+		return "", err
+	}
+
+	entries := faq.Search(arguments.Query)
+	if len(entries) == 0 {
+		return "There is no answer to this question in the FAQ.", nil
+	}
+
+	var builder strings.Builder
+	for _, entry := range entries {
+		builder.WriteString(fmt.Sprintf("Q: %s\nA: %s\n", entry.Question, entry.Answer))
+	}
+	return strings.TrimSpace(builder.String()), nil
+}
+
+// Search returns the entries which have the most terms in common with the query
+func (faq *FAQ) Search(query string) []FAQEntry {
+	type match struct {
+		index int
+		score int
+	}
+
+	scores := map[int]int{}
+	for term := range tokenize(query) {
+		for i := range faq.entries {
+			if faq.terms[i][term] {
+				scores[i]++
+			}
+		}
+	}
+
+	matches := make([]match, 0, len(scores))
+	for index, score := range scores {
+		matches = append(matches, match{index: index, score: score})
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].score != matches[j].score {
+			return matches[i].score > matches[j].score
+		}
+		return matches[i].index < matches[j].index
+	})
+
+	var entries []FAQEntry
+	for i := 0; i < len(matches) && i < maxFAQResults; i++ {
+		entries = append(entries, faq.entries[matches[i].index])
+	}
+	return entries
+}
+
+// stopWords are common words which are ignored when matching a query with the FAQ
+var stopWords = map[string]bool{
+	"the": true, "and": true, "how": true, "what": true, "why": true, "can": true, "you": true,
+	"does": true, "with": true, "for": true, "are": true, "not": true, "was": true, "your": true,
+}
+
+// tokenize returns the lowercase words of the text which have more than 2 characters and are not stop words
+func tokenize(text string) map[string]bool {
+	terms := map[string]bool{}
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if len([]rune(word)) > 2 && !stopWords[word] {
+			terms[word] = true
+		}
+	}
+	return terms
+}
@@ -0,0 +1,25 @@
+package tools
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFAQ_Call(t *testing.T) {
+	// Setup
+	t.Parallel()
+
+	// Arrange
+	entries, err := LoadFAQ("")
+	assert.Nil(t, err)
+	registry := NewRegistry(NewFAQ(entries))
+
+	// Act
+	result, err := registry.Call(context.Background(), &Invocation{Name: "faq", Arguments: `{"query": "How can I cancel a reminder?"}`})
+
+	// Assert
+	assert.Nil(t, err)
+	assert.Contains(t, result, "/reminders cancel 1")
+}
@@ -0,0 +1,95 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/palantir/stacktrace"
+	"github.com/sashabaranov/go-openai"
+)
+
+const (
+	// ErrCodeUnknownTool is returned when the language model calls a tool which is not in the Registry
+	ErrCodeUnknownTool = stacktrace.ErrorCode(4000)
+
+	// ErrCodeInvalidArguments is returned when the arguments of a tool call are not valid
+	ErrCodeInvalidArguments = stacktrace.ErrorCode(4001)
+)
+
+// Invocation is a call of a tool by the language model while replying to a user
+type Invocation struct {
+	Name      string
+	Arguments string
+	ChannelID string
+}
+
+// Tool is a Go function which the language model can call to ground its answers
+type Tool interface {
+	// Definition describes the tool and the JSON schema of its arguments to the language model
+	Definition() openai.FunctionDefinition
+
+	// Call runs the tool and returns the result which is sent back to the language model
+	Call(ctx context.Context, invocation *Invocation) (string, error)
+}
+
+// Registry contains the tools which the language model can call
+type Registry struct {
+	tools map[string]Tool
+}
+
+// NewRegistry creates a Registry with the tools
+func NewRegistry(tools ...Tool) *Registry {
+	registry := &Registry{tools: map[string]Tool{}}
+	for _, tool := range tools {
+		registry.tools[tool.Definition().Name] = tool
+	}
+	return registry
+}
+
+// Definitions returns the definitions of the tools sorted by name
+func (registry *Registry) Definitions() []openai.FunctionDefinition {
+	definitions := make([]openai.FunctionDefinition, 0, len(registry.tools))
+	for _, tool := range registry.tools {
+		definitions = append(definitions, tool.Definition())
+	}
+	sort.Slice(definitions, func(i, j int) bool {
+		return definitions[i].Name < definitions[j].Name
+	})
+	return definitions
+}
+
+// Call runs the tool of the invocation
+func (registry *Registry) Call(ctx context.Context, invocation *Invocation) (string, error) {
+	tool, ok := registry.tools[invocation.Name]
+	if !ok {
+		return "", stacktrace.NewErrorWithCode(ErrCodeUnknownTool, "there is no tool with name [%s]", invocation.Name)
+	}
+
+	result, err := tool.Call(ctx, invocation)
+	if err != nil {
+		return "", stacktrace.Propagate(err, fmt.Sprintf("cannot call tool [%s]", invocation.Name))
+	}
+	return result, nil
+}
+
+// decodeArguments decodes the JSON arguments of the invocation into the value
+func decodeArguments(invocation *Invocation, value any) error {
+	if err := json.Unmarshal([]byte(invocation.Arguments), value); err != nil {
+		return stacktrace.PropagateWithCode(err, ErrCodeInvalidArguments, fmt.Sprintf("cannot decode arguments [%s] of tool [%s] into %T", invocation.Arguments, invocation.Name, value))
+	}
+	return nil
+}
+
+// schema is a JSON schema of the arguments of a tool
+type schema map[string]any
+
+// objectSchema creates the JSON schema of an object with the properties and the required properties
+func objectSchema(properties schema, required ...string) schema {
+	return schema{
+		"type":       "object",
+		"properties": properties,
+		"required":   required,
+	}
+}
@@ -0,0 +1,64 @@
+package validators
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/NdoleStudio/discusswithai/pkg/entities"
+	"github.com/NdoleStudio/discusswithai/pkg/requests"
+	"github.com/NdoleStudio/discusswithai/pkg/telemetry"
+	"github.com/thedevsaddam/govalidator"
+)
+
+// AdminHandlerValidator validates models used in handlers.AdminHandler
+type AdminHandlerValidator struct {
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+}
+
+// NewAdminHandlerValidator creates a new handlers.AdminHandler validator
+func NewAdminHandlerValidator(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+) (v *AdminHandlerValidator) {
+	return &AdminHandlerValidator{
+		logger: logger.WithService(fmt.Sprintf("%T", v)),
+		tracer: tracer,
+	}
+}
+
+// ValidateIndex validates the requests.AdminIndexRequest
+func (validator *AdminHandlerValidator) ValidateIndex(ctx context.Context, request requests.AdminIndexRequest) url.Values {
+	_, span := validator.tracer.Start(ctx)
+	defer span.End()
+
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"skip": []string{
+				"required",
+				"numeric",
+			},
+			"limit": []string{
+				"required",
+				"numeric",
+				"numeric_between:1,100",
+			},
+			"query": []string{
+				"max:100",
+			},
+			"channel": []string{
+				"in:" + entities.ChannelSMS.String() + "," + entities.ChannelWhatsapp.String() + "," + entities.ChannelEmail.String(),
+			},
+			"from": []string{
+				timestampRule,
+			},
+			"to": []string{
+				timestampRule,
+			},
+		},
+	})
+
+	return v.ValidateStruct()
+}
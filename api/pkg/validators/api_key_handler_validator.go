@@ -0,0 +1,93 @@
+package validators
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/NdoleStudio/discusswithai/pkg/entities"
+	"github.com/NdoleStudio/discusswithai/pkg/requests"
+	"github.com/NdoleStudio/discusswithai/pkg/telemetry"
+	"github.com/thedevsaddam/govalidator"
+)
+
+// APIKeyHandlerValidator validates models used in handlers.APIKeyHandler
+type APIKeyHandlerValidator struct {
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+}
+
+// NewAPIKeyHandlerValidator creates a new handlers.APIKeyHandler validator
+func NewAPIKeyHandlerValidator(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+) (v *APIKeyHandlerValidator) {
+	return &APIKeyHandlerValidator{
+		logger: logger.WithService(fmt.Sprintf("%T", v)),
+		tracer: tracer,
+	}
+}
+
+// ValidateIndex validates the requests.AdminIndexRequest when fetching API keys
+func (validator *APIKeyHandlerValidator) ValidateIndex(ctx context.Context, request requests.AdminIndexRequest) url.Values {
+	_, span := validator.tracer.Start(ctx)
+	defer span.End()
+
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"skip": []string{
+				"required",
+				"numeric",
+			},
+			"limit": []string{
+				"required",
+				"numeric",
+				"numeric_between:1,100",
+			},
+		},
+	})
+
+	return v.ValidateStruct()
+}
+
+// ValidateStore validates the requests.APIKeyStoreRequest
+func (validator *APIKeyHandlerValidator) ValidateStore(ctx context.Context, request requests.APIKeyStoreRequest) url.Values {
+	_, span := validator.tracer.Start(ctx)
+	defer span.End()
+
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"name": []string{
+				"required",
+				"min:1",
+				"max:100",
+			},
+			"roles": []string{
+				"required",
+			},
+			"tenant_id": []string{
+				"uuid",
+			},
+		},
+	})
+
+	result := v.ValidateStruct()
+	for _, role := range request.Roles {
+		if !validator.isValidRole(role) {
+			result.Add("roles", fmt.Sprintf("the role [%s] must be one of [%s, %s, %s]", role, entities.RoleAdmin, entities.RoleSupport, entities.RoleIntegrator))
+		}
+	}
+
+	return result
+}
+
+func (validator *APIKeyHandlerValidator) isValidRole(role string) bool {
+	switch entities.Role(role) {
+	case entities.RoleAdmin, entities.RoleSupport, entities.RoleIntegrator:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,46 @@
+package validators
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/NdoleStudio/discusswithai/pkg/requests"
+	"github.com/NdoleStudio/discusswithai/pkg/telemetry"
+	"github.com/thedevsaddam/govalidator"
+)
+
+// DigestHandlerValidator validates models used in handlers.DigestHandler
+type DigestHandlerValidator struct {
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+}
+
+// NewDigestHandlerValidator creates a new handlers.DigestHandler validator
+func NewDigestHandlerValidator(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+) (v *DigestHandlerValidator) {
+	return &DigestHandlerValidator{
+		logger: logger.WithService(fmt.Sprintf("%T", v)),
+		tracer: tracer,
+	}
+}
+
+// ValidateDeliver validates the requests.DigestDeliverRequest
+func (validator *DigestHandlerValidator) ValidateDeliver(ctx context.Context, request requests.DigestDeliverRequest) url.Values {
+	_, span := validator.tracer.Start(ctx)
+	defer span.End()
+
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"subscription_id": []string{
+				"required",
+				"uuid",
+			},
+		},
+	})
+
+	return v.ValidateStruct()
+}
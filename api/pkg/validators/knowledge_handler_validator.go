@@ -0,0 +1,113 @@
+package validators
+
+import (
+	"context"
+	"fmt"
+	"mime/multipart"
+	"net/url"
+
+	"github.com/NdoleStudio/discusswithai/pkg/knowledge"
+	"github.com/NdoleStudio/discusswithai/pkg/requests"
+	"github.com/NdoleStudio/discusswithai/pkg/telemetry"
+	"github.com/thedevsaddam/govalidator"
+)
+
+// maxDocumentSize is the maximum size of a document in bytes which is below the default body limit of fiber
+const maxDocumentSize = 4 * 1000 * 1000
+
+// KnowledgeHandlerValidator validates models used in handlers.KnowledgeHandler
+type KnowledgeHandlerValidator struct {
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+}
+
+// NewKnowledgeHandlerValidator creates a new handlers.KnowledgeHandler validator
+func NewKnowledgeHandlerValidator(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+) (v *KnowledgeHandlerValidator) {
+	return &KnowledgeHandlerValidator{
+		logger: logger.WithService(fmt.Sprintf("%T", v)),
+		tracer: tracer,
+	}
+}
+
+// ValidateIndex validates the requests.AdminIndexRequest when fetching knowledge bases and their documents
+func (validator *KnowledgeHandlerValidator) ValidateIndex(ctx context.Context, request requests.AdminIndexRequest) url.Values {
+	_, span := validator.tracer.Start(ctx)
+	defer span.End()
+
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"skip": []string{
+				"required",
+				"numeric",
+			},
+			"limit": []string{
+				"required",
+				"numeric",
+				"numeric_between:1,100",
+			},
+			"query": []string{
+				"max:100",
+			},
+		},
+	})
+
+	return v.ValidateStruct()
+}
+
+// ValidateStore validates the requests.KnowledgeBaseStoreRequest
+func (validator *KnowledgeHandlerValidator) ValidateStore(ctx context.Context, request requests.KnowledgeBaseStoreRequest) url.Values {
+	_, span := validator.tracer.Start(ctx)
+	defer span.End()
+
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"name": []string{
+				"required",
+				"max:100",
+			},
+			"owner": []string{
+				"max:50",
+			},
+			"persona": []string{
+				"max:2000",
+			},
+			"tenant_id": []string{
+				"uuid",
+			},
+		},
+	})
+
+	result := v.ValidateStruct()
+	if request.Owner == "" && request.Persona == "" && request.TenantID == "" {
+		result.Add("owner", "the owner field is required when the persona and the tenant_id fields are empty")
+	}
+
+	return result
+}
+
+// ValidateUpload validates a document which is uploaded to a knowledge base
+func (validator *KnowledgeHandlerValidator) ValidateUpload(ctx context.Context, file *multipart.FileHeader) url.Values {
+	_, span := validator.tracer.Start(ctx)
+	defer span.End()
+
+	result := url.Values{}
+	if file == nil {
+		result.Add("document", "the document field is required")
+		return result
+	}
+
+	if file.Size == 0 || file.Size > maxDocumentSize {
+		result.Add("document", fmt.Sprintf("the document must have between 1 and %d bytes", maxDocumentSize))
+	}
+
+	if _, err := knowledge.DetectFormat(file.Filename, file.Header.Get("Content-Type")); err != nil {
+		result.Add("document", fmt.Sprintf("the document [%s] must be a text, Markdown or PDF file", file.Filename))
+	}
+
+	return result
+}
@@ -0,0 +1,72 @@
+package validators
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/NdoleStudio/discusswithai/pkg/entities"
+	"github.com/NdoleStudio/discusswithai/pkg/requests"
+	"github.com/NdoleStudio/discusswithai/pkg/telemetry"
+	"github.com/thedevsaddam/govalidator"
+)
+
+// MessageHandlerValidator validates models used in handlers.MessageHandler
+type MessageHandlerValidator struct {
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+}
+
+// NewMessageHandlerValidator creates a new handlers.MessageHandler validator
+func NewMessageHandlerValidator(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+) (v *MessageHandlerValidator) {
+	return &MessageHandlerValidator{
+		logger: logger.WithService(fmt.Sprintf("%T", v)),
+		tracer: tracer,
+	}
+}
+
+// ValidateSend validates the requests.MessageSendRequest
+func (validator *MessageHandlerValidator) ValidateSend(ctx context.Context, request requests.MessageSendRequest) url.Values {
+	_, span := validator.tracer.Start(ctx)
+	defer span.End()
+
+	rules := govalidator.MapData{
+		"channel": []string{
+			"required",
+			"in:" + entities.ChannelSMS.String() + "," + entities.ChannelWhatsapp.String(),
+		},
+		"from": []string{
+			"required",
+		},
+		"to": []string{
+			"required",
+			phoneNumberRule,
+		},
+		"prompt": []string{
+			"required",
+			"min:1",
+			"max:2048",
+		},
+		"persona": []string{
+			"max:1024",
+		},
+		"callback_url": []string{
+			"url",
+			publicURLRule,
+		},
+	}
+
+	if entities.Channel(request.Channel) == entities.ChannelSMS {
+		rules["from"] = append(rules["from"], phoneNumberRule)
+	}
+
+	v := govalidator.New(govalidator.Options{
+		Data:  &request,
+		Rules: rules,
+	})
+
+	return v.ValidateStruct()
+}
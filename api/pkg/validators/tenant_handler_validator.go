@@ -0,0 +1,121 @@
+package validators
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/NdoleStudio/discusswithai/pkg/entities"
+	"github.com/NdoleStudio/discusswithai/pkg/requests"
+	"github.com/NdoleStudio/discusswithai/pkg/telemetry"
+	"github.com/thedevsaddam/govalidator"
+)
+
+// TenantHandlerValidator validates models used in handlers.TenantHandler
+type TenantHandlerValidator struct {
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+}
+
+// NewTenantHandlerValidator creates a new handlers.TenantHandler validator
+func NewTenantHandlerValidator(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+) (v *TenantHandlerValidator) {
+	return &TenantHandlerValidator{
+		logger: logger.WithService(fmt.Sprintf("%T", v)),
+		tracer: tracer,
+	}
+}
+
+// ValidateIndex validates the requests.AdminIndexRequest when fetching tenants
+func (validator *TenantHandlerValidator) ValidateIndex(ctx context.Context, request requests.AdminIndexRequest) url.Values {
+	_, span := validator.tracer.Start(ctx)
+	defer span.End()
+
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"skip": []string{
+				"required",
+				"numeric",
+			},
+			"limit": []string{
+				"required",
+				"numeric",
+				"numeric_between:1,100",
+			},
+			"query": []string{
+				"max:100",
+			},
+		},
+	})
+
+	return v.ValidateStruct()
+}
+
+// ValidateStore validates the requests.TenantStoreRequest
+func (validator *TenantHandlerValidator) ValidateStore(ctx context.Context, request requests.TenantStoreRequest) url.Values {
+	_, span := validator.tracer.Start(ctx)
+	defer span.End()
+
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"name": []string{
+				"required",
+				"max:100",
+			},
+			"persona": []string{
+				"max:2000",
+			},
+			"knowledge_base_id": []string{
+				"uuid",
+			},
+			"whatsapp_access_token": []string{
+				"max:1000",
+			},
+			"nexmo_api_key": []string{
+				"max:100",
+			},
+			"nexmo_api_secret": []string{
+				"max:100",
+			},
+		},
+	})
+
+	result := v.ValidateStruct()
+	if (request.NexmoAPIKey == "") != (request.NexmoAPISecret == "") {
+		result.Add("nexmo_api_secret", "the nexmo_api_key and nexmo_api_secret fields must be set together")
+	}
+
+	return result
+}
+
+// ValidateNumberStore validates the requests.TenantNumberStoreRequest
+func (validator *TenantHandlerValidator) ValidateNumberStore(ctx context.Context, request requests.TenantNumberStoreRequest) url.Values {
+	_, span := validator.tracer.Start(ctx)
+	defer span.End()
+
+	rules := govalidator.MapData{
+		"channel": []string{
+			"required",
+			"in:" + entities.ChannelSMS.String() + "," + entities.ChannelWhatsapp.String(),
+		},
+		"number": []string{
+			"required",
+			"max:50",
+		},
+	}
+
+	if entities.Channel(request.Channel) == entities.ChannelSMS {
+		rules["number"] = append(rules["number"], phoneNumberRule)
+	}
+
+	v := govalidator.New(govalidator.Options{
+		Data:  &request,
+		Rules: rules,
+	})
+
+	return v.ValidateStruct()
+}
@@ -2,13 +2,17 @@ package validators
 
 import (
 	"fmt"
+	"time"
 
+	"github.com/NdoleStudio/discusswithai/pkg/netguard"
 	"github.com/nyaruka/phonenumbers"
 	"github.com/thedevsaddam/govalidator"
 )
 
 const (
 	phoneNumberRule = "phoneNumber"
+	timestampRule   = "timestamp"
+	publicURLRule   = "publicURL"
 )
 
 func init() {
@@ -27,4 +31,26 @@ func init() {
 
 		return nil
 	})
+
+	govalidator.AddCustomRule(timestampRule, func(field string, rule string, message string, value interface{}) error {
+		timestamp, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("the %s field must be a valid RFC3339 timestamp e.g 2022-06-05T14:26:02+03:00", field)
+		}
+
+		if _, err := time.Parse(time.RFC3339, timestamp); err != nil {
+			return fmt.Errorf("the %s field must be a valid RFC3339 timestamp e.g 2022-06-05T14:26:02+03:00", field)
+		}
+
+		return nil
+	})
+
+	govalidator.AddCustomRule(publicURLRule, func(field string, rule string, message string, value interface{}) error {
+		rawURL, ok := value.(string)
+		if !ok || (rawURL != "" && !netguard.IsPublicURL(rawURL)) {
+			return fmt.Errorf("the %s field must be a public URL which does not point to localhost or a private network", field)
+		}
+
+		return nil
+	})
 }
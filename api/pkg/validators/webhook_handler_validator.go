@@ -0,0 +1,117 @@
+package validators
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/NdoleStudio/discusswithai/pkg/events"
+	"github.com/NdoleStudio/discusswithai/pkg/requests"
+	"github.com/NdoleStudio/discusswithai/pkg/telemetry"
+	"github.com/thedevsaddam/govalidator"
+)
+
+// WebhookHandlerValidator validates models used in handlers.WebhookHandler
+type WebhookHandlerValidator struct {
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+}
+
+// NewWebhookHandlerValidator creates a new handlers.WebhookHandler validator
+func NewWebhookHandlerValidator(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+) (v *WebhookHandlerValidator) {
+	return &WebhookHandlerValidator{
+		logger: logger.WithService(fmt.Sprintf("%T", v)),
+		tracer: tracer,
+	}
+}
+
+// ValidateIndex validates the requests.AdminIndexRequest when fetching webhooks and their deliveries
+func (validator *WebhookHandlerValidator) ValidateIndex(ctx context.Context, request requests.AdminIndexRequest) url.Values {
+	_, span := validator.tracer.Start(ctx)
+	defer span.End()
+
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"skip": []string{
+				"required",
+				"numeric",
+			},
+			"limit": []string{
+				"required",
+				"numeric",
+				"numeric_between:1,100",
+			},
+			"query": []string{
+				"max:100",
+			},
+		},
+	})
+
+	return v.ValidateStruct()
+}
+
+// ValidateStore validates the requests.WebhookStoreRequest
+func (validator *WebhookHandlerValidator) ValidateStore(ctx context.Context, request requests.WebhookStoreRequest) url.Values {
+	_, span := validator.tracer.Start(ctx)
+	defer span.End()
+
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"url": []string{
+				"required",
+				"url",
+				publicURLRule,
+				"max:1024",
+			},
+			"events": []string{
+				"required",
+			},
+		},
+	})
+
+	result := v.ValidateStruct()
+	for _, event := range request.Events {
+		if !validator.isValidEvent(event) {
+			result.Add("events", fmt.Sprintf("the event [%s] must be one of [%s]", event, strings.Join(events.Types(), ", ")))
+		}
+	}
+
+	if !strings.HasPrefix(request.URL, "https://") {
+		result.Add("url", "the url field must be an https URL")
+	}
+
+	return result
+}
+
+func (validator *WebhookHandlerValidator) isValidEvent(event string) bool {
+	for _, eventType := range events.Types() {
+		if event == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateDeliver validates the requests.WebhookDeliverRequest
+func (validator *WebhookHandlerValidator) ValidateDeliver(ctx context.Context, request requests.WebhookDeliverRequest) url.Values {
+	_, span := validator.tracer.Start(ctx)
+	defer span.End()
+
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"delivery_id": []string{
+				"required",
+				"uuid",
+			},
+		},
+	})
+
+	return v.ValidateStruct()
+}
@@ -0,0 +1,7 @@
+package whatsapp
+
+// Account is the Graph API user or system user who owns the access token
+type Account struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
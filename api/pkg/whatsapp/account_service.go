@@ -0,0 +1,33 @@
+package whatsapp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// AccountService is the API client for the `/me` endpoint
+type AccountService service
+
+// Me returns the Account which owns the access token. It is used to check that the access token is valid.
+//
+// API Docs: https://developers.facebook.com/docs/graph-api/overview#me
+func (service *AccountService) Me(ctx context.Context) (*Account, *Response, error) {
+	request, err := service.client.newRequest(ctx, http.MethodGet, fmt.Sprintf("/%s/me", service.client.apiVersion), nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	response, err := service.client.do(request)
+	if err != nil {
+		return nil, response, err
+	}
+
+	account := new(Account)
+	if err = json.Unmarshal(*response.Body, account); err != nil {
+		return nil, response, err
+	}
+
+	return account, response, nil
+}
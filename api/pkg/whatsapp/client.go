@@ -20,8 +20,10 @@ type Client struct {
 	common      service
 	baseURL     string
 	accessToken string
+	apiVersion  string
 
 	Message *MessageService
+	Account *AccountService
 }
 
 // New creates and returns a new campay.Client from a slice of campay.ClientOption.
@@ -36,10 +38,12 @@ func New(options ...Option) *Client {
 		httpClient:  config.httpClient,
 		accessToken: config.accessToken,
 		baseURL:     config.baseURL,
+		apiVersion:  config.apiVersion,
 	}
 
 	client.common.client = client
 	client.Message = (*MessageService)(&client.common)
+	client.Account = (*AccountService)(&client.common)
 	return client
 }
 
@@ -6,6 +6,7 @@ type clientConfig struct {
 	httpClient  *http.Client
 	accessToken string
 	baseURL     string
+	apiVersion  string
 }
 
 func defaultClientConfig() *clientConfig {
@@ -13,5 +14,6 @@ func defaultClientConfig() *clientConfig {
 		httpClient:  http.DefaultClient,
 		accessToken: "",
 		baseURL:     "https://graph.facebook.com",
+		apiVersion:  "v16.0",
 	}
 }
@@ -35,6 +35,16 @@ func WithBaseURL(baseURL string) Option {
 	})
 }
 
+// WithAPIVersion sets the version of the Graph API e.g. "v18.0" which is used in the path of the requests.
+// By default, v16.0 is used.
+func WithAPIVersion(apiVersion string) Option {
+	return clientOptionFunc(func(config *clientConfig) {
+		if apiVersion = strings.Trim(apiVersion, "/"); apiVersion != "" {
+			config.apiVersion = apiVersion
+		}
+	})
+}
+
 // WithAccessToken sets the whatsapp API secret
 func WithAccessToken(accessToken string) Option {
 	return clientOptionFunc(func(config *clientConfig) {
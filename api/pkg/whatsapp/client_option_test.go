@@ -70,3 +70,33 @@ func TestWithBaseURL(t *testing.T) {
 		assert.Equal(t, "https://example.com", config.baseURL)
 	})
 }
+
+func TestWithAPIVersion(t *testing.T) {
+	t.Run("apiVersion is set successfully", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+
+		// Arrange
+		config := defaultClientConfig()
+
+		// Act
+		WithAPIVersion("/v18.0/").apply(config)
+
+		// Assert
+		assert.Equal(t, "v18.0", config.apiVersion)
+	})
+
+	t.Run("apiVersion is not changed when the apiVersion is empty", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+
+		// Arrange
+		config := defaultClientConfig()
+
+		// Act
+		WithAPIVersion("").apply(config)
+
+		// Assert
+		assert.Equal(t, "v16.0", config.apiVersion)
+	})
+}
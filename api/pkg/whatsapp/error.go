@@ -0,0 +1,168 @@
+package whatsapp
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/palantir/stacktrace"
+)
+
+const (
+	// ErrCodeReengagementWindowClosed is returned when a message which is not a template is sent to a user who has not
+	// messaged us in the last 24 hours.
+	ErrCodeReengagementWindowClosed = 131047
+
+	errCodeAPITooManyCalls    = 4
+	errCodeAccountRateLimited = 80007
+	errCodeThroughputReached  = 130429
+	errCodeSpamRateLimitHit   = 131048
+	errCodePairRateLimitHit   = 131056
+)
+
+const (
+	headerRetryAfter           = "Retry-After"
+	headerBusinessUseCaseUsage = "X-Business-Use-Case-Usage"
+)
+
+// Error is the error envelope which is returned by the Graph API when a request fails
+//
+// API Docs: https://developers.facebook.com/docs/whatsapp/cloud-api/support/error-codes
+type Error struct {
+	// StatusCode is the HTTP status code of the response
+	StatusCode int `json:"-"`
+
+	Message        string         `json:"message"`
+	Type           string         `json:"type"`
+	Code           int            `json:"code"`
+	ErrorSubcode   int            `json:"error_subcode"`
+	ErrorUserTitle string         `json:"error_user_title"`
+	ErrorUserMsg   string         `json:"error_user_msg"`
+	ErrorData      ErrorData      `json:"error_data"`
+	IsTransient    bool           `json:"is_transient"`
+	FBTraceID      string         `json:"fbtrace_id"`
+	RetryAfter     *time.Duration `json:"-"`
+
+	body []byte
+}
+
+// ErrorData contains the details of an Error
+type ErrorData struct {
+	MessagingProduct string `json:"messaging_product"`
+	Details          string `json:"details"`
+}
+
+// Error returns the message of the error together with the codes which identify it
+func (e *Error) Error() string {
+	if e.Code == 0 {
+		return fmt.Sprintf("%d: %s, Body: %s", e.StatusCode, http.StatusText(e.StatusCode), e.body)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(strconv.Itoa(e.StatusCode))
+	buf.WriteString(": ")
+	buf.WriteString(e.Message)
+	if e.ErrorData.Details != "" {
+		buf.WriteString(" (")
+		buf.WriteString(e.ErrorData.Details)
+		buf.WriteString(")")
+	}
+	buf.WriteString(fmt.Sprintf(", type: %s, code: %d, subcode: %d, fbtrace_id: %s", e.Type, e.Code, e.ErrorSubcode, e.FBTraceID))
+	return buf.String()
+}
+
+// IsReengagementWindowClosed checks if the message was rejected because the user has not messaged us in the last
+// 24 hours. Only template messages can be sent to the user until they send a new message.
+func (e *Error) IsReengagementWindowClosed() bool {
+	return e.Code == ErrCodeReengagementWindowClosed
+}
+
+// IsRateLimited checks if the request was rejected because a rate limit of the app, the business account or the
+// phone number was reached.
+func (e *Error) IsRateLimited() bool {
+	switch e.Code {
+	case errCodeAPITooManyCalls, errCodeAccountRateLimited, errCodeThroughputReached, errCodeSpamRateLimitHit, errCodePairRateLimitHit:
+		return true
+	default:
+		return e.StatusCode == http.StatusTooManyRequests
+	}
+}
+
+// IsRetryable checks if the same request can succeed when it is sent again later.
+// RetryAfter is the time to wait before retrying when the API returns it.
+func (e *Error) IsRetryable() bool {
+	return e.IsTransient || e.IsRateLimited() || e.StatusCode >= http.StatusInternalServerError
+}
+
+// AsError returns the *Error in the chain of err.
+// The errors which are wrapped with stacktrace.Propagate cannot be unwrapped so their root cause is checked as well.
+func AsError(err error) (*Error, bool) {
+	var apiError *Error
+	if errors.As(err, &apiError) || errors.As(stacktrace.RootCause(err), &apiError) {
+		return apiError, true
+	}
+	return nil, false
+}
+
+// IsReengagementWindowClosed checks if err is an *Error because the 24-hour session window of the user is closed
+func IsReengagementWindowClosed(err error) bool {
+	apiError, ok := AsError(err)
+	return ok && apiError.IsReengagementWindowClosed()
+}
+
+// IsRateLimited checks if err is an *Error because a rate limit was reached
+func IsRateLimited(err error) bool {
+	apiError, ok := AsError(err)
+	return ok && apiError.IsRateLimited()
+}
+
+// newError creates an *Error from a failed response. The raw body is kept in the message when it is not a Graph API error.
+func newError(httpResponse *http.Response, body []byte) *Error {
+	payload := struct {
+		Error *Error `json:"error"`
+	}{}
+
+	apiError := &Error{}
+	if err := json.Unmarshal(body, &payload); err == nil && payload.Error != nil {
+		apiError = payload.Error
+	}
+
+	apiError.StatusCode = httpResponse.StatusCode
+	apiError.RetryAfter = retryAfter(httpResponse.Header)
+	apiError.body = body
+	return apiError
+}
+
+// retryAfter returns the delay of the Retry-After header in seconds or the longest estimated_time_to_regain_access
+// in minutes of the X-Business-Use-Case-Usage header.
+func retryAfter(header http.Header) *time.Duration {
+	if seconds, err := strconv.Atoi(header.Get(headerRetryAfter)); err == nil && seconds > 0 {
+		delay := time.Duration(seconds) * time.Second
+		return &delay
+	}
+
+	usage := map[string][]struct {
+		EstimatedTimeToRegainAccess int `json:"estimated_time_to_regain_access"`
+	}{}
+	if err := json.Unmarshal([]byte(header.Get(headerBusinessUseCaseUsage)), &usage); err != nil {
+		return nil
+	}
+
+	var delay time.Duration
+	for _, entries := range usage {
+		for _, entry := range entries {
+			if minutes := time.Duration(entry.EstimatedTimeToRegainAccess) * time.Minute; minutes > delay {
+				delay = minutes
+			}
+		}
+	}
+
+	if delay == 0 {
+		return nil
+	}
+	return &delay
+}
@@ -0,0 +1,122 @@
+package whatsapp
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/palantir/stacktrace"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResponse_Error(t *testing.T) {
+	t.Run("the graph error envelope is parsed", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+
+		// Arrange
+		body := []byte(`{"error":{"message":"(#131047) Re-engagement message","type":"OAuthException","code":131047,"error_subcode":2494010,"error_data":{"messaging_product":"whatsapp","details":"Message failed to send because more than 24 hours have passed since the customer last replied to this number."},"is_transient":false,"fbtrace_id":"AbCdEf123"}}`)
+		response := &Response{HTTPResponse: &http.Response{StatusCode: http.StatusBadRequest, Header: http.Header{}}, Body: &body}
+
+		// Act
+		err := response.Error()
+
+		// Assert
+		apiError, ok := AsError(err)
+		assert.True(t, ok)
+		assert.Equal(t, http.StatusBadRequest, apiError.StatusCode)
+		assert.Equal(t, "OAuthException", apiError.Type)
+		assert.Equal(t, 131047, apiError.Code)
+		assert.Equal(t, 2494010, apiError.ErrorSubcode)
+		assert.Equal(t, "AbCdEf123", apiError.FBTraceID)
+		assert.True(t, IsReengagementWindowClosed(err))
+		assert.False(t, IsRateLimited(err))
+		assert.False(t, apiError.IsRetryable())
+	})
+
+	t.Run("the raw body is kept when it is not a graph error", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+
+		// Arrange
+		body := []byte("upstream connect error")
+		response := &Response{HTTPResponse: &http.Response{StatusCode: http.StatusBadGateway, Header: http.Header{}}, Body: &body}
+
+		// Act
+		err := response.Error()
+
+		// Assert
+		assert.Equal(t, "502: Bad Gateway, Body: upstream connect error", err.Error())
+		apiError, _ := AsError(err)
+		assert.True(t, apiError.IsRetryable())
+	})
+
+	t.Run("no error is returned when the request is successful", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+
+		// Arrange
+		body := []byte(`{"messaging_product":"whatsapp"}`)
+		response := &Response{HTTPResponse: &http.Response{StatusCode: http.StatusOK}, Body: &body}
+
+		// Act
+		err := response.Error()
+
+		// Assert
+		assert.Nil(t, err)
+	})
+}
+
+func TestIsRateLimited(t *testing.T) {
+	t.Run("the retry hint is read from the business use case usage header", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+
+		// Arrange
+		body := []byte(`{"error":{"message":"(#80007) Rate limit issues","type":"OAuthException","code":80007,"fbtrace_id":"AbCdEf123"}}`)
+		header := http.Header{}
+		header.Set("X-Business-Use-Case-Usage", `{"102290129340398":[{"type":"whatsapp","call_count":100,"estimated_time_to_regain_access":5}]}`)
+		response := &Response{HTTPResponse: &http.Response{StatusCode: http.StatusBadRequest, Header: header}, Body: &body}
+
+		// Act
+		err := fmt.Errorf("cannot send message: %w", response.Error())
+
+		// Assert
+		assert.True(t, IsRateLimited(err))
+		apiError, _ := AsError(err)
+		assert.Equal(t, 5*time.Minute, *apiError.RetryAfter)
+		assert.True(t, apiError.IsRetryable())
+	})
+
+	t.Run("the graph error is found when it is wrapped with a stack trace", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+
+		// Arrange
+		body := []byte(`{"error":{"message":"(#130429) Rate limit hit","type":"OAuthException","code":130429,"fbtrace_id":"AbCdEf123"}}`)
+		header := http.Header{}
+		header.Set("Retry-After", "30")
+		response := &Response{HTTPResponse: &http.Response{StatusCode: http.StatusBadRequest, Header: header}, Body: &body}
+
+		// Act
+		err := stacktrace.Propagate(stacktrace.Propagate(response.Error(), "cannot send whatsapp message"), "cannot deliver message")
+
+		// Assert
+		assert.True(t, IsRateLimited(err))
+		apiError, ok := AsError(err)
+		assert.True(t, ok)
+		assert.Equal(t, 30*time.Second, *apiError.RetryAfter)
+	})
+
+	t.Run("false is returned when the error is not a graph error", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+
+		// Act
+		rateLimited := IsRateLimited(fmt.Errorf("timeout"))
+
+		// Assert
+		assert.False(t, rateLimited)
+	})
+}
@@ -16,3 +16,13 @@ type MessageSendResponse struct {
 		ID string `json:"id"`
 	} `json:"messages"`
 }
+
+// MessageSendTemplateParams are parameters for sending a whatsapp template message.
+// Template messages can be sent to users who have not messaged us in the last 24 hours.
+type MessageSendTemplateParams struct {
+	From       string   `json:"from"`
+	To         string   `json:"to"`
+	Template   string   `json:"template"`
+	Language   string   `json:"language"`
+	Parameters []string `json:"parameters"`
+}
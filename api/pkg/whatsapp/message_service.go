@@ -30,7 +30,56 @@ func (service *MessageService) Send(ctx context.Context, params *MessageSendPara
 		}
 	}
 
-	request, err := service.client.newRequest(ctx, http.MethodPost, fmt.Sprintf("/v16.0/%s/messages", params.From), payload)
+	request, err := service.client.newRequest(ctx, http.MethodPost, fmt.Sprintf("/%s/%s/messages", service.client.apiVersion, params.From), payload)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	response, err := service.client.do(request)
+	if err != nil {
+		return nil, response, err
+	}
+
+	message := new(MessageSendResponse)
+	if err = json.Unmarshal(*response.Body, message); err != nil {
+		return nil, response, err
+	}
+
+	return message, response, nil
+}
+
+// SendTemplate sends a whatsapp template message to a user. The parameters fill the variables in the body of the template.
+//
+// API Docs: https://developers.facebook.com/docs/whatsapp/cloud-api/guides/send-message-templates
+func (service *MessageService) SendTemplate(ctx context.Context, params *MessageSendTemplateParams) (*MessageSendResponse, *Response, error) {
+	var parameters []map[string]string
+	for _, parameter := range params.Parameters {
+		parameters = append(parameters, map[string]string{
+			"type": "text",
+			"text": parameter,
+		})
+	}
+
+	payload := map[string]any{
+		"messaging_product": "whatsapp",
+		"recipient_type":    "individual",
+		"to":                params.To,
+		"type":              "template",
+		"template": map[string]any{
+			"name": params.Template,
+			"language": map[string]string{
+				"code": params.Language,
+			},
+			"components": []map[string]any{
+				{
+					"type":       "body",
+					"parameters": parameters,
+				},
+			},
+		},
+	}
+
+	request, err := service.client.newRequest(ctx, http.MethodPost, fmt.Sprintf("/%s/%s/messages", service.client.apiVersion, params.From), payload)
 	if err != nil {
 		return nil, nil, err
 	}
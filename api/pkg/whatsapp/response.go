@@ -1,10 +1,7 @@
 package whatsapp
 
 import (
-	"bytes"
-	"errors"
 	"net/http"
-	"strconv"
 )
 
 // Response captures the http response
@@ -13,23 +10,12 @@ type Response struct {
 	Body         *[]byte
 }
 
-// Error ensures that the response can be decoded into a string inc ase it's an error response
+// Error returns an *Error with the Graph API error envelope in case it's an error response
 func (r *Response) Error() error {
 	switch r.HTTPResponse.StatusCode {
 	case 200, 201, 202, 204, 205:
 		return nil
 	default:
-		return errors.New(r.errorMessage())
+		return newError(r.HTTPResponse, *r.Body)
 	}
 }
-
-func (r *Response) errorMessage() string {
-	var buf bytes.Buffer
-	buf.WriteString(strconv.Itoa(r.HTTPResponse.StatusCode))
-	buf.WriteString(": ")
-	buf.WriteString(http.StatusText(r.HTTPResponse.StatusCode))
-	buf.WriteString(", Body: ")
-	buf.Write(*r.Body)
-
-	return buf.String()
-}